@@ -0,0 +1,137 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// addressAccumulator accumulates the IP addresses of the DNS names across the
+// DNS lookups within a window. It is used to record the complete set of the IP
+// addresses of a DNS name whose upstream resolvers return different subsets of
+// the IP addresses for each DNS lookup (eg. load balanced DNS names).
+type addressAccumulator struct {
+	// pending stores the accumulated DNS lookups which are not recorded yet.
+	// key: DNS name, value: accumulated DNS lookups of the DNS name.
+	pending map[string]*accumulatedLookups
+	lock    sync.Mutex
+}
+
+// accumulatedLookups stores the IP addresses accumulated for a DNS name along
+// with the information required for recording them.
+type accumulatedLookups struct {
+	regularDNSInfo  namespaceDNSInfo
+	wildcardDNSInfo namespaceDNSInfo
+	displayName     string
+	// addresses stores the TTL and the time of the latest DNS lookup of each IP address.
+	// key: IP address, value: TTL and lookup time.
+	addresses map[string]accumulatedAddress
+}
+
+// accumulatedAddress is the TTL of an IP address received at the lookup time.
+type accumulatedAddress struct {
+	ttl        int32
+	lookupTime time.Time
+}
+
+// newAddressAccumulator returns an initialized addressAccumulator.
+func newAddressAccumulator() *addressAccumulator {
+	return &addressAccumulator{
+		pending: make(map[string]*accumulatedLookups),
+	}
+}
+
+// add adds the IP addresses of a DNS lookup of the DNS name at the given time. The
+// DNS name info and the display name of the latest DNS lookup are kept. It returns
+// true if this is the first DNS lookup accumulated for the DNS name, in which case
+// the caller is responsible for recording the accumulated IP addresses at the end
+// of the window.
+func (accumulator *addressAccumulator) add(
+	dnsName string,
+	regularDNSInfo namespaceDNSInfo,
+	wildcardDNSInfo namespaceDNSInfo,
+	displayName string,
+	ipTTLs map[string]int32,
+	now time.Time,
+) bool {
+	accumulator.lock.Lock()
+	defer accumulator.lock.Unlock()
+
+	lookups, exists := accumulator.pending[dnsName]
+	if !exists {
+		lookups = &accumulatedLookups{
+			addresses: make(map[string]accumulatedAddress),
+		}
+		accumulator.pending[dnsName] = lookups
+	}
+	lookups.regularDNSInfo = regularDNSInfo
+	lookups.wildcardDNSInfo = wildcardDNSInfo
+	lookups.displayName = displayName
+	for ip, ttl := range ipTTLs {
+		lookups.addresses[ip] = accumulatedAddress{ttl: ttl, lookupTime: now}
+	}
+	return !exists
+}
+
+// take removes and returns the accumulated DNS lookups of the DNS name, or nil if
+// there are none.
+func (accumulator *addressAccumulator) take(dnsName string) *accumulatedLookups {
+	accumulator.lock.Lock()
+	defer accumulator.lock.Unlock()
+
+	lookups := accumulator.pending[dnsName]
+	delete(accumulator.pending, dnsName)
+	return lookups
+}
+
+// ipTTLs returns the accumulated IP addresses and their TTLs at the given time. The
+// TTL of an IP address is reduced by the time elapsed since its DNS lookup, as the
+// IP addresses are recorded with the current time as the last lookup time. The
+// reduced TTL never drops below minTTL.
+func (lookups *accumulatedLookups) ipTTLs(now time.Time, minTTL int32) map[string]int32 {
+	ipTTLs := make(map[string]int32, len(lookups.addresses))
+	for ip, address := range lookups.addresses {
+		ttl := address.ttl - int32(now.Sub(address.lookupTime)/time.Second)
+		if ttl < minTTL {
+			ttl = minTTL
+		}
+		ipTTLs[ip] = ttl
+	}
+	return ipTTLs
+}
+
+// accumulateAddresses accumulates the IP addresses of a successful DNS lookup of the
+// DNS name. The accumulated IP addresses are recorded at the end of the
+// accumulateWindow which starts with the first DNS lookup of the DNS name.
+func (resolver *OCPDNSNameResolver) accumulateAddresses(
+	dnsName string,
+	regularDNSInfo namespaceDNSInfo,
+	wildcardDNSInfo namespaceDNSInfo,
+	displayName string,
+	ipTTLs map[string]int32,
+) {
+	if resolver.addressAccumulator.add(dnsName, regularDNSInfo, wildcardDNSInfo, displayName, ipTTLs, time.Now()) {
+		time.AfterFunc(resolver.accumulateWindow, func() {
+			resolver.flushAddresses(dnsName)
+		})
+	}
+}
+
+// flushAddresses records the accumulated IP addresses of the DNS name. The
+// accumulated IP addresses are dropped if the plugin is shut down.
+func (resolver *OCPDNSNameResolver) flushAddresses(dnsName string) {
+	lookups := resolver.addressAccumulator.take(dnsName)
+	if lookups == nil {
+		return
+	}
+
+	resolver.stopLock.Lock()
+	shutdown := resolver.shutdown
+	resolver.stopLock.Unlock()
+	if shutdown {
+		return
+	}
+
+	resolver.recordSuccess(context.Background(), lookups.regularDNSInfo, lookups.wildcardDNSInfo,
+		dnsName, lookups.displayName, lookups.ipTTLs(time.Now(), resolver.minimumTTLOf(dnsName)))
+}
@@ -0,0 +1,107 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAddressAccumulator(t *testing.T) {
+	accumulator := newAddressAccumulator()
+	now := time.Now()
+	regularDNSInfo := namespaceDNSInfo{"dns": "regular"}
+
+	if first := accumulator.add("www.example.com.", regularDNSInfo, nil, "www.example.com.",
+		map[string]int32{"1.1.1.1": 30, "1.1.1.2": 30}, now); !first {
+		t.Fatalf("Expected the first DNS lookup to be reported as first")
+	}
+	if first := accumulator.add("www.example.com.", regularDNSInfo, nil, "WWW.example.com.",
+		map[string]int32{"1.1.1.2": 60, "1.1.1.3": 10}, now.Add(10*time.Second)); first {
+		t.Fatalf("Expected the second DNS lookup not to be reported as first")
+	}
+
+	lookups := accumulator.take("www.example.com.")
+	if lookups == nil {
+		t.Fatalf("Expected accumulated DNS lookups")
+	}
+	if lookups.displayName != "WWW.example.com." {
+		t.Fatalf("Expected display name of the latest DNS lookup, found %s", lookups.displayName)
+	}
+	expectedIPTTLs := map[string]int32{
+		// Looked up 20 seconds ago.
+		"1.1.1.1": 10,
+		// Looked up again 10 seconds ago with a new TTL.
+		"1.1.1.2": 50,
+		// Looked up 10 seconds ago, the reduced TTL is floored at minTTL.
+		"1.1.1.3": defaultMinTTL,
+	}
+	if diff := cmp.Diff(expectedIPTTLs, lookups.ipTTLs(now.Add(20*time.Second), defaultMinTTL)); diff != "" {
+		t.Fatalf("Unexpected IP addresses (-want +got):\n%s", diff)
+	}
+
+	if lookups := accumulator.take("www.example.com."); lookups != nil {
+		t.Fatalf("Expected no accumulated DNS lookups after take, found %v", lookups)
+	}
+}
+
+func TestServeDNSAccumulateWindow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.accumulateWindow = 200 * time.Millisecond
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "regular",
+			Namespace: "dns",
+		},
+		Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+			Name: "www.example.com.",
+		},
+	})
+
+	// Each DNS lookup returns a different subset of the IP addresses of the DNS name.
+	for _, ip := range []string{"1.1.1.1", "1.1.1.2", "1.1.1.3"} {
+		query := test.Case{
+			Qname: "www.example.com.",
+			Qtype: dns.TypeA,
+			Rcode: dns.RcodeSuccess,
+			Answer: []dns.RR{
+				test.A("www.example.com. 30 IN A " + ip),
+			},
+		}
+		resolver.Next = fakeNextPluginHandler(query)
+		resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+	}
+
+	if count := countStatusUpdates(fakeNetworkClient); count != 0 {
+		t.Fatalf("Expected no status updates within the window, found %d", count)
+	}
+
+	resolverObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		return len(obj.Status.ResolvedNames) > 0
+	})
+	if len(resolverObj.Status.ResolvedNames) != 1 {
+		t.Fatalf("Expected 1 resolved name, found: %v", resolverObj.Status.ResolvedNames)
+	}
+	var ips []string
+	for _, address := range resolverObj.Status.ResolvedNames[0].ResolvedAddresses {
+		ips = append(ips, address.IP)
+	}
+	if diff := cmp.Diff([]string{"1.1.1.1", "1.1.1.2", "1.1.1.3"}, ips, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		t.Fatalf("Unexpected recorded IP addresses (-want +got):\n%s", diff)
+	}
+	if count := countStatusUpdates(fakeNetworkClient); count != 1 {
+		t.Fatalf("Expected a single status update for the window, found %d", count)
+	}
+}
@@ -0,0 +1,135 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"encoding/json"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+)
+
+const (
+	// schemaVersionAnnotation is the annotation used for identifying the layout of the
+	// annotations managed by the plugin, so that their consumers know how to parse them. It
+	// is set along with any of the managed annotations.
+	schemaVersionAnnotation = "dnsnameresolver.openshift.io/schema-version"
+
+	// annotationSchemaVersion is the current layout of the annotations managed by the
+	// plugin. It has to be bumped whenever the name or the format of any of the managed
+	// annotations changes.
+	annotationSchemaVersion = "1"
+)
+
+// managedAnnotations are the annotations of the DNSNameResolver objects which are written by
+// the plugin, apart from the schema version annotation.
+var managedAnnotations = []string{
+	wildcardChildrenAnnotation,
+	negativeResultsAnnotation,
+	ptrNamesAnnotation,
+	srvTargetsAnnotation,
+	upstreamAnnotation,
+	writerAnnotation,
+	extendedErrorsAnnotation,
+	fingerprintAnnotation,
+	originalCaseAnnotation,
+}
+
+// setAnnotation sets the managed annotation of the DNSNameResolver object to the value,
+// along with the schema version annotation.
+func setAnnotation(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver, key, value string) {
+	if resolverObj.Annotations == nil {
+		resolverObj.Annotations = make(map[string]string)
+	}
+	resolverObj.Annotations[key] = value
+	resolverObj.Annotations[schemaVersionAnnotation] = annotationSchemaVersion
+}
+
+// removeAnnotation removes the managed annotation of the DNSNameResolver object. The schema
+// version annotation is removed too, once none of the managed annotations is left.
+func removeAnnotation(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver, key string) {
+	delete(resolverObj.Annotations, key)
+	for _, managed := range managedAnnotations {
+		if _, exists := resolverObj.Annotations[managed]; exists {
+			return
+		}
+	}
+	delete(resolverObj.Annotations, schemaVersionAnnotation)
+}
+
+// hasAnnotation checks if the managed annotation of the DNSNameResolver object is set to the
+// value with the current schema version, i.e. if setting it would not change the object.
+func hasAnnotation(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver, key, value string) bool {
+	return resolverObj.Annotations[key] == value &&
+		resolverObj.Annotations[schemaVersionAnnotation] == annotationSchemaVersion
+}
+
+// updateAnnotationEntries updates a managed annotation holding a JSON encoded map of the
+// DNSNameResolver objects corresponding to the regular and the wildcard DNS names. Like the
+// status updates, the objects are updated in the order of fanOutOrder and paced by
+// namespacePacing, and the objects whose status updates are paused are skipped. The update
+// function modifies the entries of the annotation of an object, an invalid value of which
+// is overwritten, and returns whether they changed; the update call is skipped otherwise.
+// The annotation is removed once it has no entries left. The description is used for
+// logging.
+func updateAnnotationEntries[V any](
+	ctx context.Context,
+	resolver *OCPDNSNameResolver,
+	regularDNSInfo namespaceDNSInfo,
+	wildcardDNSInfo namespaceDNSInfo,
+	key string,
+	description string,
+	update func(entries map[string]V) bool,
+) {
+	for _, namespaceDNS := range []namespaceDNSInfo{regularDNSInfo, wildcardDNSInfo} {
+		resolver.fanOut(namespaceDNS, func(namespace string, objName string) {
+			// Wait for the next update slot in the namespace, if namespacePacing is configured.
+			if !resolver.paceUpdate(ctx, namespace) {
+				log.Debugf("Dropping update of %s of DNSNameResolver object %s/%s as its context is done", description, namespace, objName)
+				return
+			}
+
+			// Retry the update of the DNSNameResolver object if there's a conflict or a transient error during the update.
+			resolver.retrier.retryUpdate(namespace, objName, description, func() error {
+				// Fetch a copy of the DNSNameResolver object. All the updates will be applied to the copied object.
+				resolverObj, err := resolver.store.get(ctx, namespace, objName)
+				if err != nil {
+					return err
+				}
+
+				// Skip the update if the status updates of the DNSNameResolver object are paused.
+				if isPaused(resolverObj) {
+					log.Debugf("Skipping update of %s of paused DNSNameResolver object %s/%s", description, namespace, objName)
+					return nil
+				}
+
+				// Get the existing entries from the annotation. An invalid annotation value is
+				// overwritten.
+				entries := make(map[string]V)
+				if value, exists := resolverObj.Annotations[key]; exists {
+					if err := json.Unmarshal([]byte(value), &entries); err != nil {
+						log.Warningf("Overwriting invalid value of annotation %s of DNSNameResolver object %s/%s: %v",
+							key, namespace, objName, err)
+						entries = make(map[string]V)
+					}
+				}
+
+				// If there are no changes to the entries then skip the update call.
+				if !update(entries) {
+					return nil
+				}
+
+				if len(entries) == 0 {
+					removeAnnotation(resolverObj, key)
+				} else {
+					value, err := json.Marshal(entries)
+					if err != nil {
+						return err
+					}
+					setAnnotation(resolverObj, key, string(value))
+				}
+
+				// Update the DNSNameResolver object.
+				return resolver.store.update(ctx, resolverObj)
+			})
+		})
+	}
+}
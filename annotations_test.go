@@ -0,0 +1,149 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSchemaVersionAnnotation(t *testing.T) {
+	resolverObj := &ocpnetworkapiv1alpha1.DNSNameResolver{}
+
+	setAnnotation(resolverObj, writerAnnotation, "cluster-a")
+	setAnnotation(resolverObj, upstreamAnnotation, "10.0.0.1:53")
+	if version := resolverObj.Annotations[schemaVersionAnnotation]; version != annotationSchemaVersion {
+		t.Fatalf("Expected schema version annotation %q, found %q", annotationSchemaVersion, version)
+	}
+	if !hasAnnotation(resolverObj, writerAnnotation, "cluster-a") {
+		t.Fatalf("Expected writer annotation to be set")
+	}
+
+	removeAnnotation(resolverObj, writerAnnotation)
+	if _, exists := resolverObj.Annotations[schemaVersionAnnotation]; !exists {
+		t.Fatalf("Expected schema version annotation to be kept while a managed annotation is left")
+	}
+	removeAnnotation(resolverObj, upstreamAnnotation)
+	if len(resolverObj.Annotations) != 0 {
+		t.Fatalf("Expected no annotation to be left, found %v", resolverObj.Annotations)
+	}
+
+	// An annotation written without the schema version is rewritten.
+	resolverObj.Annotations = map[string]string{writerAnnotation: "cluster-a"}
+	if hasAnnotation(resolverObj, writerAnnotation, "cluster-a") {
+		t.Fatalf("Expected writer annotation without schema version not to be considered set")
+	}
+}
+
+func TestServeDNSSchemaVersionAnnotation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.instanceID = "cluster-a"
+	resolver.recordWildcardChildren = true
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	// The objects are in different namespaces, as the wildcard DNSNameResolver object is not
+	// updated if a regular one matches in the same namespace.
+	for name, dnsName := range map[string]string{"regular": "www.example.com.", "wildcard": "*.example.com."} {
+		createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: name},
+			Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: ocpnetworkapiv1alpha1.DNSName(dnsName)},
+		})
+	}
+
+	query := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("www.example.com. 30 IN A 1.1.1.1"),
+		},
+	}
+	resolver.Next = fakeNextPluginHandler(query)
+	resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+	for name, key := range map[string]string{"regular": writerAnnotation, "wildcard": wildcardChildrenAnnotation} {
+		resolverObj := getResolverObject(t, resolver, name, name, func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+			return obj.Annotations[key] != ""
+		})
+		if resolverObj.Annotations[key] == "" {
+			t.Fatalf("Expected annotation %s of DNSNameResolver object %s to be set", key, name)
+		}
+		for _, key := range managedAnnotations {
+			if _, exists := resolverObj.Annotations[key]; exists && resolverObj.Annotations[schemaVersionAnnotation] != annotationSchemaVersion {
+				t.Fatalf("Expected schema version annotation %q along with annotation %s of DNSNameResolver object %s, found %q",
+					annotationSchemaVersion, key, name, resolverObj.Annotations[schemaVersionAnnotation])
+			}
+		}
+	}
+}
+
+func TestUpdateAnnotationEntries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	for _, namespace := range []string{"dns", "paused"} {
+		resolverObj := &ocpnetworkapiv1alpha1.DNSNameResolver{
+			ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: namespace},
+			Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+		}
+		if namespace == "paused" {
+			resolverObj.Annotations = map[string]string{pauseAnnotation: "true"}
+		}
+		createTrackedResolverObject(t, resolver, fakeNetworkClient, resolverObj)
+	}
+
+	countUpdates := func() map[string]int {
+		counts := make(map[string]int)
+		for _, action := range fakeNetworkClient.Actions() {
+			if action.GetVerb() == "update" && action.GetSubresource() == "" {
+				counts[action.GetNamespace()]++
+			}
+		}
+		return counts
+	}
+
+	regularDNSInfo := namespaceDNSInfo{"dns": "regular", "paused": "regular"}
+	setTargets := func(srvTargets map[string][]string) bool {
+		if _, exists := srvTargets["www.example.com."]; exists {
+			return false
+		}
+		srvTargets["www.example.com."] = []string{"www1.example.com.:80"}
+		return true
+	}
+
+	// The annotation of the paused object should not be updated.
+	updateAnnotationEntries(ctx, resolver, regularDNSInfo, nil, srvTargetsAnnotation, "SRV targets", setTargets)
+	if counts := countUpdates(); counts["dns"] != 1 || counts["paused"] != 0 {
+		t.Fatalf("Expected 1 update of the object which is not paused only, found %v", counts)
+	}
+	getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		return obj.Annotations[srvTargetsAnnotation] == `{"www.example.com.":["www1.example.com.:80"]}`
+	})
+
+	// The update call should be skipped if the entries do not change.
+	updateAnnotationEntries(ctx, resolver, regularDNSInfo, nil, srvTargetsAnnotation, "SRV targets", setTargets)
+	if counts := countUpdates(); counts["dns"] != 1 {
+		t.Fatalf("Expected no update if the entries do not change, found %v", counts)
+	}
+
+	// The annotation should be removed once it has no entries left.
+	updateAnnotationEntries(ctx, resolver, regularDNSInfo, nil, srvTargetsAnnotation, "SRV targets",
+		func(srvTargets map[string][]string) bool {
+			delete(srvTargets, "www.example.com.")
+			return true
+		})
+	getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		_, exists := obj.Annotations[srvTargetsAnnotation]
+		return !exists
+	})
+}
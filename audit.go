@@ -0,0 +1,67 @@
+package ocp_dnsnameresolver
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+)
+
+// auditRecord is the structured log line emitted for each successful status
+// write of a DNSNameResolver object, when auditLog is enabled.
+type auditRecord struct {
+	Timestamp string         `json:"timestamp"`
+	Namespace string         `json:"namespace"`
+	Name      string         `json:"name"`
+	DNSName   string         `json:"dnsName"`
+	Addresses []auditAddress `json:"addresses"`
+}
+
+// auditAddress is an IP address of the DNS name recorded in the status of the
+// DNSNameResolver object.
+type auditAddress struct {
+	IP         string `json:"ip"`
+	TTLSeconds int32  `json:"ttlSeconds"`
+}
+
+// newAuditRecord returns the audit record of the DNS name for the given
+// DNSNameResolver object. The addresses are empty if the resolved name entry
+// of the DNS name was removed by the status write.
+func newAuditRecord(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver, dnsName string, now time.Time) auditRecord {
+	record := auditRecord{
+		Timestamp: now.UTC().Format(time.RFC3339Nano),
+		Namespace: resolverObj.Namespace,
+		Name:      resolverObj.Name,
+		DNSName:   dnsName,
+		Addresses: []auditAddress{},
+	}
+	for _, resolvedName := range resolverObj.Status.ResolvedNames {
+		if !strings.EqualFold(string(resolvedName.DNSName), dnsName) {
+			continue
+		}
+		for _, resolvedAddress := range resolvedName.ResolvedAddresses {
+			record.Addresses = append(record.Addresses, auditAddress{
+				IP:         resolvedAddress.IP,
+				TTLSeconds: resolvedAddress.TTLSeconds,
+			})
+		}
+		break
+	}
+	return record
+}
+
+// auditStatusWrite emits the audit record of the DNS name after a successful
+// status write of the DNSNameResolver object, if auditLog is enabled. The record
+// is logged at the info level, thus it is emitted regardless of debug logging.
+func (resolver *OCPDNSNameResolver) auditStatusWrite(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver, dnsName string) {
+	if !resolver.auditLog {
+		return
+	}
+	value, err := json.Marshal(newAuditRecord(resolverObj, dnsName, time.Now()))
+	if err != nil {
+		log.Errorf("Failed to encode audit record of DNSNameResolver object %s/%s: %v", resolverObj.Namespace, resolverObj.Name, err)
+		return
+	}
+	log.Infof("audit %s", value)
+}
@@ -0,0 +1,81 @@
+package ocp_dnsnameresolver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewAuditRecord(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	resolverObj := &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "wildcard",
+			Namespace: "dns",
+		},
+		Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+			Name: "*.example.com.",
+		},
+		Status: ocpnetworkapiv1alpha1.DNSNameResolverStatus{
+			ResolvedNames: []ocpnetworkapiv1alpha1.DNSNameResolverResolvedName{
+				{
+					DNSName: "*.example.com.",
+					ResolvedAddresses: []ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{
+						{IP: "1.1.1.1", TTLSeconds: 30},
+					},
+				},
+				{
+					DNSName: "WWW.example.com.",
+					ResolvedAddresses: []ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{
+						{IP: "1.1.1.2", TTLSeconds: 10},
+						{IP: "1.1.1.3", TTLSeconds: 20},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		dnsName        string
+		expectedRecord auditRecord
+	}{
+		{
+			name:    "Addresses of the matching resolved name are audited",
+			dnsName: "www.example.com.",
+			expectedRecord: auditRecord{
+				Timestamp: "2024-01-02T03:04:05Z",
+				Namespace: "dns",
+				Name:      "wildcard",
+				DNSName:   "www.example.com.",
+				Addresses: []auditAddress{
+					{IP: "1.1.1.2", TTLSeconds: 10},
+					{IP: "1.1.1.3", TTLSeconds: 20},
+				},
+			},
+		},
+		{
+			name:    "No addresses are audited for a removed resolved name",
+			dnsName: "foo.example.com.",
+			expectedRecord: auditRecord{
+				Timestamp: "2024-01-02T03:04:05Z",
+				Namespace: "dns",
+				Name:      "wildcard",
+				DNSName:   "foo.example.com.",
+				Addresses: []auditAddress{},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			record := newAuditRecord(resolverObj, tc.dnsName, now)
+			if diff := cmp.Diff(tc.expectedRecord, record); diff != "" {
+				t.Fatalf("Unexpected audit record (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+)
+
+// breakerState is the state of the circuit breaker around the updates of the
+// DNSNameResolver objects. The values are the ones reported by the breakerStateGauge
+// metric.
+type breakerState int
+
+const (
+	// breakerClosed lets all the updates through.
+	breakerClosed breakerState = 0
+	// breakerHalfOpen lets a single trial update through to test the recovery of the
+	// API server, while the other updates are rejected.
+	breakerHalfOpen breakerState = 1
+	// breakerOpen rejects all the updates until the cooldown period is over.
+	breakerOpen breakerState = 2
+
+	// defaultBreakerCooldown will be used when breakerCooldown is not explicitly configured.
+	defaultBreakerCooldown = 30 * time.Second
+)
+
+// errBreakerOpen is returned for the updates rejected by the circuit breaker.
+var errBreakerOpen = errors.New("circuit breaker is open")
+
+// circuitBreaker stops issuing the updates of the DNSNameResolver objects for a cooldown
+// period after a number of consecutive failures of the API server, so that an API server
+// returning errors en masse is not hammered further. Once the cooldown period is over, a
+// single trial update is let through: the breaker closes if it succeeds, and opens again
+// for another cooldown period if it fails.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	// now returns the current time. It is a field so that tests can replace it.
+	now func() time.Time
+
+	lock sync.Mutex
+	// state is the current state of the breaker.
+	state breakerState
+	// failures is the number of the consecutive failures while the breaker is closed.
+	failures int
+	// openedAt is the time the breaker was last opened.
+	openedAt time.Time
+	// trialInFlight indicates whether the trial update of the half open breaker is in flight.
+	trialInFlight bool
+}
+
+// newCircuitBreaker returns a closed circuitBreaker which opens after threshold
+// consecutive failures, for the cooldown period.
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	breakerStateGauge.Set(float64(breakerClosed))
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// allow returns true if an update can be issued. Once the cooldown period of the open
+// breaker is over, the breaker half opens and allows a single trial update.
+func (breaker *circuitBreaker) allow() bool {
+	breaker.lock.Lock()
+	defer breaker.lock.Unlock()
+
+	switch breaker.state {
+	case breakerOpen:
+		if breaker.now().Sub(breaker.openedAt) < breaker.cooldown {
+			return false
+		}
+		log.Infof("Circuit breaker is half open after a cooldown period of %s, testing the recovery of the API server", breaker.cooldown)
+		breaker.setState(breakerHalfOpen)
+		breaker.trialInFlight = true
+		return true
+	case breakerHalfOpen:
+		if breaker.trialInFlight {
+			return false
+		}
+		breaker.trialInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record records the result of an allowed update. Only the failures of the API server,
+// i.e. the transient errors, count as failures. The other errors are caused by the
+// update itself, they show that the API server is responsive like the successes.
+func (breaker *circuitBreaker) record(err error) {
+	breaker.lock.Lock()
+	defer breaker.lock.Unlock()
+
+	failed := err != nil && isTransientError(err)
+	switch breaker.state {
+	case breakerHalfOpen:
+		breaker.trialInFlight = false
+		if failed {
+			log.Warningf("Circuit breaker is open again for %s as the trial update failed: %v", breaker.cooldown, err)
+			breaker.open()
+			return
+		}
+		log.Infof("Circuit breaker is closed as the trial update succeeded")
+		breaker.failures = 0
+		breaker.setState(breakerClosed)
+	case breakerClosed:
+		if !failed {
+			breaker.failures = 0
+			return
+		}
+		breaker.failures++
+		if breaker.failures >= breaker.threshold {
+			log.Warningf("Circuit breaker is open for %s after %d consecutive failed updates: %v", breaker.cooldown, breaker.failures, err)
+			breaker.open()
+		}
+	}
+}
+
+// open opens the breaker. The caller must hold the lock.
+func (breaker *circuitBreaker) open() {
+	breaker.failures = 0
+	breaker.openedAt = breaker.now()
+	breaker.setState(breakerOpen)
+}
+
+// setState sets the state of the breaker and its metric. The caller must hold the lock.
+func (breaker *circuitBreaker) setState(state breakerState) {
+	breaker.state = state
+	breakerStateGauge.Set(float64(state))
+}
+
+// breakerStore is a resolverStore issuing the updates of the wrapped resolverStore
+// through the circuit breaker. The reads are served from the informer cache, thus they
+// are not affected by the breaker.
+type breakerStore struct {
+	resolverStore
+	breaker *circuitBreaker
+}
+
+var _ resolverStore = &breakerStore{}
+
+// update implements resolverStore.
+func (store *breakerStore) update(ctx context.Context, resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) error {
+	if !store.breaker.allow() {
+		return errBreakerOpen
+	}
+	err := store.resolverStore.update(ctx, resolverObj)
+	store.breaker.record(err)
+	return err
+}
+
+// updateStatus implements resolverStore.
+func (store *breakerStore) updateStatus(ctx context.Context, resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) error {
+	if !store.breaker.allow() {
+		return errBreakerOpen
+	}
+	err := store.resolverStore.updateStatus(ctx, resolverObj)
+	store.breaker.record(err)
+	return err
+}
@@ -0,0 +1,111 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	breaker := newCircuitBreaker(2, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	transientErr := apierrors.NewServiceUnavailable("unavailable")
+	conflictErr := apierrors.NewConflict(schema.GroupResource{}, "test", errors.New("conflict"))
+
+	expectState := func(step string, expected breakerState) {
+		t.Helper()
+		if breaker.state != expected {
+			t.Fatalf("%s: expected breaker state %d, found %d", step, expected, breaker.state)
+		}
+		if value := testutil.ToFloat64(breakerStateGauge); value != float64(expected) {
+			t.Fatalf("%s: expected breaker state metric %v, found %v", step, float64(expected), value)
+		}
+	}
+
+	// A success resets the count of the consecutive failures, the same as the errors
+	// other than the transient ones as the API server is responsive.
+	for _, err := range []error{transientErr, nil, transientErr, conflictErr} {
+		if !breaker.allow() {
+			t.Fatalf("expected the closed breaker to allow the update")
+		}
+		breaker.record(err)
+	}
+	expectState("non consecutive failures", breakerClosed)
+
+	// The breaker opens after the threshold of consecutive failures.
+	breaker.allow()
+	breaker.record(transientErr)
+	expectState("single failure", breakerClosed)
+	breaker.allow()
+	breaker.record(transientErr)
+	expectState("consecutive failures", breakerOpen)
+	if breaker.allow() {
+		t.Fatalf("expected the open breaker to reject the update")
+	}
+
+	// The breaker half opens after the cooldown and allows a single trial, a failed trial
+	// opens the breaker again.
+	now = now.Add(time.Minute)
+	if !breaker.allow() {
+		t.Fatalf("expected the breaker to allow the trial update after the cooldown")
+	}
+	expectState("cooldown over", breakerHalfOpen)
+	if breaker.allow() {
+		t.Fatalf("expected the half open breaker to reject the updates while the trial is in flight")
+	}
+	breaker.record(transientErr)
+	expectState("failed trial", breakerOpen)
+	now = now.Add(time.Minute - time.Second)
+	if breaker.allow() {
+		t.Fatalf("expected the reopened breaker to reject the update during the cooldown")
+	}
+
+	// A successful trial closes the breaker.
+	now = now.Add(time.Second)
+	if !breaker.allow() {
+		t.Fatalf("expected the breaker to allow the trial update after the cooldown")
+	}
+	breaker.record(nil)
+	expectState("successful trial", breakerClosed)
+	if !breaker.allow() {
+		t.Fatalf("expected the closed breaker to allow the update")
+	}
+}
+
+type failingStore struct {
+	resolverStore
+	err   error
+	calls int
+}
+
+func (store *failingStore) updateStatus(context.Context, *ocpnetworkapiv1alpha1.DNSNameResolver) error {
+	store.calls++
+	return store.err
+}
+
+func TestBreakerStore(t *testing.T) {
+	wrapped := &failingStore{err: apierrors.NewTooManyRequests("slow down", 1)}
+	store := &breakerStore{
+		resolverStore: wrapped,
+		breaker:       newCircuitBreaker(1, time.Minute),
+	}
+
+	obj := &ocpnetworkapiv1alpha1.DNSNameResolver{}
+	if err := store.updateStatus(context.Background(), obj); !apierrors.IsTooManyRequests(err) {
+		t.Fatalf("expected the error of the wrapped store, found %v", err)
+	}
+	if err := store.updateStatus(context.Background(), obj); !errors.Is(err, errBreakerOpen) {
+		t.Fatalf("expected errBreakerOpen, found %v", err)
+	}
+	if wrapped.calls != 1 {
+		t.Fatalf("expected a single update to reach the wrapped store, found %d", wrapped.calls)
+	}
+}
@@ -0,0 +1,168 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// wildcardChildrenAnnotation is the annotation used for storing the regular DNS names
+	// discovered as children of the wildcard DNS name of a DNSNameResolver object, i.e. the
+	// successfully looked up regular DNS names matching the wildcard DNS name. The
+	// DNSNameResolver status does not have a field for them, thus they are stored in the
+	// annotation as a JSON encoded list, in the order of their discovery. The list is
+	// bounded by the maximum number of the children of recordWildcardChildren.
+	wildcardChildrenAnnotation = "dnsnameresolver.openshift.io/wildcard-children"
+)
+
+// updateWildcardChildren adds the regular DNS name to the wildcard children annotation of
+// the DNSNameResolver objects corresponding to the wildcard DNS name, unless it is already
+// there or the maximum number of the children is reached. If wildcardFlushInterval is
+// configured then the DNS name is buffered instead, and written by the next flush.
+func (resolver *OCPDNSNameResolver) updateWildcardChildren(ctx context.Context, wildcardDNSInfo namespaceDNSInfo, dnsName string) {
+	if resolver.wildcardFlushInterval > 0 {
+		for namespace, objName := range wildcardDNSInfo {
+			resolver.childrenBuffer.add(types.NamespacedName{Namespace: namespace, Name: objName}, dnsName, resolver.maxWildcardChildren)
+		}
+		return
+	}
+
+	// WaitGroup variable used to wait for the completion of update of DNSNameResolver CRs
+	// for the same DNS name in different namespaces.
+	var wg sync.WaitGroup
+
+	// Iterate through the namespaces and the corresponding DNSNameResolver object names.
+	for namespace, objName := range wildcardDNSInfo {
+		wg.Add(1)
+
+		// Each update is performed in separate goroutine.
+		go func(namespace string, objName string) {
+			defer wg.Done()
+			resolver.writeWildcardChildren(ctx, namespace, objName, []string{dnsName})
+		}(namespace, objName)
+	}
+
+	// Wait for the goroutines for each namespace to complete.
+	wg.Wait()
+}
+
+// flushWildcardChildren writes the buffered children to the wildcard children annotation
+// of their DNSNameResolver objects, when wildcardFlushInterval is configured.
+func (resolver *OCPDNSNameResolver) flushWildcardChildren(ctx context.Context) {
+	// WaitGroup variable used to wait for the completion of update of DNSNameResolver CRs.
+	var wg sync.WaitGroup
+
+	for object, dnsNames := range resolver.childrenBuffer.take() {
+		wg.Add(1)
+
+		// Each update is performed in separate goroutine.
+		go func(object types.NamespacedName, dnsNames []string) {
+			defer wg.Done()
+			resolver.writeWildcardChildren(ctx, object.Namespace, object.Name, dnsNames)
+		}(object, dnsNames)
+	}
+
+	// Wait for the goroutines for each object to complete.
+	wg.Wait()
+}
+
+// writeWildcardChildren adds the regular DNS names to the wildcard children annotation of
+// the DNSNameResolver object, skipping the ones which are already there, until the maximum
+// number of the children is reached. The object is not updated if no DNS name is added.
+func (resolver *OCPDNSNameResolver) writeWildcardChildren(ctx context.Context, namespace, objName string, dnsNames []string) {
+	// Retry the update of the DNSNameResolver object if there's a conflict or a transient error during the update.
+	resolver.retrier.retryUpdate(namespace, objName, "wildcard children", func() error {
+		// Fetch a copy of the DNSNameResolver object. All the updates will be applied to the copied object.
+		resolverObj, err := resolver.store.get(ctx, namespace, objName)
+		if err != nil {
+			// The object may be deleted while its children are buffered.
+			if apierrors.IsNotFound(err) && resolver.wildcardFlushInterval > 0 {
+				return nil
+			}
+			return err
+		}
+
+		// Get the existing children from the annotation. An invalid annotation value is overwritten.
+		var children []string
+		if value, exists := resolverObj.Annotations[wildcardChildrenAnnotation]; exists {
+			if err := json.Unmarshal([]byte(value), &children); err != nil {
+				log.Warningf("Overwriting invalid value of annotation %s of DNSNameResolver object %s/%s: %v",
+					wildcardChildrenAnnotation, namespace, objName, err)
+				children = nil
+			}
+		}
+
+		added := false
+		for _, dnsName := range dnsNames {
+			// Skip the DNS names which are already children.
+			if slices.Contains(children, dnsName) {
+				continue
+			}
+			if len(children) >= resolver.maxWildcardChildren {
+				log.Debugf("Not recording DNS name %s as a child of DNSNameResolver object %s/%s as the maximum number of children %d is reached",
+					dnsName, namespace, objName, resolver.maxWildcardChildren)
+				continue
+			}
+			children = append(children, dnsName)
+			added = true
+		}
+		// If no DNS name is added then skip the update call.
+		if !added {
+			return nil
+		}
+
+		value, err := json.Marshal(children)
+		if err != nil {
+			return err
+		}
+		setAnnotation(resolverObj, wildcardChildrenAnnotation, string(value))
+
+		// Update the DNSNameResolver object.
+		return resolver.store.update(ctx, resolverObj)
+	})
+}
+
+// childrenBuffer buffers the children discovered for the wildcard DNSNameResolver objects
+// between the flushes, when wildcardFlushInterval is configured, so that an object is
+// written once per flush instead of once per discovered child.
+type childrenBuffer struct {
+	// pending stores the buffered children in the order of their discovery, without duplicates.
+	// key: DNSNameResolver object, value: buffered DNS names.
+	pending map[types.NamespacedName][]string
+	lock    sync.Mutex
+}
+
+// newChildrenBuffer returns an initialized childrenBuffer.
+func newChildrenBuffer() *childrenBuffer {
+	return &childrenBuffer{
+		pending: make(map[types.NamespacedName][]string),
+	}
+}
+
+// add buffers the DNS name as a child of the DNSNameResolver object, unless it is already
+// buffered or the maximum number of the buffered children of the object is reached.
+func (buffer *childrenBuffer) add(object types.NamespacedName, dnsName string, maxChildren int) {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+
+	children := buffer.pending[object]
+	if slices.Contains(children, dnsName) || len(children) >= maxChildren {
+		return
+	}
+	buffer.pending[object] = append(children, dnsName)
+}
+
+// take removes and returns all the buffered children.
+func (buffer *childrenBuffer) take() map[types.NamespacedName][]string {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+
+	pending := buffer.pending
+	buffer.pending = make(map[types.NamespacedName][]string)
+	return pending
+}
@@ -0,0 +1,216 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestServeDNSRecordWildcardChildren(t *testing.T) {
+	tests := []struct {
+		name                   string
+		recordWildcardChildren bool
+		queries                []string
+		expectedChildren       []string
+	}{
+		{
+			name:    "Children are not recorded by default",
+			queries: []string{"a.example.com."},
+		},
+		{
+			name:                   "Children are recorded in the order of their discovery without duplicates",
+			recordWildcardChildren: true,
+			queries:                []string{"b.example.com.", "a.example.com.", "b.example.com."},
+			expectedChildren:       []string{"b.example.com.", "a.example.com."},
+		},
+		{
+			name:                   "Children are not recorded beyond the maximum",
+			recordWildcardChildren: true,
+			queries:                []string{"a.example.com.", "b.example.com.", "c.example.com.", "a.example.com."},
+			expectedChildren:       []string{"a.example.com.", "b.example.com."},
+		},
+		{
+			name:                   "Wildcard DNS name is not recorded as a child",
+			recordWildcardChildren: true,
+			queries:                []string{"*.example.com.", "a.example.com."},
+			expectedChildren:       []string{"a.example.com."},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.recordWildcardChildren = tc.recordWildcardChildren
+			resolver.maxWildcardChildren = 2
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "wildcard",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "*.example.com.",
+				},
+			})
+
+			for i, qname := range tc.queries {
+				query := test.Case{
+					Qname: qname,
+					Qtype: dns.TypeA,
+					Rcode: dns.RcodeSuccess,
+					Answer: []dns.RR{
+						test.A(qname + " 30 IN A 1.1.1.1"),
+					},
+				}
+				resolver.Next = fakeNextPluginHandler(query)
+				resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+				// Wait for the informer cache to observe the children recorded so far.
+				expectedCount := 0
+				for _, child := range tc.expectedChildren {
+					for _, previous := range tc.queries[:i+1] {
+						if child == previous {
+							expectedCount++
+							break
+						}
+					}
+				}
+				getResolverObject(t, resolver, "dns", "wildcard", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+					var children []string
+					_ = json.Unmarshal([]byte(obj.Annotations[wildcardChildrenAnnotation]), &children)
+					return len(children) == expectedCount
+				})
+			}
+
+			resolverObj := getResolverObject(t, resolver, "dns", "wildcard", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+				return true
+			})
+			value, exists := resolverObj.Annotations[wildcardChildrenAnnotation]
+			if !exists {
+				if tc.expectedChildren != nil {
+					t.Fatalf("Expected children %v, found no annotation", tc.expectedChildren)
+				}
+				return
+			}
+			var children []string
+			if err := json.Unmarshal([]byte(value), &children); err != nil {
+				t.Fatalf("Unexpected invalid value of annotation %s: %v", wildcardChildrenAnnotation, err)
+			}
+			if !reflect.DeepEqual(children, tc.expectedChildren) {
+				t.Fatalf("Expected children %v, found %v", tc.expectedChildren, children)
+			}
+		})
+	}
+}
+
+func TestChildrenBuffer(t *testing.T) {
+	buffer := newChildrenBuffer()
+	wildcard := types.NamespacedName{Namespace: "dns", Name: "wildcard"}
+	other := types.NamespacedName{Namespace: "other", Name: "wildcard"}
+
+	for _, dnsName := range []string{"b.example.com.", "a.example.com.", "b.example.com.", "c.example.com."} {
+		buffer.add(wildcard, dnsName, 2)
+	}
+	buffer.add(other, "a.example.com.", 2)
+
+	expected := map[types.NamespacedName][]string{
+		wildcard: {"b.example.com.", "a.example.com."},
+		other:    {"a.example.com."},
+	}
+	if pending := buffer.take(); !reflect.DeepEqual(pending, expected) {
+		t.Fatalf("Expected buffered children %v, found %v", expected, pending)
+	}
+	if pending := buffer.take(); len(pending) != 0 {
+		t.Fatalf("Expected no buffered children after take, found %v", pending)
+	}
+}
+
+func TestFlushWildcardChildren(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.recordWildcardChildren = true
+	resolver.maxWildcardChildren = 3
+	// The flusher goroutine is not started by the test resolver, the flush is called explicitly.
+	resolver.wildcardFlushInterval = time.Hour
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "wildcard",
+			Namespace:   "dns",
+			Annotations: map[string]string{wildcardChildrenAnnotation: `["a.example.com."]`},
+		},
+		Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+			Name: "*.example.com.",
+		},
+	})
+
+	for _, qname := range []string{"b.example.com.", "a.example.com.", "c.example.com.", "b.example.com.", "d.example.com."} {
+		query := test.Case{
+			Qname: qname,
+			Qtype: dns.TypeA,
+			Rcode: dns.RcodeSuccess,
+			Answer: []dns.RR{
+				test.A(qname + " 30 IN A 1.1.1.1"),
+			},
+		}
+		resolver.Next = fakeNextPluginHandler(query)
+		resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+	}
+
+	countUpdates := func() int {
+		count := 0
+		for _, action := range fakeNetworkClient.Actions() {
+			if action.GetVerb() == "update" && action.GetSubresource() == "" {
+				count++
+			}
+		}
+		return count
+	}
+
+	// The children are buffered, thus the object is not updated before the flush.
+	if count := countUpdates(); count != 0 {
+		t.Fatalf("Expected no update before the flush, found %d", count)
+	}
+
+	resolver.flushWildcardChildren(ctx)
+
+	expectedChildren := []string{"a.example.com.", "b.example.com.", "c.example.com."}
+	resolverObj := getResolverObject(t, resolver, "dns", "wildcard", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		var children []string
+		_ = json.Unmarshal([]byte(obj.Annotations[wildcardChildrenAnnotation]), &children)
+		return reflect.DeepEqual(children, expectedChildren)
+	})
+	var children []string
+	if err := json.Unmarshal([]byte(resolverObj.Annotations[wildcardChildrenAnnotation]), &children); err != nil {
+		t.Fatalf("Unexpected invalid value of annotation %s: %v", wildcardChildrenAnnotation, err)
+	}
+	if !reflect.DeepEqual(children, expectedChildren) {
+		t.Fatalf("Expected children %v, found %v", expectedChildren, children)
+	}
+	// The buffered children are written with a single update.
+	if count := countUpdates(); count != 1 {
+		t.Fatalf("Expected a single update by the flush, found %d", count)
+	}
+
+	// A flush with no buffered children does not update the object.
+	resolver.flushWildcardChildren(ctx)
+	if count := countUpdates(); count != 1 {
+		t.Fatalf("Expected no update by an empty flush, found %d", count)
+	}
+}
@@ -0,0 +1,62 @@
+package ocp_dnsnameresolver
+
+import (
+	"net/netip"
+)
+
+// parseCIDR returns the prefix of the given CIDR, with the host bits masked. Note
+// that the IPv4 addresses are matched unmapped, thus only IPv4 CIDRs match them.
+func parseCIDR(value string) (netip.Prefix, error) {
+	prefix, err := netip.ParsePrefix(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return prefix.Masked(), nil
+}
+
+// filterAllowedAddresses removes the IP addresses which are not in any of the
+// allowedCIDRs from the ipTTLs map. The removed IP addresses are logged, as they
+// may indicate a hijacked DNS response. The map is not changed if allowedCIDRs
+// is not configured.
+func (resolver *OCPDNSNameResolver) filterAllowedAddresses(ipTTLs map[string]int32, dnsName string) {
+	if len(resolver.allowedCIDRs) == 0 {
+		return
+	}
+	for ip := range ipTTLs {
+		addr, err := netip.ParseAddr(ip)
+		if err == nil && resolver.allowedAddress(addr) {
+			continue
+		}
+		log.Warningf("Not recording IP address %s of DNS name %s as it is not in the allowed CIDRs", ip, dnsName)
+		delete(ipTTLs, ip)
+	}
+}
+
+// allowedAddress returns true when the IP address is in any of the allowedCIDRs.
+func (resolver *OCPDNSNameResolver) allowedAddress(addr netip.Addr) bool {
+	for _, prefix := range resolver.allowedCIDRs {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedClient returns true when the client IP address is in any of the allowedClientCIDRs,
+// or when allowedClientCIDRs is not configured.
+func (resolver *OCPDNSNameResolver) allowedClient(ip string) bool {
+	if len(resolver.allowedClientCIDRs) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range resolver.allowedClientCIDRs {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFilterAllowedAddresses(t *testing.T) {
+	tests := []struct {
+		name           string
+		allowedCIDRs   []string
+		ipTTLs         map[string]int32
+		expectedIPTTLs map[string]int32
+	}{
+		{
+			name:           "All addresses are kept when allowedCIDRs is not configured",
+			ipTTLs:         map[string]int32{"1.1.1.1": 30, "2001:db8::1": 30},
+			expectedIPTTLs: map[string]int32{"1.1.1.1": 30, "2001:db8::1": 30},
+		},
+		{
+			name:           "Addresses in range are kept",
+			allowedCIDRs:   []string{"1.1.1.0/24", "2001:db8::/32"},
+			ipTTLs:         map[string]int32{"1.1.1.1": 30, "2001:db8::1": 30},
+			expectedIPTTLs: map[string]int32{"1.1.1.1": 30, "2001:db8::1": 30},
+		},
+		{
+			name:           "Addresses out of range are removed",
+			allowedCIDRs:   []string{"1.1.1.0/24", "2001:db8::/32"},
+			ipTTLs:         map[string]int32{"1.1.1.1": 30, "1.1.2.1": 30, "2001:db9::1": 30},
+			expectedIPTTLs: map[string]int32{"1.1.1.1": 30},
+		},
+		{
+			name:           "IPv4 addresses are not matched by IPv6 CIDRs",
+			allowedCIDRs:   []string{"::/0"},
+			ipTTLs:         map[string]int32{"1.1.1.1": 30, "2001:db8::1": 30},
+			expectedIPTTLs: map[string]int32{"2001:db8::1": 30},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := New()
+			for _, cidr := range tc.allowedCIDRs {
+				resolver.allowedCIDRs = append(resolver.allowedCIDRs, netip.MustParsePrefix(cidr))
+			}
+			resolver.filterAllowedAddresses(tc.ipTTLs, "www.example.com.")
+			if diff := cmp.Diff(tc.expectedIPTTLs, tc.ipTTLs); diff != "" {
+				t.Fatalf("Unexpected IP addresses (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestServeDNSAllowedCIDRs(t *testing.T) {
+	tests := []struct {
+		name                  string
+		answer                []dns.RR
+		expectedStatusUpdates int
+		expectedIPs           []string
+	}{
+		{
+			name: "Only the addresses in range are recorded",
+			answer: []dns.RR{
+				test.A("www.example.com. 30 IN A 1.1.1.1"),
+				test.A("www.example.com. 30 IN A 6.6.6.6"),
+			},
+			expectedStatusUpdates: 1,
+			expectedIPs:           []string{"1.1.1.1"},
+		},
+		{
+			name: "Nothing is recorded when all the addresses are out of range",
+			answer: []dns.RR{
+				test.A("www.example.com. 30 IN A 6.6.6.6"),
+			},
+			expectedStatusUpdates: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.allowedCIDRs = []netip.Prefix{netip.MustParsePrefix("1.1.1.0/24")}
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			})
+
+			query := test.Case{
+				Qname:  "www.example.com.",
+				Qtype:  dns.TypeA,
+				Rcode:  dns.RcodeSuccess,
+				Answer: tc.answer,
+			}
+			resolver.Next = fakeNextPluginHandler(query)
+			resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+			if count := countStatusUpdates(fakeNetworkClient); count != tc.expectedStatusUpdates {
+				t.Fatalf("Expected %d status updates, found %d", tc.expectedStatusUpdates, count)
+			}
+			if tc.expectedStatusUpdates == 0 {
+				return
+			}
+			resolverObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+				return len(obj.Status.ResolvedNames) > 0
+			})
+			var ips []string
+			for _, address := range resolverObj.Status.ResolvedNames[0].ResolvedAddresses {
+				ips = append(ips, address.IP)
+			}
+			if diff := cmp.Diff(tc.expectedIPs, ips); diff != "" {
+				t.Fatalf("Unexpected recorded IP addresses (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestServeDNSAllowedClientCIDRs(t *testing.T) {
+	tests := []struct {
+		name                  string
+		allowedClientCIDRs    []netip.Prefix
+		responseWriter        dns.ResponseWriter
+		expectedStatusUpdates int
+	}{
+		{
+			name:                  "Queries of all the clients are recorded by default",
+			responseWriter:        &test.ResponseWriter{},
+			expectedStatusUpdates: 1,
+		},
+		{
+			name:                  "Query of an allowed IPv4 client is recorded",
+			allowedClientCIDRs:    []netip.Prefix{netip.MustParsePrefix("10.240.0.0/16")},
+			responseWriter:        &test.ResponseWriter{},
+			expectedStatusUpdates: 1,
+		},
+		{
+			name:                  "Query of an allowed IPv6 client is recorded",
+			allowedClientCIDRs:    []netip.Prefix{netip.MustParsePrefix("10.240.0.0/16"), netip.MustParsePrefix("fe80::/10")},
+			responseWriter:        &test.ResponseWriter6{},
+			expectedStatusUpdates: 1,
+		},
+		{
+			name:                  "Query of a client outside the allowed CIDRs is not recorded",
+			allowedClientCIDRs:    []netip.Prefix{netip.MustParsePrefix("192.168.0.0/16")},
+			responseWriter:        &test.ResponseWriter{},
+			expectedStatusUpdates: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.allowedClientCIDRs = tc.allowedClientCIDRs
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			})
+
+			query := test.Case{
+				Qname: "www.example.com.",
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A("www.example.com. 30 IN A 1.1.1.1"),
+				},
+			}
+			resolver.Next = fakeNextPluginHandler(query)
+
+			w := dnstest.NewRecorder(tc.responseWriter)
+			resolver.ServeDNS(context.TODO(), w, query.Msg())
+			if w.Msg == nil || len(w.Msg.Answer) != 1 {
+				t.Fatalf("Expected the response of the next plugin to be served, found: %v", w.Msg)
+			}
+			if count := countStatusUpdates(fakeNetworkClient); count != tc.expectedStatusUpdates {
+				t.Fatalf("Expected %d status updates, found %d", tc.expectedStatusUpdates, count)
+			}
+		})
+	}
+}
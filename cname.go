@@ -0,0 +1,114 @@
+package ocp_dnsnameresolver
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// partialChainPolicy determines how the failed DNS lookup responses whose answer section
+// still contains IP addresses of the CNAME chain of the DNS name are handled, eg. when a
+// hop of the CNAME chain resolves and another one fails.
+type partialChainPolicy string
+
+const (
+	// partialChainPolicyFail handles the whole DNS lookup as failed, ignoring the IP
+	// addresses of the answer section.
+	partialChainPolicyFail partialChainPolicy = "fail"
+	// partialChainPolicyRecordPartial records the IP addresses of the CNAME chain present
+	// in the answer section, as for a successful DNS lookup.
+	partialChainPolicyRecordPartial partialChainPolicy = "record-partial"
+)
+
+// parsePartialChainPolicy returns the partialChainPolicy corresponding to the given
+// value and whether the value is a valid partialChainPolicy.
+func parsePartialChainPolicy(value string) (partialChainPolicy, bool) {
+	switch policy := partialChainPolicy(value); policy {
+	case partialChainPolicyFail, partialChainPolicyRecordPartial:
+		return policy, true
+	}
+	return "", false
+}
+
+// recordPartialChain returns whether the IP addresses of the CNAME chain obtained by a failed
+// DNS lookup should be recorded as for a successful DNS lookup, according to the configured
+// partialChainPolicy. The DNS lookups failed with an error, eg. a timeout, are never recorded.
+func (resolver *OCPDNSNameResolver) recordPartialChain(dnsName string, status int, err error, ipTTLs map[string]int32) bool {
+	if resolver.partialChainPolicy != partialChainPolicyRecordPartial || err != nil ||
+		status == dns.RcodeSuccess || len(ipTTLs) == 0 {
+		return false
+	}
+	log.Debugf("Recording the %d IP addresses of the partially resolved CNAME chain of DNS name %s failed with rcode %s",
+		len(ipTTLs), dnsName, dns.RcodeToString[status])
+	return true
+}
+
+// cnameChain returns the DNS names of the CNAME chain of the DNS name in the answer
+// section of a DNS lookup response, starting with the DNS name itself. At most
+// maxCNAMEDepth CNAME hops are followed. The chain is cut with a warning if it loops
+// or if it exceeds maxCNAMEDepth, in which case the DNS names beyond the cut are not
+// part of the chain. The DNS names are lowercased.
+func (resolver *OCPDNSNameResolver) cnameChain(dnsName string, answers []dns.RR) map[string]struct{} {
+	// Get the targets of the CNAME records. Only the first CNAME record of a DNS name is
+	// considered, as a DNS name can't have multiple CNAME records.
+	targets := make(map[string]string)
+	for _, answer := range answers {
+		if rec, ok := answer.(*dns.CNAME); ok {
+			owner := strings.ToLower(rec.Hdr.Name)
+			if _, exists := targets[owner]; !exists {
+				targets[owner] = strings.ToLower(rec.Target)
+			}
+		}
+	}
+
+	current := strings.ToLower(dnsName)
+	chain := map[string]struct{}{current: {}}
+	for hops := 0; ; hops++ {
+		target, exists := targets[current]
+		if !exists {
+			break
+		}
+		if _, visited := chain[target]; visited {
+			log.Warningf("Not following the CNAME chain of DNS name %s further as it loops back from %s to %s", dnsName, current, target)
+			break
+		}
+		if hops >= resolver.maxCNAMEDepth {
+			log.Warningf("Not following the CNAME chain of DNS name %s further as it exceeds the maximum depth %d", dnsName, resolver.maxCNAMEDepth)
+			break
+		}
+		chain[target] = struct{}{}
+		current = target
+	}
+	return chain
+}
+
+// inCNAMEChain checks if the DNS name is part of the CNAME chain.
+func inCNAMEChain(chain map[string]struct{}, dnsName string) bool {
+	_, exists := chain[strings.ToLower(dnsName)]
+	return exists
+}
+
+// mismatchedOwner returns the owner name of the first record of the queried type in the
+// answer section which is not reachable from the queried DNS name through the CNAME records
+// of the answer section. The reachable DNS names include the ones beyond the cut of the
+// CNAME chain at maxCNAMEDepth, which is not a mismatch.
+func mismatchedOwner(dnsName string, qtype uint16, answers []dns.RR) (string, bool) {
+	reachable := map[string]struct{}{strings.ToLower(dnsName): {}}
+	for added := true; added; {
+		added = false
+		for _, answer := range answers {
+			rec, ok := answer.(*dns.CNAME)
+			if !ok || !inCNAMEChain(reachable, rec.Hdr.Name) || inCNAMEChain(reachable, rec.Target) {
+				continue
+			}
+			reachable[strings.ToLower(rec.Target)] = struct{}{}
+			added = true
+		}
+	}
+	for _, answer := range answers {
+		if answer.Header().Rrtype == qtype && !inCNAMEChain(reachable, answer.Header().Name) {
+			return answer.Header().Name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,389 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCNAMEChain(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxCNAMEDepth int
+		answers       []dns.RR
+		expectedChain []string
+	}{
+		{
+			name:          "No CNAME records",
+			maxCNAMEDepth: 8,
+			answers: []dns.RR{
+				test.A("www.example.com. 30 IN A 1.1.1.1"),
+			},
+			expectedChain: []string{"www.example.com."},
+		},
+		{
+			name:          "CNAME chain within the depth",
+			maxCNAMEDepth: 2,
+			answers: []dns.RR{
+				test.CNAME("www.example.com. 30 IN CNAME Edge.example.net."),
+				test.CNAME("edge.example.net. 30 IN CNAME cdn.example.org."),
+				test.A("cdn.example.org. 30 IN A 1.1.1.1"),
+			},
+			expectedChain: []string{"www.example.com.", "edge.example.net.", "cdn.example.org."},
+		},
+		{
+			name:          "CNAME chain exceeding the depth",
+			maxCNAMEDepth: 1,
+			answers: []dns.RR{
+				test.CNAME("www.example.com. 30 IN CNAME edge.example.net."),
+				test.CNAME("edge.example.net. 30 IN CNAME cdn.example.org."),
+				test.A("cdn.example.org. 30 IN A 1.1.1.1"),
+			},
+			expectedChain: []string{"www.example.com.", "edge.example.net."},
+		},
+		{
+			name:          "Looping CNAME chain",
+			maxCNAMEDepth: 8,
+			answers: []dns.RR{
+				test.CNAME("www.example.com. 30 IN CNAME edge.example.net."),
+				test.CNAME("edge.example.net. 30 IN CNAME www.example.com."),
+			},
+			expectedChain: []string{"www.example.com.", "edge.example.net."},
+		},
+		{
+			name:          "CNAME records outside the chain",
+			maxCNAMEDepth: 8,
+			answers: []dns.RR{
+				test.CNAME("other.example.com. 30 IN CNAME edge.example.net."),
+				test.A("edge.example.net. 30 IN A 1.1.1.1"),
+			},
+			expectedChain: []string{"www.example.com."},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := New()
+			resolver.maxCNAMEDepth = tc.maxCNAMEDepth
+
+			chain := resolver.cnameChain("www.example.com.", tc.answers)
+			expectedChain := make(map[string]struct{})
+			for _, dnsName := range tc.expectedChain {
+				expectedChain[dnsName] = struct{}{}
+			}
+			if !reflect.DeepEqual(chain, expectedChain) {
+				t.Fatalf("Expected CNAME chain %v, found %v", expectedChain, chain)
+			}
+		})
+	}
+}
+
+func TestServeDNSMaxCNAMEDepth(t *testing.T) {
+	tests := []struct {
+		name                  string
+		maxCNAMEDepth         int
+		answers               []dns.RR
+		expectedStatusUpdates int
+	}{
+		{
+			name:          "Addresses at the end of the CNAME chain are recorded",
+			maxCNAMEDepth: 2,
+			answers: []dns.RR{
+				test.CNAME("www.example.com. 30 IN CNAME edge.example.net."),
+				test.CNAME("edge.example.net. 30 IN CNAME cdn.example.org."),
+				test.A("cdn.example.org. 30 IN A 1.1.1.1"),
+			},
+			expectedStatusUpdates: 1,
+		},
+		{
+			name:          "Addresses beyond the depth are not recorded",
+			maxCNAMEDepth: 1,
+			answers: []dns.RR{
+				test.CNAME("www.example.com. 30 IN CNAME edge.example.net."),
+				test.CNAME("edge.example.net. 30 IN CNAME cdn.example.org."),
+				test.A("cdn.example.org. 30 IN A 1.1.1.1"),
+			},
+			expectedStatusUpdates: 0,
+		},
+		{
+			name:          "Addresses of a looping CNAME chain are not recorded",
+			maxCNAMEDepth: 8,
+			answers: []dns.RR{
+				test.CNAME("www.example.com. 30 IN CNAME edge.example.net."),
+				test.CNAME("edge.example.net. 30 IN CNAME www.example.com."),
+				test.A("cdn.example.org. 30 IN A 1.1.1.1"),
+			},
+			expectedStatusUpdates: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.maxCNAMEDepth = tc.maxCNAMEDepth
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			})
+
+			query := test.Case{
+				Qname:  "www.example.com.",
+				Qtype:  dns.TypeA,
+				Rcode:  dns.RcodeSuccess,
+				Answer: tc.answers,
+			}
+			resolver.Next = fakeNextPluginHandler(query)
+
+			w := dnstest.NewRecorder(&test.ResponseWriter{})
+			resolver.ServeDNS(ctx, w, query.Msg())
+			if w.Msg == nil || len(w.Msg.Answer) != len(tc.answers) {
+				t.Fatalf("Expected the response of the next plugin to be served, found: %v", w.Msg)
+			}
+			if count := countStatusUpdates(fakeNetworkClient); count != tc.expectedStatusUpdates {
+				t.Fatalf("Expected %d status updates, found %d", tc.expectedStatusUpdates, count)
+			}
+		})
+	}
+}
+
+func TestMismatchedOwner(t *testing.T) {
+	tests := []struct {
+		name          string
+		answers       []dns.RR
+		expectedOwner string
+	}{
+		{
+			name: "Records of the queried DNS name",
+			answers: []dns.RR{
+				test.A("WWW.example.com. 30 IN A 1.1.1.1"),
+			},
+		},
+		{
+			name: "Records at the end of the CNAME chain",
+			answers: []dns.RR{
+				test.CNAME("www.example.com. 30 IN CNAME edge.example.net."),
+				test.CNAME("edge.example.net. 30 IN CNAME cdn.example.org."),
+				test.A("cdn.example.org. 30 IN A 1.1.1.1"),
+			},
+		},
+		{
+			name: "Records of other types are ignored",
+			answers: []dns.RR{
+				test.A("www.example.com. 30 IN A 1.1.1.1"),
+				test.AAAA("other.example.com. 30 IN AAAA ::1"),
+			},
+		},
+		{
+			name: "Record of another DNS name",
+			answers: []dns.RR{
+				test.A("www.example.com. 30 IN A 1.1.1.1"),
+				test.A("other.example.com. 30 IN A 2.2.2.2"),
+			},
+			expectedOwner: "other.example.com.",
+		},
+		{
+			name: "Record of the target of a CNAME record outside the chain",
+			answers: []dns.RR{
+				test.CNAME("other.example.com. 30 IN CNAME edge.example.net."),
+				test.A("edge.example.net. 30 IN A 1.1.1.1"),
+			},
+			expectedOwner: "edge.example.net.",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			owner, mismatched := mismatchedOwner("www.example.com.", dns.TypeA, tc.answers)
+			if mismatched != (tc.expectedOwner != "") || owner != tc.expectedOwner {
+				t.Fatalf("Expected mismatched owner %q, found %q (mismatched: %t)", tc.expectedOwner, owner, mismatched)
+			}
+		})
+	}
+}
+
+func TestServeDNSStrictQNameMatch(t *testing.T) {
+	tests := []struct {
+		name                  string
+		strictQNameMatch      bool
+		answers               []dns.RR
+		expectedStatusUpdates int
+		expectedRejections    float64
+	}{
+		{
+			name:             "Matching answer is recorded",
+			strictQNameMatch: true,
+			answers: []dns.RR{
+				test.CNAME("www.example.com. 30 IN CNAME edge.example.net."),
+				test.A("edge.example.net. 30 IN A 1.1.1.1"),
+			},
+			expectedStatusUpdates: 1,
+		},
+		{
+			name:             "Answer with a mismatched owner is rejected",
+			strictQNameMatch: true,
+			answers: []dns.RR{
+				test.A("www.example.com. 30 IN A 1.1.1.1"),
+				test.A("other.example.com. 30 IN A 2.2.2.2"),
+			},
+			expectedStatusUpdates: 0,
+			expectedRejections:    1,
+		},
+		{
+			name:             "Matching records of an answer with a mismatched owner are recorded without strictQNameMatch",
+			strictQNameMatch: false,
+			answers: []dns.RR{
+				test.A("www.example.com. 30 IN A 1.1.1.1"),
+				test.A("other.example.com. 30 IN A 2.2.2.2"),
+			},
+			expectedStatusUpdates: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.strictQNameMatch = tc.strictQNameMatch
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			})
+
+			query := test.Case{
+				Qname:  "www.example.com.",
+				Qtype:  dns.TypeA,
+				Rcode:  dns.RcodeSuccess,
+				Answer: tc.answers,
+			}
+			resolver.Next = fakeNextPluginHandler(query)
+
+			rejections := testutil.ToFloat64(rejectedResponses.WithLabelValues(rejectReasonQNameMismatch))
+			w := dnstest.NewRecorder(&test.ResponseWriter{})
+			resolver.ServeDNS(ctx, w, query.Msg())
+			if w.Msg == nil || len(w.Msg.Answer) != len(tc.answers) {
+				t.Fatalf("Expected the response of the next plugin to be served, found: %v", w.Msg)
+			}
+			if count := countStatusUpdates(fakeNetworkClient); count != tc.expectedStatusUpdates {
+				t.Fatalf("Expected %d status updates, found %d", tc.expectedStatusUpdates, count)
+			}
+			if value := testutil.ToFloat64(rejectedResponses.WithLabelValues(rejectReasonQNameMismatch)) - rejections; value != tc.expectedRejections {
+				t.Fatalf("Expected %v rejections, found %v", tc.expectedRejections, value)
+			}
+		})
+	}
+}
+
+func TestServeDNSPartialChainPolicy(t *testing.T) {
+	// The CNAME chain resolves through edge.example.net. to cdn.example.org., while the
+	// lookup of another hop fails, thus the response is a SERVFAIL still containing the
+	// IP address of cdn.example.org.
+	partialAnswers := []dns.RR{
+		test.CNAME("www.example.com. 30 IN CNAME edge.example.net."),
+		test.CNAME("edge.example.net. 30 IN CNAME cdn.example.org."),
+		test.A("cdn.example.org. 30 IN A 1.1.1.1"),
+	}
+	failedAnswers := []dns.RR{
+		test.CNAME("www.example.com. 30 IN CNAME edge.example.net."),
+	}
+	tests := []struct {
+		name                  string
+		policy                partialChainPolicy
+		answers               []dns.RR
+		expectedStatusUpdates int
+		expectedIPs           []string
+	}{
+		{
+			name:                  "Partial chain is handled as failed",
+			policy:                partialChainPolicyFail,
+			answers:               partialAnswers,
+			expectedStatusUpdates: 0,
+		},
+		{
+			name:                  "Addresses of the partial chain are recorded",
+			policy:                partialChainPolicyRecordPartial,
+			answers:               partialAnswers,
+			expectedStatusUpdates: 1,
+			expectedIPs:           []string{"1.1.1.1"},
+		},
+		{
+			name:                  "Chain without addresses is handled as failed",
+			policy:                partialChainPolicyRecordPartial,
+			answers:               failedAnswers,
+			expectedStatusUpdates: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.partialChainPolicy = tc.policy
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			})
+
+			query := test.Case{
+				Qname:  "www.example.com.",
+				Qtype:  dns.TypeA,
+				Rcode:  dns.RcodeServerFailure,
+				Answer: tc.answers,
+			}
+			resolver.Next = fakeNextPluginHandler(query)
+
+			w := dnstest.NewRecorder(&test.ResponseWriter{})
+			status, _ := resolver.ServeDNS(ctx, w, query.Msg())
+			if status != dns.RcodeServerFailure {
+				t.Fatalf("Expected the rcode %s of the next plugin to be returned, found %s",
+					dns.RcodeToString[dns.RcodeServerFailure], dns.RcodeToString[status])
+			}
+			if count := countStatusUpdates(fakeNetworkClient); count != tc.expectedStatusUpdates {
+				t.Fatalf("Expected %d status updates, found %d", tc.expectedStatusUpdates, count)
+			}
+			if tc.expectedIPs == nil {
+				return
+			}
+			resolverObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+				return len(obj.Status.ResolvedNames) == 1
+			})
+			if ips := resolvedIPs(resolverObj, "www.example.com."); !reflect.DeepEqual(ips, tc.expectedIPs) {
+				t.Fatalf("Expected IP addresses %v, found %v", tc.expectedIPs, ips)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package ocp_dnsnameresolver
+
+import (
+	"sync"
+	"time"
+)
+
+// coalesceSweepSize gives the number of tracked queries above which the queries outside
+// the coalescing window are dropped by the queryCoalescer.
+const coalesceSweepSize = 1024
+
+// coalesceKey identifies the identical DNS lookups which are coalesced.
+type coalesceKey struct {
+	dnsName string
+	qtype   uint16
+}
+
+// queryCoalescer collapses the identical DNS lookups within a short window, eg. the DNS
+// lookups retried rapidly by the clients, into a single update attempt of the status of
+// the DNSNameResolver objects.
+type queryCoalescer struct {
+	// attemptTimes stores the times of the DNS lookups which started an update attempt.
+	// key: DNS name and query type, value: time of the DNS lookup.
+	attemptTimes map[coalesceKey]time.Time
+	lock         sync.Mutex
+}
+
+// newQueryCoalescer returns an initialized queryCoalescer.
+func newQueryCoalescer() *queryCoalescer {
+	return &queryCoalescer{
+		attemptTimes: make(map[coalesceKey]time.Time),
+	}
+}
+
+// admit records a DNS lookup of the DNS name of the query type at the given time and
+// returns true if the DNS lookup should start an update attempt, i.e. if no identical
+// DNS lookup started an update attempt within the window.
+func (coalescer *queryCoalescer) admit(dnsName string, qtype uint16, now time.Time, window time.Duration) bool {
+	if window <= 0 {
+		return true
+	}
+
+	coalescer.lock.Lock()
+	defer coalescer.lock.Unlock()
+
+	key := coalesceKey{dnsName: dnsName, qtype: qtype}
+	if attemptTime, exists := coalescer.attemptTimes[key]; exists && now.Sub(attemptTime) < window {
+		return false
+	}
+
+	// Drop the DNS lookups outside the window, so that the DNS names which are not looked
+	// up anymore do not accumulate.
+	if len(coalescer.attemptTimes) >= coalesceSweepSize {
+		for otherKey, attemptTime := range coalescer.attemptTimes {
+			if now.Sub(attemptTime) >= window {
+				delete(coalescer.attemptTimes, otherKey)
+			}
+		}
+	}
+	coalescer.attemptTimes[key] = now
+	return true
+}
@@ -0,0 +1,109 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestQueryCoalescerAdmit(t *testing.T) {
+	start := time.Now()
+	window := 100 * time.Millisecond
+	coalescer := newQueryCoalescer()
+
+	steps := []struct {
+		elapsed        time.Duration
+		dnsName        string
+		qtype          uint16
+		expectedResult bool
+	}{
+		// The first DNS lookup starts an update attempt.
+		{0, "www.example.com.", dns.TypeA, true},
+		// The identical DNS lookups within the window are coalesced.
+		{10 * time.Millisecond, "www.example.com.", dns.TypeA, false},
+		{90 * time.Millisecond, "www.example.com.", dns.TypeA, false},
+		// The DNS lookups of another query type or another DNS name are not coalesced.
+		{10 * time.Millisecond, "www.example.com.", dns.TypeAAAA, true},
+		{10 * time.Millisecond, "www.example.org.", dns.TypeA, true},
+		// Once the window of the first DNS lookup ends, the next DNS lookup starts an update
+		// attempt and a new window.
+		{100 * time.Millisecond, "www.example.com.", dns.TypeA, true},
+		{150 * time.Millisecond, "www.example.com.", dns.TypeA, false},
+		{200 * time.Millisecond, "www.example.com.", dns.TypeA, true},
+	}
+	for i, step := range steps {
+		if result := coalescer.admit(step.dnsName, step.qtype, start.Add(step.elapsed), window); result != step.expectedResult {
+			t.Fatalf("Step %d: Expected result %t for DNS name %s, found %t", i, step.expectedResult, step.dnsName, result)
+		}
+	}
+
+	// Without a window, every DNS lookup starts an update attempt.
+	for i := 0; i < 2; i++ {
+		if !coalescer.admit("www.example.net.", dns.TypeA, start, 0) {
+			t.Fatalf("Expected the DNS lookup not to be coalesced without a window")
+		}
+	}
+}
+
+func TestServeDNSQueryCoalesceWindow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.queryCoalesceWindow = time.Hour
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "regular",
+			Namespace: "dns",
+		},
+		Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+			Name: "www.example.com.",
+		},
+	})
+
+	// The rapidly repeated DNS lookups return different IP addresses, which would each
+	// update the status without coalescing.
+	for _, ip := range []string{"1.1.1.1", "1.1.1.2", "1.1.1.3"} {
+		query := test.Case{
+			Qname: "www.example.com.",
+			Qtype: dns.TypeA,
+			Rcode: dns.RcodeSuccess,
+			Answer: []dns.RR{
+				test.A("www.example.com. 30 IN A " + ip),
+			},
+		}
+		resolver.Next = fakeNextPluginHandler(query)
+
+		w := dnstest.NewRecorder(&test.ResponseWriter{})
+		resolver.ServeDNS(context.TODO(), w, query.Msg())
+		if w.Msg == nil || len(w.Msg.Answer) != 1 {
+			t.Fatalf("Expected the response of the next plugin to be served, found: %v", w.Msg)
+		}
+	}
+	if count := countStatusUpdates(fakeNetworkClient); count != 1 {
+		t.Fatalf("Expected 1 status update for the coalesced DNS lookups, found %d", count)
+	}
+
+	// A DNS lookup of another query type is not coalesced with the previous DNS lookups.
+	query := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeAAAA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.AAAA("www.example.com. 30 IN AAAA 2001:db8::1"),
+		},
+	}
+	resolver.Next = fakeNextPluginHandler(query)
+	resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+	if count := countStatusUpdates(fakeNetworkClient); count != 2 {
+		t.Fatalf("Expected 2 status updates, found %d", count)
+	}
+}
@@ -0,0 +1,166 @@
+package ocp_dnsnameresolver
+
+import (
+	"net/netip"
+	"sort"
+
+	"github.com/miekg/dns"
+)
+
+// EffectiveConfig returns the effective configuration of the plugin, keyed by the names
+// of the options of the Corefile, eg. for support bundles. All the options are returned,
+// including the ones which are omitted from the Corefile, with their default values. The
+// durations are formatted as strings, eg. 1m0s, and the values of the options taking
+// several arguments are returned as maps. The returned map is JSON encodable.
+func (resolver *OCPDNSNameResolver) EffectiveConfig() map[string]any {
+	namespaces := make([]string, 0, len(resolver.namespaces))
+	for namespace := range resolver.namespaces {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	labelSelector := ""
+	if resolver.labelSelector != nil {
+		labelSelector = resolver.labelSelector.String()
+	}
+	namespaceSelector := ""
+	if resolver.namespaceSelector != nil {
+		namespaceSelector = resolver.namespaceSelector.String()
+	}
+	namespaceRegex := ""
+	if resolver.namespaceRegex != nil {
+		namespaceRegex = resolver.namespaceRegex.String()
+	}
+
+	zoneTTLs := make(map[string]any, len(resolver.zoneTTLs))
+	for zone, policy := range resolver.zoneTTLs {
+		zoneTTLs[zone] = map[string]any{"minTTL": policy.minTTL, "maxTTL": policy.maxTTL}
+	}
+
+	failureWeights := make(map[string]float64, len(resolver.failureWeights))
+	for rcode, weight := range resolver.failureWeights {
+		failureWeights[dns.RcodeToString[rcode]] = weight
+	}
+
+	mirrorConfigMap := ""
+	if resolver.mirrorConfigMap.Name != "" {
+		mirrorConfigMap = resolver.mirrorConfigMap.String()
+	}
+	maintenanceSentinel := ""
+	if resolver.maintenanceSentinel.Name != "" {
+		maintenanceSentinel = resolver.maintenanceSentinel.String()
+	}
+
+	return map[string]any{
+		namespacesField:             namespaces,
+		internalZonesField:          append([]string{}, resolver.internalZones...),
+		externalZonesField:          append([]string{}, resolver.externalZones...),
+		labelSelectorField:          labelSelector,
+		namespaceSelectorField:      namespaceSelector,
+		namespaceRegexField:         namespaceRegex,
+		filterOperatorField:         string(resolver.filterOperator),
+		minTTLField:                 resolver.minimumTTL,
+		failureTTLField:             resolver.failureTTL,
+		zoneTTLField:                zoneTTLs,
+		ttlJitterField:              resolver.ttlJitter,
+		minRemainingTTLField:        resolver.minRemainingTTL,
+		failureThresholdField:       resolver.failureThreshold,
+		failureThresholdV4Field:     resolver.failureThresholdV4,
+		failureThresholdV6Field:     resolver.failureThresholdV6,
+		failureWeightField:          failureWeights,
+		minQueriesField:             resolver.minQueries,
+		minQueriesWindowField:       resolver.minQueriesWindow.String(),
+		accumulateWindowField:       resolver.accumulateWindow.String(),
+		maxRecordAgeField:           resolver.maxRecordAge.String(),
+		mergeWindowField:            resolver.mergeWindow.String(),
+		negativeMaxAgeField:         resolver.negativeMaxAge.String(),
+		settleWindowField:           resolver.settleWindow.String(),
+		wildcardFlushIntervalField:  resolver.wildcardFlushInterval.String(),
+		reconcileIntervalField:      resolver.reconcileInterval.String(),
+		summaryEventIntervalField:   resolver.summaryEventInterval.String(),
+		flushIntervalField:          resolver.flushInterval.String(),
+		staleGraceField:             resolver.staleGrace.String(),
+		namespacePacingField:        resolver.namespacePacing.String(),
+		queryCoalesceWindowField:    resolver.queryCoalesceWindow.String(),
+		failureLogIntervalField:     resolver.failureLogInterval.String(),
+		breakerThresholdField:       resolver.breakerThreshold,
+		breakerCooldownField:        resolver.breakerCooldown.String(),
+		maxAnswerRecordsField:       resolver.maxAnswerRecords,
+		maxTrackedNamesField:        resolver.maxTrackedNames,
+		maxCNAMEDepthField:          resolver.maxCNAMEDepth,
+		allowedCIDRsField:           formatPrefixes(resolver.allowedCIDRs),
+		excludeServiceCIDRField:     map[string]any{"enabled": resolver.excludeServiceCIDR, "cidrs": formatPrefixes(resolver.serviceCIDRs)},
+		allowedClientCIDRsField:     formatPrefixes(resolver.allowedClientCIDRs),
+		listPageSizeField:           resolver.listPageSize,
+		shutdownTimeoutField:        resolver.shutdownTimeout.String(),
+		overlapPolicyField:          string(resolver.overlapPolicy),
+		truncatedPolicyField:        string(resolver.truncatedPolicy),
+		partialChainPolicyField:     string(resolver.partialChainPolicy),
+		fanOutOrderField:            string(resolver.fanOutOrder),
+		conflictResolutionField:     string(resolver.conflictResolution),
+		addressOrderField:           string(resolver.addressOrder),
+		wildcardNamespaceScopeField: string(resolver.wildcardNamespaceScope),
+		wildcardSpecificityField:    string(resolver.wildcardSpecificity),
+		multiNamespaceField: map[string]any{
+			"policy":   string(resolver.multiNamespacePolicy),
+			"priority": append([]string{}, resolver.namespacePriority...),
+		},
+		maxUpdatesPerObjectField: map[string]any{
+			"updates":  resolver.maxUpdatesPerObject,
+			"interval": resolver.updateRateInterval.String(),
+		},
+		instanceIDField:          resolver.instanceID,
+		mirrorConfigMapField:     mirrorConfigMap,
+		maintenanceSentinelField: maintenanceSentinel,
+		validateOnlyField:        resolver.validateOnly,
+		recordSRVField:           resolver.recordSRV,
+		preserveCaseField:        resolver.preserveCase,
+		prefetchOnStartField:     resolver.prefetchOnStart,
+		auditLogField:            resolver.auditLog,
+		recordNegativeField:      resolver.recordNegative,
+		regexMatchField:          resolver.regexMatch,
+		retryForbiddenField:      resolver.retryForbidden,
+		failClosedField:          resolver.failClosed,
+		rejectApexWildcardField:  resolver.rejectApexWildcard,
+		strictQNameMatchField:    resolver.strictQNameMatch,
+		signalDumpField:          resolver.signalDump,
+		debugAddressField:        resolver.debugAddress,
+		webhookURLField:          resolver.webhookURL,
+		recordUpstreamField:      resolver.recordUpstream,
+		recordWildcardChildrenField: map[string]any{
+			"enabled":     resolver.recordWildcardChildren,
+			"maxChildren": resolver.maxWildcardChildren,
+		},
+		perNamespaceMetricsField: map[string]any{
+			"enabled":       resolver.perNamespaceMetrics,
+			"maxNamespaces": resolver.maxNamespaceLabels,
+		},
+		recordPTRField:                resolver.recordPTR,
+		lazyStartField:                resolver.lazyStart,
+		includeAdditionalField:        resolver.includeAdditional,
+		doubleCheckField:              resolver.doubleCheck,
+		pruneDeletedNamespacesField:   resolver.pruneDeletedNamespaces,
+		recordObservedGenerationField: resolver.recordObservedGeneration,
+		rejectInternalWildcardsField:  resolver.rejectInternalWildcards,
+		recordExtendedErrorsField:     resolver.recordExtendedErrors,
+		exemplarsField:                resolver.exemplars,
+		serveStaleOnTimeoutField:      resolver.serveStaleOnTimeout,
+		singleflightField:             resolver.singleflight,
+		useFinalizerField:             resolver.useFinalizer,
+		liveGetField:                  resolver.liveGet,
+		recordFingerprintField:        resolver.recordFingerprint,
+		refusedIsBlockField:           resolver.refusedIsBlock,
+		rejectCDBitField:              resolver.rejectCDBit,
+		diagnosticsField:              resolver.diagnostics,
+		clampTTLField:                 resolver.clampTTL,
+	}
+}
+
+// formatPrefixes formats the CIDRs of the effective configuration.
+func formatPrefixes(prefixes []netip.Prefix) []string {
+	values := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		values = append(values, prefix.String())
+	}
+	return values
+}
@@ -0,0 +1,86 @@
+package ocp_dnsnameresolver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/coredns/caddy"
+)
+
+func TestEffectiveConfig(t *testing.T) {
+	// The defaults are reflected.
+	config := New().EffectiveConfig()
+	defaults := map[string]any{
+		minTTLField:             defaultMinTTL,
+		failureThresholdField:   defaultFailureThreshold,
+		minQueriesWindowField:   "1m0s",
+		breakerCooldownField:    "30s",
+		filterOperatorField:     string(defaultFilterOperator),
+		overlapPolicyField:      string(defaultOverlapPolicy),
+		rejectApexWildcardField: true,
+		recordSRVField:          false,
+		namespacesField:         []string{},
+		instanceIDField:         "",
+	}
+	for key, expected := range defaults {
+		if value := config[key]; !reflect.DeepEqual(value, expected) {
+			t.Errorf("Expected default %s to be %#v, found %#v", key, expected, value)
+		}
+	}
+
+	// The overrides of the Corefile are reflected.
+	c := caddy.NewTestController("dns", `ocp_dnsnameresolver {
+		namespaces openshift dns
+		minTTL 10
+		zoneTTL example.com 10 60
+		failureWeight NXDOMAIN 0.5
+		maxRecordAge 10m
+		allowedCIDRs 10.0.0.0/8
+		overlapPolicy exact-only
+		mirrorConfigMap dns/status
+		recordSRV
+		recordWildcardChildren 5
+	}`)
+	resolver, err := resolverParse(c)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing the configuration: %v", err)
+	}
+	config = resolver.EffectiveConfig()
+	overrides := map[string]any{
+		namespacesField:             []string{"dns", "openshift"},
+		minTTLField:                 int32(10),
+		zoneTTLField:                map[string]any{"example.com.": map[string]any{"minTTL": int32(10), "maxTTL": int32(60)}},
+		failureWeightField:          map[string]float64{"NXDOMAIN": 0.5},
+		maxRecordAgeField:           "10m0s",
+		allowedCIDRsField:           []string{"10.0.0.0/8"},
+		overlapPolicyField:          "exact-only",
+		mirrorConfigMapField:        "dns/status",
+		recordSRVField:              true,
+		recordWildcardChildrenField: map[string]any{"enabled": true, "maxChildren": 5},
+	}
+	for key, expected := range overrides {
+		if value := config[key]; !reflect.DeepEqual(value, expected) {
+			t.Errorf("Expected %s to be %#v, found %#v", key, expected, value)
+		}
+	}
+
+	// The effective configuration is served by the debug endpoint.
+	rec := httptest.NewRecorder()
+	resolver.debugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, debugConfigPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, found %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var served map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &served); err != nil {
+		t.Fatalf("Unexpected error decoding the effective configuration: %v", err)
+	}
+	if len(served) != len(config) {
+		t.Fatalf("Expected %d options to be served, found %d", len(config), len(served))
+	}
+	if value := served[maxRecordAgeField]; value != "10m0s" {
+		t.Fatalf("Expected %s to be served as %q, found %v", maxRecordAgeField, "10m0s", value)
+	}
+}
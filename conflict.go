@@ -0,0 +1,102 @@
+package ocp_dnsnameresolver
+
+import (
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// conflictResolution determines which DNSNameResolver object is tracked when multiple
+// objects of the same namespace have the same DNS name.
+type conflictResolution string
+
+const (
+	// conflictResolutionFirst tracks the first object added to the informer, and ignores
+	// the other ones.
+	conflictResolutionFirst conflictResolution = "first"
+	// conflictResolutionOldest tracks the oldest object, by creation timestamp, and then by
+	// name, regardless of the order in which the objects are added to the informer.
+	conflictResolutionOldest conflictResolution = "oldest"
+)
+
+// parseConflictResolution returns the conflictResolution corresponding to the given value
+// and whether the value is a valid conflictResolution.
+func parseConflictResolution(value string) (conflictResolution, bool) {
+	switch resolution := conflictResolution(value); resolution {
+	case conflictResolutionFirst, conflictResolutionOldest:
+		return resolution, true
+	}
+	return "", false
+}
+
+// olderObject returns whether the DNSNameResolver object a is older than the object b. The
+// creation timestamps have a precision of a second, thus the objects created within the
+// same second are ordered by name, so that the order is deterministic.
+func olderObject(a, b *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return a.Name < b.Name
+}
+
+// cachedResolverObject returns the DNSNameResolver object from the informer cache, if it exists.
+func (resolver *OCPDNSNameResolver) cachedResolverObject(namespace, objName string) (*ocpnetworkapiv1alpha1.DNSNameResolver, bool) {
+	obj, exists, err := resolver.dnsNameResolverInformer.GetStore().GetByKey(namespace + "/" + objName)
+	if err != nil || !exists {
+		return nil, false
+	}
+	resolverObj, ok := obj.(*ocpnetworkapiv1alpha1.DNSNameResolver)
+	return resolverObj, ok
+}
+
+// keepTrackedObject returns whether the tracked DNSNameResolver object with the given name is
+// kept over the DNSNameResolver object with the same DNS name in the same namespace. The
+// tracked object is always kept if conflictResolution is first. Otherwise, the older object
+// is kept, and the tracked object is replaced if it is not cached anymore.
+func (resolver *OCPDNSNameResolver) keepTrackedObject(trackedName string, resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+	if resolver.conflictResolution != conflictResolutionOldest {
+		return true
+	}
+	trackedObj, exists := resolver.cachedResolverObject(resolverObj.Namespace, trackedName)
+	if !exists || trackedObj.Spec.Name != resolverObj.Spec.Name {
+		return false
+	}
+	if olderObject(trackedObj, resolverObj) {
+		log.Warningf("Ignoring DNSNameResolver object %s/%s with DNS name %s, as the older object %s/%s has the same DNS name",
+			resolverObj.Namespace, resolverObj.Name, resolverObj.Spec.Name, trackedObj.Namespace, trackedObj.Name)
+		return true
+	}
+	log.Warningf("Ignoring DNSNameResolver object %s/%s with DNS name %s, as the older object %s/%s has the same DNS name",
+		trackedObj.Namespace, trackedObj.Name, trackedObj.Spec.Name, resolverObj.Namespace, resolverObj.Name)
+	return false
+}
+
+// trackConflictingObject tracks the oldest cached DNSNameResolver object with the same DNS
+// name in the same namespace as the DNSNameResolver object which is not tracked anymore, if
+// conflictResolution is oldest, so that it replaces the object.
+func (resolver *OCPDNSNameResolver) trackConflictingObject(untrackedObj *ocpnetworkapiv1alpha1.DNSNameResolver) {
+	if resolver.conflictResolution != conflictResolutionOldest {
+		return
+	}
+	objs, err := resolver.dnsNameResolverInformer.GetIndexer().ByIndex(cache.NamespaceIndex, untrackedObj.Namespace)
+	if err != nil {
+		log.Errorf("Encountered error while listing the DNSNameResolver objects of namespace %s: %v", untrackedObj.Namespace, err)
+		return
+	}
+
+	var oldest *ocpnetworkapiv1alpha1.DNSNameResolver
+	for _, obj := range objs {
+		resolverObj, ok := obj.(*ocpnetworkapiv1alpha1.DNSNameResolver)
+		if !ok || resolverObj.Name == untrackedObj.Name || resolverObj.Spec.Name != untrackedObj.Spec.Name ||
+			resolverObj.DeletionTimestamp != nil || !resolver.configuredObject(resolverObj) {
+			continue
+		}
+		if oldest == nil || olderObject(resolverObj, oldest) {
+			oldest = resolverObj
+		}
+	}
+	if oldest != nil {
+		log.Infof("Tracking DNSNameResolver object %s/%s with DNS name %s in place of object %s",
+			oldest.Namespace, oldest.Name, oldest.Spec.Name, untrackedObj.Name)
+		resolver.addResolverObject(oldest)
+	}
+}
@@ -0,0 +1,100 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestOlderObject(t *testing.T) {
+	now := time.Now()
+	object := func(name string, created time.Time) *ocpnetworkapiv1alpha1.DNSNameResolver {
+		return &ocpnetworkapiv1alpha1.DNSNameResolver{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(created)},
+		}
+	}
+
+	if !olderObject(object("b", now.Add(-time.Hour)), object("a", now)) {
+		t.Errorf("Expected the object created earlier to be older")
+	}
+	if olderObject(object("a", now), object("b", now.Add(-time.Hour))) {
+		t.Errorf("Expected the object created later not to be older")
+	}
+	if !olderObject(object("a", now), object("b", now)) {
+		t.Errorf("Expected the objects created at the same time to be ordered by name")
+	}
+}
+
+func TestConflictResolution(t *testing.T) {
+	tests := []struct {
+		name               string
+		conflictResolution conflictResolution
+		expectedTracked    string
+		expectedAfter      string
+	}{
+		{
+			name:               "First object is tracked",
+			conflictResolution: conflictResolutionFirst,
+			expectedTracked:    "newer",
+		},
+		{
+			name:               "Oldest object is tracked",
+			conflictResolution: conflictResolutionOldest,
+			expectedTracked:    "older",
+			expectedAfter:      "newer",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.conflictResolution = tc.conflictResolution
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			// The newer object is added first.
+			now := time.Now()
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{Name: "newer", Namespace: "dns", CreationTimestamp: metav1.NewTime(now)},
+				Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+			})
+			_, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("dns").Create(ctx, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{Name: "older", Namespace: "dns", CreationTimestamp: metav1.NewTime(now.Add(-time.Hour))},
+				Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+			}, metav1.CreateOptions{})
+			if err != nil {
+				t.Fatalf("error injecting dns name resolver: %v", err)
+			}
+			// The informer events are handled in order, thus the older object is handled once
+			// the following object is tracked.
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{Name: "marker", Namespace: "dns"},
+				Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "marker.example.com."},
+			})
+
+			if !isTracked(resolver, "dns", tc.expectedTracked, "www.example.com.") {
+				t.Fatalf("Expected object %s to be tracked", tc.expectedTracked)
+			}
+			if tc.expectedAfter == "" {
+				return
+			}
+
+			// The remaining object is tracked once the tracked one is deleted.
+			if err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("dns").Delete(ctx, tc.expectedTracked, metav1.DeleteOptions{}); err != nil {
+				t.Fatalf("error deleting dns name resolver: %v", err)
+			}
+			err = wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 2*time.Second, true, func(context.Context) (bool, error) {
+				return isTracked(resolver, "dns", tc.expectedAfter, "www.example.com."), nil
+			})
+			if err != nil {
+				t.Fatalf("Expected object %s to be tracked once object %s is deleted", tc.expectedAfter, tc.expectedTracked)
+			}
+		})
+	}
+}
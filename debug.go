@@ -0,0 +1,260 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// debugStatePath is the path of the debug endpoint serving the dump of the tracked
+	// state of the plugin.
+	debugStatePath = "/state"
+	// debugResetFailuresPath is the path of the debug endpoint resetting the failure
+	// counters of the resolved names.
+	debugResetFailuresPath = "/reset-failures"
+	// debugConfigPath is the path of the debug endpoint serving the effective
+	// configuration of the plugin.
+	debugConfigPath = "/config"
+	// debugMaintenanceWindowPath is the path of the debug endpoint enabling and disabling
+	// the maintenance window.
+	debugMaintenanceWindowPath = "/maintenance-window"
+	// debugMetricsPath is the path of the debug endpoint serving the metrics in the
+	// OpenMetrics format, which carries the exemplars.
+	debugMetricsPath = "/metrics"
+	// debugShutdownTimeout gives the maximum time to wait for the debug server to
+	// complete the requests in flight on shutdown.
+	debugShutdownTimeout = 5 * time.Second
+)
+
+// debugHandler returns the handler of the debug endpoint.
+func (resolver *OCPDNSNameResolver) debugHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(debugStatePath, resolver.serveDebugState)
+	mux.HandleFunc(debugResetFailuresPath, resolver.serveDebugResetFailures)
+	mux.HandleFunc(debugConfigPath, resolver.serveDebugConfig)
+	mux.HandleFunc(debugMaintenanceWindowPath, resolver.serveDebugMaintenanceWindow)
+	// The metrics plugin does not serve the OpenMetrics format, thus the exemplars are
+	// only exposed by the debug endpoint.
+	mux.Handle(debugMetricsPath, promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+	return mux
+}
+
+// serveDebugState serves the dump of the tracked state of the plugin, in the format given
+// by the format query parameter: JSON encoded by default, or as plain text tables.
+func (resolver *OCPDNSNameResolver) serveDebugState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	format, ok := parseDumpFormat(r.URL.Query().Get("format"))
+	if !ok {
+		http.Error(w, fmt.Sprintf("invalid format: %q", r.URL.Query().Get("format")), http.StatusBadRequest)
+		return
+	}
+	dump := resolver.newStateDump(r.Context())
+	if format == dumpFormatText {
+		var text strings.Builder
+		if err := dump.writeText(&text); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, text.String())
+		return
+	}
+	value, err := json.Marshal(dump)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(value)
+}
+
+// serveDebugConfig serves the JSON encoded effective configuration of the plugin.
+func (resolver *OCPDNSNameResolver) serveDebugConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	value, err := json.Marshal(resolver.EffectiveConfig())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(value)
+}
+
+// serveDebugMaintenanceWindow enables or disables the maintenance window, depending on the
+// enabled query parameter. The maintenance window stays in effect while it is enabled by
+// the sentinel object of maintenanceSentinel, regardless of the debug endpoint.
+func (resolver *OCPDNSNameResolver) serveDebugMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	enabled, err := strconv.ParseBool(r.URL.Query().Get("enabled"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid value of enabled: %q", r.URL.Query().Get("enabled")), http.StatusBadRequest)
+		return
+	}
+
+	resolver.maintenance.set(true, enabled)
+	fmt.Fprintf(w, "maintenance window in effect: %t\n", resolver.maintenance.active())
+}
+
+// serveDebugResetFailures resets the failure counters of the resolved names of the DNS
+// name given by the name query parameter, or of all the resolved names if the parameter
+// is omitted. The number of the reset resolved names is returned.
+func (resolver *OCPDNSNameResolver) serveDebugResetFailures(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	dnsName := r.URL.Query().Get("name")
+	if dnsName != "" {
+		if _, ok := dns.IsDomainName(dnsName); !ok {
+			http.Error(w, fmt.Sprintf("invalid DNS name: %s", dnsName), http.StatusBadRequest)
+			return
+		}
+		dnsName = strings.ToLower(dns.Fqdn(dnsName))
+	}
+
+	reset, err := resolver.resetFailureCounters(r.Context(), dnsName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprintf(w, "reset the failure counters of %d resolved names\n", reset)
+}
+
+// resetFailureCounters resets the failure counters of the resolved names of the DNS name,
+// or of all the resolved names if the DNS name is empty, eg. once an external dependency
+// is known to have recovered. The resolutionFailures field of the resolved names in the
+// status of the tracked DNSNameResolver objects is set to zero, and the in memory counters,
+// i.e. the remainders of the weighted failures, the failures of the address families and
+// the suppressed failure logs, are dropped. The number of the reset resolved names is returned.
+func (resolver *OCPDNSNameResolver) resetFailureCounters(ctx context.Context, dnsName string) (int, error) {
+	matches := func(name string) bool {
+		return dnsName == "" || strings.EqualFold(name, dnsName)
+	}
+
+	resolver.weightedFailures.lock.Lock()
+	for key := range resolver.weightedFailures.remainders {
+		if matches(key.dnsName) {
+			delete(resolver.weightedFailures.remainders, key)
+		}
+	}
+	resolver.weightedFailures.lock.Unlock()
+
+	resolver.familyFailures.lock.Lock()
+	for key := range resolver.familyFailures.counts {
+		if matches(key.dnsName) {
+			delete(resolver.familyFailures.counts, key)
+		}
+	}
+	resolver.familyFailures.lock.Unlock()
+
+	resolver.failureLogLimiter.lock.Lock()
+	for name := range resolver.failureLogLimiter.states {
+		if matches(name) {
+			delete(resolver.failureLogLimiter.states, name)
+		}
+	}
+	resolver.failureLogLimiter.lock.Unlock()
+
+	var errs []error
+	total := 0
+	for object := range resolver.trackedObjects() {
+		reset := 0
+		err := resolver.retrier.retryUpdate(object.Namespace, object.Name, "status", func() error {
+			reset = 0
+			// Fetch a copy of the DNSNameResolver object. All the updates will be applied to the copied object.
+			newResolverObj, err := resolver.store.get(ctx, object.Namespace, object.Name)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					return nil
+				}
+				return err
+			}
+			for index, resolvedName := range newResolverObj.Status.ResolvedNames {
+				if resolvedName.ResolutionFailures != 0 && matches(string(resolvedName.DNSName)) {
+					newResolverObj.Status.ResolvedNames[index].ResolutionFailures = 0
+					reset++
+				}
+			}
+			if reset == 0 {
+				return nil
+			}
+			return resolver.store.updateStatus(ctx, newResolverObj)
+		})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if reset > 0 {
+			log.Infof("Reset the failure counters of %d resolved names of DNSNameResolver object %s/%s", reset, object.Namespace, object.Name)
+		}
+		total += reset
+	}
+	return total, errors.Join(errs...)
+}
+
+// trackedObjects returns a snapshot of the tracked DNSNameResolver objects.
+func (resolver *OCPDNSNameResolver) trackedObjects() map[types.NamespacedName]struct{} {
+	objects := make(map[types.NamespacedName]struct{})
+	resolver.regularMapLock.Lock()
+	for _, dnsInfoMap := range resolver.regularDNSInfo {
+		for namespace, objName := range dnsInfoMap {
+			objects[types.NamespacedName{Namespace: namespace, Name: objName}] = struct{}{}
+		}
+	}
+	resolver.regularMapLock.Unlock()
+	resolver.wildcardMapLock.Lock()
+	for _, dnsInfoMap := range resolver.wildcardDNSInfo {
+		for namespace, objName := range dnsInfoMap {
+			objects[types.NamespacedName{Namespace: namespace, Name: objName}] = struct{}{}
+		}
+	}
+	resolver.wildcardMapLock.Unlock()
+	return objects
+}
+
+// runDebugServer serves the debug endpoint on the debugAddress until the stop channel
+// is closed.
+func (resolver *OCPDNSNameResolver) runDebugServer(stopCh <-chan struct{}) error {
+	listener, err := net.Listen("tcp", resolver.debugAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen on the debug address %s: %w", resolver.debugAddress, err)
+	}
+	server := &http.Server{Handler: resolver.debugHandler()}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("Debug server failed: %v", err)
+		}
+	}()
+	go func() {
+		<-stopCh
+		ctx, cancel := context.WithTimeout(context.Background(), debugShutdownTimeout)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+	log.Infof("serving the debug endpoint on %s", listener.Addr())
+	return nil
+}
@@ -0,0 +1,153 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDebugResetFailures(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "wildcard", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "*.example.com."},
+	})
+	resolverObj, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("dns").Get(ctx, "wildcard", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error getting DNSNameResolver object: %v", err)
+	}
+	resolverObj.Status.ResolvedNames = []ocpnetworkapiv1alpha1.DNSNameResolverResolvedName{
+		{DNSName: "a.example.com.", ResolutionFailures: 2},
+		{DNSName: "b.example.com.", ResolutionFailures: 3},
+	}
+	if _, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("dns").UpdateStatus(ctx, resolverObj, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Unexpected error updating DNSNameResolver object: %v", err)
+	}
+	getResolverObject(t, resolver, "dns", "wildcard", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		return len(obj.Status.ResolvedNames) == 2
+	})
+	for _, dnsName := range []string{"a.example.com.", "b.example.com."} {
+		resolver.failureLogLimiter.allow(dnsName, time.Now(), time.Minute)
+	}
+
+	handler := resolver.debugHandler()
+	post := func(target string, expectedCode int) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, nil))
+		if rec.Code != expectedCode {
+			t.Fatalf("Expected status code %d for %s, found %d: %s", expectedCode, target, rec.Code, rec.Body.String())
+		}
+	}
+	// expectFailures waits until the resolution failures of the resolved names match the
+	// expected ones and checks whether the failure logs of the DNS names are tracked.
+	expectFailures := func(expected map[string]int32) {
+		t.Helper()
+		resolverObj := getResolverObject(t, resolver, "dns", "wildcard", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+			for _, resolvedName := range obj.Status.ResolvedNames {
+				if resolvedName.ResolutionFailures != expected[string(resolvedName.DNSName)] {
+					return false
+				}
+			}
+			return true
+		})
+		for _, resolvedName := range resolverObj.Status.ResolvedNames {
+			dnsName := string(resolvedName.DNSName)
+			if resolvedName.ResolutionFailures != expected[dnsName] {
+				t.Fatalf("Expected %d resolution failures of %s, found %d", expected[dnsName], dnsName, resolvedName.ResolutionFailures)
+			}
+			resolver.failureLogLimiter.lock.Lock()
+			_, logged := resolver.failureLogLimiter.states[dnsName]
+			resolver.failureLogLimiter.lock.Unlock()
+			if logged != (expected[dnsName] != 0) {
+				t.Fatalf("Expected failure log of %s to be tracked: %t, found %t", dnsName, expected[dnsName] != 0, logged)
+			}
+		}
+	}
+
+	post("/reset-failures?name=invalid..name", http.StatusBadRequest)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reset-failures", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("Expected status code %d for GET, found %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+	expectFailures(map[string]int32{"a.example.com.": 2, "b.example.com.": 3})
+
+	// The failure counter of the given DNS name is reset only.
+	post("/reset-failures?name=A.example.com", http.StatusOK)
+	expectFailures(map[string]int32{"a.example.com.": 0, "b.example.com.": 3})
+
+	// The failure counters of all the DNS names are reset.
+	post("/reset-failures", http.StatusOK)
+	expectFailures(map[string]int32{"a.example.com.": 0, "b.example.com.": 0})
+}
+
+func TestDebugStateFormat(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+	})
+
+	tests := []struct {
+		target              string
+		expectedCode        int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			target:              "/state",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        `{"regular":{"www.example.com.":{"dns":"regular"}},"wildcard":{},"failures":{}}`,
+		},
+		{
+			target:              "/state?format=json",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        `{"regular":{"www.example.com.":{"dns":"regular"}},"wildcard":{},"failures":{}}`,
+		},
+		{
+			target:              "/state?format=text",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/plain; charset=utf-8",
+			expectedBody:        "TYPE     DNS NAME          NAMESPACE  NAME\nregular  www.example.com.  dns        regular\n\nOBJECT  DNS NAME  FAILURES\n",
+		},
+		{
+			target:       "/state?format=yaml",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	handler := resolver.debugHandler()
+	for _, tc := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.target, nil))
+		if rec.Code != tc.expectedCode {
+			t.Fatalf("Expected status code %d for %s, found %d: %s", tc.expectedCode, tc.target, rec.Code, rec.Body.String())
+		}
+		if tc.expectedCode != http.StatusOK {
+			continue
+		}
+		if contentType := rec.Header().Get("Content-Type"); contentType != tc.expectedContentType {
+			t.Fatalf("Expected content type %q for %s, found %q", tc.expectedContentType, tc.target, contentType)
+		}
+		if body := rec.Body.String(); body != tc.expectedBody {
+			t.Fatalf("Expected body for %s:\n%s\nfound:\n%s", tc.target, tc.expectedBody, body)
+		}
+	}
+}
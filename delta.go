@@ -0,0 +1,77 @@
+package ocp_dnsnameresolver
+
+import (
+	"encoding/json"
+	"sort"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+)
+
+// addressChangeRecord is the structured log line emitted for each status write of a
+// DNSNameResolver object which changes its set of recorded IP addresses.
+type addressChangeRecord struct {
+	Namespace string   `json:"namespace"`
+	Name      string   `json:"name"`
+	DNSName   string   `json:"dnsName"`
+	Added     []string `json:"added"`
+	Removed   []string `json:"removed"`
+}
+
+// recordedAddresses returns the set of the IP addresses recorded in the status of the
+// DNSNameResolver object, across all its resolved names. The IP address of a regular
+// DNS name whose resolved name entry is merged into the entry of the wildcard DNS name
+// thus stays in the set.
+func recordedAddresses(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) map[string]struct{} {
+	addresses := make(map[string]struct{})
+	for _, resolvedName := range resolverObj.Status.ResolvedNames {
+		for _, resolvedAddress := range resolvedName.ResolvedAddresses {
+			addresses[resolvedAddress.IP] = struct{}{}
+		}
+	}
+	return addresses
+}
+
+// addressDelta returns the sorted IP addresses added to and removed from the previous set
+// of recorded IP addresses by the current one. The returned slices are never nil.
+func addressDelta(previous, current map[string]struct{}) (added, removed []string) {
+	added, removed = []string{}, []string{}
+	for ip := range current {
+		if _, exists := previous[ip]; !exists {
+			added = append(added, ip)
+		}
+	}
+	for ip := range previous {
+		if _, exists := current[ip]; !exists {
+			removed = append(removed, ip)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// recordAddressChanges meters the IP addresses added to and removed from the status of the
+// DNSNameResolver object by a successful status write, given the set of the IP addresses
+// recorded before the write. The change is also logged at the debug level, as a JSON
+// encoded line, when the set of the IP addresses changed.
+func recordAddressChanges(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver, dnsName string, previous map[string]struct{}) {
+	added, removed := addressDelta(previous, recordedAddresses(resolverObj))
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	addressChanges.WithLabelValues(addressChangeAdded).Add(float64(len(added)))
+	addressChanges.WithLabelValues(addressChangeRemoved).Add(float64(len(removed)))
+
+	value, err := json.Marshal(addressChangeRecord{
+		Namespace: resolverObj.Namespace,
+		Name:      resolverObj.Name,
+		DNSName:   dnsName,
+		Added:     added,
+		Removed:   removed,
+	})
+	if err != nil {
+		log.Errorf("Failed to encode address changes of DNSNameResolver object %s/%s: %v", resolverObj.Namespace, resolverObj.Name, err)
+		return
+	}
+	log.Debugf("address changes %s", value)
+}
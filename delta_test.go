@@ -0,0 +1,127 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAddressDelta(t *testing.T) {
+	tests := []struct {
+		name            string
+		previous        []string
+		current         []string
+		expectedAdded   []string
+		expectedRemoved []string
+	}{
+		{
+			name:            "No change",
+			previous:        []string{"1.1.1.1", "2.2.2.2"},
+			current:         []string{"2.2.2.2", "1.1.1.1"},
+			expectedAdded:   []string{},
+			expectedRemoved: []string{},
+		},
+		{
+			name:            "Addresses added to an empty set",
+			current:         []string{"2.2.2.2", "1.1.1.1"},
+			expectedAdded:   []string{"1.1.1.1", "2.2.2.2"},
+			expectedRemoved: []string{},
+		},
+		{
+			name:            "All addresses removed",
+			previous:        []string{"1.1.1.1", "1::1"},
+			expectedAdded:   []string{},
+			expectedRemoved: []string{"1.1.1.1", "1::1"},
+		},
+		{
+			name:            "Addresses added and removed",
+			previous:        []string{"1.1.1.1", "2.2.2.2"},
+			current:         []string{"2.2.2.2", "3.3.3.3"},
+			expectedAdded:   []string{"3.3.3.3"},
+			expectedRemoved: []string{"1.1.1.1"},
+		},
+	}
+
+	toSet := func(ips []string) map[string]struct{} {
+		set := make(map[string]struct{})
+		for _, ip := range ips {
+			set[ip] = struct{}{}
+		}
+		return set
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			added, removed := addressDelta(toSet(tc.previous), toSet(tc.current))
+			if !reflect.DeepEqual(added, tc.expectedAdded) {
+				t.Fatalf("Expected added addresses %v, found %v", tc.expectedAdded, added)
+			}
+			if !reflect.DeepEqual(removed, tc.expectedRemoved) {
+				t.Fatalf("Expected removed addresses %v, found %v", tc.expectedRemoved, removed)
+			}
+		})
+	}
+}
+
+func TestRecordedAddresses(t *testing.T) {
+	resolverObj := &ocpnetworkapiv1alpha1.DNSNameResolver{
+		Status: ocpnetworkapiv1alpha1.DNSNameResolverStatus{
+			ResolvedNames: []ocpnetworkapiv1alpha1.DNSNameResolverResolvedName{
+				{
+					DNSName:           "*.example.com.",
+					ResolvedAddresses: []ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{{IP: "1.1.1.1"}, {IP: "2.2.2.2"}},
+				},
+				{
+					DNSName:           "www.example.com.",
+					ResolvedAddresses: []ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{{IP: "2.2.2.2"}, {IP: "3.3.3.3"}},
+				},
+			},
+		},
+	}
+	expected := map[string]struct{}{"1.1.1.1": {}, "2.2.2.2": {}, "3.3.3.3": {}}
+	if addresses := recordedAddresses(resolverObj); !reflect.DeepEqual(addresses, expected) {
+		t.Fatalf("Expected recorded addresses %v, found %v", expected, addresses)
+	}
+}
+
+func TestServeDNSAddressChanges(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+	})
+
+	addedBefore := testutil.ToFloat64(addressChanges.WithLabelValues(addressChangeAdded))
+	removedBefore := testutil.ToFloat64(addressChanges.WithLabelValues(addressChangeRemoved))
+
+	query := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("www.example.com. 30 IN A 1.1.1.1"),
+			test.A("www.example.com. 30 IN A 2.2.2.2"),
+		},
+	}
+	resolver.Next = fakeNextPluginHandler(query)
+	resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+	if added := testutil.ToFloat64(addressChanges.WithLabelValues(addressChangeAdded)) - addedBefore; added != 2 {
+		t.Fatalf("Expected 2 added addresses to be metered, found %v", added)
+	}
+	if removed := testutil.ToFloat64(addressChanges.WithLabelValues(addressChangeRemoved)) - removedBefore; removed != 0 {
+		t.Fatalf("Expected no removed address to be metered, found %v", removed)
+	}
+}
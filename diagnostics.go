@@ -0,0 +1,187 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// diagnosticsTimeout gives the time within which the startup diagnostics should be
+	// collected, so that they do not hold the startup of the server.
+	diagnosticsTimeout = 5 * time.Second
+	// dnsNameResolverResource is the resource of the DNSNameResolver objects.
+	dnsNameResolverResource = "dnsnameresolvers"
+)
+
+// diagnosticsPermissions lists the verbs on the DNSNameResolver objects and their status
+// subresource which the plugin requires.
+var diagnosticsPermissions = []struct {
+	subresource string
+	verbs       []string
+}{
+	{"", []string{"get", "list", "watch", "update"}},
+	{"status", []string{"update"}},
+}
+
+// diagnosticsConfigFields lists the fields of the effective configuration reported by the
+// startup diagnostics.
+var diagnosticsConfigFields = []string{
+	minTTLField,
+	failureTTLField,
+	zoneTTLField,
+	ttlJitterField,
+	clampTTLField,
+	minRemainingTTLField,
+	failureThresholdField,
+	failureThresholdV4Field,
+	failureThresholdV6Field,
+}
+
+// diagnosticsNamespaces describes the namespaces whose DNSNameResolver objects are monitored.
+type diagnosticsNamespaces struct {
+	All      bool     `json:"all"`
+	Names    []string `json:"names,omitempty"`
+	Regex    string   `json:"regex,omitempty"`
+	Selector string   `json:"selector,omitempty"`
+}
+
+// diagnosticsReport is the structured summary logged by the startup diagnostics.
+type diagnosticsReport struct {
+	CRDPresent     bool                  `json:"crdPresent"`
+	ServedVersions []string              `json:"servedVersions"`
+	AllowedVerbs   []string              `json:"allowedVerbs"`
+	DeniedVerbs    []string              `json:"deniedVerbs"`
+	Namespaces     diagnosticsNamespaces `json:"namespaces"`
+	Config         map[string]any        `json:"config"`
+	Errors         []string              `json:"errors,omitempty"`
+}
+
+// diagnose collects the startup diagnostics: whether the DNSNameResolver CRD is served and
+// its served versions, the verbs on the DNSNameResolver objects allowed to the plugin, the
+// monitored namespaces and the effective TTL and failure threshold configuration. The
+// errors of the checks are reported, the checks which could not be completed are skipped.
+func (resolver *OCPDNSNameResolver) diagnose(ctx context.Context, discoveryClient discovery.DiscoveryInterface, kubeClient kubernetes.Interface) diagnosticsReport {
+	report := diagnosticsReport{
+		ServedVersions: []string{},
+		AllowedVerbs:   []string{},
+		DeniedVerbs:    []string{},
+		Namespaces:     resolver.diagnoseNamespaces(),
+		Config:         make(map[string]any, len(diagnosticsConfigFields)),
+	}
+
+	// Check the versions of the group of the DNSNameResolver objects serving them.
+	groups, err := discoveryClient.ServerGroups()
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("failed to discover the API groups: %v", err))
+	} else {
+		for _, group := range groups.Groups {
+			if group.Name != ocpnetworkapiv1alpha1.GroupName {
+				continue
+			}
+			for _, version := range group.Versions {
+				resources, err := discoveryClient.ServerResourcesForGroupVersion(version.GroupVersion)
+				if err != nil {
+					report.Errors = append(report.Errors, fmt.Sprintf("failed to discover the resources of %s: %v", version.GroupVersion, err))
+					continue
+				}
+				for _, resource := range resources.APIResources {
+					if resource.Name == dnsNameResolverResource {
+						report.ServedVersions = append(report.ServedVersions, version.Version)
+						break
+					}
+				}
+			}
+		}
+		sort.Strings(report.ServedVersions)
+		report.CRDPresent = len(report.ServedVersions) > 0
+	}
+
+	// Check the verbs allowed on the DNSNameResolver objects of all the namespaces, as
+	// watched by the informer.
+	if kubeClient == nil {
+		report.Errors = append(report.Errors, "no client to review the permissions")
+	} else {
+	permissions:
+		for _, permission := range diagnosticsPermissions {
+			resource := dnsNameResolverResource
+			if permission.subresource != "" {
+				resource += "/" + permission.subresource
+			}
+			for _, verb := range permission.verbs {
+				review, err := kubeClient.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+					Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+						ResourceAttributes: &authorizationv1.ResourceAttributes{
+							Verb:        verb,
+							Group:       ocpnetworkapiv1alpha1.GroupName,
+							Resource:    dnsNameResolverResource,
+							Subresource: permission.subresource,
+						},
+					},
+				}, metav1.CreateOptions{})
+				if err != nil {
+					report.Errors = append(report.Errors, fmt.Sprintf("failed to review the permission to %s %s: %v", verb, resource, err))
+					break permissions
+				}
+				if review.Status.Allowed {
+					report.AllowedVerbs = append(report.AllowedVerbs, verb+" "+resource)
+				} else {
+					report.DeniedVerbs = append(report.DeniedVerbs, verb+" "+resource)
+				}
+			}
+		}
+	}
+
+	config := resolver.EffectiveConfig()
+	for _, field := range diagnosticsConfigFields {
+		report.Config[field] = config[field]
+	}
+	return report
+}
+
+// diagnoseNamespaces returns the namespaces whose DNSNameResolver objects are monitored.
+func (resolver *OCPDNSNameResolver) diagnoseNamespaces() diagnosticsNamespaces {
+	namespaces := diagnosticsNamespaces{All: !resolver.namespacesConfigured()}
+	for namespace := range resolver.namespaces {
+		namespaces.Names = append(namespaces.Names, namespace)
+	}
+	sort.Strings(namespaces.Names)
+	if resolver.namespaceRegex != nil {
+		namespaces.Regex = resolver.namespaceRegex.String()
+	}
+	if resolver.namespaceSelector != nil {
+		namespaces.Selector = resolver.namespaceSelector.String()
+	}
+	return namespaces
+}
+
+// logDiagnostics logs the startup diagnostics as a JSON encoded line, and warns about the
+// missing DNSNameResolver CRD and the denied verbs, so that a misconfiguration is obvious
+// in the logs.
+func (resolver *OCPDNSNameResolver) logDiagnostics(discoveryClient discovery.DiscoveryInterface, kubeClient kubernetes.Interface) {
+	ctx, cancel := context.WithTimeout(context.Background(), diagnosticsTimeout)
+	defer cancel()
+
+	report := resolver.diagnose(ctx, discoveryClient, kubeClient)
+	value, err := json.Marshal(report)
+	if err != nil {
+		log.Errorf("Failed to encode startup diagnostics: %v", err)
+		return
+	}
+	log.Infof("startup diagnostics %s", value)
+
+	if !report.CRDPresent {
+		log.Warningf("The DNSNameResolver CRD of group %s is not found to be served", ocpnetworkapiv1alpha1.GroupName)
+	}
+	if len(report.DeniedVerbs) > 0 {
+		log.Warningf("The permissions to %v DNSNameResolver objects are denied", report.DeniedVerbs)
+	}
+}
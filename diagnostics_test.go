@@ -0,0 +1,138 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/coredns/caddy"
+	ocpnetworkfakeclient "github.com/openshift/client-go/network/clientset/versioned/fake"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	kubefakeclient "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+// newDiagnosticsKubeClient returns a fake client allowing the verbs of the self subject
+// access reviews which are in the allowed set.
+func newDiagnosticsKubeClient(allowed map[string]struct{}) *kubefakeclient.Clientset {
+	kubeClient := kubefakeclient.NewSimpleClientset()
+	kubeClient.PrependReactor("create", "selfsubjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview).DeepCopy()
+		attributes := review.Spec.ResourceAttributes
+		resource := attributes.Resource
+		if attributes.Subresource != "" {
+			resource += "/" + attributes.Subresource
+		}
+		_, review.Status.Allowed = allowed[attributes.Verb+" "+resource]
+		return true, review, nil
+	})
+	return kubeClient
+}
+
+func TestDiagnose(t *testing.T) {
+	tests := []struct {
+		name                   string
+		resources              []*metav1.APIResourceList
+		allowed                map[string]struct{}
+		corefile               string
+		expectedCRDPresent     bool
+		expectedServedVersions []string
+		expectedDeniedVerbs    []string
+		expectedNamespaces     diagnosticsNamespaces
+	}{
+		{
+			name: "CRD served and all verbs allowed",
+			resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: "network.openshift.io/v1alpha1",
+					APIResources: []metav1.APIResource{{Name: "dnsnameresolvers"}, {Name: "dnsnameresolvers/status"}},
+				},
+				{
+					GroupVersion: "network.openshift.io/v1",
+					APIResources: []metav1.APIResource{{Name: "egressnetworkpolicies"}},
+				},
+			},
+			allowed: map[string]struct{}{
+				"get dnsnameresolvers":           {},
+				"list dnsnameresolvers":          {},
+				"watch dnsnameresolvers":         {},
+				"update dnsnameresolvers":        {},
+				"update dnsnameresolvers/status": {},
+			},
+			corefile:               `ocp_dnsnameresolver`,
+			expectedCRDPresent:     true,
+			expectedServedVersions: []string{"v1alpha1"},
+			expectedDeniedVerbs:    []string{},
+			expectedNamespaces:     diagnosticsNamespaces{All: true},
+		},
+		{
+			name: "CRD not served and status update denied",
+			resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: "network.openshift.io/v1",
+					APIResources: []metav1.APIResource{{Name: "egressnetworkpolicies"}},
+				},
+			},
+			allowed: map[string]struct{}{
+				"get dnsnameresolvers":    {},
+				"list dnsnameresolvers":   {},
+				"watch dnsnameresolvers":  {},
+				"update dnsnameresolvers": {},
+			},
+			corefile: `ocp_dnsnameresolver {
+				namespaces dns openshift
+				namespaceRegex team-.*
+			}`,
+			expectedCRDPresent:     false,
+			expectedServedVersions: []string{},
+			expectedDeniedVerbs:    []string{"update dnsnameresolvers/status"},
+			expectedNamespaces:     diagnosticsNamespaces{Names: []string{"dns", "openshift"}, Regex: "^(?:team-.*)$"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver, err := resolverParse(caddy.NewTestController("dns", tc.corefile))
+			if err != nil {
+				t.Fatalf("Unexpected error parsing the configuration: %v", err)
+			}
+			networkClient := ocpnetworkfakeclient.NewSimpleClientset()
+			networkClient.Discovery().(*fakediscovery.FakeDiscovery).Resources = tc.resources
+
+			report := resolver.diagnose(context.Background(), networkClient.Discovery(), newDiagnosticsKubeClient(tc.allowed))
+
+			if len(report.Errors) != 0 {
+				t.Fatalf("Unexpected errors: %v", report.Errors)
+			}
+			if report.CRDPresent != tc.expectedCRDPresent {
+				t.Errorf("Expected CRD present %v, found %v", tc.expectedCRDPresent, report.CRDPresent)
+			}
+			if !reflect.DeepEqual(report.ServedVersions, tc.expectedServedVersions) {
+				t.Errorf("Expected served versions %v, found %v", tc.expectedServedVersions, report.ServedVersions)
+			}
+			if !reflect.DeepEqual(report.DeniedVerbs, tc.expectedDeniedVerbs) {
+				t.Errorf("Expected denied verbs %v, found %v", tc.expectedDeniedVerbs, report.DeniedVerbs)
+			}
+			if len(report.AllowedVerbs)+len(report.DeniedVerbs) != 5 {
+				t.Errorf("Expected 5 reviewed verbs, found allowed %v and denied %v", report.AllowedVerbs, report.DeniedVerbs)
+			}
+			if !reflect.DeepEqual(report.Namespaces, tc.expectedNamespaces) {
+				t.Errorf("Expected namespaces %+v, found %+v", tc.expectedNamespaces, report.Namespaces)
+			}
+			if report.Config[minTTLField] != resolver.minimumTTL || report.Config[failureThresholdField] != resolver.failureThreshold {
+				t.Errorf("Expected the effective TTL and threshold configuration, found %v", report.Config)
+			}
+		})
+	}
+}
+
+func TestDiagnoseWithoutKubeClient(t *testing.T) {
+	networkClient := ocpnetworkfakeclient.NewSimpleClientset()
+	report := New().diagnose(context.Background(), networkClient.Discovery(), nil)
+	if len(report.Errors) != 1 {
+		t.Fatalf("Expected the permissions not to be reviewed without a client, found errors %v", report.Errors)
+	}
+}
@@ -1,18 +1,30 @@
 package ocp_dnsnameresolver
 
 import (
+	"context"
 	"fmt"
+	"maps"
+	"net/netip"
+	"regexp"
 	"sync"
 	"time"
 
 	"github.com/coredns/coredns/plugin"
 
 	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	configclient "github.com/openshift/client-go/config/clientset/versioned"
 	ocpnetworkclient "github.com/openshift/client-go/network/clientset/versioned"
-	ocpnetworkclientv1alpha1 "github.com/openshift/client-go/network/clientset/versioned/typed/network/v1alpha1"
 	ocpnetworkinformer "github.com/openshift/client-go/network/informers/externalversions"
+	"golang.org/x/sync/singleflight"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
 )
 
 // namespaceDNSInfo is used to store information regarding DNSNameResolver
@@ -26,10 +38,116 @@ type namespaceDNSInfo map[string]string
 type OCPDNSNameResolver struct {
 	Next plugin.Handler
 
+	// TTLTransformer is an optional function applied to the TTL received in a DNS lookup
+	// response for the DNS name, before the TTL is clamped to the minimum TTL and jittered.
+	// It allows the programs embedding the plugin to implement their own TTL policies. It
+	// is not configurable in the Corefile and it must be set before the plugin is started.
+	TTLTransformer func(name string, ttl uint32) uint32
+
+	// OnStatusUpdated is an optional function called after each attempt to update the status
+	// of a DNSNameResolver object, with a copy of the object, the IP addresses of the looked
+	// up DNS name in the status of the object and the error of the attempt, if any. It allows
+	// the programs embedding the plugin to react to the status updates, eg. by pushing them to
+	// an external system. It is called on the goroutine performing the update, thus it must
+	// not block. It is not configurable in the Corefile and it must be set before the plugin
+	// is started.
+	OnStatusUpdated func(obj *ocpnetworkapiv1alpha1.DNSNameResolver, addresses []string, err error)
+
 	// configurable fields.
-	namespaces       map[string]struct{}
-	minimumTTL       int32
-	failureThreshold int32
+	namespaces               map[string]struct{}
+	internalZones            []string
+	externalZones            []string
+	labelSelector            labels.Selector
+	namespaceSelector        labels.Selector
+	namespaceRegex           *regexp.Regexp
+	filterOperator           filterOperator
+	minimumTTL               int32
+	failureTTL               int32
+	zoneTTLs                 map[string]zoneTTLPolicy
+	ttlJitter                int32
+	minRemainingTTL          uint32
+	failureThreshold         int32
+	failureThresholdV4       int32
+	failureThresholdV6       int32
+	failureWeights           map[int]float64
+	minQueries               int
+	minQueriesWindow         time.Duration
+	accumulateWindow         time.Duration
+	maxRecordAge             time.Duration
+	mergeWindow              time.Duration
+	negativeMaxAge           time.Duration
+	settleWindow             time.Duration
+	wildcardFlushInterval    time.Duration
+	reconcileInterval        time.Duration
+	summaryEventInterval     time.Duration
+	flushInterval            time.Duration
+	maxUpdatesPerObject      int
+	updateRateInterval       time.Duration
+	staleGrace               time.Duration
+	namespacePacing          time.Duration
+	failureLogInterval       time.Duration
+	queryCoalesceWindow      time.Duration
+	maxAnswerRecords         int
+	maxTrackedNames          int
+	maxCNAMEDepth            int
+	maxWildcardChildren      int
+	breakerThreshold         int
+	breakerCooldown          time.Duration
+	allowedCIDRs             []netip.Prefix
+	excludeServiceCIDR       bool
+	serviceCIDRs             []netip.Prefix
+	allowedClientCIDRs       []netip.Prefix
+	listPageSize             int64
+	shutdownTimeout          time.Duration
+	overlapPolicy            overlapPolicy
+	truncatedPolicy          truncatedPolicy
+	partialChainPolicy       partialChainPolicy
+	fanOutOrder              fanOutOrder
+	conflictResolution       conflictResolution
+	addressOrder             addressOrder
+	wildcardNamespaceScope   wildcardNamespaceScope
+	wildcardSpecificity      wildcardSpecificity
+	multiNamespacePolicy     multiNamespacePolicy
+	namespacePriority        []string
+	instanceID               string
+	mirrorConfigMap          types.NamespacedName
+	maintenanceSentinel      types.NamespacedName
+	validateOnly             bool
+	recordSRV                bool
+	preserveCase             bool
+	prefetchOnStart          bool
+	auditLog                 bool
+	recordNegative           bool
+	regexMatch               bool
+	perNamespaceMetrics      bool
+	maxNamespaceLabels       int
+	retryForbidden           bool
+	failClosed               bool
+	rejectApexWildcard       bool
+	strictQNameMatch         bool
+	signalDump               bool
+	debugAddress             string
+	webhookURL               string
+	recordUpstream           bool
+	recordWildcardChildren   bool
+	recordPTR                bool
+	lazyStart                bool
+	includeAdditional        bool
+	doubleCheck              bool
+	pruneDeletedNamespaces   bool
+	recordObservedGeneration bool
+	rejectInternalWildcards  bool
+	recordExtendedErrors     bool
+	exemplars                bool
+	serveStaleOnTimeout      bool
+	singleflight             bool
+	useFinalizer             bool
+	liveGet                  bool
+	recordFingerprint        bool
+	refusedIsBlock           bool
+	rejectCDBit              bool
+	diagnostics              bool
+	clampTTL                 bool
 
 	// Data mapping for the regularDNSInfo and wildcardDNSInfo maps:
 	// DNS name --> Namespace --> DNSNameResolver object name.
@@ -46,42 +164,294 @@ type OCPDNSNameResolver struct {
 	// wildcardMapLock is used to serialize the access to the wildcardDNSInfo
 	// map.
 	wildcardMapLock sync.Mutex
+	// regexDNSInfo map is used for storing the compiled regular expressions of the
+	// regex annotations of the DNSNameResolver objects, when regexMatch is enabled.
+	// key: namespace and name of the DNSNameResolver object, value: compiled regular
+	// expression.
+	regexDNSInfo map[types.NamespacedName]*regexp.Regexp
+	// regexMapLock is used to serialize the access to the regexDNSInfo map.
+	regexMapLock sync.Mutex
+
+	// lastQueried map is used for storing the time of the last DNS lookup of the tracked
+	// DNS names, when maxTrackedNames is configured.
+	// key: regular or wildcard DNS name, value: time of the last DNS lookup.
+	lastQueried map[string]time.Time
+	// evictedDNSInfo map is used for storing the details of the DNS names evicted as
+	// maxTrackedNames is reached, so that they are tracked again on their next DNS lookup.
+	// key: regular or wildcard DNS name, value: namespaceDNSInfo of the DNS name.
+	evictedDNSInfo map[string]namespaceDNSInfo
+	// lastQueriedLock is used to serialize the access to the lastQueried and the
+	// evictedDNSInfo maps.
+	lastQueriedLock sync.Mutex
+
+	// queryCounter counts the DNS lookups of the DNS names to check whether the
+	// minQueries threshold is met.
+	queryCounter *queryCounter
+	// queryCoalescer collapses the identical DNS lookups within the queryCoalesceWindow
+	// into a single update attempt.
+	queryCoalescer *queryCoalescer
+	// inflight collapses the concurrent identical DNS lookups into a single resolution, when
+	// singleflight is enabled.
+	inflight singleflight.Group
+	// failureLogLimiter rate limits the logging of the failed DNS lookups per DNS name.
+	failureLogLimiter *failureLogLimiter
+
+	// addressAccumulator accumulates the IP addresses of the DNS names within the
+	// accumulateWindow.
+	addressAccumulator *addressAccumulator
+
+	// childrenBuffer buffers the discovered children of the wildcard DNSNameResolver objects
+	// between the flushes, when wildcardFlushInterval is configured.
+	childrenBuffer *childrenBuffer
+
+	// writtenStatuses stores the resolved names last written to the status of the
+	// DNSNameResolver objects, when reconcileInterval is configured.
+	writtenStatuses *writtenStatuses
+
+	// weightedFailures accumulates the weighted failures of the resolved names, when
+	// failureWeight is configured.
+	weightedFailures *weightedFailures
+
+	// familyFailures counts the failures of each address family of the resolved names, when
+	// failureThresholdV4 or failureThresholdV6 is configured.
+	familyFailures *familyFailures
+
+	// blockedFailures counts the failures of the blocked resolved names not written to their
+	// status yet, when refusedIsBlock is enabled.
+	blockedFailures *blockedFailures
+
+	// namespacePacer spaces out the status updates within the same namespace, when
+	// namespacePacing is configured.
+	namespacePacer *namespacePacer
+
+	// forbiddenTracker tracks the forbidden updates of the DNSNameResolver objects, when
+	// retryForbidden is enabled.
+	forbiddenTracker *forbiddenTracker
 
-	// client and informer for handling DNSNameResolver objects.
-	ocpNetworkClient        ocpnetworkclientv1alpha1.NetworkV1alpha1Interface
+	// kubeClient is used for writing the mirror ConfigMap, when mirrorConfigMap is
+	// configured, for watching the namespaces, when pruneDeletedNamespaces is
+	// enabled or namespaceSelector is configured, for recording the events, when
+	// summaryEventInterval is configured, and for reviewing the permissions of the plugin,
+	// when diagnostics is enabled. mirroredSummary is the summary last written to the ConfigMap.
+	kubeClient      kubernetes.Interface
+	mirroredSummary string
+
+	// eventRecorder records the summary events of the DNSNameResolver objects, when
+	// summaryEventInterval is configured, and eventBroadcaster sends them to the API.
+	// summaryEventLimiter limits the rate of the summary events.
+	eventRecorder       record.EventRecorder
+	eventBroadcaster    record.EventBroadcaster
+	summaryEventLimiter flowcontrol.RateLimiter
+
+	// namespaceInformer watches the namespaces being deleted, when pruneDeletedNamespaces
+	// is enabled, and the labels of the namespaces, when namespaceSelector is configured.
+	// terminatingNamespaces stores the namespaces being deleted, whose
+	// DNSNameResolver objects are not tracked.
+	namespaceInformer     cache.SharedIndexInformer
+	terminatingNamespaces map[string]struct{}
+	terminatingLock       sync.Mutex
+
+	// selectedNamespaces stores the namespaces whose labels match the namespaceSelector,
+	// as observed by the namespace informer.
+	selectedNamespaces map[string]struct{}
+	selectedLock       sync.Mutex
+
+	// maintenance suppresses the updates of the DNSNameResolver objects during the
+	// maintenance window.
+	maintenance maintenanceWindow
+
+	// flusher buffers the status updates of the DNSNameResolver objects until the next
+	// flush, when flushInterval is configured.
+	flusher *flushStore
+
+	// throttler limits the rate of the status updates of each DNSNameResolver object, when
+	// maxUpdatesPerObject is configured.
+	throttler *throttleStore
+
+	// webhook sends the DNS names newly tracked or not tracked anymore to the webhook, when
+	// webhookURL is configured.
+	webhook *webhookSender
+
+	// retrier retries in the background the updates of the DNSNameResolver objects which
+	// failed with a transient error.
+	retrier *updateRetrier
+
+	// informer and store for handling DNSNameResolver objects.
 	dnsNameResolverInformer cache.SharedIndexInformer
+	store                   resolverStore
 	stopCh                  chan struct{}
+	informerDone            chan struct{} // closed once the informer is stopped.
 	stopLock                sync.Mutex
+	started                 bool // whether the informer is started.
+	informerStartOnce       sync.Once
 	shutdown                bool
+
+	// syncedAt gives the time at which the informer synced, for the settle window of
+	// settleWindow, or zero if it did not sync yet.
+	syncedAt   time.Time
+	settleLock sync.Mutex
+
+	// syncedSince gives the time since which the informer is seen synced by the
+	// readiness check, or zero if it is not.
+	syncedSince time.Time
+	readyLock   sync.Mutex
 }
 
 // New returns an initialized OCPDNSNameResolver with default settings.
 func New() *OCPDNSNameResolver {
 	return &OCPDNSNameResolver{
-		regularDNSInfo:   make(map[string]namespaceDNSInfo),
-		wildcardDNSInfo:  make(map[string]namespaceDNSInfo),
-		namespaces:       make(map[string]struct{}),
-		minimumTTL:       defaultMinTTL,
-		failureThreshold: defaultFailureThreshold,
+		regularDNSInfo:         make(map[string]namespaceDNSInfo),
+		wildcardDNSInfo:        make(map[string]namespaceDNSInfo),
+		regexDNSInfo:           make(map[types.NamespacedName]*regexp.Regexp),
+		terminatingNamespaces:  make(map[string]struct{}),
+		selectedNamespaces:     make(map[string]struct{}),
+		lastQueried:            make(map[string]time.Time),
+		evictedDNSInfo:         make(map[string]namespaceDNSInfo),
+		namespaces:             make(map[string]struct{}),
+		filterOperator:         defaultFilterOperator,
+		minimumTTL:             defaultMinTTL,
+		failureThreshold:       defaultFailureThreshold,
+		minQueries:             defaultMinQueries,
+		minQueriesWindow:       defaultMinQueriesWindow,
+		maxCNAMEDepth:          defaultMaxCNAMEDepth,
+		maxWildcardChildren:    defaultMaxWildcardChildren,
+		queryCounter:           newQueryCounter(),
+		queryCoalescer:         newQueryCoalescer(),
+		failureLogLimiter:      newFailureLogLimiter(),
+		failureLogInterval:     defaultFailureLogInterval,
+		breakerCooldown:        defaultBreakerCooldown,
+		addressAccumulator:     newAddressAccumulator(),
+		childrenBuffer:         newChildrenBuffer(),
+		writtenStatuses:        newWrittenStatuses(),
+		weightedFailures:       newWeightedFailures(),
+		familyFailures:         newFamilyFailures(),
+		blockedFailures:        newBlockedFailures(),
+		forbiddenTracker:       newForbiddenTracker(),
+		namespacePacer:         newNamespacePacer(),
+		summaryEventLimiter:    newSummaryEventLimiter(),
+		overlapPolicy:          defaultOverlapPolicy,
+		truncatedPolicy:        defaultTruncatedPolicy,
+		partialChainPolicy:     defaultPartialChainPolicy,
+		fanOutOrder:            defaultFanOutOrder,
+		conflictResolution:     defaultConflictResolution,
+		addressOrder:           defaultAddressOrder,
+		rejectApexWildcard:     defaultRejectApexWildcard,
+		strictQNameMatch:       defaultStrictQNameMatch,
+		wildcardNamespaceScope: defaultWildcardNamespaceScope,
+		wildcardSpecificity:    defaultWildcardSpecificity,
+		multiNamespacePolicy:   defaultMultiNamespacePolicy,
+		shutdownTimeout:        defaultShutdownTimeout,
+		maxNamespaceLabels:     defaultMaxNamespaceLabels,
 	}
 }
 
 const (
 	// defaultResyncPeriod gives the resync period used for creating the DNSNameResolver informer.
 	defaultResyncPeriod = 24 * time.Hour
+	// resourceVersionMetricPeriod gives the period of updating the metric of the resource version
+	// last observed by the DNSNameResolver informer.
+	resourceVersionMetricPeriod = 30 * time.Second
 	// defaultMinTTL will be used when minTTL is not explicitly configured.
 	defaultMinTTL int32 = 5
 	// defaultFailureThreshold will be used when failureThreshold is not explicitly configured.
 	defaultFailureThreshold int32 = 5
+	// defaultMinQueries will be used when minQueries is not explicitly configured.
+	defaultMinQueries = 1
+	// defaultMinQueriesWindow will be used when minQueriesWindow is not explicitly configured.
+	defaultMinQueriesWindow = time.Minute
+	// defaultMaxCNAMEDepth will be used when maxCNAMEDepth is not explicitly configured.
+	defaultMaxCNAMEDepth = 8
+	// defaultMaxWildcardChildren will be used when the maximum number of the children of
+	// recordWildcardChildren is not explicitly configured.
+	defaultMaxWildcardChildren = 100
+	// defaultFailureLogInterval will be used when failureLogInterval is not explicitly configured.
+	defaultFailureLogInterval = time.Minute
+	// defaultFilterOperator will be used when filterOperator is not explicitly configured.
+	defaultFilterOperator = filterOperatorAnd
+	// defaultOverlapPolicy will be used when overlapPolicy is not explicitly configured.
+	defaultOverlapPolicy = overlapPolicyBoth
+	// defaultTruncatedPolicy will be used when truncatedPolicy is not explicitly configured.
+	defaultTruncatedPolicy = truncatedPolicySkip
+	// defaultPartialChainPolicy will be used when partialChainPolicy is not explicitly configured.
+	defaultPartialChainPolicy = partialChainPolicyFail
+	// defaultFanOutOrder will be used when fanOutOrder is not explicitly configured.
+	defaultFanOutOrder = fanOutOrderParallel
+	// defaultConflictResolution will be used when conflictResolution is not explicitly configured.
+	defaultConflictResolution = conflictResolutionFirst
+	// defaultAddressOrder will be used when addressOrder is not explicitly configured.
+	defaultAddressOrder = addressOrderNone
+	// defaultWildcardNamespaceScope will be used when wildcardNamespaceScope is not explicitly configured.
+	defaultWildcardNamespaceScope = wildcardNamespaceScopeAll
+	// defaultWildcardSpecificity will be used when wildcardSpecificity is not explicitly configured.
+	defaultWildcardSpecificity = wildcardSpecificityMostSpecific
+	// defaultMultiNamespacePolicy will be used when multiNamespace is not explicitly configured.
+	defaultMultiNamespacePolicy = multiNamespacePolicyAll
+	// defaultShutdownTimeout will be used when shutdownTimeout is not explicitly configured.
+	defaultShutdownTimeout = 5 * time.Second
+	// defaultMaxNamespaceLabels will be used when the maximum number of the namespace labels
+	// of perNamespaceMetrics is not explicitly configured.
+	defaultMaxNamespaceLabels = 100
+	// defaultRejectApexWildcard will be used when rejectApexWildcard is not explicitly configured.
+	defaultRejectApexWildcard = true
+	// defaultStrictQNameMatch will be used when strictQNameMatch is not explicitly configured.
+	defaultStrictQNameMatch = true
 )
 
 // initInformer initializes the DNSNameResolver informer.
 func (resolver *OCPDNSNameResolver) initInformer(networkClient ocpnetworkclient.Interface) (err error) {
-	// Get the client for version v1alpha1 for DNSNameResolver objects.
-	resolver.ocpNetworkClient = networkClient.NetworkV1alpha1()
-
 	// Create the DNSNameResolver informer.
-	resolver.dnsNameResolverInformer = ocpnetworkinformer.NewSharedInformerFactory(networkClient, defaultResyncPeriod).Network().V1alpha1().DNSNameResolvers().Informer()
+	var options []ocpnetworkinformer.SharedInformerOption
+	if resolver.listPageSize > 0 {
+		options = append(options, ocpnetworkinformer.WithTweakListOptions(resolver.tweakListOptions))
+	}
+	dnsNameResolvers := ocpnetworkinformer.NewSharedInformerFactoryWithOptions(networkClient, defaultResyncPeriod, options...).Network().V1alpha1().DNSNameResolvers()
+	resolver.dnsNameResolverInformer = dnsNameResolvers.Informer()
+
+	// Create the retrier of the updates failed with a transient error.
+	resolver.retrier = newUpdateRetrier()
+
+	// Create the store for version v1alpha1 for DNSNameResolver objects.
+	resolver.store = newV1alpha1Store(dnsNameResolvers.Lister(), networkClient.NetworkV1alpha1(), resolver.liveGet)
+	// Count the status updates issued to the API server by namespace, if perNamespaceMetrics is
+	// enabled. The store wraps the v1alpha1 store only, so that the status updates stopped by
+	// the outer stores are not counted.
+	if resolver.perNamespaceMetrics {
+		resolver.store = &namespaceMetricsStore{resolverStore: resolver.store, labels: newNamespaceLabels(resolver.maxNamespaceLabels)}
+	}
+	// Record the statuses written to the DNSNameResolver objects, if reconcileInterval is configured.
+	if resolver.reconcileInterval > 0 {
+		resolver.store = &driftStore{resolverStore: resolver.store, statuses: resolver.writtenStatuses}
+	}
+	// Issue the updates through the circuit breaker, if breakerThreshold is configured.
+	if resolver.breakerThreshold > 0 {
+		resolver.store = &breakerStore{
+			resolverStore: resolver.store,
+			breaker:       newCircuitBreaker(resolver.breakerThreshold, resolver.breakerCooldown),
+		}
+	}
+	// Suppress the updates during the maintenance window, before they reach the circuit
+	// breaker, so that the suppressed updates are not counted as failures.
+	resolver.store = &maintenanceStore{resolverStore: resolver.store, window: &resolver.maintenance}
+	// Set the fingerprint annotation once the status is written, if recordFingerprint is enabled.
+	if resolver.recordFingerprint {
+		resolver.store = &fingerprintStore{resolverStore: resolver.store, retrier: resolver.retrier}
+	}
+	// Limit the rate of the status updates of each object, if maxUpdatesPerObject is
+	// configured. The flushed status updates are limited too.
+	if resolver.maxUpdatesPerObject > 0 {
+		resolver.throttler = newThrottleStore(resolver.store, resolver.retrier, resolver.maxUpdatesPerObject, resolver.updateRateInterval)
+		resolver.store = resolver.throttler
+	}
+	// Buffer the status updates until the next flush, if flushInterval is configured. The
+	// flushed updates go through the maintenance window and the circuit breaker.
+	if resolver.flushInterval > 0 {
+		resolver.flusher = newFlushStore(resolver.store, resolver.retrier)
+		resolver.store = resolver.flusher
+	}
+	// Send the DNS names newly tracked or not tracked anymore to the webhook, if webhookURL is configured.
+	if resolver.webhookURL != "" {
+		resolver.webhook = newWebhookSender(resolver.webhookURL)
+	}
 
 	// Add the event handlers for Add, Delete and Update events.
 	resolver.dnsNameResolverInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -94,121 +464,320 @@ func (resolver *OCPDNSNameResolver) initInformer(networkClient ocpnetworkclient.
 				return
 			}
 
-			// Check if namespace is configured or not.
-			if !resolver.configuredNamespace(resolverObj.Namespace) {
+			// Enable or disable the maintenance window if the object is the sentinel object.
+			resolver.observeSentinel(resolverObj, false)
+
+			// Check if the object is configured to be monitored or not.
+			configured := resolver.configuredObject(resolverObj)
+
+			// Add or remove the finalizer of the object. The object being deleted is not tracked.
+			if resolver.observeFinalizer(resolverObj, configured) || !configured {
 				return
 			}
 
-			dnsName := string(resolverObj.Spec.Name)
-			// Check if the DNS name is wildcard or regular.
-			if isWildcard(dnsName) {
-				// If the DNS name is wildcard, add the details of the DNSNameResolver
-				// object to the wildcardDNSInfo map.
-				resolver.wildcardMapLock.Lock()
-				dnsInfoMap, dnsInfoExists := resolver.wildcardDNSInfo[dnsName]
-				// If details of DNS name and the DNSNameResolver objects already exist
-				// then check if the existing information match with the current one.
-				// In a namespace only one DNSNameResolver object should be created
-				// corresponding to a DNS name. If more than one DNSNameResolver object
-				// exists in a namespace corresponding to a DNS name, only the first
-				// object will be considered. Thus, if the existing information doesn't
-				// match, then don't proceed.
-				if dnsInfoExists {
-					if objName, objNameFound := dnsInfoMap[resolverObj.Namespace]; objNameFound && objName != resolverObj.Name {
-						resolver.wildcardMapLock.Unlock()
-						return
-					}
-				}
-				if !dnsInfoExists {
-					dnsInfoMap = make(namespaceDNSInfo)
-				}
-				dnsInfoMap[resolverObj.Namespace] = resolverObj.Name
-				resolver.wildcardDNSInfo[dnsName] = dnsInfoMap
-				resolver.wildcardMapLock.Unlock()
-			} else {
-				// If the DNS name is regular, add the details of the DNSNameResolver
-				// object to the regularDNSInfo map.
-				resolver.regularMapLock.Lock()
-				dnsInfoMap, dnsInfoExists := resolver.regularDNSInfo[dnsName]
-				// If details of DNS name and the DNSNameResolver objects already exist
-				// then check if the existing information match with the current one.
-				// In a namespace only one DNSNameResolver object should be created
-				// corresponding to a DNS name. If more than one DNSNameResolver object
-				// exists in a namespace corresponding to a DNS name, only the first
-				// object will be considered. Thus, if the existing information doesn't
-				// match, then don't proceed.
-				if dnsInfoExists {
-					if objName, objNameFound := dnsInfoMap[resolverObj.Namespace]; objNameFound && objName != resolverObj.Name {
-						resolver.regularMapLock.Unlock()
-						return
-					}
-				}
-				if !dnsInfoExists {
-					dnsInfoMap = make(namespaceDNSInfo)
-				}
-				dnsInfoMap[resolverObj.Namespace] = resolverObj.Name
-				resolver.regularDNSInfo[dnsName] = dnsInfoMap
-				resolver.regularMapLock.Unlock()
-			}
+			resolver.addResolverObject(resolverObj)
 		},
 		// Delete event.
-		DeleteFunc: func(obj interface{}) {
-			// Get the DNSNameResolver object.
-			resolverObj, ok := obj.(*ocpnetworkapiv1alpha1.DNSNameResolver)
+		DeleteFunc: resolver.handleDelete,
+		// Update event.
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			// Get the old and the new DNSNameResolver objects.
+			oldResolverObj, ok := oldObj.(*ocpnetworkapiv1alpha1.DNSNameResolver)
 			if !ok {
-				log.Infof("object not of type DNSNameResolver: %v", obj)
+				log.Infof("object not of type DNSNameResolver: %v", oldObj)
+				return
+			}
+			newResolverObj, ok := newObj.(*ocpnetworkapiv1alpha1.DNSNameResolver)
+			if !ok {
+				log.Infof("object not of type DNSNameResolver: %v", newObj)
 				return
 			}
 
-			// Check if namespace is configured or not.
-			if !resolver.configuredNamespace(resolverObj.Namespace) {
+			// Enable or disable the maintenance window if the object is the sentinel object.
+			resolver.observeSentinel(newResolverObj, false)
+
+			// The details of the DNSNameResolver object only need to be changed if its
+			// DNS name, its regex annotation or whether it is configured to be monitored
+			// changed. Other updates, eg. of the status by the plugin itself, are ignored.
+			oldConfigured := resolver.configuredObject(oldResolverObj)
+			newConfigured := resolver.configuredObject(newResolverObj)
+
+			// Add or remove the finalizer of the object. The details of the object being
+			// deleted are dropped when it is finalized.
+			if resolver.observeFinalizer(newResolverObj, newConfigured) {
+				return
+			}
+			if oldResolverObj.Spec.Name == newResolverObj.Spec.Name &&
+				oldResolverObj.Annotations[regexAnnotation] == newResolverObj.Annotations[regexAnnotation] &&
+				oldConfigured == newConfigured {
 				return
 			}
 
-			dnsName := string(resolverObj.Spec.Name)
-			// Check if the DNS name is wildcard or regular.
-			if isWildcard(dnsName) {
-				// If the DNS name is wildcard, delete the details of the DNSNameResolver
-				// object from the wildcardDNSInfo map.
-				resolver.wildcardMapLock.Lock()
-				if dnsInfoMap, exists := resolver.wildcardDNSInfo[dnsName]; exists {
-					// If details of DNS name and the DNSNameResolver objects already exist
-					// then check if the existing information match with the current one.
-					// Otherwise, don't proceed.
-					if dnsInfoMap[resolverObj.Namespace] == resolverObj.Name {
-						delete(dnsInfoMap, resolverObj.Namespace)
-						if len(dnsInfoMap) > 0 {
-							resolver.wildcardDNSInfo[dnsName] = dnsInfoMap
-						} else {
-							delete(resolver.wildcardDNSInfo, dnsName)
-						}
-					}
-				}
-				resolver.wildcardMapLock.Unlock()
-			} else {
-				// If the DNS name is regular, delete the details of the DNSNameResolver
-				// object from the regularDNSInfo map.
-				resolver.regularMapLock.Lock()
-				if dnsInfoMap, exists := resolver.regularDNSInfo[dnsName]; exists {
-					// If details of DNS name and the DNSNameResolver objects already exist
-					// then check if the existing information match with the current one.
-					// Otherwise, don't proceed.
-					if dnsInfoMap[resolverObj.Namespace] == resolverObj.Name {
-						delete(dnsInfoMap, resolverObj.Namespace)
-						if len(dnsInfoMap) > 0 {
-							resolver.regularDNSInfo[dnsName] = dnsInfoMap
-						} else {
-							delete(resolver.regularDNSInfo, dnsName)
-						}
-					}
-				}
-				resolver.regularMapLock.Unlock()
+			// Remove the details of the old object and add the details of the new one.
+			// If the DNS name changed between regular and wildcard, the details are
+			// thus moved between the regularDNSInfo and the wildcardDNSInfo maps.
+			if oldConfigured {
+				resolver.deleteResolverObject(oldResolverObj)
+			}
+			if newConfigured {
+				resolver.addResolverObject(newResolverObj)
 			}
 		},
 	})
 	return nil
 }
 
+// handleDelete handles the delete events of the DNSNameResolver informer. The informer
+// delivers a DeletedFinalStateUnknown tombstone instead of the object if the deletion was
+// missed while watching, eg. on a disconnection from the API server. The last known state
+// of the object is then unwrapped from the tombstone, so that its details are removed.
+func (resolver *OCPDNSNameResolver) handleDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	// Get the DNSNameResolver object.
+	resolverObj, ok := obj.(*ocpnetworkapiv1alpha1.DNSNameResolver)
+	if !ok {
+		log.Infof("object not of type DNSNameResolver: %v", obj)
+		return
+	}
+
+	// Disable the maintenance window if the object is the sentinel object.
+	resolver.observeSentinel(resolverObj, true)
+
+	// Drop the rate limiter of the object, if maxUpdatesPerObject is configured.
+	if resolver.throttler != nil {
+		resolver.throttler.forget(types.NamespacedName{Namespace: resolverObj.Namespace, Name: resolverObj.Name})
+	}
+
+	// Check if the object is configured to be monitored or not.
+	if !resolver.configuredObject(resolverObj) {
+		return
+	}
+
+	resolver.deleteResolverObject(resolverObj)
+}
+
+// addResolverObject adds the details of the DNSNameResolver object to the regularDNSInfo
+// or the wildcardDNSInfo map, depending on its DNS name, and tracks the regular
+// expression of its regex annotation if regexMatch is enabled.
+func (resolver *OCPDNSNameResolver) addResolverObject(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) {
+	// The DNSNameResolver objects of the namespaces being deleted are about to be deleted.
+	if resolver.pruneDeletedNamespaces && resolver.terminatingNamespace(resolverObj.Namespace) {
+		log.Debugf("Ignoring DNSNameResolver object %s/%s of namespace being deleted", resolverObj.Namespace, resolverObj.Name)
+		return
+	}
+
+	dnsName := string(resolverObj.Spec.Name)
+	// The apex wildcard DNS name is most probably a misconfiguration, as it would match
+	// every single label DNS name. Ignore the object if rejectApexWildcard is enabled.
+	if isApexWildcard(dnsName) {
+		if resolver.rejectApexWildcard {
+			log.Warningf("Ignoring DNSNameResolver object %s/%s with apex wildcard DNS name %s",
+				resolverObj.Namespace, resolverObj.Name, dnsName)
+			return
+		}
+		log.Warningf("DNSNameResolver object %s/%s has apex wildcard DNS name %s", resolverObj.Namespace, resolverObj.Name, dnsName)
+	}
+	// A wildcard DNS name overlapping an internal zone may capture the DNS lookups of the
+	// internal DNS names. Ignore the object if rejectInternalWildcards is enabled.
+	if zone := resolver.overlappingInternalZone(dnsName); zone != "" {
+		if resolver.rejectInternalWildcards {
+			log.Warningf("Ignoring DNSNameResolver object %s/%s with wildcard DNS name %s overlapping internal zone %s",
+				resolverObj.Namespace, resolverObj.Name, dnsName, zone)
+			return
+		}
+		log.Warningf("DNSNameResolver object %s/%s has wildcard DNS name %s overlapping internal zone %s",
+			resolverObj.Namespace, resolverObj.Name, dnsName, zone)
+	}
+
+	// Add the regular expression of the regex annotation of the object, if regexMatch is enabled.
+	if resolver.regexMatch {
+		resolver.trackRegex(resolverObj)
+	}
+
+	// Evict the least recently queried DNS name if tracking the DNS name would exceed
+	// maxTrackedNames.
+	resolver.makeRoomForName(dnsName, time.Now())
+
+	// Check if the DNS name is wildcard or regular.
+	if isWildcard(dnsName) {
+		// If the DNS name is wildcard, add the details of the DNSNameResolver
+		// object to the wildcardDNSInfo map.
+		resolver.wildcardMapLock.Lock()
+		dnsInfoMap, dnsInfoExists := resolver.wildcardDNSInfo[dnsName]
+		// If details of DNS name and the DNSNameResolver objects already exist
+		// then check if the existing information match with the current one.
+		// In a namespace only one DNSNameResolver object should be created
+		// corresponding to a DNS name. If more than one DNSNameResolver object
+		// exists in a namespace corresponding to a DNS name, only the first
+		// object will be considered, or the oldest one if conflictResolution is
+		// oldest. Thus, if the existing information doesn't match and is kept,
+		// then don't proceed.
+		if dnsInfoExists {
+			if objName, objNameFound := dnsInfoMap[resolverObj.Namespace]; objNameFound && objName != resolverObj.Name &&
+				resolver.keepTrackedObject(objName, resolverObj) {
+				resolver.wildcardMapLock.Unlock()
+				return
+			}
+		}
+		if !dnsInfoExists {
+			// Keep the other DNSNameResolver objects of the DNS name, if it was evicted.
+			dnsInfoMap = resolver.takeEvictedName(dnsName)
+			if dnsInfoMap == nil {
+				dnsInfoMap = make(namespaceDNSInfo)
+			}
+			resolver.notifyWebhook(webhookActionTracked, dnsName)
+		}
+		dnsInfoMap[resolverObj.Namespace] = resolverObj.Name
+		resolver.wildcardDNSInfo[dnsName] = dnsInfoMap
+		resolver.wildcardMapLock.Unlock()
+	} else {
+		// If the DNS name is regular, add the details of the DNSNameResolver
+		// object to the regularDNSInfo map.
+		resolver.regularMapLock.Lock()
+		dnsInfoMap, dnsInfoExists := resolver.regularDNSInfo[dnsName]
+		// If details of DNS name and the DNSNameResolver objects already exist
+		// then check if the existing information match with the current one.
+		// In a namespace only one DNSNameResolver object should be created
+		// corresponding to a DNS name. If more than one DNSNameResolver object
+		// exists in a namespace corresponding to a DNS name, only the first
+		// object will be considered, or the oldest one if conflictResolution is
+		// oldest. Thus, if the existing information doesn't match and is kept,
+		// then don't proceed.
+		if dnsInfoExists {
+			if objName, objNameFound := dnsInfoMap[resolverObj.Namespace]; objNameFound && objName != resolverObj.Name &&
+				resolver.keepTrackedObject(objName, resolverObj) {
+				resolver.regularMapLock.Unlock()
+				return
+			}
+		}
+		if !dnsInfoExists {
+			// Keep the other DNSNameResolver objects of the DNS name, if it was evicted.
+			dnsInfoMap = resolver.takeEvictedName(dnsName)
+			if dnsInfoMap == nil {
+				dnsInfoMap = make(namespaceDNSInfo)
+			}
+			resolver.notifyWebhook(webhookActionTracked, dnsName)
+		}
+		dnsInfoMap[resolverObj.Namespace] = resolverObj.Name
+		resolver.regularDNSInfo[dnsName] = dnsInfoMap
+		resolver.regularMapLock.Unlock()
+	}
+}
+
+// deleteResolverObject deletes the details of the DNSNameResolver object from the
+// regularDNSInfo or the wildcardDNSInfo map, depending on its DNS name, and stops
+// tracking the regular expression of its regex annotation. If conflictResolution is
+// oldest, the object with the same DNS name in the same namespace is tracked instead.
+func (resolver *OCPDNSNameResolver) deleteResolverObject(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) {
+	// Remove the regular expression of the regex annotation of the object.
+	resolver.untrackRegex(resolverObj)
+
+	// Forget the DNSNameResolver object, if its DNS name was evicted.
+	resolver.forgetEvictedObject(resolverObj)
+
+	// untracked indicates whether the details of the DNSNameResolver object were deleted.
+	untracked := false
+
+	dnsName := string(resolverObj.Spec.Name)
+	// Check if the DNS name is wildcard or regular.
+	if isWildcard(dnsName) {
+		// If the DNS name is wildcard, delete the details of the DNSNameResolver
+		// object from the wildcardDNSInfo map.
+		resolver.wildcardMapLock.Lock()
+		if dnsInfoMap, exists := resolver.wildcardDNSInfo[dnsName]; exists {
+			// If details of DNS name and the DNSNameResolver objects already exist
+			// then check if the existing information match with the current one.
+			// Otherwise, don't proceed.
+			if dnsInfoMap[resolverObj.Namespace] == resolverObj.Name {
+				delete(dnsInfoMap, resolverObj.Namespace)
+				untracked = true
+				if len(dnsInfoMap) > 0 {
+					resolver.wildcardDNSInfo[dnsName] = dnsInfoMap
+				} else {
+					delete(resolver.wildcardDNSInfo, dnsName)
+					resolver.forgetTrackedName(dnsName)
+					resolver.notifyWebhook(webhookActionUntracked, dnsName)
+				}
+			}
+		}
+		resolver.wildcardMapLock.Unlock()
+	} else {
+		// If the DNS name is regular, delete the details of the DNSNameResolver
+		// object from the regularDNSInfo map.
+		resolver.regularMapLock.Lock()
+		if dnsInfoMap, exists := resolver.regularDNSInfo[dnsName]; exists {
+			// If details of DNS name and the DNSNameResolver objects already exist
+			// then check if the existing information match with the current one.
+			// Otherwise, don't proceed.
+			if dnsInfoMap[resolverObj.Namespace] == resolverObj.Name {
+				delete(dnsInfoMap, resolverObj.Namespace)
+				untracked = true
+				if len(dnsInfoMap) > 0 {
+					resolver.regularDNSInfo[dnsName] = dnsInfoMap
+				} else {
+					delete(resolver.regularDNSInfo, dnsName)
+					resolver.forgetTrackedName(dnsName)
+					resolver.notifyWebhook(webhookActionUntracked, dnsName)
+				}
+			}
+		}
+		resolver.regularMapLock.Unlock()
+	}
+
+	// Track the object with the same DNS name in the same namespace in place of the deleted
+	// one, if conflictResolution is oldest.
+	if untracked {
+		resolver.trackConflictingObject(resolverObj)
+	}
+}
+
+// lookupRegular returns a copy of the details of the regular DNS name, if it is tracked.
+// The details are copied, as the map of the details is modified by the informer event
+// handlers while the DNS lookup is handled. The lookup time of the DNS name is recorded
+// for the eviction of the least recently queried DNS names, if maxTrackedNames is
+// configured, and an evicted DNS name is tracked again.
+func (resolver *OCPDNSNameResolver) lookupRegular(dnsName string) (namespaceDNSInfo, bool) {
+	resolver.regularMapLock.Lock()
+	dnsInfoMap, exists := resolver.regularDNSInfo[dnsName]
+	dnsInfoMap = maps.Clone(dnsInfoMap)
+	resolver.regularMapLock.Unlock()
+
+	if exists {
+		resolver.touchTrackedName(dnsName, time.Now())
+		return dnsInfoMap, true
+	}
+	return resolver.readmitEvictedName(dnsName, time.Now())
+}
+
+// lookupWildcard returns a copy of the details of the wildcard DNS name, if it is
+// tracked, like lookupRegular.
+func (resolver *OCPDNSNameResolver) lookupWildcard(dnsName string) (namespaceDNSInfo, bool) {
+	resolver.wildcardMapLock.Lock()
+	dnsInfoMap, exists := resolver.wildcardDNSInfo[dnsName]
+	dnsInfoMap = maps.Clone(dnsInfoMap)
+	resolver.wildcardMapLock.Unlock()
+
+	if exists {
+		resolver.touchTrackedName(dnsName, time.Now())
+		return dnsInfoMap, true
+	}
+	return resolver.readmitEvictedName(dnsName, time.Now())
+}
+
+// tweakListOptions sets the page size of the list requests of the DNSNameResolver
+// informer to the configured listPageSize. Only the list requests for which the
+// reflector already requested a page are changed: a zero limit is kept, as the
+// reflector uses it for the full list requests, eg. when falling back from an
+// expired paginated list.
+func (resolver *OCPDNSNameResolver) tweakListOptions(options *metav1.ListOptions) {
+	if options.Limit > 0 {
+		options.Limit = resolver.listPageSize
+	}
+}
+
 // initPlugin initializes the ocp_dnsnameresolver plugin and returns the plugin startup and
 // shutdown callback functions.
 func (resolver *OCPDNSNameResolver) initPlugin() (func() error, func() error, error) {
@@ -223,17 +792,145 @@ func (resolver *OCPDNSNameResolver) initPlugin() (func() error, func() error, er
 		return nil, nil, err
 	}
 
-	err = resolver.initInformer(networkClient)
+	// Create a client for writing the mirror ConfigMap, if it is configured, for watching the
+	// namespaces, if pruneDeletedNamespaces is enabled or namespaceSelector is configured, for
+	// recording the summary events, if summaryEventInterval is configured, and for reviewing
+	// the permissions of the plugin, if diagnostics is enabled.
+	if resolver.mirrorConfigMap.Name != "" || resolver.watchNamespaces() || resolver.summaryEventInterval > 0 || resolver.diagnostics {
+		resolver.kubeClient, err = kubernetes.NewForConfig(kubeConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if resolver.summaryEventInterval > 0 {
+		resolver.eventRecorder, resolver.eventBroadcaster = newEventRecorder(resolver.kubeClient)
+	}
+
+	// Detect the service CIDRs of the cluster, if excludeServiceCIDR is enabled without any CIDR.
+	if resolver.excludeServiceCIDR && len(resolver.serviceCIDRs) == 0 {
+		configClient, err := configclient.NewForConfig(kubeConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		resolver.serviceCIDRs, err = detectServiceCIDRs(context.Background(), configClient)
+		if err != nil {
+			return nil, nil, err
+		}
+		log.Infof("Excluding the IP addresses of the detected service CIDRs %v", formatPrefixes(resolver.serviceCIDRs))
+	}
+
+	return resolver.initPluginWithClient(networkClient)
+}
+
+// initPluginWithClient initializes the ocp_dnsnameresolver plugin using the given client and
+// returns the plugin startup and shutdown callback functions.
+func (resolver *OCPDNSNameResolver) initPluginWithClient(networkClient ocpnetworkclient.Interface) (func() error, func() error, error) {
+	err := resolver.initInformer(networkClient)
 	if err != nil {
 		return nil, nil, err
 	}
+	if resolver.watchNamespaces() {
+		if err := resolver.initNamespaceInformer(resolver.kubeClient); err != nil {
+			return nil, nil, err
+		}
+	}
 
 	resolver.stopCh = make(chan struct{})
+	resolver.informerDone = make(chan struct{})
 
 	onStart := func() error {
-		go func() {
-			resolver.dnsNameResolverInformer.Run(resolver.stopCh)
-		}()
+		// Log the startup diagnostics, if diagnostics is enabled.
+		if resolver.diagnostics {
+			resolver.logDiagnostics(networkClient.Discovery(), resolver.kubeClient)
+		}
+
+		// With lazyStart, the informer is started by the first DNS lookup of an external zone.
+		if !resolver.lazyStart {
+			resolver.startInformer()
+		}
+
+		// Watch the namespaces, if pruneDeletedNamespaces is enabled or namespaceSelector is configured.
+		if resolver.namespaceInformer != nil {
+			go resolver.namespaceInformer.Run(resolver.stopCh)
+		}
+
+		// Prefetch the IP addresses of the tracked DNS names once the informer is synced.
+		if resolver.prefetchOnStart {
+			go resolver.prefetchOnSync(resolver.stopCh)
+		}
+
+		// Periodically retry the forbidden updates of the DNSNameResolver objects.
+		if resolver.retryForbidden {
+			go wait.Until(func() {
+				resolver.retryForbiddenUpdates(context.Background(), time.Now())
+			}, forbiddenRetryPeriod, resolver.stopCh)
+		}
+
+		// Periodically clear the negative results older than negativeMaxAge.
+		if resolver.recordNegative && resolver.negativeMaxAge > 0 {
+			go wait.Until(func() {
+				resolver.sweepNegativeResults(context.Background(), time.Now())
+			}, min(resolver.negativeMaxAge, negativeSweepPeriod), resolver.stopCh)
+		}
+
+		// Periodically flush the buffered wildcard children, if wildcardFlushInterval is configured.
+		if resolver.recordWildcardChildren && resolver.wildcardFlushInterval > 0 {
+			go wait.Until(func() {
+				resolver.flushWildcardChildren(context.Background())
+			}, resolver.wildcardFlushInterval, resolver.stopCh)
+		}
+
+		// Periodically reconcile the drifted statuses, if reconcileInterval is configured.
+		if resolver.reconcileInterval > 0 {
+			go wait.Until(func() {
+				resolver.reconcileDrift(context.Background())
+			}, resolver.reconcileInterval, resolver.stopCh)
+		}
+
+		// Periodically flush the buffered status updates, if flushInterval is configured.
+		if resolver.flusher != nil {
+			go resolver.runFlusher(resolver.stopCh)
+		}
+
+		// Periodically emit the summary events, if summaryEventInterval is configured.
+		if resolver.summaryEventInterval > 0 {
+			go resolver.runSummaryEvents(resolver.stopCh)
+		}
+
+		// Send the webhook events in batches, if webhookURL is configured.
+		if resolver.webhook != nil {
+			go resolver.webhook.run(resolver.stopCh)
+		}
+
+		// Periodically write the summary of the status to the mirror ConfigMap.
+		if resolver.mirrorConfigMap.Name != "" {
+			go resolver.runMirror(resolver.stopCh)
+		}
+
+		// Dump the tracked state on SIGUSR1, if signalDump is enabled.
+		if resolver.signalDump {
+			resolver.watchDumpSignal(resolver.stopCh)
+		}
+
+		// Serve the debug endpoint, if debugAddress is configured.
+		if resolver.debugAddress != "" {
+			if err := resolver.runDebugServer(resolver.stopCh); err != nil {
+				return err
+			}
+		}
+
+		// Retry the updates failed with a transient error in the background.
+		resolver.retrier.run(resolver.stopCh)
+
+		// Periodically update the metric of the resource version last observed by the informer.
+		go wait.Until(func() {
+			updateResourceVersionMetric(resolver.dnsNameResolverInformer.LastSyncResourceVersion())
+		}, resourceVersionMetricPeriod, resolver.stopCh)
+
+		// With lazyStart, the server is started without waiting for the informer.
+		if resolver.lazyStart {
+			return nil
+		}
 
 		timeout := 5 * time.Second
 		timeoutTicker := time.NewTicker(timeout)
@@ -270,6 +967,46 @@ func (resolver *OCPDNSNameResolver) initPlugin() (func() error, func() error, er
 			close(resolver.stopCh)
 			resolver.shutdown = true
 
+			// Wait for the informer to stop, so that its watch does not linger after the
+			// shutdown, eg. during rapid restarts.
+			if resolver.started {
+				select {
+				case <-resolver.informerDone:
+				case <-time.After(resolver.shutdownTimeout):
+					log.Warningf("DNS Name Resolver Informer did not stop within %s", resolver.shutdownTimeout)
+				}
+			}
+
+			// Drain the buffered status updates, if flushInterval is configured.
+			if resolver.flusher != nil {
+				ctx, cancel := context.WithTimeout(context.Background(), resolver.shutdownTimeout)
+				resolver.flusher.flush(ctx)
+				cancel()
+			}
+
+			// Drain the throttled status updates, if maxUpdatesPerObject is configured.
+			if resolver.throttler != nil {
+				ctx, cancel := context.WithTimeout(context.Background(), resolver.shutdownTimeout)
+				resolver.throttler.drain(ctx)
+				cancel()
+			}
+
+			// Stop retrying the updates failed with a transient error. The queued retries are
+			// dropped, including the ones of the drained status updates.
+			resolver.retrier.stop()
+
+			// Drain the webhook events, if webhookURL is configured.
+			if resolver.webhook != nil {
+				ctx, cancel := context.WithTimeout(context.Background(), resolver.shutdownTimeout)
+				resolver.webhook.drain(ctx)
+				cancel()
+			}
+
+			// Stop sending the events to the API.
+			if resolver.eventBroadcaster != nil {
+				resolver.eventBroadcaster.Shutdown()
+			}
+
 			return nil
 		}
 
@@ -278,3 +1015,27 @@ func (resolver *OCPDNSNameResolver) initPlugin() (func() error, func() error, er
 
 	return onStart, onShut, nil
 }
+
+// startInformer runs the DNSNameResolver informer until the plugin is shut down. The
+// informer is run at most once, and never once the plugin is shut down. It is safe to
+// call concurrently, eg. from ServeDNS with lazyStart.
+func (resolver *OCPDNSNameResolver) startInformer() {
+	resolver.informerStartOnce.Do(func() {
+		resolver.stopLock.Lock()
+		defer resolver.stopLock.Unlock()
+		if resolver.shutdown {
+			return
+		}
+		resolver.started = true
+
+		go func() {
+			// Signal the shutdown callback once the informer is stopped.
+			defer close(resolver.informerDone)
+			resolver.dnsNameResolverInformer.Run(resolver.stopCh)
+		}()
+		// Start the settle window once the informer synced, if settleWindow is configured.
+		if resolver.settleWindow > 0 {
+			go resolver.waitSettle(resolver.stopCh)
+		}
+	})
+}
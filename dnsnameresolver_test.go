@@ -0,0 +1,434 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"maps"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	ocpnetworkfakeclient "github.com/openshift/client-go/network/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestTweakListOptions(t *testing.T) {
+	tests := []struct {
+		name          string
+		limit         int64
+		expectedLimit int64
+	}{
+		{
+			name:          "Page size requested by the reflector is replaced",
+			limit:         500,
+			expectedLimit: 100,
+		},
+		{
+			name:          "Full list request is kept",
+			limit:         0,
+			expectedLimit: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := New()
+			resolver.listPageSize = 100
+
+			options := &metav1.ListOptions{Limit: tc.limit}
+			resolver.tweakListOptions(options)
+			if options.Limit != tc.expectedLimit {
+				t.Fatalf("Expected limit %d, found %d", tc.expectedLimit, options.Limit)
+			}
+		})
+	}
+}
+
+func TestOnShutWaitsForInformer(t *testing.T) {
+	resolver := New()
+	onStart, onShut, err := resolver.initPluginWithClient(ocpnetworkfakeclient.NewSimpleClientset())
+	if err != nil {
+		t.Fatalf("Failed to initialize plugin: %v", err)
+	}
+
+	if err := onStart(); err != nil {
+		t.Fatalf("Failed to start plugin: %v", err)
+	}
+	if err := onShut(); err != nil {
+		t.Fatalf("Failed to shut down plugin: %v", err)
+	}
+
+	// The informer goroutine should have exited before onShut returned.
+	select {
+	case <-resolver.informerDone:
+	default:
+		t.Fatalf("Expected the informer to be stopped once onShut returned")
+	}
+
+	if err := onShut(); err == nil {
+		t.Fatalf("Expected an error for the repeated shutdown")
+	}
+}
+
+func TestOnShutWithoutStart(t *testing.T) {
+	resolver := New()
+	resolver.shutdownTimeout = time.Minute
+	_, onShut, err := resolver.initPluginWithClient(ocpnetworkfakeclient.NewSimpleClientset())
+	if err != nil {
+		t.Fatalf("Failed to initialize plugin: %v", err)
+	}
+
+	// onShut should not wait for the informer which is never started.
+	done := make(chan error)
+	go func() { done <- onShut() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Failed to shut down plugin: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected onShut to return without waiting for the informer")
+	}
+}
+
+func TestLazyStart(t *testing.T) {
+	resolver := New()
+	resolver.lazyStart = true
+	resolver.internalZones = []string{"cluster.local."}
+	onStart, onShut, err := resolver.initPluginWithClient(ocpnetworkfakeclient.NewSimpleClientset())
+	if err != nil {
+		t.Fatalf("Failed to initialize plugin: %v", err)
+	}
+	defer onShut()
+
+	informerStarted := func() bool {
+		resolver.stopLock.Lock()
+		defer resolver.stopLock.Unlock()
+		return resolver.started
+	}
+
+	if err := onStart(); err != nil {
+		t.Fatalf("Failed to start plugin: %v", err)
+	}
+	if informerStarted() {
+		t.Fatalf("Expected the informer not to be started by onStart")
+	}
+	if !resolver.Ready() {
+		t.Fatalf("Expected the plugin to be ready before the informer is started")
+	}
+
+	// The DNS lookups of the internal zones do not start the informer.
+	internalQuery := test.Case{Qname: "foo.cluster.local.", Qtype: dns.TypeA}
+	resolver.Next = fakeNextPluginHandler(internalQuery)
+	resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), internalQuery.Msg())
+	if informerStarted() {
+		t.Fatalf("Expected the informer not to be started by the DNS lookup of an internal zone")
+	}
+
+	// The concurrent DNS lookups of the external zones start the informer once.
+	query := test.Case{
+		Qname:  "www.example.com.",
+		Qtype:  dns.TypeA,
+		Answer: []dns.RR{test.A("www.example.com. 30 IN A 1.1.1.1")},
+	}
+	resolver.Next = fakeNextPluginHandler(query)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+		}()
+	}
+	wg.Wait()
+	if !informerStarted() {
+		t.Fatalf("Expected the informer to be started by the DNS lookup")
+	}
+	if !cache.WaitForCacheSync(resolver.stopCh, resolver.dnsNameResolverInformer.HasSynced) {
+		t.Fatalf("Expected the started informer to sync")
+	}
+
+	if err := onShut(); err != nil {
+		t.Fatalf("Failed to shut down plugin: %v", err)
+	}
+	select {
+	case <-resolver.informerDone:
+	default:
+		t.Fatalf("Expected the informer to be stopped once onShut returned")
+	}
+}
+
+func TestUpdateFuncNameClassChange(t *testing.T) {
+	dnsNames := []string{"www.example.com.", "*.example.com.", "www.example.com."}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "resolver",
+			Namespace: "dns",
+		},
+		Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+			Name: ocpnetworkapiv1alpha1.DNSName(dnsNames[0]),
+		},
+	})
+
+	for i := 1; i < len(dnsNames); i++ {
+		oldDNSName, newDNSName := dnsNames[i-1], dnsNames[i]
+
+		resolverObj, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("dns").Get(ctx, "resolver", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error getting DNSNameResolver object: %v", err)
+		}
+		resolverObj.Spec.Name = ocpnetworkapiv1alpha1.DNSName(newDNSName)
+		if _, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("dns").Update(ctx, resolverObj, metav1.UpdateOptions{}); err != nil {
+			t.Fatalf("Unexpected error updating DNSNameResolver object: %v", err)
+		}
+
+		err = wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 2*time.Second, true, func(context.Context) (bool, error) {
+			return isTracked(resolver, "dns", "resolver", newDNSName), nil
+		})
+		if err != nil {
+			t.Fatalf("Expected DNS name %s to be tracked after the update from %s", newDNSName, oldDNSName)
+		}
+
+		// Only the new DNS name should be tracked, in the map of its class.
+		resolver.regularMapLock.Lock()
+		regularDNSInfo := maps.Clone(resolver.regularDNSInfo)
+		resolver.regularMapLock.Unlock()
+		resolver.wildcardMapLock.Lock()
+		wildcardDNSInfo := maps.Clone(resolver.wildcardDNSInfo)
+		resolver.wildcardMapLock.Unlock()
+
+		expectedRegularDNSInfo := map[string]namespaceDNSInfo{}
+		expectedWildcardDNSInfo := map[string]namespaceDNSInfo{}
+		if isWildcard(newDNSName) {
+			expectedWildcardDNSInfo[newDNSName] = namespaceDNSInfo{"dns": "resolver"}
+		} else {
+			expectedRegularDNSInfo[newDNSName] = namespaceDNSInfo{"dns": "resolver"}
+		}
+		if !reflect.DeepEqual(regularDNSInfo, expectedRegularDNSInfo) {
+			t.Fatalf("Expected regular DNS names %v after the update from %s to %s, found: %v",
+				expectedRegularDNSInfo, oldDNSName, newDNSName, regularDNSInfo)
+		}
+		if !reflect.DeepEqual(wildcardDNSInfo, expectedWildcardDNSInfo) {
+			t.Fatalf("Expected wildcard DNS names %v after the update from %s to %s, found: %v",
+				expectedWildcardDNSInfo, oldDNSName, newDNSName, wildcardDNSInfo)
+		}
+	}
+}
+
+func TestAddApexWildcard(t *testing.T) {
+	tests := []struct {
+		name               string
+		rejectApexWildcard bool
+		dnsName            string
+		expectedTracked    bool
+	}{
+		{
+			name:               "Apex wildcard * is rejected",
+			rejectApexWildcard: true,
+			dnsName:            "*",
+			expectedTracked:    false,
+		},
+		{
+			name:               "Apex wildcard *. is rejected",
+			rejectApexWildcard: true,
+			dnsName:            "*.",
+			expectedTracked:    false,
+		},
+		{
+			name:               "Apex wildcard *. is tracked when not rejected",
+			rejectApexWildcard: false,
+			dnsName:            "*.",
+			expectedTracked:    true,
+		},
+		{
+			name:               "Wildcard with a parent domain is tracked",
+			rejectApexWildcard: true,
+			dnsName:            "*.example.com.",
+			expectedTracked:    true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.rejectApexWildcard = tc.rejectApexWildcard
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			_, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("dns").Create(ctx, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "apex",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: ocpnetworkapiv1alpha1.DNSName(tc.dnsName),
+				},
+			}, metav1.CreateOptions{})
+			if err != nil {
+				t.Fatalf("Unexpected error creating DNSNameResolver object: %v", err)
+			}
+
+			// The informer handles the events in order, thus once the object created afterwards
+			// is tracked, the first object is handled too.
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			})
+
+			if tracked := isTracked(resolver, "dns", "apex", tc.dnsName); tracked != tc.expectedTracked {
+				t.Fatalf("Expected DNS name %s tracked to be %t, found %t", tc.dnsName, tc.expectedTracked, tracked)
+			}
+		})
+	}
+}
+
+func TestAddInternalWildcard(t *testing.T) {
+	tests := []struct {
+		name                    string
+		rejectInternalWildcards bool
+		dnsName                 string
+		expectedTracked         bool
+	}{
+		{
+			name:                    "Wildcard of the internal zone is rejected",
+			rejectInternalWildcards: true,
+			dnsName:                 "*.cluster.local.",
+			expectedTracked:         false,
+		},
+		{
+			name:                    "Wildcard within the internal zone is rejected",
+			rejectInternalWildcards: true,
+			dnsName:                 "*.svc.cluster.local.",
+			expectedTracked:         false,
+		},
+		{
+			name:                    "Wildcard containing the internal zone is rejected",
+			rejectInternalWildcards: true,
+			dnsName:                 "*.local.",
+			expectedTracked:         false,
+		},
+		{
+			name:                    "Wildcard of the internal zone is tracked when not rejected",
+			rejectInternalWildcards: false,
+			dnsName:                 "*.cluster.local.",
+			expectedTracked:         true,
+		},
+		{
+			name:                    "Wildcard sharing a suffix with the internal zone is tracked",
+			rejectInternalWildcards: true,
+			dnsName:                 "*.mycluster.local.",
+			expectedTracked:         true,
+		},
+		{
+			name:                    "Regular DNS name of the internal zone is tracked",
+			rejectInternalWildcards: true,
+			dnsName:                 "api.cluster.local.",
+			expectedTracked:         true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.internalZones = []string{"cluster.local."}
+			resolver.rejectInternalWildcards = tc.rejectInternalWildcards
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			_, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("dns").Create(ctx, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "internal",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: ocpnetworkapiv1alpha1.DNSName(tc.dnsName),
+				},
+			}, metav1.CreateOptions{})
+			if err != nil {
+				t.Fatalf("Unexpected error creating DNSNameResolver object: %v", err)
+			}
+
+			// The informer handles the events in order, thus once the object created afterwards
+			// is tracked, the first object is handled too.
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			})
+
+			if tracked := isTracked(resolver, "dns", "internal", tc.dnsName); tracked != tc.expectedTracked {
+				t.Fatalf("Expected DNS name %s tracked to be %t, found %t", tc.dnsName, tc.expectedTracked, tracked)
+			}
+		})
+	}
+}
+
+func TestHandleDeleteTombstone(t *testing.T) {
+	tests := []struct {
+		name    string
+		dnsName string
+	}{
+		{
+			name:    "Regular DNS name is removed",
+			dnsName: "www.example.com.",
+		},
+		{
+			name:    "Wildcard DNS name is removed",
+			dnsName: "*.example.com.",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := New()
+			resolverObj := &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "resolver",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: ocpnetworkapiv1alpha1.DNSName(tc.dnsName),
+				},
+			}
+			resolver.addResolverObject(resolverObj)
+			if !isTracked(resolver, "dns", "resolver", tc.dnsName) {
+				t.Fatalf("Expected DNS name %s to be tracked", tc.dnsName)
+			}
+
+			// The deletion missed by the informer is delivered as a tombstone.
+			resolver.handleDelete(cache.DeletedFinalStateUnknown{Key: "dns/resolver", Obj: resolverObj})
+			if isTracked(resolver, "dns", "resolver", tc.dnsName) {
+				t.Fatalf("Expected DNS name %s not to be tracked after the deletion", tc.dnsName)
+			}
+			if len(resolver.regularDNSInfo) != 0 || len(resolver.wildcardDNSInfo) != 0 {
+				t.Fatalf("Expected no DNS name to be tracked, found %v and %v", resolver.regularDNSInfo, resolver.wildcardDNSInfo)
+			}
+		})
+	}
+}
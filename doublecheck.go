@@ -0,0 +1,61 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/miekg/dns"
+)
+
+// doubleCheckTimeout gives the maximum duration of the second DNS lookup of the DNS name
+// when doubleCheck is enabled.
+const doubleCheckTimeout = 2 * time.Second
+
+// doubleCheckAddresses looks up the DNS name once more through the plugin chain and
+// removes the IP addresses which are not in the response of the second DNS lookup from
+// ipTTLs, so that only the IP addresses present in both responses are recorded. All
+// the IP addresses are removed if the second DNS lookup fails. The response of the
+// first DNS lookup is already written to the client, thus the second DNS lookup does
+// not delay it.
+func (resolver *OCPDNSNameResolver) doubleCheckAddresses(ctx context.Context, r *dns.Msg, qname string, ipTTLs map[string]int32) {
+	ctx, cancel := context.WithTimeout(ctx, doubleCheckTimeout)
+	defer cancel()
+
+	rw := dnstest.NewRecorder(&prefetchResponseWriter{})
+	status, err := plugin.NextOrFailure(resolver.Name(), resolver.Next, ctx, rw, r.Copy())
+	if status != dns.RcodeSuccess || err != nil || rw.Msg == nil {
+		log.Debugf("Not recording the response for DNS name %s as its second DNS lookup failed with rcode %s: %v",
+			qname, dns.RcodeToString[status], err)
+		clear(ipTTLs)
+		return
+	}
+
+	records := rw.Msg.Answer
+	if resolver.includeAdditional {
+		records = append(slices.Clip(rw.Msg.Answer), rw.Msg.Extra...)
+	}
+	chain := resolver.cnameChain(qname, records)
+	confirmed := make(map[string]int32)
+	for _, answer := range records {
+		switch rec := answer.(type) {
+		case *dns.A:
+			if inCNAMEChain(chain, rec.Hdr.Name) {
+				addIPTTL(confirmed, rec.A, 0)
+			}
+		case *dns.AAAA:
+			if inCNAMEChain(chain, rec.Hdr.Name) {
+				addIPTTL(confirmed, rec.AAAA, 0)
+			}
+		}
+	}
+
+	for ip := range ipTTLs {
+		if _, exists := confirmed[ip]; !exists {
+			log.Debugf("Not recording IP address %s of DNS name %s as it is not in the response of its second DNS lookup", ip, qname)
+			delete(ipTTLs, ip)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeSequenceNextPluginHandler returns a plugin.Handler responding to the successive DNS
+// lookups with the given test cases in order, repeating the last one. The number of the
+// DNS lookups is counted.
+func fakeSequenceNextPluginHandler(lookups *int, tcs ...test.Case) plugin.Handler {
+	return plugin.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+		tc := tcs[min(*lookups, len(tcs)-1)]
+		*lookups++
+		return fakeNextPluginHandler(tc).ServeDNS(ctx, w, r)
+	})
+}
+
+func TestServeDNSDoubleCheck(t *testing.T) {
+	first := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("www.example.com. 30 IN A 1.1.1.1"),
+			test.A("www.example.com. 30 IN A 2.2.2.2"),
+		},
+	}
+	second := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("www.example.com. 30 IN A 1.1.1.1"),
+			test.A("www.example.com. 30 IN A 3.3.3.3"),
+		},
+	}
+	failed := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeServerFailure,
+	}
+
+	tests := []struct {
+		name            string
+		doubleCheck     bool
+		second          test.Case
+		expectedLookups int
+		expectedIPs     []string
+	}{
+		{
+			name:            "Addresses are recorded without a second lookup when doubleCheck is disabled",
+			second:          second,
+			expectedLookups: 1,
+			expectedIPs:     []string{"1.1.1.1", "2.2.2.2"},
+		},
+		{
+			name:            "Only the addresses present in both responses are recorded",
+			doubleCheck:     true,
+			second:          second,
+			expectedLookups: 2,
+			expectedIPs:     []string{"1.1.1.1"},
+		},
+		{
+			name:            "No address is recorded when the second lookup fails",
+			doubleCheck:     true,
+			second:          failed,
+			expectedLookups: 2,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.doubleCheck = tc.doubleCheck
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			})
+
+			lookups := 0
+			resolver.Next = fakeSequenceNextPluginHandler(&lookups, first, tc.second)
+			rec := dnstest.NewRecorder(&test.ResponseWriter{})
+			resolver.ServeDNS(context.TODO(), rec, first.Msg())
+			if rec.Msg == nil || len(rec.Msg.Answer) != len(first.Answer) {
+				t.Fatalf("Expected the response of the first lookup to be served, found: %v", rec.Msg)
+			}
+			if lookups != tc.expectedLookups {
+				t.Fatalf("Expected %d lookups through the plugin chain, found %d", tc.expectedLookups, lookups)
+			}
+
+			if len(tc.expectedIPs) == 0 {
+				if count := countStatusUpdates(fakeNetworkClient); count != 0 {
+					t.Fatalf("Expected no status updates, found %d", count)
+				}
+				return
+			}
+			resolverObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+				return len(obj.Status.ResolvedNames) == 1
+			})
+			if len(resolverObj.Status.ResolvedNames) != 1 {
+				t.Fatalf("Expected a resolved name, found status: %v", resolverObj.Status)
+			}
+			var ips []string
+			for _, resolvedAddress := range resolverObj.Status.ResolvedNames[0].ResolvedAddresses {
+				ips = append(ips, resolvedAddress.IP)
+			}
+			if diff := cmp.Diff(tc.expectedIPs, ips, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+				t.Fatalf("Recorded addresses did not match the expected addresses:\nDiff: %s", diff)
+			}
+		})
+	}
+}
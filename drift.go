@@ -0,0 +1,135 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"sync"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// writtenStatuses stores the resolved names last written to the status of the
+// DNSNameResolver objects, when reconcileInterval is configured, so that the status
+// drifting from them, eg. when edited by hand, is reconciled.
+type writtenStatuses struct {
+	// written stores the resolved names last written to the status of the objects.
+	// key: DNSNameResolver object, value: resolved names.
+	written map[types.NamespacedName][]ocpnetworkapiv1alpha1.DNSNameResolverResolvedName
+	lock    sync.Mutex
+}
+
+// newWrittenStatuses returns an initialized writtenStatuses.
+func newWrittenStatuses() *writtenStatuses {
+	return &writtenStatuses{
+		written: make(map[types.NamespacedName][]ocpnetworkapiv1alpha1.DNSNameResolverResolvedName),
+	}
+}
+
+// record stores a copy of the resolved names of the status of the DNSNameResolver object.
+func (statuses *writtenStatuses) record(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) {
+	resolvedNames := resolverObj.Status.DeepCopy().ResolvedNames
+
+	statuses.lock.Lock()
+	defer statuses.lock.Unlock()
+	statuses.written[types.NamespacedName{Namespace: resolverObj.Namespace, Name: resolverObj.Name}] = resolvedNames
+}
+
+// forget drops the resolved names stored for the DNSNameResolver object.
+func (statuses *writtenStatuses) forget(object types.NamespacedName) {
+	statuses.lock.Lock()
+	defer statuses.lock.Unlock()
+	delete(statuses.written, object)
+}
+
+// snapshot returns a copy of the stored resolved names of all the DNSNameResolver objects.
+func (statuses *writtenStatuses) snapshot() map[types.NamespacedName][]ocpnetworkapiv1alpha1.DNSNameResolverResolvedName {
+	statuses.lock.Lock()
+	defer statuses.lock.Unlock()
+
+	snapshot := make(map[types.NamespacedName][]ocpnetworkapiv1alpha1.DNSNameResolverResolvedName, len(statuses.written))
+	for object, resolvedNames := range statuses.written {
+		snapshot[object] = resolvedNames
+	}
+	return snapshot
+}
+
+// driftStore is a resolverStore recording the statuses successfully written through the
+// wrapped resolverStore, so that they can be re-applied by the drift reconciler.
+type driftStore struct {
+	resolverStore
+	statuses *writtenStatuses
+}
+
+var _ resolverStore = &driftStore{}
+
+// updateStatus implements resolverStore.
+func (store *driftStore) updateStatus(ctx context.Context, resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) error {
+	err := store.resolverStore.updateStatus(ctx, resolverObj)
+	if err == nil {
+		store.statuses.record(resolverObj)
+	}
+	return err
+}
+
+// reconcileDrift re-applies the resolved names last written to the status of each tracked
+// DNSNameResolver object whose status does not match them anymore, eg. because the status
+// was edited by hand. The objects which are deleted or not tracked anymore are forgotten.
+func (resolver *OCPDNSNameResolver) reconcileDrift(ctx context.Context) {
+	var wg sync.WaitGroup
+	for object, resolvedNames := range resolver.writtenStatuses.snapshot() {
+		wg.Add(1)
+
+		// Each update is performed in separate goroutine.
+		go func(object types.NamespacedName, resolvedNames []ocpnetworkapiv1alpha1.DNSNameResolverResolvedName) {
+			defer wg.Done()
+
+			// Retry the update of the DNSNameResolver object if there's a conflict or a transient error during the update.
+			resolver.retrier.retryUpdate(object.Namespace, object.Name, "reconciled status", func() error {
+				// Fetch a copy of the DNSNameResolver object. All the updates will be applied to the copied object.
+				resolverObj, err := resolver.store.get(ctx, object.Namespace, object.Name)
+				if err != nil {
+					if apierrors.IsNotFound(err) {
+						resolver.writtenStatuses.forget(object)
+						return nil
+					}
+					return err
+				}
+				if !resolver.trackedObject(resolverObj) {
+					resolver.writtenStatuses.forget(object)
+					return nil
+				}
+
+				// Skip the update if the status matches or if the status updates are paused.
+				if equality.Semantic.DeepEqual(resolverObj.Status.ResolvedNames, resolvedNames) || isPaused(resolverObj) {
+					return nil
+				}
+				log.Infof("Reconciling drifted status of DNSNameResolver object %s/%s", object.Namespace, object.Name)
+				resolverObj.Status.ResolvedNames = make([]ocpnetworkapiv1alpha1.DNSNameResolverResolvedName, len(resolvedNames))
+				for i := range resolvedNames {
+					resolvedNames[i].DeepCopyInto(&resolverObj.Status.ResolvedNames[i])
+				}
+
+				// Update the status of the DNSNameResolver object.
+				return resolver.store.updateStatus(ctx, resolverObj)
+			})
+		}(object, resolvedNames)
+	}
+
+	// Wait for the goroutines for each object to complete.
+	wg.Wait()
+}
+
+// trackedObject returns whether the DNSNameResolver object is tracked for its DNS name.
+func (resolver *OCPDNSNameResolver) trackedObject(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+	dnsName := string(resolverObj.Spec.Name)
+	if isWildcard(dnsName) {
+		resolver.wildcardMapLock.Lock()
+		defer resolver.wildcardMapLock.Unlock()
+		return resolver.wildcardDNSInfo[dnsName][resolverObj.Namespace] == resolverObj.Name
+	}
+	resolver.regularMapLock.Lock()
+	defer resolver.regularMapLock.Unlock()
+	return resolver.regularDNSInfo[dnsName][resolverObj.Namespace] == resolverObj.Name
+}
@@ -0,0 +1,112 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestReconcileDrift(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.reconcileInterval = time.Minute
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+	})
+
+	query := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("www.example.com. 30 IN A 1.1.1.1"),
+		},
+	}
+	resolver.Next = fakeNextPluginHandler(query)
+	resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+	hasIP := func(ip string) func(*ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		return func(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+			ips := resolvedIPs(resolverObj, "www.example.com.")
+			return len(ips) == 1 && ips[0] == ip
+		}
+	}
+	resolverObj := getResolverObject(t, resolver, "dns", "regular", hasIP("1.1.1.1"))
+	if !hasIP("1.1.1.1")(resolverObj) {
+		t.Fatalf("Expected the IP address to be recorded, found status %v", resolverObj.Status)
+	}
+
+	// The status matching the last written one is not updated.
+	updates := countStatusUpdates(fakeNetworkClient)
+	resolver.reconcileDrift(ctx)
+	if count := countStatusUpdates(fakeNetworkClient); count != updates {
+		t.Fatalf("Expected no status update without drift, found %d", count-updates)
+	}
+
+	// The status edited by hand is reconciled.
+	resolverObj = resolverObj.DeepCopy()
+	resolverObj.Status.ResolvedNames[0].ResolvedAddresses[0].IP = "9.9.9.9"
+	if _, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("dns").UpdateStatus(ctx, resolverObj, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Unexpected error updating the status of DNSNameResolver object: %v", err)
+	}
+	getResolverObject(t, resolver, "dns", "regular", hasIP("9.9.9.9"))
+	resolver.reconcileDrift(ctx)
+	resolverObj = getResolverObject(t, resolver, "dns", "regular", hasIP("1.1.1.1"))
+	if !hasIP("1.1.1.1")(resolverObj) {
+		t.Fatalf("Expected the drifted status to be reconciled, found status %v", resolverObj.Status)
+	}
+
+	// The deleted object is forgotten.
+	if err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("dns").Delete(ctx, "regular", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Unexpected error deleting DNSNameResolver object: %v", err)
+	}
+	object := types.NamespacedName{Namespace: "dns", Name: "regular"}
+	for i := 0; i < 100; i++ {
+		resolver.reconcileDrift(ctx)
+		if _, exists := resolver.writtenStatuses.snapshot()[object]; !exists {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Expected the statuses of the deleted DNSNameResolver object to be forgotten")
+}
+
+func TestWrittenStatusesNotRecordedByDefault(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+	})
+
+	query := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("www.example.com. 30 IN A 1.1.1.1"),
+		},
+	}
+	resolver.Next = fakeNextPluginHandler(query)
+	resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+	if written := resolver.writtenStatuses.snapshot(); len(written) != 0 {
+		t.Fatalf("Expected no status to be recorded when reconcileInterval is not configured, found %v", written)
+	}
+}
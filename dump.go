@@ -0,0 +1,187 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+)
+
+// dumpFormat is the serialization format of the dump of the tracked state served by the
+// debug endpoint.
+type dumpFormat string
+
+const (
+	// dumpFormatJSON serializes the dump as JSON, the same as the one logged on SIGUSR1.
+	dumpFormatJSON dumpFormat = "json"
+	// dumpFormatText serializes the dump as plain text tables, for reading by operators.
+	dumpFormatText dumpFormat = "text"
+)
+
+// parseDumpFormat returns the dumpFormat corresponding to the given value and whether the
+// value is a valid dumpFormat. An empty value gives the JSON format.
+func parseDumpFormat(value string) (dumpFormat, bool) {
+	switch format := dumpFormat(value); format {
+	case "":
+		return dumpFormatJSON, true
+	case dumpFormatJSON, dumpFormatText:
+		return format, true
+	}
+	return "", false
+}
+
+// stateDump is the JSON encoded dump of the tracked state of the plugin, logged on
+// SIGUSR1 when signalDump is enabled.
+type stateDump struct {
+	// Regular and Wildcard give the details of the tracked regular and wildcard DNS
+	// names: DNS name --> namespace --> DNSNameResolver object name.
+	Regular  map[string]namespaceDNSInfo `json:"regular"`
+	Wildcard map[string]namespaceDNSInfo `json:"wildcard"`
+	// Failures gives the resolution failures of the resolved names in the status of the
+	// tracked DNSNameResolver objects, omitting the resolved names without failures:
+	// namespace/name of the DNSNameResolver object --> DNS name --> resolution failures.
+	Failures map[string]map[string]int32 `json:"failures"`
+}
+
+// newStateDump returns the dump of the tracked state of the plugin.
+func (resolver *OCPDNSNameResolver) newStateDump(ctx context.Context) stateDump {
+	dump := stateDump{
+		Regular:  make(map[string]namespaceDNSInfo),
+		Wildcard: make(map[string]namespaceDNSInfo),
+		Failures: make(map[string]map[string]int32),
+	}
+
+	resolver.regularMapLock.Lock()
+	for dnsName, dnsInfoMap := range resolver.regularDNSInfo {
+		dump.Regular[dnsName] = maps.Clone(dnsInfoMap)
+	}
+	resolver.regularMapLock.Unlock()
+	resolver.wildcardMapLock.Lock()
+	for dnsName, dnsInfoMap := range resolver.wildcardDNSInfo {
+		dump.Wildcard[dnsName] = maps.Clone(dnsInfoMap)
+	}
+	resolver.wildcardMapLock.Unlock()
+
+	// Get the resolution failures from the status of the tracked DNSNameResolver objects.
+	for _, dnsInfo := range []map[string]namespaceDNSInfo{dump.Regular, dump.Wildcard} {
+		for _, dnsInfoMap := range dnsInfo {
+			for namespace, objName := range dnsInfoMap {
+				resolverObj, err := resolver.store.get(ctx, namespace, objName)
+				if err != nil {
+					continue
+				}
+				for _, resolvedName := range resolverObj.Status.ResolvedNames {
+					if resolvedName.ResolutionFailures == 0 {
+						continue
+					}
+					key := namespace + "/" + objName
+					if dump.Failures[key] == nil {
+						dump.Failures[key] = make(map[string]int32)
+					}
+					dump.Failures[key][string(resolvedName.DNSName)] = resolvedName.ResolutionFailures
+				}
+			}
+		}
+	}
+	return dump
+}
+
+// writeText writes the dump as plain text tables: a table of the tracked DNS names, with a
+// row for each DNSNameResolver object, followed by a table of the resolution failures. The
+// rows are sorted, so that the output is stable.
+func (dump stateDump) writeText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "TYPE\tDNS NAME\tNAMESPACE\tNAME")
+	for _, tracked := range []struct {
+		kind    string
+		dnsInfo map[string]namespaceDNSInfo
+	}{{"regular", dump.Regular}, {"wildcard", dump.Wildcard}} {
+		for _, dnsName := range sortedKeys(tracked.dnsInfo) {
+			for _, namespace := range sortedKeys(tracked.dnsInfo[dnsName]) {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", tracked.kind, dnsName, namespace, tracked.dnsInfo[dnsName][namespace])
+			}
+		}
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "OBJECT\tDNS NAME\tFAILURES")
+	for _, object := range sortedKeys(dump.Failures) {
+		for _, dnsName := range sortedKeys(dump.Failures[object]) {
+			fmt.Fprintf(tw, "%s\t%s\t%d\n", object, dnsName, dump.Failures[object][dnsName])
+		}
+	}
+	return tw.Flush()
+}
+
+// sortedKeys returns the keys of the map in lexicographical order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// dumpState logs the dump of the tracked state of the plugin at the info level.
+func (resolver *OCPDNSNameResolver) dumpState() {
+	value, err := json.Marshal(resolver.newStateDump(context.Background()))
+	if err != nil {
+		log.Errorf("Failed to encode the dump of the tracked state: %v", err)
+		return
+	}
+	log.Infof("state %s", value)
+}
+
+// dumpSignal dispatches SIGUSR1 to the plugin instances with signalDump enabled. The handler
+// of SIGUSR1 is registered once per process and never unregistered, as SIGUSR1 would get its
+// default behavior back otherwise, which terminates the process, eg. after the shutdown of
+// the plugin during a reload.
+var dumpSignal = struct {
+	once sync.Once
+	// resolvers stores the plugin instances dumping their tracked state on SIGUSR1.
+	resolvers map[*OCPDNSNameResolver]struct{}
+	lock      sync.Mutex
+}{
+	resolvers: make(map[*OCPDNSNameResolver]struct{}),
+}
+
+// watchDumpSignal dumps the tracked state of the plugin on SIGUSR1 until the stop channel is
+// closed. SIGUSR1 is ignored once no plugin instance watches it anymore.
+func (resolver *OCPDNSNameResolver) watchDumpSignal(stopCh <-chan struct{}) {
+	dumpSignal.once.Do(func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGUSR1)
+		go func() {
+			for range signals {
+				dumpSignal.lock.Lock()
+				resolvers := make([]*OCPDNSNameResolver, 0, len(dumpSignal.resolvers))
+				for other := range dumpSignal.resolvers {
+					resolvers = append(resolvers, other)
+				}
+				dumpSignal.lock.Unlock()
+				for _, other := range resolvers {
+					other.dumpState()
+				}
+			}
+		}()
+	})
+
+	dumpSignal.lock.Lock()
+	dumpSignal.resolvers[resolver] = struct{}{}
+	dumpSignal.lock.Unlock()
+	go func() {
+		<-stopCh
+		dumpSignal.lock.Lock()
+		delete(dumpSignal.resolvers, resolver)
+		dumpSignal.lock.Unlock()
+	}()
+}
@@ -0,0 +1,176 @@
+package ocp_dnsnameresolver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	golog "log"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use, used for capturing the logs.
+type syncBuffer struct {
+	buf  bytes.Buffer
+	lock sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func TestNewStateDump(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+	})
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "wildcard", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "*.example.com."},
+	})
+
+	// Record a resolution failure for the regular DNS name.
+	resolverObj, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("dns").Get(ctx, "regular", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error getting DNSNameResolver object: %v", err)
+	}
+	resolverObj.Status.ResolvedNames = []ocpnetworkapiv1alpha1.DNSNameResolverResolvedName{
+		{DNSName: "www.example.com.", ResolutionFailures: 2},
+	}
+	if _, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("dns").UpdateStatus(ctx, resolverObj, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Unexpected error updating DNSNameResolver object: %v", err)
+	}
+	getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		return len(obj.Status.ResolvedNames) == 1
+	})
+
+	expectedDump := stateDump{
+		Regular:  map[string]namespaceDNSInfo{"www.example.com.": {"dns": "regular"}},
+		Wildcard: map[string]namespaceDNSInfo{"*.example.com.": {"dns": "wildcard"}},
+		Failures: map[string]map[string]int32{"dns/regular": {"www.example.com.": 2}},
+	}
+	if dump := resolver.newStateDump(context.Background()); !reflect.DeepEqual(dump, expectedDump) {
+		t.Fatalf("Expected dump %v, found %v", expectedDump, dump)
+	}
+}
+
+func TestWatchDumpSignal(t *testing.T) {
+	logs := &syncBuffer{}
+	golog.SetOutput(logs)
+	defer golog.SetOutput(os.Stderr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	newTestResolver(ctx, t, resolver)
+	resolver.regularDNSInfo["www.example.com."] = namespaceDNSInfo{"dns": "regular"}
+	stopCh := make(chan struct{})
+	resolver.watchDumpSignal(stopCh)
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatalf("Unexpected error sending SIGUSR1: %v", err)
+	}
+	err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 2*time.Second, true, func(context.Context) (bool, error) {
+		return strings.Contains(logs.String(), `state {"regular":{"www.example.com.":{"dns":"regular"}}`), nil
+	})
+	if err != nil {
+		t.Fatalf("Expected the state to be dumped on SIGUSR1, found logs: %s", logs.String())
+	}
+
+	// Once the plugin is shut down, SIGUSR1 does not dump the state anymore and does not
+	// terminate the process either.
+	close(stopCh)
+	err = wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 2*time.Second, true, func(context.Context) (bool, error) {
+		dumpSignal.lock.Lock()
+		defer dumpSignal.lock.Unlock()
+		_, exists := dumpSignal.resolvers[resolver]
+		return !exists, nil
+	})
+	if err != nil {
+		t.Fatalf("Expected the plugin to stop watching SIGUSR1")
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatalf("Unexpected error sending SIGUSR1: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if count := strings.Count(logs.String(), "state {"); count != 1 {
+		t.Fatalf("Expected the state not to be dumped after the shutdown, found %d dumps in logs: %s", count, logs.String())
+	}
+}
+
+func TestStateDumpFormats(t *testing.T) {
+	dump := stateDump{
+		Regular: map[string]namespaceDNSInfo{
+			"www.example.com.": {"dns": "regular", "apps": "www"},
+			"api.example.org.": {"dns": "api"},
+		},
+		Wildcard: map[string]namespaceDNSInfo{"*.example.com.": {"dns": "wildcard"}},
+		Failures: map[string]map[string]int32{
+			"dns/wildcard": {"b.example.com.": 3, "a.example.com.": 12},
+			"dns/api":      {"api.example.org.": 1},
+		},
+	}
+
+	expectedText := `TYPE      DNS NAME          NAMESPACE  NAME
+regular   api.example.org.  dns        api
+regular   www.example.com.  apps       www
+regular   www.example.com.  dns        regular
+wildcard  *.example.com.    dns        wildcard
+
+OBJECT        DNS NAME          FAILURES
+dns/api       api.example.org.  1
+dns/wildcard  a.example.com.    12
+dns/wildcard  b.example.com.    3
+`
+	var text strings.Builder
+	if err := dump.writeText(&text); err != nil {
+		t.Fatalf("Unexpected error writing the dump as text: %v", err)
+	}
+	if text.String() != expectedText {
+		t.Fatalf("Expected text dump:\n%s\nfound:\n%s", expectedText, text.String())
+	}
+
+	expectedJSON := `{"regular":{"api.example.org.":{"dns":"api"},"www.example.com.":{"apps":"www","dns":"regular"}},` +
+		`"wildcard":{"*.example.com.":{"dns":"wildcard"}},` +
+		`"failures":{"dns/api":{"api.example.org.":1},"dns/wildcard":{"a.example.com.":12,"b.example.com.":3}}}`
+	value, err := json.Marshal(dump)
+	if err != nil {
+		t.Fatalf("Unexpected error encoding the dump: %v", err)
+	}
+	if string(value) != expectedJSON {
+		t.Fatalf("Expected JSON dump %s, found %s", expectedJSON, value)
+	}
+
+	// An empty dump gives the headers only.
+	text.Reset()
+	if err := (stateDump{}).writeText(&text); err != nil {
+		t.Fatalf("Unexpected error writing the dump as text: %v", err)
+	}
+	if expected := "TYPE  DNS NAME  NAMESPACE  NAME\n\nOBJECT  DNS NAME  FAILURES\n"; text.String() != expected {
+		t.Fatalf("Expected text dump:\n%s\nfound:\n%s", expected, text.String())
+	}
+}
@@ -0,0 +1,48 @@
+package ocp_dnsnameresolver
+
+import (
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// exemplarRequestIDLabel is the exemplar label of the ID of the DNS lookup, which is the
+	// same as the one logged by the log plugin, eg. for correlating the exemplar with the
+	// query log.
+	exemplarRequestIDLabel = "request_id"
+	// exemplarDNSNameLabel is the exemplar label of the looked up DNS name.
+	exemplarDNSNameLabel = "dns_name"
+)
+
+// countLookupResult increments the lookup results counter of the result of the DNS lookup.
+// If exemplars is enabled then the increment carries an exemplar with the ID and the DNS
+// name of the DNS lookup.
+func (resolver *OCPDNSNameResolver) countLookupResult(result string, r *dns.Msg, dnsName string) {
+	counter := lookupResults.WithLabelValues(result)
+	if !resolver.exemplars {
+		counter.Inc()
+		return
+	}
+	adder, ok := counter.(prometheus.ExemplarAdder)
+	if !ok {
+		counter.Inc()
+		return
+	}
+	adder.AddWithExemplar(1, exemplarLabels(r.Id, dnsName))
+}
+
+// exemplarLabels returns the exemplar labels of the DNS lookup. The DNS name is omitted if
+// the labels would exceed the maximum number of runes of the exemplar labels, as adding
+// such an exemplar panics.
+func exemplarLabels(id uint16, dnsName string) prometheus.Labels {
+	requestID := strconv.Itoa(int(id))
+	labels := prometheus.Labels{exemplarRequestIDLabel: requestID}
+	runes := len(exemplarRequestIDLabel) + len(requestID) + len(exemplarDNSNameLabel) + utf8.RuneCountInString(dnsName)
+	if runes <= prometheus.ExemplarMaxRunes {
+		labels[exemplarDNSNameLabel] = dnsName
+	}
+	return labels
+}
@@ -0,0 +1,117 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExemplarLabels(t *testing.T) {
+	longName := strings.Repeat("a", 63) + "." + strings.Repeat("b", 63) + ".example.com."
+
+	tests := []struct {
+		name     string
+		dnsName  string
+		expected prometheus.Labels
+	}{
+		{
+			name:     "DNS name is included",
+			dnsName:  "www.example.com.",
+			expected: prometheus.Labels{exemplarRequestIDLabel: "4242", exemplarDNSNameLabel: "www.example.com."},
+		},
+		{
+			name:     "DNS name exceeding the maximum number of runes is omitted",
+			dnsName:  longName,
+			expected: prometheus.Labels{exemplarRequestIDLabel: "4242"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, exemplarLabels(4242, tc.dnsName)); diff != "" {
+				t.Fatalf("Unexpected exemplar labels (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestServeDNSExemplars(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "regular",
+			Namespace: "dns",
+		},
+		Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+			Name: "www.example.com.",
+		},
+	})
+
+	query := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeServerFailure,
+	}
+	resolver.Next = fakeNextPluginHandler(query)
+	lookup := func(id uint16) {
+		t.Helper()
+		msg := query.Msg()
+		msg.Id = id
+		resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), msg)
+	}
+	// scrape returns the line of the failure lookup results in the OpenMetrics format
+	// served by the debug endpoint.
+	scrape := func() string {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, debugMetricsPath, nil)
+		req.Header.Set("Accept", "application/openmetrics-text; version=0.0.1")
+		resolver.debugHandler().ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, found %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		for _, line := range strings.Split(rec.Body.String(), "\n") {
+			if strings.HasPrefix(line, `coredns_ocp_dnsnameresolver_lookup_results_total{result="failure"}`) {
+				return line
+			}
+		}
+		t.Fatalf("Expected the failure lookup results to be served, found: %s", rec.Body.String())
+		return ""
+	}
+
+	// hasExemplar checks if the line carries an exemplar with the labels of the DNS lookup,
+	// in any order.
+	hasExemplar := func(line string, id string) bool {
+		_, exemplar, found := strings.Cut(line, " # ")
+		return found && strings.Contains(exemplar, `request_id="`+id+`"`) && strings.Contains(exemplar, `dns_name="www.example.com."`)
+	}
+
+	// The exemplar is attached with exemplars enabled.
+	resolver.exemplars = true
+	lookup(4242)
+	if line := scrape(); !hasExemplar(line, "4242") {
+		t.Fatalf("Expected exemplar of DNS lookup 4242, found: %s", line)
+	}
+
+	// The exemplar is left in place with exemplars disabled.
+	resolver.exemplars = false
+	lookup(4343)
+	if line := scrape(); !hasExemplar(line, "4242") {
+		t.Fatalf("Expected exemplar of DNS lookup 4242 to be kept, found: %s", line)
+	}
+}
@@ -0,0 +1,81 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+
+	"github.com/miekg/dns"
+)
+
+// extendedErrorsAnnotation is the annotation used for storing the extended DNS error (EDE,
+// RFC 8914) of the last failed DNS lookup of the DNS names matching a DNSNameResolver
+// object, so that the reason of the failure, eg. blocked or DNSSEC bogus, is visible. The
+// DNSNameResolver status does not have a field for it, thus the extended errors are stored
+// in the annotation as a JSON encoded map. The entry of a DNS name is removed once the DNS
+// name is successfully resolved again.
+// key: DNS name, value: extended error of the last failed DNS lookup.
+const extendedErrorsAnnotation = "dnsnameresolver.openshift.io/extended-errors"
+
+// extendedError is the extended DNS error of a failed DNS lookup.
+type extendedError struct {
+	// InfoCode is the INFO-CODE of the extended DNS error.
+	InfoCode uint16 `json:"infoCode"`
+	// Name is the name of the INFO-CODE, empty if the INFO-CODE is unknown.
+	Name string `json:"name,omitempty"`
+	// ExtraText is the EXTRA-TEXT of the extended DNS error.
+	ExtraText string `json:"extraText,omitempty"`
+	// Rcode is the rcode of the failed DNS lookup.
+	Rcode string `json:"rcode"`
+}
+
+// extendedErrorOf returns the first extended DNS error option of the response, or nil if
+// the response is nil or it does not carry an extended DNS error option.
+func extendedErrorOf(msg *dns.Msg) *extendedError {
+	if msg == nil {
+		return nil
+	}
+	opt := msg.IsEdns0()
+	if opt == nil {
+		return nil
+	}
+	for _, option := range opt.Option {
+		if ede, ok := option.(*dns.EDNS0_EDE); ok {
+			return &extendedError{
+				InfoCode:  ede.InfoCode,
+				Name:      dns.ExtendedErrorCodeToString[ede.InfoCode],
+				ExtraText: ede.ExtraText,
+				Rcode:     dns.RcodeToString[msg.Rcode],
+			}
+		}
+	}
+	return nil
+}
+
+// updateExtendedErrors updates the extended errors annotation of the DNSNameResolver
+// objects corresponding to the regular and the wildcard DNS names. If the extended error is
+// not nil then it is recorded for the DNS name, otherwise the entry of the DNS name is
+// removed, if it exists.
+func (resolver *OCPDNSNameResolver) updateExtendedErrors(
+	ctx context.Context,
+	regularDNSInfo namespaceDNSInfo,
+	wildcardDNSInfo namespaceDNSInfo,
+	dnsName string,
+	extended *extendedError,
+) {
+	updateAnnotationEntries(ctx, resolver, regularDNSInfo, wildcardDNSInfo, extendedErrorsAnnotation, "extended errors",
+		func(extendedErrors map[string]extendedError) bool {
+			if extended != nil {
+				// If the same extended error is already recorded then skip the update call.
+				if existing, exists := extendedErrors[dnsName]; exists && existing == *extended {
+					return false
+				}
+				extendedErrors[dnsName] = *extended
+				return true
+			}
+			// If there is no extended error of the DNS name then skip the update call.
+			if _, exists := extendedErrors[dnsName]; !exists {
+				return false
+			}
+			delete(extendedErrors, dnsName)
+			return true
+		})
+}
@@ -0,0 +1,228 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// extendedErrorOPT returns an OPT record carrying an extended DNS error option.
+func extendedErrorOPT(infoCode uint16, extraText string) *dns.OPT {
+	opt := &dns.OPT{Hdr: dns.RR_Header{Name: ".", Rrtype: dns.TypeOPT}}
+	opt.SetUDPSize(dns.DefaultMsgSize)
+	opt.Option = append(opt.Option, &dns.EDNS0_EDE{InfoCode: infoCode, ExtraText: extraText})
+	return opt
+}
+
+// getExtendedErrors returns the extended errors stored in the annotation of the DNSNameResolver object.
+func getExtendedErrors(t *testing.T, resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) map[string]extendedError {
+	t.Helper()
+
+	extendedErrors := make(map[string]extendedError)
+	if value, exists := resolverObj.Annotations[extendedErrorsAnnotation]; exists {
+		if err := json.Unmarshal([]byte(value), &extendedErrors); err != nil {
+			t.Fatalf("Invalid value of annotation %s: %v", extendedErrorsAnnotation, err)
+		}
+	}
+	return extendedErrors
+}
+
+func TestExtendedErrorOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      *dns.Msg
+		expected *extendedError
+	}{
+		{
+			name:     "Nil response",
+			msg:      nil,
+			expected: nil,
+		},
+		{
+			name:     "Response without OPT record",
+			msg:      &dns.Msg{MsgHdr: dns.MsgHdr{Rcode: dns.RcodeServerFailure}},
+			expected: nil,
+		},
+		{
+			name: "OPT record without extended DNS error",
+			msg: &dns.Msg{
+				MsgHdr: dns.MsgHdr{Rcode: dns.RcodeServerFailure},
+				Extra:  []dns.RR{&dns.OPT{Hdr: dns.RR_Header{Name: ".", Rrtype: dns.TypeOPT}}},
+			},
+			expected: nil,
+		},
+		{
+			name: "Known INFO-CODE",
+			msg: &dns.Msg{
+				MsgHdr: dns.MsgHdr{Rcode: dns.RcodeServerFailure},
+				Extra:  []dns.RR{extendedErrorOPT(dns.ExtendedErrorCodeDNSBogus, "signature expired")},
+			},
+			expected: &extendedError{
+				InfoCode:  dns.ExtendedErrorCodeDNSBogus,
+				Name:      "DNSSEC Bogus",
+				ExtraText: "signature expired",
+				Rcode:     "SERVFAIL",
+			},
+		},
+		{
+			name: "Unknown INFO-CODE",
+			msg: &dns.Msg{
+				MsgHdr: dns.MsgHdr{Rcode: dns.RcodeRefused},
+				Extra:  []dns.RR{extendedErrorOPT(4000, "")},
+			},
+			expected: &extendedError{
+				InfoCode: 4000,
+				Rcode:    "REFUSED",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, extendedErrorOf(tc.msg)); diff != "" {
+				t.Fatalf("Unexpected extended error (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestServeDNSRecordExtendedErrors(t *testing.T) {
+	blockedQuery := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeRefused,
+		Extra: []dns.RR{extendedErrorOPT(dns.ExtendedErrorCodeBlocked, "blocked by policy")},
+	}
+	bogusQuery := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeServerFailure,
+		Extra: []dns.RR{extendedErrorOPT(dns.ExtendedErrorCodeDNSBogus, "")},
+	}
+	servfailQuery := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeServerFailure,
+	}
+	successQuery := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("www.example.com. 30 IN A 1.1.1.1"),
+		},
+	}
+
+	blocked := &extendedError{
+		InfoCode:  dns.ExtendedErrorCodeBlocked,
+		Name:      "Blocked",
+		ExtraText: "blocked by policy",
+		Rcode:     "REFUSED",
+	}
+	bogus := &extendedError{
+		InfoCode: dns.ExtendedErrorCodeDNSBogus,
+		Name:     "DNSSEC Bogus",
+		Rcode:    "SERVFAIL",
+	}
+
+	tests := []struct {
+		name                 string
+		recordExtendedErrors bool
+		queries              []test.Case
+		// expected gives the extended error expected to be recorded after each query.
+		expected []*extendedError
+	}{
+		{
+			name:     "Extended error is not recorded by default",
+			queries:  []test.Case{blockedQuery},
+			expected: []*extendedError{nil},
+		},
+		{
+			name:                 "Extended error is recorded with recordExtendedErrors",
+			recordExtendedErrors: true,
+			queries:              []test.Case{blockedQuery},
+			expected:             []*extendedError{blocked},
+		},
+		{
+			name:                 "Failure without extended error is not recorded",
+			recordExtendedErrors: true,
+			queries:              []test.Case{servfailQuery},
+			expected:             []*extendedError{nil},
+		},
+		{
+			name:                 "Extended error is replaced by the one of the next failure",
+			recordExtendedErrors: true,
+			queries:              []test.Case{blockedQuery, bogusQuery},
+			expected:             []*extendedError{blocked, bogus},
+		},
+		{
+			name:                 "Extended error is kept after a failure without extended error",
+			recordExtendedErrors: true,
+			queries:              []test.Case{blockedQuery, servfailQuery},
+			expected:             []*extendedError{blocked, blocked},
+		},
+		{
+			name:                 "Extended error is removed once the DNS name is resolved",
+			recordExtendedErrors: true,
+			queries:              []test.Case{blockedQuery, successQuery},
+			expected:             []*extendedError{blocked, nil},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.recordExtendedErrors = tc.recordExtendedErrors
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			})
+
+			for i, query := range tc.queries {
+				resolver.Next = fakeNextPluginHandler(query)
+				resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+				// Wait for the informer cache to observe the update before the next query.
+				expected := tc.expected[i]
+				resolverObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+					extended, found := getExtendedErrors(t, obj)["www.example.com."]
+					if expected == nil {
+						return !found
+					}
+					return found && extended == *expected
+				})
+
+				extended, found := getExtendedErrors(t, resolverObj)["www.example.com."]
+				if expected == nil {
+					if found {
+						t.Fatalf("Expected no extended error after query %d, found: %+v", i, extended)
+					}
+					if _, exists := resolverObj.Annotations[extendedErrorsAnnotation]; exists {
+						t.Fatalf("Expected annotation %s to be removed, found: %v", extendedErrorsAnnotation, resolverObj.Annotations)
+					}
+					continue
+				}
+				if diff := cmp.Diff(*expected, extended); diff != "" {
+					t.Fatalf("Unexpected extended error after query %d (-want +got):\n%s", i, diff)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package ocp_dnsnameresolver
+
+import (
+	"math"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// failureKey identifies the resolved name of a DNS name in the status of a
+// DNSNameResolver object.
+type failureKey struct {
+	object  types.NamespacedName
+	dnsName string
+}
+
+// weightedFailures accumulates the weighted failures of the DNS lookups of the resolved
+// names, when failureWeight is configured. The resolutionFailures field of the status
+// only counts whole failures, thus the fractional remainder of the weighted failures of
+// each resolved name is kept until it adds up to a whole failure. The remainders are
+// not persisted, thus they are lost on a restart.
+type weightedFailures struct {
+	remainders map[failureKey]float64
+	lock       sync.Mutex
+}
+
+// newWeightedFailures returns an initialized weightedFailures.
+func newWeightedFailures() *weightedFailures {
+	return &weightedFailures{
+		remainders: make(map[failureKey]float64),
+	}
+}
+
+// add adds the weight of a failure to the remainder of the resolved name and returns
+// the number of whole failures to count, keeping the fractional remainder.
+func (failures *weightedFailures) add(key failureKey, weight float64) int32 {
+	failures.lock.Lock()
+	defer failures.lock.Unlock()
+
+	total := failures.remainders[key] + weight
+	whole := math.Floor(total)
+	if remainder := total - whole; remainder > 0 {
+		failures.remainders[key] = remainder
+	} else {
+		delete(failures.remainders, key)
+	}
+	return int32(whole)
+}
+
+// reset drops the remainder of the resolved name, eg. once the DNS name is resolved.
+func (failures *weightedFailures) reset(key failureKey) {
+	failures.lock.Lock()
+	defer failures.lock.Unlock()
+	delete(failures.remainders, key)
+}
+
+// failureIncrement returns the number of failures to count for the failure of the DNS
+// lookup of the DNS name with the rcode, in the status of the DNSNameResolver object.
+// Each failure counts as one unless failureWeight is configured, in which case the
+// weight of the rcode is accumulated. The rcodes without a configured weight count as
+// one failure.
+func (resolver *OCPDNSNameResolver) failureIncrement(namespace, objName, dnsName string, rcode int) int32 {
+	if len(resolver.failureWeights) == 0 {
+		return 1
+	}
+	weight, exists := resolver.failureWeights[rcode]
+	if !exists {
+		weight = 1
+	}
+	return resolver.weightedFailures.add(failureKey{object: types.NamespacedName{Namespace: namespace, Name: objName}, dnsName: dnsName}, weight)
+}
+
+// failureTTLOf returns the TTL set on the expired IP addresses of the DNS name when its DNS
+// lookup fails: failureTTL, if configured, regardless of the minimum TTL of the DNS name,
+// and the minimum TTL of the DNS name otherwise.
+func (resolver *OCPDNSNameResolver) failureTTLOf(dnsName string) int32 {
+	if resolver.failureTTL > 0 {
+		return resolver.failureTTL
+	}
+	return resolver.minimumTTLOf(dnsName)
+}
+
+// resetFailures drops the accumulated weighted failures of the DNS name in the
+// DNSNameResolver objects, once the DNS name is resolved.
+func (resolver *OCPDNSNameResolver) resetFailures(namespaceDNS namespaceDNSInfo, dnsName string) {
+	if len(resolver.failureWeights) == 0 {
+		return
+	}
+	for namespace, objName := range namespaceDNS {
+		resolver.weightedFailures.reset(failureKey{object: types.NamespacedName{Namespace: namespace, Name: objName}, dnsName: dnsName})
+	}
+}
@@ -0,0 +1,201 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestWeightedFailures(t *testing.T) {
+	failures := newWeightedFailures()
+	key := failureKey{object: types.NamespacedName{Namespace: "dns", Name: "regular"}, dnsName: "www.example.com."}
+
+	steps := []struct {
+		weight            float64
+		expectedIncrement int32
+	}{
+		{0.5, 0},
+		{0.5, 1},
+		{1, 1},
+		{0.75, 0},
+		{0.75, 1},
+		{0.5, 1},
+		{0, 0},
+		{2.5, 2},
+	}
+	for i, step := range steps {
+		if increment := failures.add(key, step.weight); increment != step.expectedIncrement {
+			t.Fatalf("Step %d: Expected increment %d, found %d", i, step.expectedIncrement, increment)
+		}
+	}
+
+	failures.reset(key)
+	if len(failures.remainders) != 0 {
+		t.Fatalf("Expected no remainders after the reset, found: %v", failures.remainders)
+	}
+}
+
+func TestServeDNSFailureWeights(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.failureThreshold = 2
+	resolver.failureWeights = map[int]float64{dns.RcodeRefused: 0.5}
+	// The TTLs of the IP addresses are reset to zero on each failure, thus the resolved
+	// name is removed as soon as the failure threshold is crossed.
+	resolver.minimumTTL = 0
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "regular",
+			Namespace: "dns",
+		},
+		Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+			Name: "www.example.com.",
+		},
+	})
+
+	// Record the IP address of the DNS name.
+	query := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("www.example.com. 0 IN A 1.1.1.1"),
+		},
+	}
+	resolver.Next = fakeNextPluginHandler(query)
+	resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+	getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		return len(obj.Status.ResolvedNames) == 1
+	})
+	// Make sure that the TTL of the IP address is expired.
+	time.Sleep(time.Second)
+
+	steps := []struct {
+		rcode            int
+		expectedRemoved  bool
+		expectedFailures int32
+	}{
+		// A REFUSED response counts as half a failure.
+		{dns.RcodeRefused, false, 0},
+		{dns.RcodeRefused, false, 1},
+		// A SERVFAIL response counts as a full failure by default.
+		{dns.RcodeServerFailure, false, 2},
+		// The failure threshold has been crossed, thus the resolved name is removed.
+		{dns.RcodeRefused, true, 0},
+	}
+	for i, step := range steps {
+		query := test.Case{
+			Qname: "www.example.com.",
+			Qtype: dns.TypeA,
+			Rcode: step.rcode,
+		}
+		resolver.Next = fakeNextPluginHandler(query)
+		resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+		resolverObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+			if step.expectedRemoved {
+				return len(obj.Status.ResolvedNames) == 0
+			}
+			return len(obj.Status.ResolvedNames) == 1 && obj.Status.ResolvedNames[0].ResolutionFailures == step.expectedFailures &&
+				obj.Status.ResolvedNames[0].Conditions[0].Reason == dns.RcodeToString[step.rcode]
+		})
+		if step.expectedRemoved {
+			if len(resolverObj.Status.ResolvedNames) != 0 {
+				t.Fatalf("Step %d: Expected the resolved name to be removed, found: %v", i, resolverObj.Status.ResolvedNames)
+			}
+			continue
+		}
+		if len(resolverObj.Status.ResolvedNames) != 1 || resolverObj.Status.ResolvedNames[0].ResolutionFailures != step.expectedFailures {
+			t.Fatalf("Step %d: Expected %d resolution failures, found: %v", i, step.expectedFailures, resolverObj.Status.ResolvedNames)
+		}
+	}
+}
+
+func TestServeDNSFailureTTL(t *testing.T) {
+	tests := []struct {
+		name               string
+		failureTTL         int32
+		expectedFailureTTL int32
+	}{
+		{
+			name:               "Expired IP address of a failed DNS name gets the minimum TTL of its zone",
+			expectedFailureTTL: 1,
+		},
+		{
+			name:               "Expired IP address of a failed DNS name gets the failureTTL",
+			failureTTL:         7,
+			expectedFailureTTL: 7,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.failureTTL = tc.failureTTL
+			resolver.zoneTTLs = map[string]zoneTTLPolicy{"example.com.": {minTTL: 1, maxTTL: 60}}
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+				Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+			})
+
+			ttls := func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) map[string]int32 {
+				ttls := make(map[string]int32)
+				for _, resolvedName := range obj.Status.ResolvedNames {
+					for _, resolvedAddress := range resolvedName.ResolvedAddresses {
+						ttls[resolvedAddress.IP] = resolvedAddress.TTLSeconds
+					}
+				}
+				return ttls
+			}
+
+			// The TTLs of the successful DNS lookup are clamped by the zone TTL policy,
+			// regardless of failureTTL.
+			query := test.Case{
+				Qname: "www.example.com.",
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A("www.example.com. 0 IN A 1.1.1.1"),
+					test.A("www.example.com. 3600 IN A 1.1.1.2"),
+				},
+			}
+			resolver.Next = fakeNextPluginHandler(query)
+			resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+			resolverObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+				return len(ttls(obj)) == 2
+			})
+			if got := ttls(resolverObj); got["1.1.1.1"] != 1 || got["1.1.1.2"] != 60 {
+				t.Fatalf("Expected the TTLs of the successful DNS lookup to be clamped, found %v", got)
+			}
+			// Make sure that the TTL of the first IP address is expired.
+			time.Sleep(1100 * time.Millisecond)
+
+			// The expired IP address gets the failure TTL, the other one is kept as is.
+			query = test.Case{Qname: "www.example.com.", Qtype: dns.TypeA, Rcode: dns.RcodeServerFailure}
+			resolver.Next = fakeNextPluginHandler(query)
+			resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+			resolverObj = getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+				return len(obj.Status.ResolvedNames) == 1 && obj.Status.ResolvedNames[0].ResolutionFailures == 1
+			})
+			if got := ttls(resolverObj); got["1.1.1.1"] != tc.expectedFailureTTL || got["1.1.1.2"] != 60 {
+				t.Fatalf("Expected the TTL %d for the expired IP address of the failed DNS lookup, found %v", tc.expectedFailureTTL, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package ocp_dnsnameresolver
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// failureLogSweepSize gives the number of DNS names above which the DNS names not logged
+// within the failureLogInterval are dropped by the failureLogLimiter.
+const failureLogSweepSize = 1024
+
+// failureLogState stores the state of the failure logging of a DNS name.
+type failureLogState struct {
+	// lastLogged is the time the last failure of the DNS name was logged.
+	lastLogged time.Time
+	// suppressed is the number of the failures of the DNS name which were not logged
+	// since the last logged failure.
+	suppressed int
+}
+
+// failureLogLimiter rate limits the logging of the failed DNS lookups per DNS name, so
+// that the logs are not flooded with the failures of a DNS name which is down.
+type failureLogLimiter struct {
+	// states stores the state of the failure logging of the DNS names.
+	// key: DNS name, value: state of the failure logging.
+	states map[string]*failureLogState
+	lock   sync.Mutex
+}
+
+// newFailureLogLimiter returns an initialized failureLogLimiter.
+func newFailureLogLimiter() *failureLogLimiter {
+	return &failureLogLimiter{
+		states: make(map[string]*failureLogState),
+	}
+}
+
+// allow records a failure of the DNS name at the given time and returns true if the
+// failure should be logged, i.e. if it is the first failure of the DNS name or if the
+// last logged failure is at least interval old. The number of the failures suppressed
+// since the last logged failure is returned as well.
+func (limiter *failureLogLimiter) allow(dnsName string, now time.Time, interval time.Duration) (bool, int) {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+
+	state, exists := limiter.states[dnsName]
+	if exists && now.Sub(state.lastLogged) < interval {
+		state.suppressed++
+		return false, 0
+	}
+
+	// Drop the DNS names which were not logged within the interval, so that the DNS names
+	// which do not fail anymore do not accumulate.
+	if !exists && len(limiter.states) >= failureLogSweepSize {
+		for otherName, otherState := range limiter.states {
+			if now.Sub(otherState.lastLogged) >= interval {
+				delete(limiter.states, otherName)
+			}
+		}
+	}
+
+	suppressed := 0
+	if exists {
+		suppressed = state.suppressed
+	}
+	limiter.states[dnsName] = &failureLogState{lastLogged: now}
+	return true, suppressed
+}
+
+// logFailure logs the failed DNS lookup of the DNS name, at most once per
+// failureLogInterval for each DNS name.
+func (resolver *OCPDNSNameResolver) logFailure(dnsName string, rcode int, err error) {
+	allowed, suppressed := resolver.failureLogLimiter.allow(dnsName, time.Now(), resolver.failureLogInterval)
+	if !allowed {
+		return
+	}
+	// Mention the suppressed failures only if there are any.
+	var suppressedSuffix string
+	if suppressed > 0 {
+		suppressedSuffix = fmt.Sprintf(" (%d similar failures suppressed)", suppressed)
+	}
+	if err != nil {
+		log.Warningf("DNS lookup of DNS name %s failed with error: %v%s", dnsName, err, suppressedSuffix)
+		return
+	}
+	log.Warningf("DNS lookup of DNS name %s failed with rcode %s%s", dnsName, dns.RcodeToString[rcode], suppressedSuffix)
+}
@@ -0,0 +1,110 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	golog "log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFailureLogLimiterAllow(t *testing.T) {
+	start := time.Now()
+	interval := time.Minute
+	limiter := newFailureLogLimiter()
+
+	steps := []struct {
+		elapsed            time.Duration
+		dnsName            string
+		expectedAllowed    bool
+		expectedSuppressed int
+	}{
+		// The first failure of a DNS name is logged.
+		{0, "www.example.com.", true, 0},
+		// The next failures within the interval are suppressed.
+		{time.Second, "www.example.com.", false, 0},
+		{30 * time.Second, "www.example.com.", false, 0},
+		// The failures of the other DNS names are not suppressed.
+		{30 * time.Second, "www.example.org.", true, 0},
+		// Once the interval is over, the next failure is logged with the suppressed count.
+		{time.Minute, "www.example.com.", true, 2},
+		{time.Minute + time.Second, "www.example.com.", false, 0},
+		{3 * time.Minute, "www.example.com.", true, 1},
+		{3 * time.Minute, "www.example.org.", true, 0},
+	}
+	for i, step := range steps {
+		allowed, suppressed := limiter.allow(step.dnsName, start.Add(step.elapsed), interval)
+		if allowed != step.expectedAllowed || suppressed != step.expectedSuppressed {
+			t.Fatalf("Step %d: Expected allowed %t with %d suppressed for DNS name %s, found allowed %t with %d suppressed",
+				i, step.expectedAllowed, step.expectedSuppressed, step.dnsName, allowed, suppressed)
+		}
+	}
+}
+
+func TestServeDNSFailureLogInterval(t *testing.T) {
+	logs := &syncBuffer{}
+	golog.SetOutput(logs)
+	defer golog.SetOutput(os.Stderr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "regular",
+			Namespace: "dns",
+		},
+		Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+			Name: "www.example.com.",
+		},
+	})
+
+	query := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("www.example.com. 30 IN A 1.1.1.1"),
+		},
+	}
+	resolver.Next = fakeNextPluginHandler(query)
+	resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+	failureQuery := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeServerFailure,
+	}
+	resolver.Next = fakeNextPluginHandler(failureQuery)
+	for i := 1; i <= 3; i++ {
+		getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+			return len(obj.Status.ResolvedNames) > 0 && obj.Status.ResolvedNames[0].ResolutionFailures == int32(i-1)
+		})
+		resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), failureQuery.Msg())
+	}
+
+	// The failure counter is incremented on every failure, while only the first failure is logged.
+	resolverObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		return len(obj.Status.ResolvedNames) > 0 && obj.Status.ResolvedNames[0].ResolutionFailures == 3
+	})
+	if failures := resolverObj.Status.ResolvedNames[0].ResolutionFailures; failures != 3 {
+		t.Fatalf("Expected 3 resolution failures, found %d", failures)
+	}
+	if count := strings.Count(logs.String(), "DNS lookup of DNS name www.example.com. failed"); count != 1 {
+		t.Fatalf("Expected 1 logged failure, found %d in logs:\n%s", count, logs.String())
+	}
+	// The first logged failure has no suppressed failures to mention.
+	if strings.Contains(logs.String(), "similar failures suppressed") {
+		t.Fatalf("Expected no suppressed failures mentioned, found logs:\n%s", logs.String())
+	}
+}
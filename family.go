@@ -0,0 +1,156 @@
+package ocp_dnsnameresolver
+
+import (
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// addressFamily is the IP address family of the resolved addresses.
+type addressFamily string
+
+const (
+	// addressFamilyV4 is the family of the IPv4 addresses, looked up with the A queries.
+	addressFamilyV4 addressFamily = "v4"
+	// addressFamilyV6 is the family of the IPv6 addresses, looked up with the AAAA queries.
+	addressFamilyV6 addressFamily = "v6"
+)
+
+// queryFamily returns the address family looked up by the query type, and whether the
+// query type looks up IP addresses at all.
+func queryFamily(qtype uint16) (addressFamily, bool) {
+	switch qtype {
+	case dns.TypeA:
+		return addressFamilyV4, true
+	case dns.TypeAAAA:
+		return addressFamilyV6, true
+	}
+	return "", false
+}
+
+// ipFamily returns the address family of the IP address, and whether the IP address is valid.
+func ipFamily(ip string) (addressFamily, bool) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return "", false
+	}
+	if addr.Unmap().Is4() {
+		return addressFamilyV4, true
+	}
+	return addressFamilyV6, true
+}
+
+// familyFailureKey identifies the failures of an address family of the resolved name of a
+// DNS name in the status of a DNSNameResolver object.
+type familyFailureKey struct {
+	failureKey
+	family addressFamily
+}
+
+// familyFailures counts the consecutive failures of the DNS lookups of each address family
+// of the resolved names, when failureThresholdV4 or failureThresholdV6 is configured. The
+// resolutionFailures field of the status counts the failures of both the families, thus
+// the counts of each family are kept in memory, and are lost on a restart.
+type familyFailures struct {
+	counts map[familyFailureKey]int32
+	lock   sync.Mutex
+}
+
+// newFamilyFailures returns an initialized familyFailures.
+func newFamilyFailures() *familyFailures {
+	return &familyFailures{
+		counts: make(map[familyFailureKey]int32),
+	}
+}
+
+// add adds the failures to the count of the address family of the resolved name and
+// returns the new count.
+func (failures *familyFailures) add(key familyFailureKey, increment int32) int32 {
+	failures.lock.Lock()
+	defer failures.lock.Unlock()
+	failures.counts[key] += increment
+	return failures.counts[key]
+}
+
+// reset drops the count of the address family of the resolved name, eg. once the IP
+// addresses of the family are resolved.
+func (failures *familyFailures) reset(key familyFailureKey) {
+	failures.lock.Lock()
+	defer failures.lock.Unlock()
+	delete(failures.counts, key)
+}
+
+// familyFailureThreshold returns the failure threshold of the address family, and whether
+// it is configured.
+func (resolver *OCPDNSNameResolver) familyFailureThreshold(family addressFamily) (int32, bool) {
+	threshold := resolver.failureThresholdV4
+	if family == addressFamilyV6 {
+		threshold = resolver.failureThresholdV6
+	}
+	return threshold, threshold > 0
+}
+
+// familyThresholdReached counts the failure of the DNS lookup of the DNS name with the
+// query type in the DNSNameResolver object and returns the address family whose failure
+// threshold is reached, if any. Only the families with a configured failure threshold
+// are counted.
+func (resolver *OCPDNSNameResolver) familyThresholdReached(namespace, objName, dnsName string, qtype uint16, increment int32) (addressFamily, bool) {
+	family, ok := queryFamily(qtype)
+	if !ok {
+		return "", false
+	}
+	threshold, configured := resolver.familyFailureThreshold(family)
+	if !configured {
+		return "", false
+	}
+	key := familyFailureKey{
+		failureKey: failureKey{object: types.NamespacedName{Namespace: namespace, Name: objName}, dnsName: dnsName},
+		family:     family,
+	}
+	return family, resolver.familyFailures.add(key, increment) >= threshold
+}
+
+// resetFamilyFailures drops the failure counts of the address families of the resolved
+// IP addresses of the DNS name in the DNSNameResolver objects, once they are resolved.
+// The counts of the other family are kept, so that the failures of one family are not
+// cleared by the successes of the other.
+func (resolver *OCPDNSNameResolver) resetFamilyFailures(namespaceDNS namespaceDNSInfo, dnsName string, ipTTLs map[string]int32) {
+	if resolver.failureThresholdV4 == 0 && resolver.failureThresholdV6 == 0 {
+		return
+	}
+	families := make(map[addressFamily]struct{})
+	for ip := range ipTTLs {
+		if family, ok := ipFamily(ip); ok {
+			families[family] = struct{}{}
+		}
+	}
+	for namespace, objName := range namespaceDNS {
+		for family := range families {
+			resolver.familyFailures.reset(familyFailureKey{
+				failureKey: failureKey{object: types.NamespacedName{Namespace: namespace, Name: objName}, dnsName: dnsName},
+				family:     family,
+			})
+		}
+	}
+}
+
+// removeExpiredFamilyAddresses removes the IP addresses of the address family whose TTLs
+// have expired from the resolved name, leaving the IP addresses of the other family. It
+// returns whether any IP address is removed.
+func removeExpiredFamilyAddresses(resolvedName *ocpnetworkapiv1alpha1.DNSNameResolverResolvedName, family addressFamily, currentTime time.Time) bool {
+	kept := resolvedName.ResolvedAddresses[:0]
+	for _, resolvedAddress := range resolvedName.ResolvedAddresses {
+		nextLookupTime := resolvedAddress.LastLookupTime.Time.Add(time.Duration(resolvedAddress.TTLSeconds) * time.Second)
+		if addressFamily, ok := ipFamily(resolvedAddress.IP); ok && addressFamily == family && !nextLookupTime.After(currentTime) {
+			continue
+		}
+		kept = append(kept, resolvedAddress)
+	}
+	removed := len(kept) < len(resolvedName.ResolvedAddresses)
+	resolvedName.ResolvedAddresses = kept
+	return removed
+}
@@ -0,0 +1,155 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRemoveExpiredFamilyAddresses(t *testing.T) {
+	now := time.Now()
+	expired := metav1.NewTime(now.Add(-time.Minute))
+	resolvedName := ocpnetworkapiv1alpha1.DNSNameResolverResolvedName{
+		DNSName: "www.example.com.",
+		ResolvedAddresses: []ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{
+			{IP: "1.1.1.1", TTLSeconds: 30, LastLookupTime: &expired},
+			{IP: "2001:db8::1", TTLSeconds: 30, LastLookupTime: &expired},
+			{IP: "2001:db8::2", TTLSeconds: 3600, LastLookupTime: &expired},
+		},
+	}
+
+	if !removeExpiredFamilyAddresses(&resolvedName, addressFamilyV6, now) {
+		t.Fatalf("Expected the expired IPv6 address to be removed")
+	}
+	if ips := resolvedIPs(&ocpnetworkapiv1alpha1.DNSNameResolver{
+		Status: ocpnetworkapiv1alpha1.DNSNameResolverStatus{ResolvedNames: []ocpnetworkapiv1alpha1.DNSNameResolverResolvedName{resolvedName}},
+	}, "www.example.com."); len(ips) != 2 || ips[0] != "1.1.1.1" || ips[1] != "2001:db8::2" {
+		t.Fatalf("Expected the IPv4 address and the unexpired IPv6 address to be kept, found %v", ips)
+	}
+	if removeExpiredFamilyAddresses(&resolvedName, addressFamilyV6, now) {
+		t.Fatalf("Expected no IP address to be removed once the expired IPv6 addresses are removed")
+	}
+}
+
+func TestServeDNSFamilyFailureThresholds(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.failureThreshold = 10
+	resolver.failureThresholdV4 = 3
+	resolver.failureThresholdV6 = 2
+	// The TTLs of the IP addresses are reset to zero on each failure, thus the IP addresses
+	// are expired as soon as the next failure happens a second later.
+	resolver.minimumTTL = 0
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+	})
+
+	serve := func(qtype uint16, rcode int, answer ...dns.RR) {
+		t.Helper()
+		query := test.Case{Qname: "www.example.com.", Qtype: qtype, Rcode: rcode, Answer: answer}
+		resolver.Next = fakeNextPluginHandler(query)
+		resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+	}
+	expectIPs := func(step string, expected ...string) {
+		t.Helper()
+		resolverObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+			ips := resolvedIPs(obj, "www.example.com.")
+			if len(ips) != len(expected) {
+				return false
+			}
+			for i := range ips {
+				if ips[i] != expected[i] {
+					return false
+				}
+			}
+			return true
+		})
+		if ips := resolvedIPs(resolverObj, "www.example.com."); len(ips) != len(expected) {
+			t.Fatalf("%s: Expected IP addresses %v, found %v", step, expected, ips)
+		}
+	}
+
+	// Record the IPv4 and the IPv6 addresses of the DNS name.
+	serve(dns.TypeA, dns.RcodeSuccess, test.A("www.example.com. 0 IN A 1.1.1.1"))
+	expectIPs("Recorded IPv4", "1.1.1.1")
+	serve(dns.TypeAAAA, dns.RcodeSuccess, test.AAAA("www.example.com. 0 IN AAAA 2001:db8::1"))
+	expectIPs("Recorded IPv6", "1.1.1.1", "2001:db8::1")
+	time.Sleep(time.Second)
+
+	// The first AAAA failure keeps both the IP addresses.
+	serve(dns.TypeAAAA, dns.RcodeServerFailure)
+	expectIPs("First AAAA failure", "1.1.1.1", "2001:db8::1")
+
+	// The successful A lookup does not reset the failures of the IPv6 addresses.
+	serve(dns.TypeA, dns.RcodeSuccess, test.A("www.example.com. 3600 IN A 1.1.1.1"))
+	getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		return len(obj.Status.ResolvedNames) == 1 && obj.Status.ResolvedNames[0].ResolvedAddresses[0].TTLSeconds == 3600
+	})
+	time.Sleep(time.Second)
+
+	// The second AAAA failure reaches the IPv6 failure threshold, thus the expired IPv6
+	// address is removed while the IPv4 address is kept.
+	serve(dns.TypeAAAA, dns.RcodeServerFailure)
+	expectIPs("Second AAAA failure", "1.1.1.1")
+
+	// The A failures are counted independently: the IPv4 address is not expired, thus it is
+	// kept once the IPv4 failure threshold is reached.
+	for i := 0; i < 3; i++ {
+		serve(dns.TypeA, dns.RcodeServerFailure)
+		getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+			return len(obj.Status.ResolvedNames) == 1 && obj.Status.ResolvedNames[0].ResolutionFailures == int32(i+1)
+		})
+	}
+	expectIPs("A failures", "1.1.1.1")
+}
+
+func TestServeDNSFamilyFailureThresholdRemovesResolvedName(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.failureThresholdV4 = 1
+	resolver.minimumTTL = 0
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+	})
+
+	query := test.Case{
+		Qname:  "www.example.com.",
+		Qtype:  dns.TypeA,
+		Rcode:  dns.RcodeSuccess,
+		Answer: []dns.RR{test.A("www.example.com. 0 IN A 1.1.1.1")},
+	}
+	resolver.Next = fakeNextPluginHandler(query)
+	resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+	getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		return len(obj.Status.ResolvedNames) == 1
+	})
+	time.Sleep(time.Second)
+
+	// The resolved name is removed once its only IP address is removed, although the
+	// failureThreshold is not reached.
+	query = test.Case{Qname: "www.example.com.", Qtype: dns.TypeA, Rcode: dns.RcodeServerFailure}
+	resolver.Next = fakeNextPluginHandler(query)
+	resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+	resolverObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		return len(obj.Status.ResolvedNames) == 0
+	})
+	if len(resolverObj.Status.ResolvedNames) != 0 {
+		t.Fatalf("Expected the resolved name to be removed, found %v", resolverObj.Status.ResolvedNames)
+	}
+}
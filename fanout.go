@@ -0,0 +1,69 @@
+package ocp_dnsnameresolver
+
+import (
+	"sort"
+	"sync"
+)
+
+// fanOutOrder determines the order of the status updates of the DNSNameResolver objects
+// of the same DNS name in different namespaces.
+type fanOutOrder string
+
+const (
+	// fanOutOrderParallel updates the DNSNameResolver objects in parallel, in no
+	// particular order.
+	fanOutOrderParallel fanOutOrder = "parallel"
+	// fanOutOrderSorted updates the DNSNameResolver objects one after the other, ordered
+	// by namespace, so that the writes, and their logs and events, are reproducible
+	// across the instances of the plugin.
+	fanOutOrderSorted fanOutOrder = "sorted"
+)
+
+// parseFanOutOrder returns the fanOutOrder corresponding to the given value and whether
+// the value is a valid fanOutOrder.
+func parseFanOutOrder(value string) (fanOutOrder, bool) {
+	switch order := fanOutOrder(value); order {
+	case fanOutOrderParallel, fanOutOrderSorted:
+		return order, true
+	}
+	return "", false
+}
+
+// sortedNamespaces returns the namespaces of the DNSNameResolver objects in order.
+func sortedNamespaces(namespaceDNS namespaceDNSInfo) []string {
+	namespaces := make([]string, 0, len(namespaceDNS))
+	for namespace := range namespaceDNS {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// fanOut calls update with each namespace and the name of the corresponding
+// DNSNameResolver object, and waits for the updates to complete. Each update is
+// performed in a separate goroutine, unless fanOutOrder is sorted, in which case the
+// updates are performed one after the other, ordered by namespace.
+func (resolver *OCPDNSNameResolver) fanOut(namespaceDNS namespaceDNSInfo, update func(namespace string, objName string)) {
+	if resolver.fanOutOrder == fanOutOrderSorted {
+		for _, namespace := range sortedNamespaces(namespaceDNS) {
+			update(namespace, namespaceDNS[namespace])
+		}
+		return
+	}
+
+	// WaitGroup variable used to wait for the completion of update of DNSNameResolver CRs
+	// for the same DNS name in different namespaces.
+	var wg sync.WaitGroup
+	for namespace, objName := range namespaceDNS {
+		wg.Add(1)
+
+		// Each update is performed in separate goroutine.
+		go func(namespace string, objName string) {
+			defer wg.Done()
+			update(namespace, objName)
+		}(namespace, objName)
+	}
+
+	// Wait for the goroutines for each namespace to complete.
+	wg.Wait()
+}
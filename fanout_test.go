@@ -0,0 +1,82 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFanOut(t *testing.T) {
+	namespaceDNS := namespaceDNSInfo{"ns-c": "c", "ns-a": "a", "ns-d": "d", "ns-b": "b", "ns-e": "e"}
+	expected := []string{"ns-a", "ns-b", "ns-c", "ns-d", "ns-e"}
+
+	for _, order := range []fanOutOrder{fanOutOrderParallel, fanOutOrderSorted} {
+		t.Run(string(order), func(t *testing.T) {
+			resolver := New()
+			resolver.fanOutOrder = order
+
+			var lock sync.Mutex
+			var namespaces []string
+			resolver.fanOut(namespaceDNS, func(namespace string, objName string) {
+				if namespaceDNS[namespace] != objName {
+					t.Errorf("Expected object %s for namespace %s, found %s", namespaceDNS[namespace], namespace, objName)
+				}
+				lock.Lock()
+				defer lock.Unlock()
+				namespaces = append(namespaces, namespace)
+			})
+
+			if order == fanOutOrderParallel {
+				slices.Sort(namespaces)
+			}
+			if !slices.Equal(namespaces, expected) {
+				t.Fatalf("Expected the namespaces %v to be updated, found %v", expected, namespaces)
+			}
+		})
+	}
+}
+
+func TestServeDNSSortedFanOutOrder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.fanOutOrder = fanOutOrderSorted
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	expected := []string{"ns-a", "ns-b", "ns-c", "ns-d", "ns-e"}
+	for _, namespace := range []string{"ns-d", "ns-b", "ns-e", "ns-a", "ns-c"} {
+		createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+			ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: namespace},
+			Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+		})
+	}
+	fakeNetworkClient.ClearActions()
+
+	query := test.Case{
+		Qname:  "www.example.com.",
+		Qtype:  dns.TypeA,
+		Rcode:  dns.RcodeSuccess,
+		Answer: []dns.RR{test.A("www.example.com. 30 IN A 1.1.1.1")},
+	}
+	resolver.Next = fakeNextPluginHandler(query)
+	resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+	// The status updates are issued one after the other, ordered by namespace.
+	var namespaces []string
+	for _, action := range fakeNetworkClient.Actions() {
+		if action.GetVerb() == "update" && action.GetSubresource() == "status" {
+			namespaces = append(namespaces, action.GetNamespace())
+		}
+	}
+	if !slices.Equal(namespaces, expected) {
+		t.Fatalf("Expected the status updates in the namespaces %v, found %v", expected, namespaces)
+	}
+}
@@ -0,0 +1,96 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"slices"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// resolverFinalizer is the finalizer added to the monitored DNSNameResolver objects, when
+	// useFinalizer is enabled, so that the plugin drops the details of an object before the
+	// object is deleted.
+	resolverFinalizer = "dnsnameresolver.openshift.io/ocp-dnsnameresolver"
+)
+
+// hasFinalizer returns whether the DNSNameResolver object carries the finalizer of the plugin.
+func hasFinalizer(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+	return slices.Contains(resolverObj.Finalizers, resolverFinalizer)
+}
+
+// observeFinalizer handles the finalizer of the DNSNameResolver object added or updated in the
+// informer. The finalizer is added to the monitored objects, when useFinalizer is enabled, and
+// the objects being deleted which carry the finalizer are finalized. The finalizer is removed
+// even if useFinalizer is disabled, so that the objects finalized by an earlier configuration
+// are not stuck. It returns true if the object is being deleted, in which case its details must
+// not be added.
+func (resolver *OCPDNSNameResolver) observeFinalizer(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver, configured bool) bool {
+	if resolverObj.DeletionTimestamp != nil {
+		if hasFinalizer(resolverObj) {
+			go resolver.finalize(context.Background(), resolverObj, configured)
+		}
+		return resolver.useFinalizer
+	}
+	if resolver.useFinalizer && configured && !hasFinalizer(resolverObj) {
+		go resolver.addFinalizer(context.Background(), resolverObj.Namespace, resolverObj.Name)
+	}
+	return false
+}
+
+// addFinalizer adds the finalizer of the plugin to the DNSNameResolver object.
+func (resolver *OCPDNSNameResolver) addFinalizer(ctx context.Context, namespace, objName string) {
+	// Retry the update of the DNSNameResolver object if there's a conflict or a transient error during the update.
+	resolver.retrier.retryUpdate(namespace, objName, "finalizer", func() error {
+		// Fetch a copy of the DNSNameResolver object. All the updates will be applied to the copied object.
+		resolverObj, err := resolver.store.get(ctx, namespace, objName)
+		if err != nil {
+			// The object may be deleted before the finalizer is added.
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		// If the object already carries the finalizer or is being deleted then skip the update call.
+		if hasFinalizer(resolverObj) || resolverObj.DeletionTimestamp != nil {
+			return nil
+		}
+		resolverObj.Finalizers = append(resolverObj.Finalizers, resolverFinalizer)
+
+		// Update the DNSNameResolver object.
+		return resolver.store.update(ctx, resolverObj)
+	})
+}
+
+// finalize drops the details of the DNSNameResolver object being deleted, if it is monitored,
+// and then removes the finalizer of the plugin from the object, so that its deletion proceeds.
+func (resolver *OCPDNSNameResolver) finalize(ctx context.Context, resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver, configured bool) {
+	if configured {
+		resolver.deleteResolverObject(resolverObj)
+	}
+	log.Infof("Finalizing DNSNameResolver object %s/%s with DNS name %s being deleted", resolverObj.Namespace, resolverObj.Name, resolverObj.Spec.Name)
+
+	namespace, objName := resolverObj.Namespace, resolverObj.Name
+	// Retry the update of the DNSNameResolver object if there's a conflict or a transient error during the update.
+	resolver.retrier.retryUpdate(namespace, objName, "finalizer", func() error {
+		// Fetch a copy of the DNSNameResolver object. All the updates will be applied to the copied object.
+		resolverObj, err := resolver.store.get(ctx, namespace, objName)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		// If the finalizer is already removed then skip the update call.
+		if !hasFinalizer(resolverObj) {
+			return nil
+		}
+		resolverObj.Finalizers = slices.DeleteFunc(resolverObj.Finalizers, func(finalizer string) bool {
+			return finalizer == resolverFinalizer
+		})
+
+		// Update the DNSNameResolver object.
+		return resolver.store.update(ctx, resolverObj)
+	})
+}
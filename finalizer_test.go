@@ -0,0 +1,93 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUseFinalizer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.useFinalizer = true
+	resolver.namespaces = map[string]struct{}{"dns": {}}
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	// The finalizer is added to the monitored object.
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns", Finalizers: []string{"example.com/other"}},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+	})
+	resolverObj := getResolverObject(t, resolver, "dns", "regular", hasFinalizer)
+	if !hasFinalizer(resolverObj) {
+		t.Fatalf("Expected the finalizer to be added to the monitored DNSNameResolver object, found finalizers %v", resolverObj.Finalizers)
+	}
+
+	// The finalizer is not added to the object which is not monitored.
+	if _, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("other").Create(ctx, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "other"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Unexpected error creating DNSNameResolver object: %v", err)
+	}
+	if otherObj := getResolverObject(t, resolver, "other", "regular", hasFinalizer); hasFinalizer(otherObj) {
+		t.Fatalf("Expected the finalizer not to be added to the DNSNameResolver object which is not monitored")
+	}
+
+	// Once the deletion of the object starts, its DNS name is not tracked anymore and the
+	// finalizer is removed, leaving the other finalizers untouched.
+	now := metav1.Now()
+	resolverObj = resolverObj.DeepCopy()
+	resolverObj.DeletionTimestamp = &now
+	if _, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("dns").Update(ctx, resolverObj, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Unexpected error updating DNSNameResolver object: %v", err)
+	}
+	resolverObj = getResolverObject(t, resolver, "dns", "regular", func(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		return !hasFinalizer(resolverObj)
+	})
+	if hasFinalizer(resolverObj) {
+		t.Fatalf("Expected the finalizer to be removed from the DNSNameResolver object being deleted")
+	}
+	if len(resolverObj.Finalizers) != 1 || resolverObj.Finalizers[0] != "example.com/other" {
+		t.Fatalf("Expected the other finalizers to be kept, found finalizers %v", resolverObj.Finalizers)
+	}
+	if isTracked(resolver, "dns", "regular", "www.example.com.") {
+		t.Fatalf("Expected the DNSNameResolver object being deleted not to be tracked")
+	}
+}
+
+func TestFinalizerRemovedWhenDisabled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	// The finalizer is not added when useFinalizer is disabled.
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+	})
+	if resolverObj := getResolverObject(t, resolver, "dns", "regular", hasFinalizer); hasFinalizer(resolverObj) {
+		t.Fatalf("Expected the finalizer not to be added when useFinalizer is disabled")
+	}
+
+	// The finalizer added by an earlier configuration is still removed from the object being deleted.
+	now := metav1.Now()
+	if _, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("dns").Create(ctx, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "finalized", Namespace: "dns", Finalizers: []string{resolverFinalizer}, DeletionTimestamp: &now},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "finalized.example.com."},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Unexpected error creating DNSNameResolver object: %v", err)
+	}
+	resolverObj := getResolverObject(t, resolver, "dns", "finalized", func(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		return !hasFinalizer(resolverObj)
+	})
+	if hasFinalizer(resolverObj) {
+		t.Fatalf("Expected the finalizer to be removed from the DNSNameResolver object being deleted")
+	}
+}
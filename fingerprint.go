@@ -0,0 +1,84 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+)
+
+const (
+	// fingerprintAnnotation is the annotation used for storing the fingerprint of the set of
+	// the IP addresses recorded in the status of a DNSNameResolver object, when
+	// recordFingerprint is enabled, so that its consumers can detect the changes of the IP
+	// addresses by watching the annotation only.
+	fingerprintAnnotation = "dnsnameresolver.openshift.io/address-fingerprint"
+)
+
+// addressFingerprint returns the fingerprint of the set of the IP addresses recorded in the
+// status of the DNSNameResolver object: the hex encoded 64-bit FNV-1a hash of the sorted
+// unique IP addresses of all the resolved names. The fingerprint only changes with the set
+// of the IP addresses, and not with their TTLs, order or resolved names.
+func addressFingerprint(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) string {
+	unique := make(map[string]struct{})
+	for _, resolvedName := range resolverObj.Status.ResolvedNames {
+		for _, resolvedAddress := range resolvedName.ResolvedAddresses {
+			unique[resolvedAddress.IP] = struct{}{}
+		}
+	}
+	ips := make([]string, 0, len(unique))
+	for ip := range unique {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+
+	hash := fnv.New64a()
+	hash.Write([]byte(strings.Join(ips, ",")))
+	return fmt.Sprintf("%016x", hash.Sum64())
+}
+
+// fingerprintStore is a resolverStore setting the fingerprint annotation of the
+// DNSNameResolver objects once their status is successfully written through the wrapped
+// resolverStore, when recordFingerprint is enabled.
+type fingerprintStore struct {
+	resolverStore
+	retrier *updateRetrier
+}
+
+var _ resolverStore = &fingerprintStore{}
+
+// updateStatus implements resolverStore.
+func (store *fingerprintStore) updateStatus(ctx context.Context, resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) error {
+	if err := store.resolverStore.updateStatus(ctx, resolverObj); err != nil {
+		return err
+	}
+	fingerprint := addressFingerprint(resolverObj)
+	if hasAnnotation(resolverObj, fingerprintAnnotation, fingerprint) {
+		return nil
+	}
+
+	// Retry the update of the DNSNameResolver object if there's a conflict or a transient error during the update.
+	store.retrier.retryUpdate(resolverObj.Namespace, resolverObj.Name, "fingerprint annotation", func() error {
+		// Fetch a copy of the DNSNameResolver object. All the updates will be applied to the copied object.
+		newResolverObj, err := store.resolverStore.get(ctx, resolverObj.Namespace, resolverObj.Name)
+		if err != nil {
+			return err
+		}
+
+		// If the annotation is already set then skip the update call.
+		if hasAnnotation(newResolverObj, fingerprintAnnotation, fingerprint) {
+			return nil
+		}
+		setAnnotation(newResolverObj, fingerprintAnnotation, fingerprint)
+		// The status is not written by the update, it is set to the written status so that the
+		// object does not carry a status older than its fingerprint.
+		resolverObj.Status.DeepCopyInto(&newResolverObj.Status)
+
+		// Update the DNSNameResolver object.
+		return store.resolverStore.update(ctx, newResolverObj)
+	})
+	return nil
+}
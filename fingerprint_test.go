@@ -0,0 +1,129 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAddressFingerprint(t *testing.T) {
+	resolverObjWith := func(resolvedNames map[string][]ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress) *ocpnetworkapiv1alpha1.DNSNameResolver {
+		resolverObj := &ocpnetworkapiv1alpha1.DNSNameResolver{}
+		for dnsName, resolvedAddresses := range resolvedNames {
+			resolverObj.Status.ResolvedNames = append(resolverObj.Status.ResolvedNames, ocpnetworkapiv1alpha1.DNSNameResolverResolvedName{
+				DNSName:           ocpnetworkapiv1alpha1.DNSName(dnsName),
+				ResolvedAddresses: resolvedAddresses,
+			})
+		}
+		return resolverObj
+	}
+	reference := addressFingerprint(resolverObjWith(map[string][]ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{
+		"www.example.com.": {{IP: "1.1.1.1", TTLSeconds: 30}, {IP: "1.1.1.2", TTLSeconds: 30}},
+	}))
+
+	tests := []struct {
+		name          string
+		resolvedNames map[string][]ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress
+		expectChange  bool
+	}{
+		{
+			name: "Same addresses in another order with other TTLs",
+			resolvedNames: map[string][]ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{
+				"www.example.com.": {{IP: "1.1.1.2", TTLSeconds: 60}, {IP: "1.1.1.1", TTLSeconds: 5}},
+			},
+			expectChange: false,
+		},
+		{
+			name: "Same addresses across resolved names",
+			resolvedNames: map[string][]ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{
+				"*.example.com.":   {{IP: "1.1.1.1", TTLSeconds: 30}, {IP: "1.1.1.2", TTLSeconds: 30}},
+				"www.example.com.": {{IP: "1.1.1.2", TTLSeconds: 30}},
+			},
+			expectChange: false,
+		},
+		{
+			name: "Added address",
+			resolvedNames: map[string][]ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{
+				"www.example.com.": {{IP: "1.1.1.1", TTLSeconds: 30}, {IP: "1.1.1.2", TTLSeconds: 30}, {IP: "1.1.1.3", TTLSeconds: 30}},
+			},
+			expectChange: true,
+		},
+		{
+			name: "Removed address",
+			resolvedNames: map[string][]ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{
+				"www.example.com.": {{IP: "1.1.1.1", TTLSeconds: 30}},
+			},
+			expectChange: true,
+		},
+		{
+			name: "Replaced address",
+			resolvedNames: map[string][]ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{
+				"www.example.com.": {{IP: "1.1.1.1", TTLSeconds: 30}, {IP: "1.1.1.20", TTLSeconds: 30}},
+			},
+			expectChange: true,
+		},
+		{
+			name:          "No address",
+			resolvedNames: nil,
+			expectChange:  true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fingerprint := addressFingerprint(resolverObjWith(tc.resolvedNames))
+			if len(fingerprint) != 16 {
+				t.Fatalf("Expected a fingerprint of 16 hex digits, found %q", fingerprint)
+			}
+			if changed := fingerprint != reference; changed != tc.expectChange {
+				t.Fatalf("Expected fingerprint change %t, found fingerprint %q for reference %q", tc.expectChange, fingerprint, reference)
+			}
+		})
+	}
+}
+
+func TestServeDNSFingerprintAnnotation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.recordFingerprint = true
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+	})
+
+	serve := func(answer ...dns.RR) *ocpnetworkapiv1alpha1.DNSNameResolver {
+		t.Helper()
+		query := test.Case{Qname: "www.example.com.", Qtype: dns.TypeA, Rcode: dns.RcodeSuccess, Answer: answer}
+		resolver.Next = fakeNextPluginHandler(query)
+		resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+		return getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+			return obj.Annotations[fingerprintAnnotation] == addressFingerprint(obj) && len(resolvedIPs(obj, "www.example.com.")) == len(answer)
+		})
+	}
+
+	resolverObj := serve(test.A("www.example.com. 30 IN A 1.1.1.1"))
+	first := resolverObj.Annotations[fingerprintAnnotation]
+	if first != addressFingerprint(resolverObj) || resolverObj.Annotations[schemaVersionAnnotation] != annotationSchemaVersion {
+		t.Fatalf("Expected fingerprint annotation %q with the schema version, found annotations %v", addressFingerprint(resolverObj), resolverObj.Annotations)
+	}
+
+	// A change of the TTL keeps the fingerprint.
+	resolverObj = serve(test.A("www.example.com. 60 IN A 1.1.1.1"))
+	if fingerprint := resolverObj.Annotations[fingerprintAnnotation]; fingerprint != first {
+		t.Fatalf("Expected fingerprint %q to be kept, found %q", first, fingerprint)
+	}
+
+	// A new IP address changes the fingerprint.
+	resolverObj = serve(test.A("www.example.com. 60 IN A 1.1.1.1"), test.A("www.example.com. 60 IN A 1.1.1.2"))
+	if fingerprint := resolverObj.Annotations[fingerprintAnnotation]; fingerprint == first {
+		t.Fatalf("Expected fingerprint %q to change with the new IP address", first)
+	}
+}
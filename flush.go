@@ -0,0 +1,138 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// flushStore is a resolverStore buffering the status updates of the DNSNameResolver
+// objects, when flushInterval is configured. Only the last status of each object is kept,
+// and the buffered statuses are written by the flusher in a single pass at each
+// flushInterval. The reads return the buffered status of the objects, so that the
+// following status updates build on it. The other updates are issued as usual.
+type flushStore struct {
+	resolverStore
+	retrier *updateRetrier
+	// pending stores the buffered statuses.
+	// key: DNSNameResolver object, value: status.
+	pending map[types.NamespacedName]*ocpnetworkapiv1alpha1.DNSNameResolverStatus
+	// flushing stores the statuses being written by the flusher, which are still read
+	// until the flush completes.
+	flushing map[types.NamespacedName]*ocpnetworkapiv1alpha1.DNSNameResolverStatus
+	lock     sync.Mutex
+	// flushLock serializes the flushes, eg. the periodic flush and the drain on shutdown.
+	flushLock sync.Mutex
+}
+
+var _ resolverStore = &flushStore{}
+
+// newFlushStore returns a flushStore buffering the status updates of the wrapped resolverStore.
+func newFlushStore(store resolverStore, retrier *updateRetrier) *flushStore {
+	return &flushStore{
+		resolverStore: store,
+		retrier:       retrier,
+		pending:       make(map[types.NamespacedName]*ocpnetworkapiv1alpha1.DNSNameResolverStatus),
+	}
+}
+
+// get implements resolverStore.
+func (store *flushStore) get(ctx context.Context, namespace, name string) (*ocpnetworkapiv1alpha1.DNSNameResolver, error) {
+	resolverObj, err := store.resolverStore.get(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	object := types.NamespacedName{Namespace: namespace, Name: name}
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	if status, exists := store.pending[object]; exists {
+		status.DeepCopyInto(&resolverObj.Status)
+	} else if status, exists := store.flushing[object]; exists {
+		status.DeepCopyInto(&resolverObj.Status)
+	}
+	return resolverObj, nil
+}
+
+// updateStatus implements resolverStore.
+func (store *flushStore) updateStatus(_ context.Context, resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	store.pending[types.NamespacedName{Namespace: resolverObj.Namespace, Name: resolverObj.Name}] = resolverObj.Status.DeepCopy()
+	return nil
+}
+
+// flush writes the buffered statuses through the wrapped resolverStore. The objects are
+// written one by one, ordered by namespace and name, and the statuses which already match
+// the ones of the objects are skipped, to minimize the churn of the API server. The
+// statuses of the deleted objects are dropped.
+func (store *flushStore) flush(ctx context.Context) {
+	store.flushLock.Lock()
+	defer store.flushLock.Unlock()
+
+	store.lock.Lock()
+	pending := store.pending
+	store.pending = make(map[types.NamespacedName]*ocpnetworkapiv1alpha1.DNSNameResolverStatus)
+	store.flushing = pending
+	store.lock.Unlock()
+
+	defer func() {
+		store.lock.Lock()
+		store.flushing = nil
+		store.lock.Unlock()
+	}()
+
+	objects := make([]types.NamespacedName, 0, len(pending))
+	for object := range pending {
+		objects = append(objects, object)
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].Namespace != objects[j].Namespace {
+			return objects[i].Namespace < objects[j].Namespace
+		}
+		return objects[i].Name < objects[j].Name
+	})
+
+	for _, object := range objects {
+		// Retry the update of the DNSNameResolver object if there's a conflict or a transient error during the update.
+		store.retrier.retryUpdate(object.Namespace, object.Name, "flushed status", func() error {
+			// Fetch a copy of the DNSNameResolver object. All the updates will be applied to the copied object.
+			resolverObj, err := store.resolverStore.get(ctx, object.Namespace, object.Name)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					return nil
+				}
+				return err
+			}
+			// If the status already matches then skip the update status call.
+			if equality.Semantic.DeepEqual(resolverObj.Status, *pending[object]) {
+				return nil
+			}
+			pending[object].DeepCopyInto(&resolverObj.Status)
+
+			// Update the status of the DNSNameResolver object.
+			return store.resolverStore.updateStatus(ctx, resolverObj)
+		})
+	}
+}
+
+// runFlusher flushes the buffered status updates at each flushInterval, until the stop
+// channel is closed.
+func (resolver *OCPDNSNameResolver) runFlusher(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(resolver.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
+		resolver.flusher.flush(context.Background())
+	}
+}
@@ -0,0 +1,147 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	ocpnetworkfakeclient "github.com/openshift/client-go/network/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestFlushInterval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.flushInterval = time.Hour
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	for _, namespace := range []string{"ns-b", "ns-a"} {
+		createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+			ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: namespace},
+			Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+		})
+	}
+
+	for _, ip := range []string{"1.1.1.1", "1.1.1.2"} {
+		query := test.Case{
+			Qname:  "www.example.com.",
+			Qtype:  dns.TypeA,
+			Rcode:  dns.RcodeSuccess,
+			Answer: []dns.RR{test.A("www.example.com. 30 IN A " + ip)},
+		}
+		resolver.Next = fakeNextPluginHandler(query)
+		resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+	}
+
+	// The status updates are buffered until the flush, and the buffered statuses are read.
+	if count := countStatusUpdates(fakeNetworkClient); count != 0 {
+		t.Fatalf("Expected no status update before the flush, found %d", count)
+	}
+	resolverObj, err := resolver.store.get(ctx, "ns-a", "regular")
+	if err != nil {
+		t.Fatalf("Unexpected error getting DNSNameResolver object: %v", err)
+	}
+	if ips := resolvedIPs(resolverObj, "www.example.com."); len(ips) != 2 {
+		t.Fatalf("Expected the buffered status to contain 2 IP addresses, found %v", ips)
+	}
+
+	// The flush writes the last status of each object once, ordered by namespace.
+	resolver.flusher.flush(ctx)
+	var namespaces []string
+	for _, action := range fakeNetworkClient.Actions() {
+		if action.GetVerb() == "update" && action.GetSubresource() == "status" {
+			namespaces = append(namespaces, action.(clienttesting.UpdateAction).GetNamespace())
+		}
+	}
+	if expected := []string{"ns-a", "ns-b"}; !reflect.DeepEqual(namespaces, expected) {
+		t.Fatalf("Expected the status updates of the namespaces %v, found %v", expected, namespaces)
+	}
+	for _, namespace := range []string{"ns-a", "ns-b"} {
+		resolverObj, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers(namespace).Get(ctx, "regular", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error getting DNSNameResolver object: %v", err)
+		}
+		if ips := resolvedIPs(resolverObj, "www.example.com."); len(ips) != 2 {
+			t.Fatalf("Expected the flushed status of namespace %s to contain 2 IP addresses, found %v", namespace, ips)
+		}
+	}
+
+	// Nothing is written by a flush without buffered status updates.
+	resolver.flusher.flush(ctx)
+	if count := countStatusUpdates(fakeNetworkClient); count != 2 {
+		t.Fatalf("Expected 2 status updates, found %d", count)
+	}
+}
+
+func TestFlushIntervalSkipsUnchangedStatus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.flushInterval = time.Hour
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+	})
+
+	// A buffered status matching the status of the object is not written.
+	resolverObj, err := resolver.store.get(ctx, "dns", "regular")
+	if err != nil {
+		t.Fatalf("Unexpected error getting DNSNameResolver object: %v", err)
+	}
+	if err := resolver.store.updateStatus(ctx, resolverObj); err != nil {
+		t.Fatalf("Unexpected error updating DNSNameResolver object: %v", err)
+	}
+	resolver.flusher.flush(ctx)
+	if count := countStatusUpdates(fakeNetworkClient); count != 0 {
+		t.Fatalf("Expected no status update, found %d", count)
+	}
+}
+
+func TestFlushIntervalDrainOnShutdown(t *testing.T) {
+	resolver := New()
+	resolver.flushInterval = time.Hour
+	fakeNetworkClient := ocpnetworkfakeclient.NewSimpleClientset()
+	onStart, onShut, err := resolver.initPluginWithClient(fakeNetworkClient)
+	if err != nil {
+		t.Fatalf("Failed to initialize plugin: %v", err)
+	}
+	if err := onStart(); err != nil {
+		t.Fatalf("Failed to start plugin: %v", err)
+	}
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+	})
+
+	query := test.Case{
+		Qname:  "www.example.com.",
+		Qtype:  dns.TypeA,
+		Rcode:  dns.RcodeSuccess,
+		Answer: []dns.RR{test.A("www.example.com. 30 IN A 1.1.1.1")},
+	}
+	resolver.Next = fakeNextPluginHandler(query)
+	resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+	if count := countStatusUpdates(fakeNetworkClient); count != 0 {
+		t.Fatalf("Expected no status update before the shutdown, found %d", count)
+	}
+
+	// The buffered status updates are drained on the shutdown.
+	if err := onShut(); err != nil {
+		t.Fatalf("Failed to shut down plugin: %v", err)
+	}
+	if count := countStatusUpdates(fakeNetworkClient); count != 1 {
+		t.Fatalf("Expected 1 status update on the shutdown, found %d", count)
+	}
+}
@@ -0,0 +1,137 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// forbiddenRetryPeriod gives the period of checking for the forbidden updates which
+	// are due for a retry.
+	forbiddenRetryPeriod = 10 * time.Second
+	// forbiddenInitialBackoff gives the delay of the first retry of a forbidden update.
+	forbiddenInitialBackoff = 30 * time.Second
+	// forbiddenMaxBackoff gives the maximum delay between the retries of a forbidden update.
+	forbiddenMaxBackoff = 10 * time.Minute
+	// maxForbiddenUpdates gives the maximum number of tracked forbidden updates.
+	maxForbiddenUpdates = 1000
+)
+
+// forbiddenKey identifies a forbidden update of the status of a DNSNameResolver
+// object for a DNS name.
+type forbiddenKey struct {
+	object  types.NamespacedName
+	dnsName string
+}
+
+// forbiddenRetry gives the schedule of the retries of a forbidden update.
+type forbiddenRetry struct {
+	backoff   time.Duration
+	nextRetry time.Time
+}
+
+// forbiddenTracker tracks the updates of the DNSNameResolver objects which failed as
+// forbidden, so that they are retried once the permissions are granted, without
+// waiting for the next DNS lookup of the DNS names.
+type forbiddenTracker struct {
+	retries map[forbiddenKey]*forbiddenRetry
+	lock    sync.Mutex
+}
+
+// newForbiddenTracker returns an initialized forbiddenTracker.
+func newForbiddenTracker() *forbiddenTracker {
+	return &forbiddenTracker{
+		retries: make(map[forbiddenKey]*forbiddenRetry),
+	}
+}
+
+// add tracks the forbidden update. The retry of an already tracked update keeps its
+// schedule. New updates are not tracked once maxForbiddenUpdates are tracked.
+func (tracker *forbiddenTracker) add(key forbiddenKey, now time.Time) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	if _, exists := tracker.retries[key]; exists {
+		return
+	}
+	if len(tracker.retries) >= maxForbiddenUpdates {
+		log.Warningf("Not retrying the forbidden update of DNSNameResolver object %s for DNS name %s as %d forbidden updates are already tracked",
+			key.object, key.dnsName, maxForbiddenUpdates)
+		return
+	}
+	tracker.retries[key] = &forbiddenRetry{
+		backoff:   forbiddenInitialBackoff,
+		nextRetry: now.Add(forbiddenInitialBackoff),
+	}
+}
+
+// remove stops tracking the update.
+func (tracker *forbiddenTracker) remove(key forbiddenKey) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	delete(tracker.retries, key)
+}
+
+// due returns the sorted DNS names of the updates which are due for a retry at the
+// given time. The next retries of the returned updates are scheduled with an
+// exponential backoff, capped at forbiddenMaxBackoff.
+func (tracker *forbiddenTracker) due(now time.Time) []string {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	dnsNameSet := make(map[string]struct{})
+	for key, retry := range tracker.retries {
+		if now.Before(retry.nextRetry) {
+			continue
+		}
+		dnsNameSet[key.dnsName] = struct{}{}
+		retry.backoff *= 2
+		if retry.backoff > forbiddenMaxBackoff {
+			retry.backoff = forbiddenMaxBackoff
+		}
+		retry.nextRetry = now.Add(retry.backoff)
+	}
+
+	dnsNames := make([]string, 0, len(dnsNameSet))
+	for dnsName := range dnsNameSet {
+		dnsNames = append(dnsNames, dnsName)
+	}
+	sort.Strings(dnsNames)
+	return dnsNames
+}
+
+// trackForbidden tracks the update of the status of the DNSNameResolver object for the
+// DNS name if it failed as forbidden, and stops tracking it once it succeeds. Nothing
+// is tracked unless retryForbidden is enabled. The updates for wildcard DNS names are
+// not tracked, as the wildcard DNS names cannot be looked up for the retry.
+func (resolver *OCPDNSNameResolver) trackForbidden(namespace, objName, dnsName string, err error) {
+	if !resolver.retryForbidden || isWildcard(dnsName) {
+		return
+	}
+	key := forbiddenKey{object: types.NamespacedName{Namespace: namespace, Name: objName}, dnsName: dnsName}
+	switch {
+	case err == nil:
+		resolver.forbiddenTracker.remove(key)
+	case apierrors.IsForbidden(err):
+		resolver.forbiddenTracker.add(key, time.Now())
+	}
+}
+
+// retryForbiddenUpdates retries the forbidden updates which are due at the given time. The
+// updates are retried by looking up the A and AAAA records of their DNS names
+// through the next plugins, so that the status is updated with fresh IP addresses.
+// The responses are recorded directly, thus the retries are neither counted for
+// minQueries nor subject to the client policies of ServeDNS.
+func (resolver *OCPDNSNameResolver) retryForbiddenUpdates(ctx context.Context, now time.Time) {
+	for _, dnsName := range resolver.forbiddenTracker.due(now) {
+		for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+			resolver.lookup(ctx, dnsName, qtype, "retrying forbidden update of")
+		}
+	}
+}
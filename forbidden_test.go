@@ -0,0 +1,205 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"net/netip"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestForbiddenTracker(t *testing.T) {
+	now := time.Now()
+	tracker := newForbiddenTracker()
+	regular := forbiddenKey{object: types.NamespacedName{Namespace: "dns", Name: "regular"}, dnsName: "www.example.com."}
+	other := forbiddenKey{object: types.NamespacedName{Namespace: "other", Name: "regular"}, dnsName: "www.example.com."}
+
+	tracker.add(regular, now)
+	tracker.add(other, now.Add(time.Second))
+
+	if dnsNames := tracker.due(now); len(dnsNames) != 0 {
+		t.Fatalf("Expected no DNS names to be due before the initial backoff, found: %v", dnsNames)
+	}
+
+	// The DNS names of the updates are deduplicated.
+	at := now.Add(forbiddenInitialBackoff + time.Second)
+	if dnsNames := tracker.due(at); !reflect.DeepEqual(dnsNames, []string{"www.example.com."}) {
+		t.Fatalf("Expected DNS name www.example.com. to be due, found: %v", dnsNames)
+	}
+
+	// The backoff is doubled after each retry, capped at forbiddenMaxBackoff.
+	if dnsNames := tracker.due(at.Add(2*forbiddenInitialBackoff - time.Second)); len(dnsNames) != 0 {
+		t.Fatalf("Expected no DNS names to be due before the doubled backoff, found: %v", dnsNames)
+	}
+	for i := 0; i < 10; i++ {
+		at = at.Add(forbiddenMaxBackoff)
+		if dnsNames := tracker.due(at); len(dnsNames) != 1 {
+			t.Fatalf("Expected DNS name www.example.com. to be due after the maximum backoff, found: %v", dnsNames)
+		}
+	}
+
+	// Adding an already tracked update keeps its schedule.
+	tracker.add(regular, at)
+	if dnsNames := tracker.due(at.Add(forbiddenMaxBackoff)); len(dnsNames) != 1 {
+		t.Fatalf("Expected DNS name www.example.com. to be due, found: %v", dnsNames)
+	}
+
+	tracker.remove(regular)
+	tracker.remove(other)
+	if len(tracker.retries) != 0 {
+		t.Fatalf("Expected no tracked updates, found: %v", tracker.retries)
+	}
+}
+
+func TestServeDNSRetryForbidden(t *testing.T) {
+	tests := []struct {
+		name            string
+		retryForbidden  bool
+		expectedTracked bool
+	}{
+		{
+			name:            "Forbidden update is not tracked by default",
+			retryForbidden:  false,
+			expectedTracked: false,
+		},
+		{
+			name:            "Forbidden update is retried once allowed with retryForbidden",
+			retryForbidden:  true,
+			expectedTracked: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.retryForbidden = tc.retryForbidden
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			})
+
+			// Forbid the updates of the status until the permissions are granted.
+			var forbidden atomic.Bool
+			forbidden.Store(true)
+			var forbiddenUpdates atomic.Int32
+			fakeNetworkClient.PrependReactor("update", "dnsnameresolvers", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				if action.GetSubresource() != "status" || !forbidden.Load() {
+					return false, nil, nil
+				}
+				forbiddenUpdates.Add(1)
+				return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "network.openshift.io", Resource: "dnsnameresolvers"},
+					"regular", nil)
+			})
+
+			resolver.Next = fakeNextPluginHandler(test.Case{
+				Qname: "www.example.com.",
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A("www.example.com. 30 IN A 1.1.1.1"),
+				},
+			})
+			resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), test.Case{Qname: "www.example.com.", Qtype: dns.TypeA}.Msg())
+
+			key := forbiddenKey{object: types.NamespacedName{Namespace: "dns", Name: "regular"}, dnsName: "www.example.com."}
+			isForbiddenTracked := func() bool {
+				resolver.forbiddenTracker.lock.Lock()
+				defer resolver.forbiddenTracker.lock.Unlock()
+				_, tracked := resolver.forbiddenTracker.retries[key]
+				return tracked
+			}
+			err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 2*time.Second, true, func(context.Context) (bool, error) {
+				return forbiddenUpdates.Load() > 0 && isForbiddenTracked() == tc.expectedTracked, nil
+			})
+			if err != nil {
+				t.Fatalf("Expected forbidden update tracked to be %t, found %t", tc.expectedTracked, isForbiddenTracked())
+			}
+			if !tc.expectedTracked {
+				return
+			}
+
+			// Grant the permissions and retry the forbidden update.
+			forbidden.Store(false)
+			resolver.retryForbiddenUpdates(ctx, time.Now().Add(forbiddenInitialBackoff))
+
+			resolverObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+				return len(obj.Status.ResolvedNames) == 1
+			})
+			if len(resolverObj.Status.ResolvedNames) != 1 {
+				t.Fatalf("Expected the status to be updated by the retry, found: %v", resolverObj.Status)
+			}
+			err = wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 2*time.Second, true, func(context.Context) (bool, error) {
+				return !isForbiddenTracked(), nil
+			})
+			if err != nil {
+				t.Fatalf("Expected the update to not be tracked once it succeeded")
+			}
+		})
+	}
+}
+
+func TestRetryForbiddenBypassesClientPolicies(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The DNS lookups of the retries are neither counted for minQueries nor subject to
+	// allowedClientCIDRs.
+	resolver := New()
+	resolver.retryForbidden = true
+	resolver.minQueries = 3
+	resolver.allowedClientCIDRs = []netip.Prefix{netip.MustParsePrefix("10.240.0.0/16")}
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "regular",
+			Namespace: "dns",
+		},
+		Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+			Name: "www.example.com.",
+		},
+	})
+
+	resolver.Next = fakeNextPluginHandler(test.Case{
+		Qname:  "www.example.com.",
+		Qtype:  dns.TypeA,
+		Rcode:  dns.RcodeSuccess,
+		Answer: []dns.RR{test.A("www.example.com. 30 IN A 1.1.1.1")},
+	})
+
+	now := time.Now()
+	resolver.forbiddenTracker.add(forbiddenKey{object: types.NamespacedName{Namespace: "dns", Name: "regular"}, dnsName: "www.example.com."}, now)
+	resolver.retryForbiddenUpdates(ctx, now.Add(forbiddenInitialBackoff))
+
+	resolverObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		return len(obj.Status.ResolvedNames) == 1
+	})
+	if len(resolverObj.Status.ResolvedNames) != 1 {
+		t.Fatalf("Expected the status to be updated by the retry, found %v", resolverObj.Status)
+	}
+	if reached := resolver.queryCounter.record("www.example.com.", time.Now(), resolver.minQueries, resolver.minQueriesWindow); reached {
+		t.Fatalf("Expected the retry not to be counted for minQueries")
+	}
+}
@@ -0,0 +1,30 @@
+package ocp_dnsnameresolver
+
+import (
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+)
+
+// updateObservedGeneration sets the observedGeneration field of the conditions of the
+// resolved names of the DNSNameResolver object to the generation of the object, if
+// recordObservedGeneration is enabled. The DNSNameResolver status does not have an
+// observedGeneration field of its own, thus the consumers compare the one of the
+// conditions with the generation of the object to detect whether the recorded IP
+// addresses reflect its latest spec. The object is the copy fetched from the informer
+// cache, whose generation is the one the status is written for. It returns whether any
+// of the conditions was changed.
+func (resolver *OCPDNSNameResolver) updateObservedGeneration(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+	if !resolver.recordObservedGeneration {
+		return false
+	}
+	updated := false
+	for i := range resolverObj.Status.ResolvedNames {
+		conditions := resolverObj.Status.ResolvedNames[i].Conditions
+		for j := range conditions {
+			if conditions[j].ObservedGeneration != resolverObj.Generation {
+				conditions[j].ObservedGeneration = resolverObj.Generation
+				updated = true
+			}
+		}
+	}
+	return updated
+}
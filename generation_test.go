@@ -0,0 +1,87 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestServeDNSRecordObservedGeneration(t *testing.T) {
+	tests := []struct {
+		name                     string
+		recordObservedGeneration bool
+		expectedGenerations      []int64
+	}{
+		{
+			name:                "Observed generation is not recorded by default",
+			expectedGenerations: []int64{0, 0},
+		},
+		{
+			name:                     "Observed generation tracks the generation across spec edits",
+			recordObservedGeneration: true,
+			expectedGenerations:      []int64{1, 2},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.recordObservedGeneration = tc.recordObservedGeneration
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns", Generation: 1},
+				Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+			})
+
+			query := test.Case{
+				Qname: "www.example.com.",
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A("www.example.com. 30 IN A 1.1.1.1"),
+				},
+			}
+			resolver.Next = fakeNextPluginHandler(query)
+
+			for i, expectedGeneration := range tc.expectedGenerations {
+				if i > 0 {
+					// The fake client does not bump the generation, thus it is bumped here
+					// as the API server would on a spec edit.
+					resolverObj, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("dns").Get(ctx, "regular", metav1.GetOptions{})
+					if err != nil {
+						t.Fatalf("Unexpected error getting DNSNameResolver object: %v", err)
+					}
+					resolverObj.Generation++
+					if _, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("dns").Update(ctx, resolverObj, metav1.UpdateOptions{}); err != nil {
+						t.Fatalf("Unexpected error updating DNSNameResolver object: %v", err)
+					}
+					getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+						return obj.Generation == resolverObj.Generation
+					})
+				}
+
+				resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+				resolverObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+					return len(obj.Status.ResolvedNames) == 1 && len(obj.Status.ResolvedNames[0].Conditions) == 1 &&
+						obj.Status.ResolvedNames[0].Conditions[0].ObservedGeneration == expectedGeneration
+				})
+				if len(resolverObj.Status.ResolvedNames) != 1 || len(resolverObj.Status.ResolvedNames[0].Conditions) != 1 {
+					t.Fatalf("Expected a single resolved name with a single condition, found %v", resolverObj.Status.ResolvedNames)
+				}
+				if generation := resolverObj.Status.ResolvedNames[0].Conditions[0].ObservedGeneration; generation != expectedGeneration {
+					t.Fatalf("Lookup %d: expected observed generation %d, found %d", i, expectedGeneration, generation)
+				}
+			}
+		})
+	}
+}
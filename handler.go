@@ -2,24 +2,29 @@ package ocp_dnsnameresolver
 
 import (
 	"context"
+	"math"
+	"net"
+	"net/netip"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/pkg/dnsutil"
 	"github.com/coredns/coredns/request"
 	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
-	ocpnetworkv1alpha1lister "github.com/openshift/client-go/network/listers/network/v1alpha1"
 
 	"github.com/miekg/dns"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
-	"k8s.io/client-go/util/retry"
 )
 
 const (
 	ConditionDegraded = "Degraded"
+	ConditionBlocked  = "Blocked"
 )
 
 var rcodeMessage = map[int]string{
@@ -52,301 +57,667 @@ func (resolver *OCPDNSNameResolver) ServeDNS(ctx context.Context, w dns.Response
 	// Get the DNS name from the DNS lookup request.
 	qname := strings.ToLower(state.QName())
 
-	var regularDnsInfo, wildcardDnsInfo namespaceDNSInfo
-	var regularDNSExists, wildcardDNSExists bool
+	// The DNS names of the internal zones never match the DNSNameResolver objects. Return
+	// the response received from the plugin chain without any further processing.
+	if resolver.inInternalZone(qname) {
+		return plugin.NextOrFailure(resolver.Name(), resolver.Next, ctx, w, r)
+	}
 
-	// Check if the query was for a wildcard DNS name or a regular DNS name.
-	if isWildcard(qname) {
-		// Get the wildcard DNS name info, if it exists.
-		wildcardDnsInfo, wildcardDNSExists = resolver.wildcardDNSInfo[qname]
-	} else {
-		// Get the regular DNS name info, if it exists.
-		regularDnsInfo, regularDNSExists = resolver.regularDNSInfo[qname]
+	// Only the DNS names of the external zones, i.e. the zones resolved by the upstream
+	// resolvers, are recorded, if externalZones is configured. The responses for the DNS
+	// names of the other zones may be produced locally, eg. by the hosts plugin.
+	if !resolver.inExternalZone(qname) {
+		return plugin.NextOrFailure(resolver.Name(), resolver.Next, ctx, w, r)
+	}
 
-		// Get the corresponding wildcard DNS name for the reguar DNS name.
-		wildcard := getWildcard(qname)
-		// Get the wildcard DNS name info, if it exists.
-		wildcardDnsInfo, wildcardDNSExists = resolver.wildcardDNSInfo[wildcard]
+	// With lazyStart, the informer is started by the first DNS lookup of an external zone.
+	if resolver.lazyStart {
+		resolver.startInformer()
 	}
 
+	// The DNS lookups of the clients which are not in the allowedClientCIDRs, eg. external
+	// probes, are served without recording their responses, if allowedClientCIDRs is configured.
+	if !resolver.allowedClient(state.IP()) {
+		return plugin.NextOrFailure(resolver.Name(), resolver.Next, ctx, w, r)
+	}
+
+	// With failClosed, the DNS lookups fail until the DNSNameResolver informer is synced.
+	// Until then, the DNS names matching the DNSNameResolver objects are not known, thus
+	// the DNS lookups would be answered without updating the status of the objects.
+	if resolver.failClosed && !resolver.dnsNameResolverInformer.HasSynced() {
+		return dns.RcodeServerFailure, nil
+	}
+
+	// Get the DNSNameResolver objects matching the DNS name.
+	regularDnsInfo, wildcardDnsInfo := resolver.matchResolverObjects(qname)
+
 	// If neither regular DNS name info nor wildcard DNS name info exists for the DNS name
-	// then return the response received from the plugin chain.
-	if !regularDNSExists && !wildcardDNSExists {
+	// then return the response received from the plugin chain. During the settle window of
+	// settleWindow, the DNS name may just not be tracked yet, thus the TTLs of the response
+	// are capped so that it is not cached beyond the window.
+	if len(regularDnsInfo) == 0 && len(wildcardDnsInfo) == 0 {
+		if remaining := resolver.settleRemaining(time.Now()); remaining > 0 {
+			w = &settleResponseWriter{ResponseWriter: w, maxTTL: uint32((remaining + time.Second - 1) / time.Second)}
+		}
+		return plugin.NextOrFailure(resolver.Name(), resolver.Next, ctx, w, r)
+	}
+
+	// The response of a DNS lookup with the CD (checking disabled) bit set bypasses the DNSSEC
+	// validation of the upstream resolvers. Reject it from being recorded, if rejectCDBit is
+	// enabled.
+	if resolver.rejectCDBit && r.CheckingDisabled {
+		log.Debugf("Not recording the response for DNS name %s as the CD bit of its query is set", qname)
+		rejectedResponses.WithLabelValues(rejectReasonCheckingDisabled).Inc()
+		return plugin.NextOrFailure(resolver.Name(), resolver.Next, ctx, w, r)
+	}
+
+	// Start recording the status of the DNS name only after the DNS name has been looked up
+	// at least minQueries times within the minQueriesWindow.
+	if !resolver.queryCounter.record(qname, time.Now(), resolver.minQueries, resolver.minQueriesWindow) {
+		return plugin.NextOrFailure(resolver.Name(), resolver.Next, ctx, w, r)
+	}
+
+	// Collapse the identical DNS lookups within the queryCoalesceWindow, eg. the DNS lookups
+	// retried rapidly by the clients, into the update attempt of the first DNS lookup.
+	if !resolver.queryCoalescer.admit(qname, state.QType(), time.Now(), resolver.queryCoalesceWindow) {
 		return plugin.NextOrFailure(resolver.Name(), resolver.Next, ctx, w, r)
 	}
 
 	// Record response to get status code and size of the reply.
 	rw := dnstest.NewRecorder(w)
 
-	// Get the response for the DNS lookup from the plugin chain.
-	status, err := plugin.NextOrFailure(resolver.Name(), resolver.Next, ctx, rw, r)
+	// Get the response for the DNS lookup from the plugin chain. If singleflight is enabled,
+	// the concurrent identical DNS lookups share the response of the first one, which is the
+	// only one updating the status.
+	var status int
+	var err error
+	if resolver.singleflight {
+		var follower bool
+		status, err, follower = resolver.resolveOnce(ctx, rw, r, state)
+		if follower {
+			return status, err
+		}
+	} else {
+		status, err = plugin.NextOrFailure(resolver.Name(), resolver.Next, ctx, rw, r)
+	}
+
+	return resolver.recordResponse(ctx, w, r, rw, status, err, regularDnsInfo, wildcardDnsInfo)
+}
+
+// recordResponse records the response of the DNS lookup received from the plugin chain,
+// through the recorder, in the DNSNameResolver objects corresponding to the regular and
+// the wildcard DNS names, and returns the status and the error of the DNS lookup. The
+// response writer is only used for answering from the recorded IP addresses, with
+// serveStaleOnTimeout.
+func (resolver *OCPDNSNameResolver) recordResponse(
+	ctx context.Context,
+	w dns.ResponseWriter,
+	r *dns.Msg,
+	rw *dnstest.Recorder,
+	status int,
+	err error,
+	regularDnsInfo namespaceDNSInfo,
+	wildcardDnsInfo namespaceDNSInfo,
+) (int, error) {
+	state := request.Request{W: w, Req: r}
+
+	// Get the DNS name from the DNS lookup request.
+	qname := strings.ToLower(state.QName())
+
+	// A truncated response may contain only a subset of the DNS records. Skip the recording
+	// of the response, without counting it as a failure, unless configured otherwise.
+	if rw.Msg != nil && rw.Msg.Truncated && resolver.truncatedPolicy == truncatedPolicySkip {
+		return status, err
+	}
+
+	// An implausibly large number of records in the answer section may indicate an amplification
+	// or a poisoning attempt. Reject the whole response from being recorded, if maxAnswerRecords
+	// is configured and the number of records exceeds it.
+	if resolver.maxAnswerRecords > 0 && rw.Msg != nil && len(rw.Msg.Answer) > resolver.maxAnswerRecords {
+		log.Warningf("Not recording the response for DNS name %s as the number of answer records %d exceeds the maximum %d",
+			qname, len(rw.Msg.Answer), resolver.maxAnswerRecords)
+		rejectedResponses.WithLabelValues(rejectReasonMaxAnswerRecords).Inc()
+		return status, err
+	}
+
+	// SRV records are only recorded when recordSRV is enabled. The host:port targets
+	// of a successful lookup are stored in an annotation of the DNSNameResolver objects.
+	if state.QType() == dns.TypeSRV {
+		if resolver.recordSRV && status == dns.RcodeSuccess && err == nil {
+			if targets := getSRVTargets(rw.Msg); len(targets) > 0 {
+				resolver.updateSRVTargets(ctx, regularDnsInfo, wildcardDnsInfo, qname, targets)
+			}
+		}
+		return status, err
+	}
+
+	// PTR records are only recorded when recordPTR is enabled. The host names of a successful
+	// lookup of a reverse DNS name are stored in an annotation of the DNSNameResolver objects,
+	// by the IP address of the reverse DNS name.
+	if state.QType() == dns.TypePTR {
+		if resolver.recordPTR && status == dns.RcodeSuccess && err == nil {
+			if ip := dnsutil.ExtractAddressFromReverse(qname); ip != "" {
+				if names := getPTRNames(qname, rw.Msg); len(names) > 0 {
+					resolver.updatePTRNames(ctx, regularDnsInfo, wildcardDnsInfo, ip, names)
+				}
+			}
+		}
+		return status, err
+	}
 
 	// Get the IP addresses and the corresponding TTLs in a map. Only A and AAAA type DNS records
 	// are considered.
+	// Only the DNS records of the DNS name and of the DNS names of its CNAME chain, up to
+	// maxCNAMEDepth hops, are considered.
+	// The DNS records of the additional section are only considered if includeAdditional is
+	// enabled, eg. for the upstreams placing the IP addresses in the additional section.
+	// No response is written by the plugin chain if the DNS lookup fails with an error, eg. a
+	// timeout of the upstream resolvers.
+	var answers, records []dns.RR
+	if rw.Msg != nil {
+		answers = rw.Msg.Answer
+		records = answers
+		if resolver.includeAdditional {
+			records = append(slices.Clip(answers), rw.Msg.Extra...)
+		}
+	}
+	chain := resolver.cnameChain(qname, records)
+	// An answer for a DNS name off the CNAME chain of the queried DNS name may be an off-path
+	// or a misdirected answer. Reject the whole response from being recorded, unless
+	// strictQNameMatch is disabled, in which case only the records of the CNAME chain are
+	// considered.
+	if resolver.strictQNameMatch && (state.QType() == dns.TypeA || state.QType() == dns.TypeAAAA) {
+		if owner, mismatched := mismatchedOwner(qname, state.QType(), answers); mismatched {
+			log.Warningf("Not recording the response for DNS name %s as its answer section contains a record of DNS name %s off its CNAME chain",
+				qname, owner)
+			rejectedResponses.WithLabelValues(rejectReasonQNameMismatch).Inc()
+			return status, err
+		}
+	}
 	ipTTLs := make(map[string]int32)
-	for _, answer := range rw.Msg.Answer {
+	// lowestTTL gives the lowest TTL received in the considered DNS records.
+	lowestTTL := uint32(math.MaxUint32)
+	for _, answer := range records {
 		switch state.QType() {
 		case dns.TypeA:
-			if rec, ok := answer.(*dns.A); ok {
-				ttl := int32(rec.Hdr.Ttl)
-				if ttl == 0 {
-					ttl = resolver.minimumTTL
-				}
-				ipTTLs[rec.A.String()] = ttl
+			if rec, ok := answer.(*dns.A); ok && inCNAMEChain(chain, rec.Hdr.Name) {
+				addIPTTL(ipTTLs, rec.A, resolver.recordedTTL(qname, rec.Hdr.Ttl))
+				lowestTTL = min(lowestTTL, rec.Hdr.Ttl)
 			}
 		case dns.TypeAAAA:
-			if rec, ok := answer.(*dns.AAAA); ok {
-				ttl := int32(rec.Hdr.Ttl)
-				if ttl == 0 {
-					ttl = resolver.minimumTTL
-				}
-				ipTTLs[rec.AAAA.String()] = ttl
+			if rec, ok := answer.(*dns.AAAA); ok && inCNAMEChain(chain, rec.Hdr.Name) {
+				addIPTTL(ipTTLs, rec.AAAA, resolver.recordedTTL(qname, rec.Hdr.Ttl))
+				lowestTTL = min(lowestTTL, rec.Hdr.Ttl)
 			}
 		default:
 			return status, err
 		}
 	}
 
-	// Check if the DNS lookup is unsuccessful or an error is encountered during the lookup.
-	if status != dns.RcodeSuccess || err != nil {
+	// On a timeout of the upstream, answer from the IP addresses recorded in the status which
+	// are still usable, if serveStaleOnTimeout is enabled. The timeout is not counted as a
+	// failure, so that the status is kept.
+	if resolver.serveStaleOnTimeout && isTimeoutError(err) {
+		if stale := resolver.staleAnswer(ctx, r, state.QType(), regularDnsInfo, wildcardDnsInfo, time.Now()); stale != nil {
+			if writeErr := w.WriteMsg(stale); writeErr != nil {
+				return dns.RcodeServerFailure, writeErr
+			}
+			return dns.RcodeSuccess, nil
+		}
+		return status, err
+	}
+
+	// Check if the DNS lookup is unsuccessful or an error is encountered during the lookup. A
+	// failed DNS lookup which still obtained IP addresses of the CNAME chain, eg. with a failing
+	// intermediate hop, is recorded as a successful one, if partialChainPolicy is record-partial.
+	if (status != dns.RcodeSuccess || err != nil) && !resolver.recordPartialChain(qname, status, err, ipTTLs) {
+		// Log the failure, rate limited per DNS name.
+		resolver.logFailure(qname, status, err)
+		resolver.countLookupResult(lookupResultFailure, r, qname)
+
 		// WaitGroup variable used to wait for the completion of update of DNSNameResolver CRs
 		// corresponding to the regular and the wildcard DNS names.
 		var wg sync.WaitGroup
 
 		// If regular DNS name info exists then update the corresponding DNSNameResolver CR for
 		// the DNS lookup failure.
-		if regularDNSExists {
+		if len(regularDnsInfo) > 0 {
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
-				resolver.updateResolvedNamesFailure(ctx, regularDnsInfo, qname, status)
+				resolver.updateResolvedNamesFailure(ctx, regularDnsInfo, qname, state.QType(), status)
 			}()
 		}
 
 		// If wildcard DNS name info exists then update the corresponding DNSNameResolver CR for
 		// the DNS lookup failure.
-		if wildcardDNSExists {
+		if len(wildcardDnsInfo) > 0 {
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
-				resolver.updateResolvedNamesFailure(ctx, wildcardDnsInfo, qname, status)
+				resolver.updateResolvedNamesFailure(ctx, wildcardDnsInfo, qname, state.QType(), status)
 			}()
 		}
 
 		// Wait for the goroutines to complete.
 		wg.Wait()
 
+		// Record the NXDOMAIN response distinctly from the other failures, if recordNegative is enabled.
+		if resolver.recordNegative && status == dns.RcodeNameError && err == nil {
+			resolver.updateNegativeResults(ctx, regularDnsInfo, wildcardDnsInfo, qname, true)
+		}
+
+		// Record the extended DNS error of the response, if recordExtendedErrors is enabled.
+		if resolver.recordExtendedErrors {
+			if extended := extendedErrorOf(rw.Msg); extended != nil {
+				resolver.updateExtendedErrors(ctx, regularDnsInfo, wildcardDnsInfo, qname, extended)
+			}
+		}
+
 		// Return the response received from the plugin chain.
 		return status, err
 	}
 
+	// An answer with a low remaining TTL, eg. served by a cache plugin earlier in the plugin
+	// chain, is near expiry. Skip its recording, if minRemainingTTL is configured, and wait
+	// for a fresher answer.
+	if len(ipTTLs) > 0 && lowestTTL < resolver.minRemainingTTL {
+		log.Debugf("Not recording the response for DNS name %s as its remaining TTL %d is below the minimum %d",
+			qname, lowestTTL, resolver.minRemainingTTL)
+		return status, err
+	}
+
+	// Remove the IP addresses which are not in the allowed CIDRs, if allowedCIDRs is configured.
+	resolver.filterAllowedAddresses(ipTTLs, qname)
+
+	// Remove the IP addresses which are in the service CIDRs, if excludeServiceCIDR is enabled.
+	resolver.filterServiceAddresses(ipTTLs, qname)
+
+	// Only keep the IP addresses confirmed by a second DNS lookup, if doubleCheck is enabled.
+	if resolver.doubleCheck && len(ipTTLs) > 0 {
+		resolver.doubleCheckAddresses(ctx, r, qname, ipTTLs)
+	}
+
 	// If no IP address is Return the response received from the plugin chain.
 	if len(ipTTLs) == 0 {
 		return status, err
 	}
+	resolver.countLookupResult(lookupResultSuccess, r, qname)
+
+	// The DNS name is matched case-insensitively using the lowercased qname. The DNS name
+	// stored in a new resolved name entry is also lowercased, as required by the status. If
+	// preserveCase is enabled then the DNS name as received in the response is recorded in
+	// the original case annotation.
+	displayName := qname
+	if resolver.preserveCase {
+		displayName = responseQName(rw.Msg, state.QName())
+	}
+
+	// If accumulateWindow is configured then the IP addresses of the DNS name are accumulated
+	// across the DNS lookups within the window and recorded together once the window ends.
+	if resolver.accumulateWindow > 0 {
+		resolver.accumulateAddresses(qname, regularDnsInfo, wildcardDnsInfo, displayName, ipTTLs)
+		return status, err
+	}
+
+	resolver.recordSuccess(ctx, regularDnsInfo, wildcardDnsInfo, qname, displayName, ipTTLs)
+
+	// Return the response received from the plugin chain.
+	return status, err
+}
+
+// matchResolverObjects returns the DNSNameResolver objects matching the DNS name,
+// corresponding to the regular and the wildcard DNS names, according to the configured
+// policies.
+func (resolver *OCPDNSNameResolver) matchResolverObjects(qname string) (regularDnsInfo namespaceDNSInfo, wildcardDnsInfo namespaceDNSInfo) {
+	// Check if the query was for a wildcard DNS name or a regular DNS name.
+	if isWildcard(qname) {
+		// Get the wildcard DNS name info, if it exists.
+		wildcardDnsInfo, _ = resolver.lookupWildcard(qname)
+	} else {
+		// Get the regular DNS name info, if it exists.
+		regularDnsInfo, _ = resolver.lookupRegular(qname)
+
+		// Get the corresponding wildcard DNS name for the reguar DNS name.
+		wildcard := getWildcard(qname)
+		// Get the wildcard DNS name info, if it exists.
+		wildcardDnsInfo, _ = resolver.lookupWildcard(wildcard)
+		// Add the wildcard DNS names of the ancestor domains, if wildcardSpecificity is set to all.
+		wildcardDnsInfo = resolver.lookupAncestorWildcards(qname, wildcardDnsInfo)
+
+		// Filter the namespaces which have both a regular and a wildcard DNSNameResolver
+		// object matching the DNS name according to the configured overlap policy.
+		regularDnsInfo, wildcardDnsInfo = applyOverlapPolicy(resolver.overlapPolicy, regularDnsInfo, wildcardDnsInfo)
+	}
+
+	// Filter the namespaces of the wildcard DNSNameResolver objects matching the DNS name
+	// according to the configured wildcard namespace scope.
+	wildcardDnsInfo = applyWildcardNamespaceScope(resolver.wildcardNamespaceScope, wildcardDnsInfo)
 
+	// Add the DNSNameResolver objects whose regular expressions match the DNS name, if regexMatch
+	// is enabled. They are handled like the wildcard DNSNameResolver objects matching the DNS name.
+	if resolver.regexMatch {
+		wildcardDnsInfo = addRegexMatches(resolver.matchRegex(qname), regularDnsInfo, wildcardDnsInfo)
+	}
+
+	// Filter the namespaces of the DNSNameResolver objects matching the DNS name according
+	// to the configured multi namespace policy.
+	if resolver.multiNamespacePolicy != multiNamespacePolicyAll {
+		regularDnsInfo, wildcardDnsInfo = applyMultiNamespacePolicy(resolver.multiNamespacePolicy, resolver.namespacePriority,
+			regularDnsInfo, wildcardDnsInfo)
+	}
+
+	return regularDnsInfo, wildcardDnsInfo
+}
+
+// recordSuccess updates the DNSNameResolver objects corresponding to the regular and
+// the wildcard DNS names with the IP addresses of the successful DNS lookup.
+func (resolver *OCPDNSNameResolver) recordSuccess(
+	ctx context.Context,
+	regularDnsInfo namespaceDNSInfo,
+	wildcardDnsInfo namespaceDNSInfo,
+	dnsName string,
+	displayName string,
+	ipTTLs map[string]int32,
+) {
 	// WaitGroup variable used to wait for the completion of update of DNSNameResolver CRs
 	// corresponding to the regular and the wildcard DNS names.
 	var wg sync.WaitGroup
 
 	// If regular DNS name info exists then update the corresponding DNSNameResolver CR for
 	// the successful DNS lookup.
-	if regularDNSExists {
+	if len(regularDnsInfo) > 0 {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			resolver.updateResolvedNamesSuccess(ctx, regularDnsInfo, qname, ipTTLs)
+			resolver.updateResolvedNamesSuccess(ctx, regularDnsInfo, dnsName, displayName, ipTTLs)
 
 		}()
 	}
 
 	// If wildcard DNS name info exists then update the corresponding DNSNameResolver CR for
 	// the successful DNS lookup.
-	if wildcardDNSExists {
+	if len(wildcardDnsInfo) > 0 {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			resolver.updateResolvedNamesSuccess(ctx, wildcardDnsInfo, qname, ipTTLs)
+			resolver.updateResolvedNamesSuccess(ctx, wildcardDnsInfo, dnsName, displayName, ipTTLs)
 		}()
 	}
 
 	// Wait for the goroutines to complete.
 	wg.Wait()
 
-	// Return the response received from the plugin chain.
-	return status, err
+	// Record the regular DNS name as a child of the matching wildcard DNSNameResolver objects,
+	// if recordWildcardChildren is enabled.
+	if resolver.recordWildcardChildren && !isWildcard(dnsName) {
+		resolver.updateWildcardChildren(ctx, wildcardDnsInfo, dnsName)
+	}
+
+	// The DNS name is resolved, thus drop its accumulated weighted failures.
+	resolver.resetFailures(regularDnsInfo, dnsName)
+	resolver.resetFailures(wildcardDnsInfo, dnsName)
+	resolver.resetFamilyFailures(regularDnsInfo, dnsName, ipTTLs)
+	resolver.resetFamilyFailures(wildcardDnsInfo, dnsName, ipTTLs)
+	resolver.resetBlockedFailures(regularDnsInfo, dnsName)
+	resolver.resetBlockedFailures(wildcardDnsInfo, dnsName)
+
+	// The DNS name is resolved, thus remove the negative result of the DNS name, if recordNegative is enabled.
+	if resolver.recordNegative {
+		resolver.updateNegativeResults(ctx, regularDnsInfo, wildcardDnsInfo, dnsName, false)
+	}
+
+	// The DNS name is resolved, thus remove the extended error of the DNS name, if recordExtendedErrors is enabled.
+	if resolver.recordExtendedErrors {
+		resolver.updateExtendedErrors(ctx, regularDnsInfo, wildcardDnsInfo, dnsName, nil)
+	}
 }
 
-// Name implements the Handler interface.
+// Name implements the Handler interface. The name is the same as the name of the
+// directive with which the plugin is registered.
 func (resolver *OCPDNSNameResolver) Name() string { return pluginName }
 
+var _ plugin.Handler = &OCPDNSNameResolver{}
+
+// responseQName returns the DNS name from the question section of the response, if it matches
+// the DNS name of the request case-insensitively. Otherwise, the DNS name of the request is returned.
+func responseQName(msg *dns.Msg, requestQName string) string {
+	if msg != nil && len(msg.Question) > 0 && strings.EqualFold(msg.Question[0].Name, requestQName) {
+		return msg.Question[0].Name
+	}
+	return requestQName
+}
+
 // updateResolvedNamesSuccess updates the ResolvedNames field of the corresponding DNSNameResolver object when DNS lookup is successfully completed.
+// The dnsName is used for matching and adding the resolved names, whereas the displayName is recorded in the original case
+// annotation for a new resolved name entry.
 func (resolver *OCPDNSNameResolver) updateResolvedNamesSuccess(
 	ctx context.Context,
 	namespaceDNS namespaceDNSInfo,
 	dnsName string,
+	displayName string,
 	ipTTLs map[string]int32,
 ) {
-	// WaitGroup variable used to wait for the completion of update of DNSNameResolver CRs
-	// for the same DNS name in different namespaces.
-	var wg sync.WaitGroup
-
-	// Iterate through the namespaces and the corresponding DNSNameResolver object names.
-	for namespace, objName := range namespaceDNS {
-		wg.Add(1)
+	// Iterate through the namespaces and the corresponding DNSNameResolver object names,
+	// in the order of fanOutOrder, and wait for the completion of the updates.
+	resolver.fanOut(namespaceDNS, func(namespace string, objName string) {
+		// Wait for the next update slot in the namespace, if namespacePacing is configured.
+		if !resolver.paceUpdate(ctx, namespace) {
+			log.Debugf("Dropping status update of DNSNameResolver object %s/%s as its context is done", namespace, objName)
+			return
+		}
 
-		// Each update is performed in separate goroutine.
-		go func(namespace string, objName string) {
-			defer wg.Done()
+		// Retry the update of the DNSNameResolver object if there's a conflict or a transient error during the update.
+		err := resolver.retrier.retryUpdate(namespace, objName, "status", func() error {
+			// Fetch a copy of the DNSNameResolver object. All the updates will be applied to the copied object.
+			newResolverObj, err := resolver.store.get(ctx, namespace, objName)
+			if err != nil {
+				return err
+			}
 
-			// Retry the update of the DNSNameResolver object if there's a conflict during the update.
-			retryErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-				// Fetch the DNSNameResolver object.
-				resolverObj, err := ocpnetworkv1alpha1lister.NewDNSNameResolverLister(
-					resolver.dnsNameResolverInformer.GetIndexer()).DNSNameResolvers(namespace).Get(objName)
-				if err != nil {
-					return err
-				}
+			// Skip the update if the status updates of the DNSNameResolver object are paused.
+			if isPaused(newResolverObj) {
+				log.Debugf("Skipping status update of paused DNSNameResolver object %s/%s", namespace, objName)
+				return nil
+			}
 
-				// Make a copy of the object. All the updates will be applied to the copied object.
-				newResolverObj := resolverObj.DeepCopy()
-				// Get the DNS name from the spec.name field.
-				specDNSName := string(newResolverObj.Spec.Name)
-				// Get the current time.
-				currentTime := metav1.NewTime(time.Now())
-
-				// existingIndex gives the index of the of the resolved name corresponding to the
-				// DNS name, which is currently being looked up, if it exists.
-				var existingIndex int
-				// foundResolvedName indicates whether the resolved name corresponding to the DNS name,
-				// which is currently being looked up, was found or not.
-				foundResolvedName := false
-				// matchedWildcard indicates whether the current regular DNS name being looked up
-				// completely matches the resolved name entry of the wildcard DNS name corresponding
-				// to the DNSNameResolver object. For the match to succeed, the IP addresses associated
-				// with the regular DNS name should be contained in the list of IP addresses present
-				// in the resolved name entry of the wildcard DNS name. If the current DNS name being
-				// looked up is regular or the DNSNameResolver object corresponds to a regular DNS
-				// name then the value of matchedWildcard will be false.
-				matchedWildcard := false
-				// statusUpdated indicates whether the status of the DNSNameResolver object should
-				// be updated or not.
-				statusUpdated := false
-				// indicesMatchingWildcard contains the existing resolved name entries of the regular
-				// DNS names completely matching that of the wildcard DNS name's resolved name entry.
-				// This map will contain the indices only when the DNSNameResolver object is for a
-				// wildcard DNS name and the DNS name lookup is also for the same DNS name.
-				indicesMatchingWildcard := []int{}
-
-				// Iterate through each resolved name present in the status of the DNSNameResolver object.
-				//
-				// NOTE: The resolved name for a wildcard DNS name, if it exists, will always be the first one in the list of
-				// resolved names in the status of the DNSNameResolver object corresponding to the wildcard DNS name.
-				for index, resolvedName := range newResolverObj.Status.ResolvedNames {
-					if isWildcard(specDNSName) && !isWildcard(dnsName) && strings.EqualFold(string(resolvedName.DNSName), specDNSName) {
-						// Case 1: When the DNSNameResolver object is for a wildcard DNS name, the lookup is for a regular DNS name
-						// which matches the wildcard DNS name, and the current resolved name is for the wildcard DNS name.
-
-						// Check if the regular DNS name completely matches the resolved name entry of the wildcard DNS name.
-						// The regular DNS name will completely match the wildcard DNS name if all the IP addresses that are received
-						// in the response of the DNS name lookup already exists in the wildcard DNS name's resolved name field, the
-						// corresponding next lookup time of the IP addresses also matches.
-						matchedWildcard = isMatchingResolvedName(ipTTLs, resolvedName)
-					} else if strings.EqualFold(string(resolvedName.DNSName), dnsName) {
-						// Case 2: When the DNS name which is being resolved matches the current resolved name. This is applicable
-						// for DNSNameResolver objects for both the regular and wildcard DNS names.
-
-						// If matchedWildcard is set to true, then the DNS lookup is for a regular DNS name and the DNSNameResolver
-						// object is corresponding to a wildcard DNS name. The IP addresses that are received in the response of the
-						// DNS name lookup already exists in the wildcard DNS name's resolved name field. However, as the regular
-						// DNS name's resolved name also exists, it means that some of the existing IP addresses associated with the
-						// regular DNS name do not match with the IP addresses associated with the wildcard DNS name. Thus,
-						// matchedWildcard is set to false.
-						matchedWildcard = false
-
-						// As the resolved name for the DNS name being looked up is found, set foundResolvedName to true.
-						foundResolvedName = true
-						// Set existingIndex to the current value of the index variable to indicate the index at which the
-						// resolved name corresponding to the DNS name exists.
-						existingIndex = index
-
-						// If any of the IP address already exists, it's corresponding TTL and last lookup time will be updated if
-						// the next lookup time (TTL + last lookup time) has changed.
-						//
-						// The IP addresses which do not already exist, will be added to the existing resolvedAddresses list.
-						//
-						// The resolutionFailures field will be set to zero. If the conditions field is not set or if the existing
-						// status of the "Degraded" condition is not false, then the status of the condition will be set to false,
-						// reason and message will be set to corresponding to that of success rcode.
-						statusUpdated = addUpdateResolvedNameIPTTLs(index, ipTTLs, currentTime, newResolverObj)
-					} else if isWildcard(dnsName) {
-						// Case 3: When the DNSNameResolver object is for a wildcard DNS name, the lookup is also for the wildcard DNS name,
-						// and the current resolved name is for a regular DNS name which matches the wildcard DNS name.
-
-						// Check if the resolved name for the regular DNS name completely matches the wildcard DNS name corresponding to the
-						// DNSNameResolver object, along with the IP addresses. If it matches then add the index of the resolved name entry
-						// of the regular DNS name to the indicesMatchingWildcard map.
-						if isRegularMatchingWildcardResolvedName(foundResolvedName, newResolverObj, resolvedName, ipTTLs, currentTime) {
-							indicesMatchingWildcard = append(indicesMatchingWildcard, index)
+			// Snapshot the IP addresses recorded before the update, for metering the changes.
+			previousAddresses := recordedAddresses(newResolverObj)
+
+			// Get the DNS name from the spec.name field.
+			specDNSName := string(newResolverObj.Spec.Name)
+			// Get the current time.
+			currentTime := metav1.NewTime(time.Now())
+
+			// existingIndex gives the index of the of the resolved name corresponding to the
+			// DNS name, which is currently being looked up, if it exists.
+			var existingIndex int
+			// foundResolvedName indicates whether the resolved name corresponding to the DNS name,
+			// which is currently being looked up, was found or not.
+			foundResolvedName := false
+			// matchedWildcard indicates whether the current regular DNS name being looked up
+			// completely matches the resolved name entry of the wildcard DNS name corresponding
+			// to the DNSNameResolver object. For the match to succeed, the IP addresses associated
+			// with the regular DNS name should be contained in the list of IP addresses present
+			// in the resolved name entry of the wildcard DNS name. If the current DNS name being
+			// looked up is regular or the DNSNameResolver object corresponds to a regular DNS
+			// name then the value of matchedWildcard will be false.
+			matchedWildcard := false
+			// statusUpdated indicates whether the status of the DNSNameResolver object should
+			// be updated or not.
+			statusUpdated := false
+			// addedResolvedName indicates whether a new resolved name entry is added for the DNS name.
+			addedResolvedName := false
+			// indicesMatchingWildcard contains the existing resolved name entries of the regular
+			// DNS names completely matching that of the wildcard DNS name's resolved name entry.
+			// This map will contain the indices only when the DNSNameResolver object is for a
+			// wildcard DNS name and the DNS name lookup is also for the same DNS name.
+			indicesMatchingWildcard := []int{}
+
+			// Iterate through each resolved name present in the status of the DNSNameResolver object.
+			//
+			// NOTE: The resolved name for a wildcard DNS name, if it exists, will always be the first one in the list of
+			// resolved names in the status of the DNSNameResolver object corresponding to the wildcard DNS name.
+			for index, resolvedName := range newResolverObj.Status.ResolvedNames {
+				if isWildcard(specDNSName) && !isWildcard(dnsName) && strings.EqualFold(string(resolvedName.DNSName), specDNSName) {
+					// Case 1: When the DNSNameResolver object is for a wildcard DNS name, the lookup is for a regular DNS name
+					// which matches the wildcard DNS name, and the current resolved name is for the wildcard DNS name.
+
+					// Check if the regular DNS name completely matches the resolved name entry of the wildcard DNS name.
+					// The regular DNS name will completely match the wildcard DNS name if all the IP addresses that are received
+					// in the response of the DNS name lookup already exists in the wildcard DNS name's resolved name field, the
+					// corresponding next lookup time of the IP addresses also matches.
+					matchedWildcard = isMatchingResolvedName(ipTTLs, resolvedName, resolver.lookupTimeMargin())
+				} else if strings.EqualFold(string(resolvedName.DNSName), dnsName) {
+					// Case 2: When the DNS name which is being resolved matches the current resolved name. This is applicable
+					// for DNSNameResolver objects for both the regular and wildcard DNS names.
+
+					// If matchedWildcard is set to true, then the DNS lookup is for a regular DNS name and the DNSNameResolver
+					// object is corresponding to a wildcard DNS name. The IP addresses that are received in the response of the
+					// DNS name lookup already exists in the wildcard DNS name's resolved name field. However, as the regular
+					// DNS name's resolved name also exists, it means that some of the existing IP addresses associated with the
+					// regular DNS name do not match with the IP addresses associated with the wildcard DNS name. Thus,
+					// matchedWildcard is set to false.
+					matchedWildcard = false
+
+					// As the resolved name for the DNS name being looked up is found, set foundResolvedName to true.
+					foundResolvedName = true
+					// Set existingIndex to the current value of the index variable to indicate the index at which the
+					// resolved name corresponding to the DNS name exists.
+					existingIndex = index
+
+					// If any of the IP address already exists, it's corresponding TTL and last lookup time will be updated if
+					// the next lookup time (TTL + last lookup time) has changed.
+					//
+					// The IP addresses which do not already exist, will be added to the existing resolvedAddresses list.
+					//
+					// The resolutionFailures field will be set to zero. If the conditions field is not set or if the existing
+					// status of the "Degraded" condition is not false, then the status of the condition will be set to false,
+					// reason and message will be set to corresponding to that of success rcode.
+					//
+					// If maxRecordAge is configured and the DNS lookup only changes the TTLs and the last lookup
+					// times of the existing IP addresses, the resolved name is only updated once its last write
+					// is older than maxRecordAge. The update then refreshes all the IP addresses of the DNS lookup.
+					//
+					// If mergeWindow is configured, the next lookup times recorded by the other writers within the
+					// window are never shortened.
+					lookupIPTTLs := resolver.mergeIPTTLs(resolvedName, ipTTLs, currentTime.Time)
+					if resolver.maxRecordAge > 0 && isUnchangedResolvedName(resolvedName, lookupIPTTLs) {
+						if currentTime.Sub(lastWriteTime(resolvedName)) >= resolver.maxRecordAge {
+							statusUpdated = refreshResolvedNameIPTTLs(index, lookupIPTTLs, currentTime, newResolverObj)
 						}
+					} else {
+						statusUpdated = addUpdateResolvedNameIPTTLs(index, lookupIPTTLs, currentTime, newResolverObj, resolver.lookupTimeMargin())
 					}
-
-					// Skip all the remaining resolved names, if the DNS lookup is for a regular DNS name, the DNSNameResolver object
-					// is corresponding to a wildcard DNS name, the regular DNS name's resolved name field is already found, and the
-					// check for the complete match of the regular DNS name with the wildcard DNS name has already been performed.
-					if !isWildcard(dnsName) && isWildcard(specDNSName) && foundResolvedName && index > 0 {
-						break
+					// Clear the Blocked condition of the resolved name, once the DNS name is resolved again.
+					if unblockResolvedName(index, newResolverObj) {
+						statusUpdated = true
+					}
+				} else if isWildcard(dnsName) {
+					// Case 3: When the DNSNameResolver object is for a wildcard DNS name, the lookup is also for the wildcard DNS name,
+					// and the current resolved name is for a regular DNS name which matches the wildcard DNS name.
+
+					// Check if the resolved name for the regular DNS name completely matches the wildcard DNS name corresponding to the
+					// DNSNameResolver object, along with the IP addresses. If it matches then add the index of the resolved name entry
+					// of the regular DNS name to the indicesMatchingWildcard map.
+					if isRegularMatchingWildcardResolvedName(foundResolvedName, newResolverObj, resolvedName, ipTTLs, currentTime, resolver.lookupTimeMargin()) {
+						indicesMatchingWildcard = append(indicesMatchingWildcard, index)
 					}
 				}
 
-				// If the DNS lookup is for a wildcard DNS name, then remove the existing resolved name entries of the regular DNS names
-				// completely matching that of the wildcard DNS name's resolved name entry.
-				if isWildcard(dnsName) {
-					isRemoved := removeResolvedNames(indicesMatchingWildcard, newResolverObj)
-					statusUpdated = statusUpdated || isRemoved
+				// Skip all the remaining resolved names, if the DNS lookup is for a regular DNS name, the DNSNameResolver object
+				// is corresponding to a wildcard DNS name, the regular DNS name's resolved name field is already found, and the
+				// check for the complete match of the regular DNS name with the wildcard DNS name has already been performed.
+				if !isWildcard(dnsName) && isWildcard(specDNSName) && foundResolvedName && index > 0 {
+					break
 				}
+			}
 
-				if !isWildcard(dnsName) && matchedWildcard {
-					// Remove the regular DNS name's resolved name entry which completely matches that of the wildcard DNS name's resolved name.
+			// If the DNS lookup is for a wildcard DNS name, then remove the existing resolved name entries of the regular DNS names
+			// completely matching that of the wildcard DNS name's resolved name entry.
+			if isWildcard(dnsName) {
+				isRemoved := removeResolvedNames(indicesMatchingWildcard, newResolverObj)
+				statusUpdated = statusUpdated || isRemoved
+			}
 
-					indexList := []int{}
-					// Add the index of the regular DNS name's resolved name entry to the indexList, if it is found.
-					if foundResolvedName {
-						indexList = append(indexList, existingIndex)
-					}
-					isRemoved := removeResolvedNames(indexList, newResolverObj)
-					statusUpdated = statusUpdated || isRemoved
-				} else if !foundResolvedName {
-					// Add the resolved name entry for the DNS name (applies to both regular and wildcard DNS names) if the entry is not found.
-					addResolvedName(dnsName, currentTime, ipTTLs, newResolverObj)
-					statusUpdated = true
-				}
+			if !isWildcard(dnsName) && matchedWildcard {
+				// Remove the regular DNS name's resolved name entry which completely matches that of the wildcard DNS name's resolved name.
 
-				// If there are no changes to the status of the DNSNameResolver object then skip the update status call.
-				if !statusUpdated {
-					return nil
+				indexList := []int{}
+				// Add the index of the regular DNS name's resolved name entry to the indexList, if it is found.
+				if foundResolvedName {
+					indexList = append(indexList, existingIndex)
 				}
+				isRemoved := removeResolvedNames(indexList, newResolverObj)
+				statusUpdated = statusUpdated || isRemoved
+			} else if !foundResolvedName {
+				// Add the resolved name entry for the DNS name (applies to both regular and wildcard DNS names) if the entry is not found.
+				addResolvedName(dnsName, currentTime, ipTTLs, newResolverObj)
+				statusUpdated = true
+				addedResolvedName = true
+			}
+
+			// Order the IP addresses of the resolved names according to the configured address order.
+			if resolver.orderResolvedAddresses(newResolverObj) {
+				statusUpdated = true
+			}
+
+			// Record the generation of the DNSNameResolver object the status is written for.
+			if resolver.updateObservedGeneration(newResolverObj) {
+				statusUpdated = true
+			}
 
-				// Update the status of the DNSNameResolver object.
-				_, err = resolver.ocpNetworkClient.DNSNameResolvers(namespace).UpdateStatus(ctx, newResolverObj, metav1.UpdateOptions{})
+			// If there are no changes to the status of the DNSNameResolver object then skip the update status call.
+			if !statusUpdated {
+				return nil
+			}
+
+			// Update the status of the DNSNameResolver object, truncating the IP addresses of the
+			// DNS name if the object is too large.
+			err = resolver.updateStatusTruncating(ctx, newResolverObj, dnsName)
+			resolver.notifyStatusUpdated(newResolverObj, dnsName, err)
+			if err != nil {
 				return err
-			})
-			if retryErr != nil {
-				log.Errorf("Encountered error while updating status of DNSNameResolver object: %v", retryErr)
 			}
-		}(namespace, objName)
-	}
+			resolver.auditStatusWrite(newResolverObj, dnsName)
+			recordAddressChanges(newResolverObj, dnsName, previousAddresses)
+			resolver.updateWriterAnnotation(ctx, newResolverObj)
+			resolver.updateUpstreamAnnotation(ctx, newResolverObj)
+			if addedResolvedName {
+				resolver.updateOriginalCase(ctx, newResolverObj, dnsName, displayName)
+			}
+			return nil
+		})
 
-	// Wait for the goroutines for each namespace to complete.
-	wg.Wait()
+		// Track the forbidden update for a later retry, if retryForbidden is enabled.
+		resolver.trackForbidden(namespace, objName, dnsName, err)
+	})
+}
+
+// addIPTTL adds the IP address and the corresponding TTL to the ipTTLs map. The IP address
+// is converted to its canonical form (IPv4-mapped IPv6 addresses are converted to IPv4
+// addresses and IPv6 addresses are compressed and lowercased) so that the same IP address
+// is never recorded twice. If the IP address already exists in the map, which happens when
+// the same IP address appears multiple times in the answer, the lower TTL is kept.
+func addIPTTL(ipTTLs map[string]int32, ip net.IP, ttl int32) {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return
+	}
+	key := addr.Unmap().String()
+	if existingTTL, exists := ipTTLs[key]; exists && existingTTL <= ttl {
+		return
+	}
+	ipTTLs[key] = ttl
 }
 
 // isMatchingResolvedName checks if all the IP addresses in the ipTTLs map are contained
 // in the resolved addresses of the resolved name and the corresponding next lookup times of
-// IP addresses also match.
+// IP addresses also match, within the margin.
 func isMatchingResolvedName(
 	ipTTLs map[string]int32,
 	resolvedName ocpnetworkapiv1alpha1.DNSNameResolverResolvedName,
+	margin time.Duration,
 ) bool {
 
 	matchedIPTTLs := sets.New[string]()
@@ -360,7 +731,7 @@ func isMatchingResolvedName(
 			matched = false
 			break
 		}
-		if !isSameNextLookupTime(resolvedAddress.LastLookupTime.Time, resolvedAddress.TTLSeconds, ttl) {
+		if !isSameNextLookupTime(resolvedAddress.LastLookupTime.Time, resolvedAddress.TTLSeconds, ttl, margin) {
 			matched = false
 			break
 		}
@@ -374,12 +745,13 @@ func isMatchingResolvedName(
 
 // addUpdateResolvedNameIPTTLs adds the IP addresses to the resolved name's resolved addresses which currently does not exist
 // in the resolved addresses. If an IP address already exists but the corresponding next lookup time of the IP address has
-// changed then it updates the TTL of the IP address.
+// changed beyond the margin then it updates the TTL of the IP address.
 func addUpdateResolvedNameIPTTLs(
 	index int,
 	ipTTLs map[string]int32,
 	currentTime metav1.Time,
 	resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver,
+	margin time.Duration,
 ) bool {
 
 	matchedIPTTLs := sets.New[string]()
@@ -389,7 +761,7 @@ func addUpdateResolvedNameIPTTLs(
 	// lookup if the next lookup time has changed.
 	for i, resolvedAddress := range resolverObj.Status.ResolvedNames[index].ResolvedAddresses {
 		if ttl, matched := ipTTLs[resolvedAddress.IP]; matched {
-			if !isSameNextLookupTime(resolvedAddress.LastLookupTime.Time, resolvedAddress.TTLSeconds, ttl) {
+			if !isSameNextLookupTime(resolvedAddress.LastLookupTime.Time, resolvedAddress.TTLSeconds, ttl, margin) {
 				resolverObj.Status.ResolvedNames[index].ResolvedAddresses[i].TTLSeconds = ttl
 				resolverObj.Status.ResolvedNames[index].ResolvedAddresses[i].LastLookupTime = currentTime.DeepCopy()
 				statusUpdated = true
@@ -449,6 +821,7 @@ func isRegularMatchingWildcardResolvedName(
 	resolvedName ocpnetworkapiv1alpha1.DNSNameResolverResolvedName,
 	ipTTLs map[string]int32,
 	currentTime metav1.Time,
+	margin time.Duration,
 ) bool {
 	wildcardIPTTLs := make(map[string]int32)
 
@@ -476,7 +849,7 @@ func isRegularMatchingWildcardResolvedName(
 			addIndex = false
 			break
 		}
-		if !isSameNextLookupTime(resolvedAddress.LastLookupTime.Time, resolvedAddress.TTLSeconds, ttl) {
+		if !isSameNextLookupTime(resolvedAddress.LastLookupTime.Time, resolvedAddress.TTLSeconds, ttl, margin) {
 			addIndex = false
 			break
 		}
@@ -547,103 +920,148 @@ func addResolvedName(
 }
 
 // updateResolvedNamesFailure updates the ResolvedNames field of the corresponding DNSNameResolver object.
-func (resolver *OCPDNSNameResolver) updateResolvedNamesFailure(ctx context.Context, namespaceDNS namespaceDNSInfo, dnsName string, rcode int) {
-	// WaitGroup variable used to wait for the completion of update of DNSNameResolver CRs
-	// for the same DNS name in different namespaces.
-	var wg sync.WaitGroup
-
-	// Iterate through the namespaces and the corresponding DNSNameResolver object names.
-	for namespace, objName := range namespaceDNS {
-		wg.Add(1)
+func (resolver *OCPDNSNameResolver) updateResolvedNamesFailure(ctx context.Context, namespaceDNS namespaceDNSInfo, dnsName string, qtype uint16, rcode int) {
+	// Iterate through the namespaces and the corresponding DNSNameResolver object names,
+	// in the order of fanOutOrder, and wait for the completion of the updates.
+	resolver.fanOut(namespaceDNS, func(namespace string, objName string) {
+		// Wait for the next update slot in the namespace, if namespacePacing is configured.
+		if !resolver.paceUpdate(ctx, namespace) {
+			log.Debugf("Dropping status update of DNSNameResolver object %s/%s as its context is done", namespace, objName)
+			return
+		}
 
-		// Each update is performed in separate goroutine.
-		go func(namespace string, objName string) {
-			defer wg.Done()
+		// Get the number of failures to count for the failure, outside of the retries of the update.
+		increment := resolver.failureIncrement(namespace, objName, dnsName, rcode)
+		// Count the failure for the address family of the query type, if its failure threshold is configured.
+		family, familyReached := resolver.familyThresholdReached(namespace, objName, dnsName, qtype, increment)
+		// Count the failure of the blocked DNS name until it is written to the status, if refusedIsBlock is enabled.
+		blockedKey := failureKey{object: types.NamespacedName{Namespace: namespace, Name: objName}, dnsName: dnsName}
+		blockedFailures := int32(0)
+		if resolver.blocksDNSName(rcode) {
+			blockedFailures = resolver.blockedFailures.add(blockedKey, increment)
+		}
 
-			// Retry the update of the DNSNameResolver object if there's a conflict during the update.
-			retryErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-				// Fetch the DNSNameResolver object.
-				resolverObj, err := ocpnetworkv1alpha1lister.NewDNSNameResolverLister(resolver.dnsNameResolverInformer.GetIndexer()).DNSNameResolvers(namespace).Get(objName)
-				if err != nil {
-					return err
-				}
+		// Retry the update of the DNSNameResolver object if there's a conflict or a transient error during the update.
+		err := resolver.retrier.retryUpdate(namespace, objName, "status", func() error {
+			// Fetch a copy of the DNSNameResolver object. All the updates will be applied to the copied object.
+			newResolverObj, err := resolver.store.get(ctx, namespace, objName)
+			if err != nil {
+				return err
+			}
 
-				// Make a copy of the object. All the updates will be applied to the copied object.
-				newResolverObj := resolverObj.DeepCopy()
-				// Get the current time.
-				currentTime := metav1.NewTime(time.Now())
-
-				// existingIndex gives the index of the of the resolved name corresponding to the
-				// DNS name, which is currently being looked up, if it exists.
-				var existingIndex int
-				// foundResolvedName indicates whether the resolved name corresponding to the DNS name,
-				// which is currently being looked up, was found or not.
-				foundResolvedName := false
-				// removeResolvedName indicates whether the resolved name entry corresponding to the
-				// DNS name being looked up needs to be removed or not. The value of removeResolvedName
-				// will be true if the value of resolutionFailures of the resolved name is greater than
-				// equal to the configured failure threshold, otherwise the value of removeResolvedName
-				// will be false.
-				removeResolvedName := false
-				// statusUpdated indicates whether the status of the DNSNameResolver object should
-				// be updated or not.
-				statusUpdated := false
-
-				// Iterate through each resolved name present in the status of the DNSNameResolver object.
-				for index, resolvedName := range newResolverObj.Status.ResolvedNames {
-
-					// Check if the DNS name which is being resolved matches the current resolved name.
-					if strings.EqualFold(string(resolvedName.DNSName), dnsName) {
-
-						// As the resolved name for the DNS name being looked up is found, set foundResolvedName to true.
-						foundResolvedName = true
-						// Set existingIndex to the current value of the index variable to indicate the index at which the
-						// resolved name corresponding to the DNS name exists.
-						existingIndex = index
+			// Skip the update if the status updates of the DNSNameResolver object are paused.
+			if isPaused(newResolverObj) {
+				log.Debugf("Skipping status update of paused DNSNameResolver object %s/%s", namespace, objName)
+				return nil
+			}
 
+			// Snapshot the IP addresses recorded before the update, for metering the changes.
+			previousAddresses := recordedAddresses(newResolverObj)
+
+			// Get the current time.
+			currentTime := metav1.NewTime(time.Now())
+
+			// existingIndex gives the index of the of the resolved name corresponding to the
+			// DNS name, which is currently being looked up, if it exists.
+			var existingIndex int
+			// foundResolvedName indicates whether the resolved name corresponding to the DNS name,
+			// which is currently being looked up, was found or not.
+			foundResolvedName := false
+			// removeResolvedName indicates whether the resolved name entry corresponding to the
+			// DNS name being looked up needs to be removed or not. The value of removeResolvedName
+			// will be true if the value of resolutionFailures of the resolved name is greater than
+			// equal to the configured failure threshold, otherwise the value of removeResolvedName
+			// will be false.
+			removeResolvedName := false
+			// statusUpdated indicates whether the status of the DNSNameResolver object should
+			// be updated or not.
+			statusUpdated := false
+			// blockedWritten indicates whether the failures of the blocked DNS name are written
+			// to the status.
+			blockedWritten := false
+
+			// Iterate through each resolved name present in the status of the DNSNameResolver object.
+			for index, resolvedName := range newResolverObj.Status.ResolvedNames {
+
+				// Check if the DNS name which is being resolved matches the current resolved name.
+				if strings.EqualFold(string(resolvedName.DNSName), dnsName) {
+
+					// As the resolved name for the DNS name being looked up is found, set foundResolvedName to true.
+					foundResolvedName = true
+					// Set existingIndex to the current value of the index variable to indicate the index at which the
+					// resolved name corresponding to the DNS name exists.
+					existingIndex = index
+
+					// Remove the expired IP addresses of the address family whose failure threshold is reached,
+					// leaving the IP addresses of the other family. The resolved name is removed once no IP
+					// address is left.
+					familyCleared := familyReached &&
+						removeExpiredFamilyAddresses(&newResolverObj.Status.ResolvedNames[index], family, currentTime.Time)
+					if resolver.blocksDNSName(rcode) {
+						// Clear the IP addresses of the refused DNS name right away, if refusedIsBlock is enabled,
+						// and remove its resolved name once the failure threshold is reached.
+						removeResolvedName, statusUpdated =
+							blockResolvedName(index, newResolverObj, currentTime, resolver.failureThreshold, rcode, blockedFailures)
+						blockedWritten = statusUpdated
+					} else if familyCleared && len(newResolverObj.Status.ResolvedNames[index].ResolvedAddresses) == 0 {
+						removeResolvedName = true
+					} else {
 						// Check whether the resolved name for the DNS name needs to be removed or not. If not, then update
 						// the resolved name entry to reflect the failure in DNS resolution.
 						removeResolvedName, statusUpdated =
-							checkAndUpdateResolvedName(index, newResolverObj, currentTime, resolver.failureThreshold, resolver.minimumTTL, rcode)
-					}
-
-					// Skip all the remaining resolved names, if the DNS name's resolved name is already found.
-					if foundResolvedName {
-						break
+							checkAndUpdateResolvedName(index, newResolverObj, currentTime, resolver.failureThreshold, resolver.failureTTLOf(dnsName), rcode, increment)
 					}
+					statusUpdated = statusUpdated || familyCleared
 				}
 
-				if !foundResolvedName {
-					// If the resolved name entry is not found then no update operation is required.
-					return nil
-				} else if removeResolvedName {
-					// Remove the resolved name entry if the resolutionFailures field's value is greater than or equal
-					// to the failure threshold.
-					newResolverObj.Status.ResolvedNames = append(newResolverObj.Status.ResolvedNames[:existingIndex], newResolverObj.Status.ResolvedNames[existingIndex+1:]...)
-					statusUpdated = true
+				// Skip all the remaining resolved names, if the DNS name's resolved name is already found.
+				if foundResolvedName {
+					break
 				}
+			}
 
-				// If there are no changes to the status of the DNSNameResolver object then skip the update status call.
-				if !statusUpdated {
-					return nil
-				}
+			if !foundResolvedName {
+				// If the resolved name entry is not found then no update operation is required.
+				return nil
+			} else if removeResolvedName {
+				// Remove the resolved name entry if the resolutionFailures field's value is greater than or equal
+				// to the failure threshold.
+				newResolverObj.Status.ResolvedNames = append(newResolverObj.Status.ResolvedNames[:existingIndex], newResolverObj.Status.ResolvedNames[existingIndex+1:]...)
+				statusUpdated = true
+			}
+
+			// Record the generation of the DNSNameResolver object the status is written for.
+			if resolver.updateObservedGeneration(newResolverObj) {
+				statusUpdated = true
+			}
 
-				// Update the status of the DNSNameResolver object.
-				_, err = resolver.ocpNetworkClient.DNSNameResolvers(namespace).UpdateStatus(ctx, newResolverObj, metav1.UpdateOptions{})
+			// If there are no changes to the status of the DNSNameResolver object then skip the update status call.
+			if !statusUpdated {
+				return nil
+			}
+
+			// Update the status of the DNSNameResolver object.
+			err = resolver.store.updateStatus(ctx, newResolverObj)
+			resolver.notifyStatusUpdated(newResolverObj, dnsName, err)
+			if err != nil {
 				return err
-			})
-			if retryErr != nil {
-				log.Errorf("Encountered error while updating status of DNSNameResolver object: %v", retryErr)
 			}
-		}(namespace, objName)
-	}
+			resolver.auditStatusWrite(newResolverObj, dnsName)
+			recordAddressChanges(newResolverObj, dnsName, previousAddresses)
+			resolver.updateWriterAnnotation(ctx, newResolverObj)
+			if blockedWritten {
+				resolver.blockedFailures.written(blockedKey, blockedFailures)
+			}
+			return nil
+		})
 
-	// Wait for the goroutines for each namespace to complete.
-	wg.Wait()
+		// Track the forbidden update for a later retry, if retryForbidden is enabled.
+		resolver.trackForbidden(namespace, objName, dnsName, err)
+	})
 }
 
 // checkAndUpdateResolvedName checks whether the resolved name needs to be removed or not. If not, then the resolutionFailures
-// of the resolved name is incremented by one, and the "Degraded" condition is set to true.
+// of the resolved name is incremented by the given increment, and the "Degraded" condition is set to true.
 func checkAndUpdateResolvedName(
 	index int,
 	newResolverObj *ocpnetworkapiv1alpha1.DNSNameResolver,
@@ -651,6 +1069,7 @@ func checkAndUpdateResolvedName(
 	failureThreshold int32,
 	minimumTTL int32,
 	rcode int,
+	increment int32,
 ) (removeResolvedName bool, statusUpdated bool) {
 
 	// Check if the resolutionFailures of the resolved name is greater than or equal to the failure threshold.
@@ -671,7 +1090,7 @@ func checkAndUpdateResolvedName(
 
 	// If the resolved name entry is not getting removed, then the IP addresses whose TTLs have expired or about
 	// to expire should be set to the minimum TTL value and the last lookup time should be set to current time.
-	// Additionally, the resolutionFailures field value should be incremented by 1. If the conditions field is not
+	// Additionally, the resolutionFailures field value should be incremented. If the conditions field is not
 	// set or if the existing status of the "Degraded" condition is not true, then the status of the condition
 	// will be set to true, reason and message will be set to corresponding to that of corresponding failure rcode.
 	if !removeResolvedName {
@@ -680,15 +1099,15 @@ func checkAndUpdateResolvedName(
 		for i, resolvedAdress := range newResolverObj.Status.ResolvedNames[index].ResolvedAddresses {
 			nextLookupTime := resolvedAdress.LastLookupTime.Time.Add(time.Duration(resolvedAdress.TTLSeconds) * time.Second)
 			if !nextLookupTime.After(currentTime.Time) ||
-				isSameNextLookupTime(resolvedAdress.LastLookupTime.Time, resolvedAdress.TTLSeconds, 0) {
+				isSameNextLookupTime(resolvedAdress.LastLookupTime.Time, resolvedAdress.TTLSeconds, 0, nextLookupTimeMargin) {
 				newResolverObj.Status.ResolvedNames[index].ResolvedAddresses[i].TTLSeconds = minimumTTL
 				newResolverObj.Status.ResolvedNames[index].ResolvedAddresses[i].LastLookupTime = &currentTime
 				statusUpdated = true
 			}
 		}
 
-		// Increment the resolutionFailures field value by 1.
-		newResolverObj.Status.ResolvedNames[index].ResolutionFailures++
+		// Increment the resolutionFailures field value.
+		newResolverObj.Status.ResolvedNames[index].ResolutionFailures += increment
 
 		// If the conditions field is not set or if the existing status of the "Degraded" condition is not true, then
 		// the status of the condition will be set to true, reason and message will be set to corresponding to that
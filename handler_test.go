@@ -2,6 +2,8 @@ package ocp_dnsnameresolver
 
 import (
 	"context"
+	"encoding/json"
+	"regexp"
 	"testing"
 	"time"
 
@@ -17,6 +19,7 @@ import (
 	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
 	ocpnetworkfakeclient "github.com/openshift/client-go/network/clientset/versioned/fake"
 	ocpnetworklisterv1alpha1 "github.com/openshift/client-go/network/listers/network/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 )
@@ -28,7 +31,10 @@ func fakeNextPluginHandler(tc test.Case) plugin.Handler {
 		m := new(dns.Msg)
 		m.SetQuestion(tc.Qname, tc.Qtype)
 		m.Response = true
+		m.Rcode = tc.Rcode
 		m.Answer = append(m.Answer, tc.Answer...)
+		m.Ns = append(m.Ns, tc.Ns...)
+		m.Extra = append(m.Extra, tc.Extra...)
 		w.WriteMsg(m)
 		return tc.Rcode, nil
 	})
@@ -2367,6 +2373,60 @@ var dnsTestCases []dnsTestCase = []dnsTestCase{
 			},
 		},
 	},
+	{
+		name: "Update regular dns name resolver object status with deduplicated IPv6 addresses",
+		dnsNameResolvers: []ocpnetworkapiv1alpha1.DNSNameResolver{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			},
+		},
+		dnsQueryTestCases: []test.Case{
+			{
+				Qname: "www.example.com.",
+				Qtype: dns.TypeAAAA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.AAAA("www.example.com. 30 IN AAAA 2001:DB8:0:0:0:0:0:1"),
+					test.AAAA("www.example.com. 30 IN AAAA 2001:db8::1"),
+					test.AAAA("www.example.com. 30 IN AAAA 2001:db8::2"),
+				},
+			},
+		},
+		expectedStatuses: []ocpnetworkapiv1alpha1.DNSNameResolverStatus{
+			{
+				ResolvedNames: []ocpnetworkapiv1alpha1.DNSNameResolverResolvedName{
+					{
+						DNSName: "www.example.com.",
+						ResolvedAddresses: []ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{
+							{
+								IP:         "2001:db8::1",
+								TTLSeconds: 30,
+							},
+							{
+								IP:         "2001:db8::2",
+								TTLSeconds: 30,
+							},
+						},
+						ResolutionFailures: 0,
+						Conditions: []metav1.Condition{
+							{
+								Type:    ConditionDegraded,
+								Status:  metav1.ConditionFalse,
+								Reason:  dns.RcodeToString[dns.RcodeSuccess],
+								Message: rcodeMessage[dns.RcodeSuccess],
+							},
+						},
+					},
+				},
+			},
+		},
+	},
 }
 
 func TestServeDNS(t *testing.T) {
@@ -2487,3 +2547,614 @@ func TestServeDNS(t *testing.T) {
 		})
 	}
 }
+
+// newTestResolver returns an OCPDNSNameResolver whose informer is initialized with a fake
+// client and is running until the context is cancelled.
+func newTestResolver(ctx context.Context, t *testing.T, resolver *OCPDNSNameResolver) *ocpnetworkfakeclient.Clientset {
+	t.Helper()
+
+	// Create the fake client.
+	fakeNetworkClient := ocpnetworkfakeclient.NewSimpleClientset()
+	// Initialize the informer with the fake client.
+	if err := resolver.initInformer(fakeNetworkClient); err != nil {
+		t.Fatalf("error initializing informer: %v", err)
+	}
+
+	// Make sure DNS Name Resolver informer is started and synced.
+	go resolver.dnsNameResolverInformer.Run(ctx.Done())
+	cache.WaitForCacheSync(ctx.Done(), resolver.dnsNameResolverInformer.HasSynced)
+
+	return fakeNetworkClient
+}
+
+// createTrackedResolverObject creates the DNSNameResolver object and waits until the DNS name
+// of the object is tracked by the resolver.
+func createTrackedResolverObject(
+	t *testing.T,
+	resolver *OCPDNSNameResolver,
+	fakeNetworkClient *ocpnetworkfakeclient.Clientset,
+	dnsNameResolver *ocpnetworkapiv1alpha1.DNSNameResolver,
+) {
+	t.Helper()
+
+	_, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers(dnsNameResolver.Namespace).Create(context.TODO(),
+		dnsNameResolver, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("error injecting dns name resolver: %v", err)
+	}
+
+	// Wait for the informer to get the create event and the DNS name to get tracked.
+	err = wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, 1*time.Minute, true, func(ctx context.Context) (done bool, err error) {
+		return isTracked(resolver, dnsNameResolver.Namespace, dnsNameResolver.Name, string(dnsNameResolver.Spec.Name)), nil
+	})
+	if err != nil {
+		t.Fatalf("Informer did not get the added dns name resolver: %v", err)
+	}
+}
+
+// getResolverObject waits until the DNSNameResolver object fetched from the informer cache
+// satisfies the condition and returns it. If the condition is never satisfied then the last
+// fetched object is returned.
+func getResolverObject(
+	t *testing.T,
+	resolver *OCPDNSNameResolver,
+	namespace, name string,
+	condition func(*ocpnetworkapiv1alpha1.DNSNameResolver) bool,
+) *ocpnetworkapiv1alpha1.DNSNameResolver {
+	t.Helper()
+
+	lister := ocpnetworklisterv1alpha1.NewDNSNameResolverLister(resolver.dnsNameResolverInformer.GetIndexer())
+	var resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver
+	_ = wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, 2*time.Second, true, func(ctx context.Context) (done bool, err error) {
+		obj, err := lister.DNSNameResolvers(namespace).Get(name)
+		if err != nil {
+			return false, nil
+		}
+		resolverObj = obj
+		return condition(obj), nil
+	})
+	if resolverObj == nil {
+		t.Fatalf("error retrieving dns name resolver %s/%s", namespace, name)
+	}
+	return resolverObj
+}
+
+// isTracked checks if the DNSNameResolver object is tracked by the resolver for the DNS name.
+func isTracked(resolver *OCPDNSNameResolver, namespace, name, dnsName string) bool {
+	if isWildcard(dnsName) {
+		resolver.wildcardMapLock.Lock()
+		defer resolver.wildcardMapLock.Unlock()
+		return resolver.wildcardDNSInfo[dnsName][namespace] == name
+	}
+	resolver.regularMapLock.Lock()
+	defer resolver.regularMapLock.Unlock()
+	return resolver.regularDNSInfo[dnsName][namespace] == name
+}
+
+func TestAddIPTTL(t *testing.T) {
+	tests := []struct {
+		name           string
+		answers        []dns.RR
+		expectedIPTTLs map[string]int32
+	}{
+		{
+			name: "Duplicate IPv4 addresses are recorded once with the lower TTL",
+			answers: []dns.RR{
+				test.A("www.example.com. 30 IN A 1.1.1.1"),
+				test.A("www.example.com. 20 IN A 1.1.1.1"),
+				test.A("www.example.com. 30 IN A 1.1.1.2"),
+			},
+			expectedIPTTLs: map[string]int32{"1.1.1.1": 20, "1.1.1.2": 30},
+		},
+		{
+			name: "Differently formatted but equal IPv6 addresses are recorded once in canonical form",
+			answers: []dns.RR{
+				test.AAAA("www.example.com. 30 IN AAAA 2001:DB8:0:0:0:0:0:1"),
+				test.AAAA("www.example.com. 40 IN AAAA 2001:db8::1"),
+				test.AAAA("www.example.com. 25 IN AAAA 2001:0db8:0000::0001"),
+			},
+			expectedIPTTLs: map[string]int32{"2001:db8::1": 25},
+		},
+		{
+			name: "IPv4-mapped IPv6 address is deduplicated with the IPv4 address",
+			answers: []dns.RR{
+				test.A("www.example.com. 30 IN A 1.1.1.1"),
+				test.AAAA("www.example.com. 10 IN AAAA ::ffff:1.1.1.1"),
+			},
+			expectedIPTTLs: map[string]int32{"1.1.1.1": 10},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ipTTLs := make(map[string]int32)
+			for _, answer := range tc.answers {
+				switch rec := answer.(type) {
+				case *dns.A:
+					addIPTTL(ipTTLs, rec.A, int32(rec.Hdr.Ttl))
+				case *dns.AAAA:
+					addIPTTL(ipTTLs, rec.AAAA, int32(rec.Hdr.Ttl))
+				}
+			}
+			if diff := cmp.Diff(tc.expectedIPTTLs, ipTTLs); diff != "" {
+				t.Fatalf("IP addresses and TTLs did not match the expected values:\nDiff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestServeDNSPreserveCase(t *testing.T) {
+	// resolvedDNSNamePattern is the pattern of the DNS names of the resolved names of the
+	// DNSNameResolver CRD.
+	resolvedDNSNamePattern := regexp.MustCompile(`^(\*\.)?([a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?\.){2,}$`)
+
+	tests := []struct {
+		name                 string
+		preserveCase         bool
+		expectedOriginalCase map[string]string
+	}{
+		{
+			name:         "Original case is not recorded by default",
+			preserveCase: false,
+		},
+		{
+			name:                 "Original case is recorded in the annotation when preserveCase is enabled",
+			preserveCase:         true,
+			expectedOriginalCase: map[string]string{"www.example.com.": "WWW.Example.COM."},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.preserveCase = tc.preserveCase
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			})
+
+			query := test.Case{
+				Qname: "WWW.Example.COM.",
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A("WWW.Example.COM. 30 IN A 1.1.1.1"),
+				},
+			}
+			resolver.Next = fakeNextPluginHandler(query)
+
+			// The DNS name should match case-insensitively on every lookup, while only a
+			// single resolved name entry is stored.
+			for i := 0; i < 2; i++ {
+				resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+			}
+
+			resolverObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+				return len(obj.Status.ResolvedNames) > 0
+			})
+			if len(resolverObj.Status.ResolvedNames) != 1 {
+				t.Fatalf("Expected 1 resolved name, found: %v", resolverObj.Status.ResolvedNames)
+			}
+			// The DNS name of the resolved name is always lowercased, as required by the CRD.
+			if dnsName := string(resolverObj.Status.ResolvedNames[0].DNSName); dnsName != "www.example.com." || !resolvedDNSNamePattern.MatchString(dnsName) {
+				t.Fatalf("Expected stored DNS name www.example.com. matching the CRD pattern, found %s", dnsName)
+			}
+
+			resolverObj = getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+				_, exists := obj.Annotations[originalCaseAnnotation]
+				return exists == (tc.expectedOriginalCase != nil)
+			})
+			var originalCase map[string]string
+			if value, exists := resolverObj.Annotations[originalCaseAnnotation]; exists {
+				if err := json.Unmarshal([]byte(value), &originalCase); err != nil {
+					t.Fatalf("Invalid value of annotation %s: %v", originalCaseAnnotation, err)
+				}
+			}
+			if diff := cmp.Diff(tc.expectedOriginalCase, originalCase); diff != "" {
+				t.Fatalf("Original case did not match the expected one:\nDiff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestServeDNSMaxAnswerRecords(t *testing.T) {
+	tests := []struct {
+		name                  string
+		maxAnswerRecords      int
+		expectedStatusUpdates int
+		expectedRejections    float64
+	}{
+		{
+			name:                  "Response is recorded when maxAnswerRecords is not configured",
+			maxAnswerRecords:      0,
+			expectedStatusUpdates: 1,
+		},
+		{
+			name:                  "Response is recorded when the number of answer records does not exceed maxAnswerRecords",
+			maxAnswerRecords:      3,
+			expectedStatusUpdates: 1,
+		},
+		{
+			name:                  "Response is not recorded when the number of answer records exceeds maxAnswerRecords",
+			maxAnswerRecords:      2,
+			expectedStatusUpdates: 0,
+			expectedRejections:    1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.maxAnswerRecords = tc.maxAnswerRecords
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			})
+
+			query := test.Case{
+				Qname: "www.example.com.",
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A("www.example.com. 30 IN A 1.1.1.1"),
+					test.A("www.example.com. 30 IN A 1.1.1.2"),
+					test.A("www.example.com. 30 IN A 1.1.1.3"),
+				},
+			}
+			resolver.Next = fakeNextPluginHandler(query)
+
+			rejections := testutil.ToFloat64(rejectedResponses.WithLabelValues(rejectReasonMaxAnswerRecords))
+			resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+			if count := countStatusUpdates(fakeNetworkClient); count != tc.expectedStatusUpdates {
+				t.Fatalf("Expected %d status updates, found %d", tc.expectedStatusUpdates, count)
+			}
+			if value := testutil.ToFloat64(rejectedResponses.WithLabelValues(rejectReasonMaxAnswerRecords)) - rejections; value != tc.expectedRejections {
+				t.Fatalf("Expected %v rejected responses, found %v", tc.expectedRejections, value)
+			}
+		})
+	}
+}
+
+func TestServeDNSRejectCDBit(t *testing.T) {
+	tests := []struct {
+		name                  string
+		rejectCDBit           bool
+		checkingDisabled      bool
+		expectedStatusUpdates int
+		expectedRejections    float64
+	}{
+		{
+			name:                  "Response of a query with the CD bit is recorded when rejectCDBit is not enabled",
+			checkingDisabled:      true,
+			expectedStatusUpdates: 1,
+		},
+		{
+			name:                  "Response of a query without the CD bit is recorded when rejectCDBit is enabled",
+			rejectCDBit:           true,
+			expectedStatusUpdates: 1,
+		},
+		{
+			name:                  "Response of a query with the CD bit is not recorded when rejectCDBit is enabled",
+			rejectCDBit:           true,
+			checkingDisabled:      true,
+			expectedStatusUpdates: 0,
+			expectedRejections:    1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.rejectCDBit = tc.rejectCDBit
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			})
+
+			query := test.Case{
+				Qname:  "www.example.com.",
+				Qtype:  dns.TypeA,
+				Rcode:  dns.RcodeSuccess,
+				Answer: []dns.RR{test.A("www.example.com. 30 IN A 1.1.1.1")},
+			}
+			resolver.Next = fakeNextPluginHandler(query)
+			msg := query.Msg()
+			msg.CheckingDisabled = tc.checkingDisabled
+
+			rejections := testutil.ToFloat64(rejectedResponses.WithLabelValues(rejectReasonCheckingDisabled))
+			rec := dnstest.NewRecorder(&test.ResponseWriter{})
+			resolver.ServeDNS(context.TODO(), rec, msg)
+
+			// The response is returned to the client regardless of the CD bit.
+			if rec.Msg == nil || len(rec.Msg.Answer) != 1 {
+				t.Fatalf("Expected the response to be returned, found %v", rec.Msg)
+			}
+			if count := countStatusUpdates(fakeNetworkClient); count != tc.expectedStatusUpdates {
+				t.Fatalf("Expected %d status updates, found %d", tc.expectedStatusUpdates, count)
+			}
+			if value := testutil.ToFloat64(rejectedResponses.WithLabelValues(rejectReasonCheckingDisabled)) - rejections; value != tc.expectedRejections {
+				t.Fatalf("Expected %v rejected responses, found %v", tc.expectedRejections, value)
+			}
+		})
+	}
+}
+
+func TestServeDNSInternalZones(t *testing.T) {
+	tests := []struct {
+		name                  string
+		internalZones         []string
+		expectedStatusUpdates int
+	}{
+		{
+			name:                  "DNS name of an internal zone is recorded when internalZones is not configured",
+			expectedStatusUpdates: 1,
+		},
+		{
+			name:                  "DNS name of an internal zone is not recorded",
+			internalZones:         []string{"cluster.local."},
+			expectedStatusUpdates: 0,
+		},
+		{
+			name:                  "DNS name of another zone is recorded",
+			internalZones:         []string{"svc.example.com."},
+			expectedStatusUpdates: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.internalZones = tc.internalZones
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "foo.cluster.local.",
+				},
+			})
+
+			query := test.Case{
+				Qname: "FOO.cluster.local.",
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A("FOO.cluster.local. 30 IN A 1.1.1.1"),
+				},
+			}
+			resolver.Next = fakeNextPluginHandler(query)
+
+			rec := dnstest.NewRecorder(&test.ResponseWriter{})
+			resolver.ServeDNS(context.TODO(), rec, query.Msg())
+			if rec.Msg == nil || len(rec.Msg.Answer) != 1 {
+				t.Fatalf("Expected the DNS lookup to be served, found response: %v", rec.Msg)
+			}
+
+			if count := countStatusUpdates(fakeNetworkClient); count != tc.expectedStatusUpdates {
+				t.Fatalf("Expected %d status updates, found %d", tc.expectedStatusUpdates, count)
+			}
+		})
+	}
+}
+
+func TestServeDNSExternalZones(t *testing.T) {
+	tests := []struct {
+		name                  string
+		externalZones         []string
+		expectedStatusUpdates int
+	}{
+		{
+			name:                  "DNS name is recorded when externalZones is not configured",
+			expectedStatusUpdates: 1,
+		},
+		{
+			name:                  "DNS name of an external zone is recorded",
+			externalZones:         []string{"example.org.", "example.com."},
+			expectedStatusUpdates: 1,
+		},
+		{
+			name:                  "DNS name of another zone is not recorded",
+			externalZones:         []string{"example.org."},
+			expectedStatusUpdates: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.externalZones = tc.externalZones
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			})
+
+			query := test.Case{
+				Qname: "WWW.example.com.",
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A("WWW.example.com. 30 IN A 1.1.1.1"),
+				},
+			}
+			resolver.Next = fakeNextPluginHandler(query)
+
+			rec := dnstest.NewRecorder(&test.ResponseWriter{})
+			resolver.ServeDNS(context.TODO(), rec, query.Msg())
+			if rec.Msg == nil || len(rec.Msg.Answer) != 1 {
+				t.Fatalf("Expected the DNS lookup to be served, found response: %v", rec.Msg)
+			}
+
+			if count := countStatusUpdates(fakeNetworkClient); count != tc.expectedStatusUpdates {
+				t.Fatalf("Expected %d status updates, found %d", tc.expectedStatusUpdates, count)
+			}
+		})
+	}
+}
+
+func TestServeDNSIncludeAdditional(t *testing.T) {
+	tests := []struct {
+		name              string
+		includeAdditional bool
+		answer            []dns.RR
+		extra             []dns.RR
+		expectedIPs       []string
+	}{
+		{
+			name: "Addresses of the additional section are not recorded by default",
+			answer: []dns.RR{
+				test.A("www.example.com. 30 IN A 1.1.1.1"),
+			},
+			extra: []dns.RR{
+				test.A("www.example.com. 30 IN A 2.2.2.2"),
+			},
+			expectedIPs: []string{"1.1.1.1"},
+		},
+		{
+			name:              "Addresses of the answer and the additional sections are recorded",
+			includeAdditional: true,
+			answer: []dns.RR{
+				test.CNAME("www.example.com. 30 IN CNAME edge.example.net."),
+				test.A("edge.example.net. 30 IN A 1.1.1.1"),
+			},
+			extra: []dns.RR{
+				test.A("edge.example.net. 30 IN A 2.2.2.2"),
+				test.A("ns1.example.net. 30 IN A 3.3.3.3"),
+			},
+			expectedIPs: []string{"1.1.1.1", "2.2.2.2"},
+		},
+		{
+			name:              "Addresses only in the additional section are recorded",
+			includeAdditional: true,
+			extra: []dns.RR{
+				test.A("www.example.com. 30 IN A 2.2.2.2"),
+			},
+			expectedIPs: []string{"2.2.2.2"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.includeAdditional = tc.includeAdditional
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			})
+
+			query := test.Case{
+				Qname:  "www.example.com.",
+				Qtype:  dns.TypeA,
+				Rcode:  dns.RcodeSuccess,
+				Answer: tc.answer,
+				Extra:  tc.extra,
+			}
+			resolver.Next = fakeNextPluginHandler(query)
+			resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+			resolverObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+				return len(obj.Status.ResolvedNames) == 1
+			})
+			if len(resolverObj.Status.ResolvedNames) != 1 {
+				t.Fatalf("Expected a resolved name, found status: %v", resolverObj.Status)
+			}
+			var ips []string
+			for _, resolvedAddress := range resolverObj.Status.ResolvedNames[0].ResolvedAddresses {
+				ips = append(ips, resolvedAddress.IP)
+			}
+			if diff := cmp.Diff(tc.expectedIPs, ips, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+				t.Fatalf("Recorded addresses did not match the expected addresses:\nDiff: %s", diff)
+			}
+		})
+	}
+}
+
+func BenchmarkServeDNSInternalZones(b *testing.B) {
+	query := test.Case{
+		Qname: "kubernetes.default.svc.cluster.local.",
+		Qtype: dns.TypeA,
+	}
+
+	for _, bc := range []struct {
+		name          string
+		internalZones []string
+	}{
+		{name: "WithoutInternalZones"},
+		{name: "WithInternalZones", internalZones: []string{"cluster.local."}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			resolver := New()
+			resolver.internalZones = bc.internalZones
+			resolver.regularDNSInfo["www.example.com."] = namespaceDNSInfo{"dns": "regular"}
+			resolver.wildcardDNSInfo["*.example.com."] = namespaceDNSInfo{"dns": "wildcard"}
+			// The next plugin does not write a response, so that only the overhead of the
+			// plugin itself is measured.
+			resolver.Next = plugin.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+				return dns.RcodeSuccess, nil
+			})
+			msg := query.Msg()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				resolver.ServeDNS(context.TODO(), &test.ResponseWriter{}, msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package ocp_dnsnameresolver
+
+import (
+	"time"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// isUnchangedResolvedName returns true when the resolved name already contains all
+// the IP addresses of the DNS lookup and it does not reflect any DNS lookup failure,
+// i.e. when a successful DNS lookup would only change the TTLs and the last lookup
+// times of the IP addresses of the resolved name.
+func isUnchangedResolvedName(
+	resolvedName ocpnetworkapiv1alpha1.DNSNameResolverResolvedName,
+	ipTTLs map[string]int32,
+) bool {
+	if resolvedName.ResolutionFailures != 0 ||
+		len(resolvedName.Conditions) == 0 || resolvedName.Conditions[0].Status != metav1.ConditionFalse {
+		return false
+	}
+	existingIPs := make(map[string]struct{}, len(resolvedName.ResolvedAddresses))
+	for _, resolvedAddress := range resolvedName.ResolvedAddresses {
+		existingIPs[resolvedAddress.IP] = struct{}{}
+	}
+	for ip := range ipTTLs {
+		if _, exists := existingIPs[ip]; !exists {
+			return false
+		}
+	}
+	return true
+}
+
+// lastWriteTime returns the latest last lookup time of the IP addresses of the
+// resolved name, which is the time of the last write of the resolved name.
+func lastWriteTime(resolvedName ocpnetworkapiv1alpha1.DNSNameResolverResolvedName) time.Time {
+	var lastWrite time.Time
+	for _, resolvedAddress := range resolvedName.ResolvedAddresses {
+		if resolvedAddress.LastLookupTime != nil && resolvedAddress.LastLookupTime.After(lastWrite) {
+			lastWrite = resolvedAddress.LastLookupTime.Time
+		}
+	}
+	return lastWrite
+}
+
+// refreshResolvedNameIPTTLs sets the TTLs of the IP addresses of the resolved name at
+// the given index to the ones of the DNS lookup and their last lookup times to the
+// current time, regardless of whether their next lookup times have changed. It
+// returns true if any of the IP addresses is refreshed.
+func refreshResolvedNameIPTTLs(
+	index int,
+	ipTTLs map[string]int32,
+	currentTime metav1.Time,
+	resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver,
+) bool {
+	refreshed := false
+	for i, resolvedAddress := range resolverObj.Status.ResolvedNames[index].ResolvedAddresses {
+		if ttl, matched := ipTTLs[resolvedAddress.IP]; matched {
+			resolverObj.Status.ResolvedNames[index].ResolvedAddresses[i].TTLSeconds = ttl
+			resolverObj.Status.ResolvedNames[index].ResolvedAddresses[i].LastLookupTime = currentTime.DeepCopy()
+			refreshed = true
+		}
+	}
+	return refreshed
+}
@@ -0,0 +1,132 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestServeDNSMaxRecordAge(t *testing.T) {
+	tests := []struct {
+		name                  string
+		maxRecordAge          time.Duration
+		timeSinceLastWrite    time.Duration
+		answer                []dns.RR
+		expectedStatusUpdates int
+	}{
+		{
+			name:               "Changed next lookup time is written when maxRecordAge is not configured",
+			timeSinceLastWrite: time.Minute,
+			answer: []dns.RR{
+				test.A("www.example.com. 30 IN A 1.1.1.1"),
+			},
+			expectedStatusUpdates: 1,
+		},
+		{
+			name:               "Changed next lookup time is not written within maxRecordAge",
+			maxRecordAge:       10 * time.Minute,
+			timeSinceLastWrite: time.Minute,
+			answer: []dns.RR{
+				test.A("www.example.com. 30 IN A 1.1.1.1"),
+			},
+			expectedStatusUpdates: 0,
+		},
+		{
+			name:               "New address is written within maxRecordAge",
+			maxRecordAge:       10 * time.Minute,
+			timeSinceLastWrite: time.Minute,
+			answer: []dns.RR{
+				test.A("www.example.com. 30 IN A 1.1.1.1"),
+				test.A("www.example.com. 30 IN A 1.1.1.2"),
+			},
+			expectedStatusUpdates: 1,
+		},
+		{
+			name:               "Unchanged addresses are written once older than maxRecordAge",
+			maxRecordAge:       10 * time.Minute,
+			timeSinceLastWrite: 20 * time.Minute,
+			answer: []dns.RR{
+				test.A("www.example.com. 30 IN A 1.1.1.1"),
+			},
+			expectedStatusUpdates: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.maxRecordAge = tc.maxRecordAge
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			lastWrite := metav1.NewTime(time.Now().Add(-tc.timeSinceLastWrite))
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+				Status: ocpnetworkapiv1alpha1.DNSNameResolverStatus{
+					ResolvedNames: []ocpnetworkapiv1alpha1.DNSNameResolverResolvedName{
+						{
+							DNSName: "www.example.com.",
+							ResolvedAddresses: []ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{
+								{
+									IP:             "1.1.1.1",
+									TTLSeconds:     30,
+									LastLookupTime: &lastWrite,
+								},
+							},
+							Conditions: []metav1.Condition{
+								{
+									Type:   ConditionDegraded,
+									Status: metav1.ConditionFalse,
+								},
+							},
+						},
+					},
+				},
+			})
+
+			query := test.Case{
+				Qname:  "www.example.com.",
+				Qtype:  dns.TypeA,
+				Rcode:  dns.RcodeSuccess,
+				Answer: tc.answer,
+			}
+			resolver.Next = fakeNextPluginHandler(query)
+			resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+			if count := countStatusUpdates(fakeNetworkClient); count != tc.expectedStatusUpdates {
+				t.Fatalf("Expected %d status updates, found %d", tc.expectedStatusUpdates, count)
+			}
+			if tc.expectedStatusUpdates == 0 {
+				return
+			}
+
+			// All the addresses of the DNS lookup should be written with a recent last lookup time.
+			isRecent := func(address ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress) bool {
+				return time.Since(address.LastLookupTime.Time) < 10*time.Second
+			}
+			resolverObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+				return len(obj.Status.ResolvedNames) > 0 && len(obj.Status.ResolvedNames[0].ResolvedAddresses) == len(tc.answer) &&
+					isRecent(obj.Status.ResolvedNames[0].ResolvedAddresses[0])
+			})
+			for _, address := range resolverObj.Status.ResolvedNames[0].ResolvedAddresses {
+				if !isRecent(address) {
+					t.Fatalf("Expected a recent last lookup time of IP address %s, found %s", address.IP, address.LastLookupTime)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package ocp_dnsnameresolver
+
+import (
+	"strings"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+)
+
+// notifyStatusUpdated calls the OnStatusUpdated function, if set, after an attempt to
+// update the status of the DNSNameResolver object for the DNS name. The function gets a
+// copy of the object, so that it can't modify the object being updated.
+func (resolver *OCPDNSNameResolver) notifyStatusUpdated(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver, dnsName string, err error) {
+	if resolver.OnStatusUpdated == nil {
+		return
+	}
+	resolver.OnStatusUpdated(resolverObj.DeepCopy(), resolvedIPs(resolverObj, dnsName), err)
+}
+
+// resolvedIPs returns the IP addresses of the resolved name of the DNS name in the status
+// of the DNSNameResolver object, or nil if the resolved name doesn't exist.
+func resolvedIPs(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver, dnsName string) []string {
+	for _, resolvedName := range resolverObj.Status.ResolvedNames {
+		if !strings.EqualFold(string(resolvedName.DNSName), dnsName) {
+			continue
+		}
+		ips := make([]string, 0, len(resolvedName.ResolvedAddresses))
+		for _, resolvedAddress := range resolvedName.ResolvedAddresses {
+			ips = append(ips, resolvedAddress.IP)
+		}
+		return ips
+	}
+	return nil
+}
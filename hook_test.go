@@ -0,0 +1,90 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestServeDNSOnStatusUpdated(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type statusUpdate struct {
+		name      string
+		addresses []string
+		err       error
+	}
+	var lock sync.Mutex
+	var updates []statusUpdate
+
+	resolver := New()
+	resolver.OnStatusUpdated = func(obj *ocpnetworkapiv1alpha1.DNSNameResolver, addresses []string, err error) {
+		lock.Lock()
+		defer lock.Unlock()
+		updates = append(updates, statusUpdate{name: obj.Namespace + "/" + obj.Name, addresses: addresses, err: err})
+	}
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "regular",
+			Namespace: "dns",
+		},
+		Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+			Name: "www.example.com.",
+		},
+	})
+
+	// The hook is called after a successful status update.
+	query := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("www.example.com. 30 IN A 1.1.1.1"),
+		},
+	}
+	resolver.Next = fakeNextPluginHandler(query)
+	resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+	expectedUpdates := []statusUpdate{{name: "dns/regular", addresses: []string{"1.1.1.1"}}}
+	if !reflect.DeepEqual(updates, expectedUpdates) {
+		t.Fatalf("Expected status updates %v, found %v", expectedUpdates, updates)
+	}
+
+	// The hook is called after a failed status update, with the error of the update.
+	updateErr := apierrors.NewBadRequest("rejected")
+	fakeNetworkClient.PrependReactor("update", "dnsnameresolvers", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "status" {
+			return false, nil, nil
+		}
+		return true, nil, updateErr
+	})
+	getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		return len(obj.Status.ResolvedNames) > 0
+	})
+
+	failureQuery := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeServerFailure,
+	}
+	resolver.Next = fakeNextPluginHandler(failureQuery)
+	resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), failureQuery.Msg())
+
+	expectedUpdates = append(expectedUpdates, statusUpdate{name: "dns/regular", addresses: []string{"1.1.1.1"}, err: updateErr})
+	if !reflect.DeepEqual(updates, expectedUpdates) {
+		t.Fatalf("Expected status updates %v, found %v", expectedUpdates, updates)
+	}
+}
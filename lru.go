@@ -0,0 +1,163 @@
+package ocp_dnsnameresolver
+
+import (
+	"maps"
+	"time"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+)
+
+// touchTrackedName records the time of the DNS lookup of the tracked DNS name, if
+// maxTrackedNames is configured.
+func (resolver *OCPDNSNameResolver) touchTrackedName(dnsName string, now time.Time) {
+	if resolver.maxTrackedNames <= 0 {
+		return
+	}
+	resolver.lastQueriedLock.Lock()
+	defer resolver.lastQueriedLock.Unlock()
+	resolver.lastQueried[dnsName] = now
+}
+
+// forgetTrackedName removes the time of the last DNS lookup of the DNS name, once it
+// is not tracked anymore. The lastQueriedLock is taken by the function, the caller
+// must not hold it.
+func (resolver *OCPDNSNameResolver) forgetTrackedName(dnsName string) {
+	resolver.lastQueriedLock.Lock()
+	defer resolver.lastQueriedLock.Unlock()
+	delete(resolver.lastQueried, dnsName)
+}
+
+// makeRoomForName evicts the least recently queried DNS names until the DNS name can be
+// tracked without exceeding maxTrackedNames. The DNS names which were never queried are
+// evicted first, in the order of their names. Nothing is done if the DNS name is already
+// tracked or if maxTrackedNames is not configured. The details of the evicted DNS names
+// are kept in the evictedDNSInfo map, so that their DNSNameResolver objects are tracked
+// again on the next DNS lookup of the DNS names, or when they are added again by the
+// informer event handlers.
+func (resolver *OCPDNSNameResolver) makeRoomForName(dnsName string, now time.Time) {
+	if resolver.maxTrackedNames <= 0 {
+		return
+	}
+
+	resolver.regularMapLock.Lock()
+	defer resolver.regularMapLock.Unlock()
+	resolver.wildcardMapLock.Lock()
+	defer resolver.wildcardMapLock.Unlock()
+	resolver.lastQueriedLock.Lock()
+	defer resolver.lastQueriedLock.Unlock()
+
+	if _, exists := resolver.regularDNSInfo[dnsName]; exists {
+		return
+	}
+	if _, exists := resolver.wildcardDNSInfo[dnsName]; exists {
+		return
+	}
+
+	for len(resolver.regularDNSInfo)+len(resolver.wildcardDNSInfo) >= resolver.maxTrackedNames {
+		evicted, evictedTime := "", time.Time{}
+		for _, dnsInfo := range []map[string]namespaceDNSInfo{resolver.regularDNSInfo, resolver.wildcardDNSInfo} {
+			for trackedName := range dnsInfo {
+				lastQueried := resolver.lastQueried[trackedName]
+				if evicted == "" || lastQueried.Before(evictedTime) ||
+					(lastQueried.Equal(evictedTime) && trackedName < evicted) {
+					evicted, evictedTime = trackedName, lastQueried
+				}
+			}
+		}
+
+		dnsInfoMap, isRegular := resolver.regularDNSInfo[evicted]
+		if isRegular {
+			delete(resolver.regularDNSInfo, evicted)
+		} else {
+			dnsInfoMap = resolver.wildcardDNSInfo[evicted]
+			delete(resolver.wildcardDNSInfo, evicted)
+		}
+		delete(resolver.lastQueried, evicted)
+		resolver.evictedDNSInfo[evicted] = dnsInfoMap
+		evictedNames.Inc()
+		log.Warningf("Evicted DNS name %s of DNSNameResolver objects %v to track DNS name %s as maxTrackedNames %d is reached",
+			evicted, dnsInfoMap, dnsName, resolver.maxTrackedNames)
+	}
+
+	// The newly tracked DNS name counts as queried now, so that it is not evicted
+	// before it gets a chance to be queried.
+	resolver.lastQueried[dnsName] = now
+}
+
+// readmitEvictedName tracks again the evicted DNS name on its DNS lookup, evicting the
+// least recently queried DNS name in its place if maxTrackedNames is reached, and returns
+// a copy of its details. It returns false if the DNS name was not evicted.
+func (resolver *OCPDNSNameResolver) readmitEvictedName(dnsName string, now time.Time) (namespaceDNSInfo, bool) {
+	if resolver.maxTrackedNames <= 0 {
+		return nil, false
+	}
+	resolver.lastQueriedLock.Lock()
+	_, evicted := resolver.evictedDNSInfo[dnsName]
+	resolver.lastQueriedLock.Unlock()
+	if !evicted {
+		return nil, false
+	}
+
+	resolver.makeRoomForName(dnsName, now)
+
+	dnsInfo, mapLock := resolver.regularDNSInfo, &resolver.regularMapLock
+	if isWildcard(dnsName) {
+		dnsInfo, mapLock = resolver.wildcardDNSInfo, &resolver.wildcardMapLock
+	}
+	mapLock.Lock()
+	defer mapLock.Unlock()
+
+	// The DNS name may have been added again by the informer event handlers meanwhile.
+	if dnsInfoMap, exists := dnsInfo[dnsName]; exists {
+		return maps.Clone(dnsInfoMap), true
+	}
+	// The DNSNameResolver objects of the DNS name may have been deleted meanwhile.
+	dnsInfoMap := resolver.takeEvictedName(dnsName)
+	if dnsInfoMap == nil {
+		resolver.forgetTrackedName(dnsName)
+		return nil, false
+	}
+	dnsInfo[dnsName] = dnsInfoMap
+	log.Infof("Readmitted evicted DNS name %s of DNSNameResolver objects %v on its DNS lookup", dnsName, dnsInfoMap)
+	return maps.Clone(dnsInfoMap), true
+}
+
+// takeEvictedName returns the details of the evicted DNS name and removes them from the
+// evictedDNSInfo map, or nil if the DNS name was not evicted.
+func (resolver *OCPDNSNameResolver) takeEvictedName(dnsName string) namespaceDNSInfo {
+	resolver.lastQueriedLock.Lock()
+	defer resolver.lastQueriedLock.Unlock()
+	dnsInfoMap := resolver.evictedDNSInfo[dnsName]
+	delete(resolver.evictedDNSInfo, dnsName)
+	return dnsInfoMap
+}
+
+// forgetEvictedObject removes the DNSNameResolver object from the details of its DNS name,
+// if the DNS name was evicted. The DNS name is removed from the evictedDNSInfo map once
+// none of its DNSNameResolver objects is left.
+func (resolver *OCPDNSNameResolver) forgetEvictedObject(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) {
+	resolver.lastQueriedLock.Lock()
+	defer resolver.lastQueriedLock.Unlock()
+	dnsName := string(resolverObj.Spec.Name)
+	dnsInfoMap, exists := resolver.evictedDNSInfo[dnsName]
+	if !exists || dnsInfoMap[resolverObj.Namespace] != resolverObj.Name {
+		return
+	}
+	delete(dnsInfoMap, resolverObj.Namespace)
+	if len(dnsInfoMap) == 0 {
+		delete(resolver.evictedDNSInfo, dnsName)
+	}
+}
+
+// forgetEvictedNamespace removes the namespace from the details of the evicted DNS names,
+// like dropNamespace.
+func (resolver *OCPDNSNameResolver) forgetEvictedNamespace(namespace string) {
+	resolver.lastQueriedLock.Lock()
+	defer resolver.lastQueriedLock.Unlock()
+	for dnsName, dnsInfoMap := range resolver.evictedDNSInfo {
+		delete(dnsInfoMap, namespace)
+		if len(dnsInfoMap) == 0 {
+			delete(resolver.evictedDNSInfo, dnsName)
+		}
+	}
+}
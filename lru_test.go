@@ -0,0 +1,157 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMaxTrackedNames(t *testing.T) {
+	resolver := New()
+	resolver.maxTrackedNames = 3
+
+	newResolverObj := func(namespace, name, dnsName string) *ocpnetworkapiv1alpha1.DNSNameResolver {
+		return &ocpnetworkapiv1alpha1.DNSNameResolver{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+				Name: ocpnetworkapiv1alpha1.DNSName(dnsName),
+			},
+		}
+	}
+	nameTracked := func(dnsName string) bool {
+		_, regularExists := resolver.regularDNSInfo[dnsName]
+		_, wildcardExists := resolver.wildcardDNSInfo[dnsName]
+		return regularExists || wildcardExists
+	}
+
+	resolver.addResolverObject(newResolverObj("dns", "regular1", "www.example.com."))
+	resolver.addResolverObject(newResolverObj("dns", "wildcard", "*.example.com."))
+	resolver.addResolverObject(newResolverObj("dns", "regular2", "www.example.org."))
+
+	// The DNS names are queried in a different order than they were added.
+	now := time.Now().Add(time.Minute)
+	resolver.touchTrackedName("www.example.org.", now)
+	resolver.touchTrackedName("www.example.com.", now.Add(time.Second))
+	resolver.touchTrackedName("*.example.com.", now.Add(2*time.Second))
+
+	// Adding another namespace to a tracked DNS name doesn't evict any DNS name.
+	evictedBefore := testutil.ToFloat64(evictedNames)
+	resolver.addResolverObject(newResolverObj("other", "regular", "www.example.com."))
+	if evicted := testutil.ToFloat64(evictedNames) - evictedBefore; evicted != 0 {
+		t.Fatalf("Expected no DNS name to be evicted, found %v evicted", evicted)
+	}
+
+	// Adding a new DNS name evicts the least recently queried DNS name.
+	resolver.addResolverObject(newResolverObj("dns", "regular3", "www.example.net."))
+	if nameTracked("www.example.org.") {
+		t.Fatalf("Expected www.example.org. to be evicted")
+	}
+	for _, dnsName := range []string{"www.example.com.", "*.example.com.", "www.example.net."} {
+		if !nameTracked(dnsName) {
+			t.Fatalf("Expected %s to be tracked", dnsName)
+		}
+	}
+	if evicted := testutil.ToFloat64(evictedNames) - evictedBefore; evicted != 1 {
+		t.Fatalf("Expected 1 DNS name to be evicted, found %v evicted", evicted)
+	}
+
+	// The DNSNameResolver objects of the evicted DNS name are remembered.
+	if dnsInfoMap := resolver.evictedDNSInfo["www.example.org."]; dnsInfoMap["dns"] != "regular2" {
+		t.Fatalf("Expected www.example.org. to be remembered as evicted, found %v", dnsInfoMap)
+	}
+
+	// Querying the wildcard DNS name through a regular DNS name keeps it tracked, while
+	// the least recently queried regular DNS name is evicted next.
+	resolver.touchTrackedName("*.example.com.", now.Add(3*time.Second))
+	resolver.touchTrackedName("www.example.net.", now.Add(4*time.Second))
+	resolver.addResolverObject(newResolverObj("dns", "wildcard2", "*.example.org."))
+	if nameTracked("www.example.com.") {
+		t.Fatalf("Expected www.example.com. to be evicted")
+	}
+	if !nameTracked("*.example.com.") || !nameTracked("*.example.org.") {
+		t.Fatalf("Expected the wildcard DNS names to be tracked")
+	}
+
+	// Deleting a DNS name frees room without evicting any other DNS name.
+	resolver.deleteResolverObject(newResolverObj("dns", "regular3", "www.example.net."))
+	if _, exists := resolver.lastQueried["www.example.net."]; exists {
+		t.Fatalf("Expected the last lookup of www.example.net. to be forgotten")
+	}
+	resolver.addResolverObject(newResolverObj("dns", "regular4", "www.example.io."))
+	if evicted := testutil.ToFloat64(evictedNames) - evictedBefore; evicted != 2 {
+		t.Fatalf("Expected 2 DNS names to be evicted, found %v evicted", evicted)
+	}
+
+	// Deleting the DNSNameResolver object of an evicted DNS name forgets the DNS name.
+	resolver.deleteResolverObject(newResolverObj("dns", "regular2", "www.example.org."))
+	if _, exists := resolver.evictedDNSInfo["www.example.org."]; exists {
+		t.Fatalf("Expected evicted www.example.org. to be forgotten")
+	}
+	if _, exists := resolver.lookupRegular("www.example.org."); exists {
+		t.Fatalf("Expected www.example.org. not to be found")
+	}
+}
+
+func TestReadmitEvictedName(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.maxTrackedNames = 1
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	newResolverObj := func(name, dnsName string) *ocpnetworkapiv1alpha1.DNSNameResolver {
+		return &ocpnetworkapiv1alpha1.DNSNameResolver{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "dns",
+			},
+			Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+				Name: ocpnetworkapiv1alpha1.DNSName(dnsName),
+			},
+		}
+	}
+
+	// Tracking the second DNS name evicts the first one.
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, newResolverObj("first", "www.example.com."))
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, newResolverObj("second", "www.example.org."))
+	if isTracked(resolver, "dns", "first", "www.example.com.") {
+		t.Fatalf("Expected www.example.com. to be evicted")
+	}
+
+	// The next DNS lookup of the evicted DNS name tracks it again, without waiting for an
+	// informer event, and records the lookup in the status of its object.
+	query := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("www.example.com. 30 IN A 1.1.1.1"),
+		},
+	}
+	resolver.Next = fakeNextPluginHandler(query)
+	resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+	if !isTracked(resolver, "dns", "first", "www.example.com.") {
+		t.Fatalf("Expected www.example.com. to be tracked again")
+	}
+	if isTracked(resolver, "dns", "second", "www.example.org.") {
+		t.Fatalf("Expected www.example.org. to be evicted in its place")
+	}
+	resolverObj := getResolverObject(t, resolver, "dns", "first", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		return len(obj.Status.ResolvedNames) > 0
+	})
+	if len(resolverObj.Status.ResolvedNames) != 1 {
+		t.Fatalf("Expected the lookup to be recorded after the readmission, found: %v", resolverObj.Status.ResolvedNames)
+	}
+}
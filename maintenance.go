@@ -0,0 +1,93 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// errMaintenanceWindow is returned for the updates suppressed during a maintenance window.
+var errMaintenanceWindow = errors.New("maintenance window is in effect")
+
+// maintenanceWindow suppresses all the updates of the DNSNameResolver objects while the
+// API server is known to be unavailable, eg. during an upgrade, so that the failing
+// updates do not spam the logs. The DNS lookups are still served. The maintenance window
+// is in effect if it is enabled through the debug endpoint or through the pause
+// annotation of the sentinel DNSNameResolver object of maintenanceSentinel.
+type maintenanceWindow struct {
+	lock       sync.Mutex
+	byEndpoint bool
+	bySentinel bool
+}
+
+// active returns whether the maintenance window is in effect.
+func (window *maintenanceWindow) active() bool {
+	window.lock.Lock()
+	defer window.lock.Unlock()
+	return window.byEndpoint || window.bySentinel
+}
+
+// set enables or disables the maintenance window for the given source, i.e. the debug
+// endpoint or the sentinel object. The transitions of the maintenance window are logged
+// and reported by the maintenanceWindowGauge metric.
+func (window *maintenanceWindow) set(byEndpoint bool, enabled bool) {
+	window.lock.Lock()
+	defer window.lock.Unlock()
+
+	wasActive := window.byEndpoint || window.bySentinel
+	if byEndpoint {
+		window.byEndpoint = enabled
+	} else {
+		window.bySentinel = enabled
+	}
+	isActive := window.byEndpoint || window.bySentinel
+
+	switch {
+	case isActive && !wasActive:
+		log.Warningf("Maintenance window started, the updates of the DNSNameResolver objects are suppressed")
+		maintenanceWindowGauge.Set(1)
+	case !isActive && wasActive:
+		log.Infof("Maintenance window ended, the updates of the DNSNameResolver objects are resumed")
+		maintenanceWindowGauge.Set(0)
+	}
+}
+
+// observeSentinel enables or disables the maintenance window on the events of the
+// DNSNameResolver informer for the sentinel object of maintenanceSentinel, depending on
+// its pause annotation. The maintenance window is disabled once the sentinel object is
+// deleted. The events of the other objects are ignored.
+func (resolver *OCPDNSNameResolver) observeSentinel(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver, deleted bool) {
+	if resolver.maintenanceSentinel.Name == "" ||
+		(types.NamespacedName{Namespace: resolverObj.Namespace, Name: resolverObj.Name}) != resolver.maintenanceSentinel {
+		return
+	}
+	resolver.maintenance.set(false, !deleted && isPaused(resolverObj))
+}
+
+// maintenanceStore is a resolverStore suppressing the updates of the DNSNameResolver
+// objects during the maintenance window. The reads are served as usual.
+type maintenanceStore struct {
+	resolverStore
+	window *maintenanceWindow
+}
+
+var _ resolverStore = &maintenanceStore{}
+
+// update implements resolverStore.
+func (store *maintenanceStore) update(ctx context.Context, resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) error {
+	if store.window.active() {
+		return errMaintenanceWindow
+	}
+	return store.resolverStore.update(ctx, resolverObj)
+}
+
+// updateStatus implements resolverStore.
+func (store *maintenanceStore) updateStatus(ctx context.Context, resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) error {
+	if store.window.active() {
+		return errMaintenanceWindow
+	}
+	return store.resolverStore.updateStatus(ctx, resolverObj)
+}
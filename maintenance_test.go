@@ -0,0 +1,119 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestMaintenanceWindow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.maintenanceSentinel = types.NamespacedName{Namespace: "dns", Name: "sentinel"}
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+	})
+
+	handler := resolver.debugHandler()
+	post := func(target string, expectedCode int) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, nil))
+		if rec.Code != expectedCode {
+			t.Fatalf("Expected status code %d for %s, found %d: %s", expectedCode, target, rec.Code, rec.Body.String())
+		}
+	}
+	// lookup serves the DNS lookup of the IP address and checks that it is answered.
+	lookup := func(ip string) {
+		t.Helper()
+		query := test.Case{
+			Qname: "www.example.com.",
+			Qtype: dns.TypeA,
+			Rcode: dns.RcodeSuccess,
+			Answer: []dns.RR{
+				test.A("www.example.com. 30 IN A " + ip),
+			},
+		}
+		resolver.Next = fakeNextPluginHandler(query)
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		resolver.ServeDNS(ctx, rec, query.Msg())
+		if rec.Msg == nil || len(rec.Msg.Answer) != 1 {
+			t.Fatalf("Expected the DNS lookup to be served, found response: %v", rec.Msg)
+		}
+	}
+	// waitActive waits until the maintenance window is in effect or not, as expected.
+	waitActive := func(expected bool) {
+		t.Helper()
+		if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 2*time.Second, true, func(ctx context.Context) (bool, error) {
+			return resolver.maintenance.active() == expected, nil
+		}); err != nil {
+			t.Fatalf("Expected maintenance window in effect to be %t: %v", expected, err)
+		}
+		if value := testutil.ToFloat64(maintenanceWindowGauge); (value == 1) != expected {
+			t.Fatalf("Expected maintenance window metric to reflect %t, found %v", expected, value)
+		}
+	}
+
+	// The updates are suppressed while the maintenance window is enabled through the debug endpoint.
+	post(debugMaintenanceWindowPath+"?enabled=foo", http.StatusBadRequest)
+	post(debugMaintenanceWindowPath+"?enabled=true", http.StatusOK)
+	waitActive(true)
+	lookup("1.1.1.1")
+	if count := countStatusUpdates(fakeNetworkClient); count != 0 {
+		t.Fatalf("Expected no status updates during the maintenance window, found %d", count)
+	}
+	post(debugMaintenanceWindowPath+"?enabled=false", http.StatusOK)
+	waitActive(false)
+	lookup("1.1.1.1")
+	if count := countStatusUpdates(fakeNetworkClient); count != 1 {
+		t.Fatalf("Expected 1 status update once the maintenance window ended, found %d", count)
+	}
+
+	// The updates are suppressed while the sentinel object is paused.
+	sentinel, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("dns").Create(ctx, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "sentinel",
+			Namespace:   "dns",
+			Annotations: map[string]string{pauseAnnotation: "true"},
+		},
+		Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "sentinel.example.org."},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error creating DNSNameResolver object: %v", err)
+	}
+	waitActive(true)
+	lookup("2.2.2.2")
+	if count := countStatusUpdates(fakeNetworkClient); count != 1 {
+		t.Fatalf("Expected no more status updates while the sentinel object is paused, found %d", count)
+	}
+
+	// The maintenance window stays in effect while the sentinel object is paused.
+	post(debugMaintenanceWindowPath+"?enabled=false", http.StatusOK)
+	waitActive(true)
+
+	delete(sentinel.Annotations, pauseAnnotation)
+	if _, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("dns").Update(ctx, sentinel, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Unexpected error updating DNSNameResolver object: %v", err)
+	}
+	waitActive(false)
+	lookup("2.2.2.2")
+	if count := countStatusUpdates(fakeNetworkClient); count != 2 {
+		t.Fatalf("Expected 2 status updates once the sentinel object is resumed, found %d", count)
+	}
+}
@@ -0,0 +1,49 @@
+package ocp_dnsnameresolver
+
+import (
+	"maps"
+	"time"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+)
+
+// mergeIPTTLs returns the IP addresses and the TTLs of a DNS lookup to record in the
+// resolved name, when mergeWindow is configured. Replicas without leader election may
+// receive different TTLs for the same IP address, eg. from differently aged upstream
+// caches, and keep overwriting each other's next lookup times. The IP addresses not
+// received in the DNS lookup are always kept in the resolved name, thus the recorded
+// IP addresses are already the union of the ones of all the writers. Within the
+// mergeWindow since the last lookup of an IP address, its recorded next lookup time is
+// extended by the DNS lookup but never shortened, so that the recorded next lookup
+// times converge to the latest one of all the writers instead of flapping. Once the
+// mergeWindow is over, the next lookup time of the DNS lookup is recorded as usual.
+func (resolver *OCPDNSNameResolver) mergeIPTTLs(
+	resolvedName ocpnetworkapiv1alpha1.DNSNameResolverResolvedName,
+	ipTTLs map[string]int32,
+	now time.Time,
+) map[string]int32 {
+	if resolver.mergeWindow == 0 {
+		return ipTTLs
+	}
+
+	var merged map[string]int32
+	for _, resolvedAddress := range resolvedName.ResolvedAddresses {
+		ttl, exists := ipTTLs[resolvedAddress.IP]
+		if !exists || resolvedAddress.LastLookupTime == nil || now.Sub(resolvedAddress.LastLookupTime.Time) >= resolver.mergeWindow {
+			continue
+		}
+		existingNextLookupTime := resolvedAddress.LastLookupTime.Add(time.Duration(resolvedAddress.TTLSeconds) * time.Second)
+		if !existingNextLookupTime.After(now.Add(time.Duration(ttl) * time.Second)) {
+			continue
+		}
+		// Keep the existing next lookup time by recording the TTL remaining until it.
+		if merged == nil {
+			merged = maps.Clone(ipTTLs)
+		}
+		merged[resolvedAddress.IP] = int32(existingNextLookupTime.Sub(now) / time.Second)
+	}
+	if merged == nil {
+		return ipTTLs
+	}
+	return merged
+}
@@ -0,0 +1,128 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestServeDNSMergeWindow(t *testing.T) {
+	// The writers resolve the DNS name to different address sets, with different TTLs
+	// for the common IP address.
+	queryA := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("www.example.com. 300 IN A 1.1.1.1"),
+		},
+	}
+	queryB := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("www.example.com. 30 IN A 1.1.1.1"),
+			test.A("www.example.com. 30 IN A 2.2.2.2"),
+		},
+	}
+
+	tests := []struct {
+		name               string
+		mergeWindow        time.Duration
+		expectedTTL        int32
+		expectedRewriteByA int
+	}{
+		{
+			name:               "Writers overwrite each other's next lookup times when mergeWindow is not configured",
+			expectedTTL:        30,
+			expectedRewriteByA: 1,
+		},
+		{
+			name:               "Next lookup times converge to the latest one when mergeWindow is configured",
+			mergeWindow:        5 * time.Minute,
+			expectedTTL:        300,
+			expectedRewriteByA: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			writerA := New()
+			writerA.mergeWindow = tc.mergeWindow
+			fakeNetworkClient := newTestResolver(ctx, t, writerA)
+
+			// The second writer simulates another replica sharing the DNSNameResolver objects.
+			writerB := New()
+			writerB.mergeWindow = tc.mergeWindow
+			if err := writerB.initInformer(fakeNetworkClient); err != nil {
+				t.Fatalf("error initializing informer: %v", err)
+			}
+			go writerB.dnsNameResolverInformer.Run(ctx.Done())
+			cache.WaitForCacheSync(ctx.Done(), writerB.dnsNameResolverInformer.HasSynced)
+
+			createTrackedResolverObject(t, writerA, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			})
+			if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, time.Minute, true, func(ctx context.Context) (bool, error) {
+				return isTracked(writerB, "dns", "regular", "www.example.com."), nil
+			}); err != nil {
+				t.Fatalf("Second writer did not track the DNS name: %v", err)
+			}
+
+			// addresses waits until the informer cache of the writer has the expected number of
+			// addresses and returns the remaining TTLs of the recorded addresses.
+			addresses := func(writer *OCPDNSNameResolver, expected int) map[string]int32 {
+				t.Helper()
+				resolverObj := getResolverObject(t, writer, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+					return len(obj.Status.ResolvedNames) == 1 && len(obj.Status.ResolvedNames[0].ResolvedAddresses) == expected
+				})
+				if len(resolverObj.Status.ResolvedNames) != 1 || len(resolverObj.Status.ResolvedNames[0].ResolvedAddresses) != expected {
+					t.Fatalf("Expected %d recorded addresses, found status: %v", expected, resolverObj.Status)
+				}
+				remainingTTLs := make(map[string]int32)
+				for _, resolvedAddress := range resolverObj.Status.ResolvedNames[0].ResolvedAddresses {
+					nextLookupTime := resolvedAddress.LastLookupTime.Add(time.Duration(resolvedAddress.TTLSeconds) * time.Second)
+					remainingTTLs[resolvedAddress.IP] = int32(time.Until(nextLookupTime).Round(time.Second) / time.Second)
+				}
+				return remainingTTLs
+			}
+
+			writerA.Next = fakeNextPluginHandler(queryA)
+			writerA.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), queryA.Msg())
+			addresses(writerB, 1)
+
+			writerB.Next = fakeNextPluginHandler(queryB)
+			writerB.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), queryB.Msg())
+			remainingTTLs := addresses(writerA, 2)
+			if ttl := remainingTTLs["1.1.1.1"]; ttl < tc.expectedTTL-5 || ttl > tc.expectedTTL {
+				t.Fatalf("Expected remaining TTL of about %d for 1.1.1.1, found %d", tc.expectedTTL, ttl)
+			}
+
+			// The first writer only rewrites the status if its next lookup time was overwritten.
+			statusUpdates := countStatusUpdates(fakeNetworkClient)
+			writerA.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), queryA.Msg())
+			if count := countStatusUpdates(fakeNetworkClient) - statusUpdates; count != tc.expectedRewriteByA {
+				t.Fatalf("Expected %d status updates by the first writer, found %d", tc.expectedRewriteByA, count)
+			}
+			addresses(writerA, 2)
+		})
+	}
+}
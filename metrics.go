@@ -0,0 +1,155 @@
+package ocp_dnsnameresolver
+
+import (
+	"strconv"
+
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// informerLastSyncResourceVersion is the resource version last observed by the
+	// DNSNameResolver informer.
+	informerLastSyncResourceVersion = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: pluginName,
+		Name:      "informer_last_sync_resource_version",
+		Help:      "The resource version last observed by the DNSNameResolver informer.",
+	})
+	// statusUpdateErrors is a counter of the errors encountered while updating the
+	// DNSNameResolver objects, by the class of the error. Updates failing with
+	// transient errors are retried, while updates failing with permanent errors
+	// are dropped.
+	statusUpdateErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: pluginName,
+		Name:      "update_errors_total",
+		Help:      "The count of errors encountered while updating DNSNameResolver objects, by error class.",
+	}, []string{"class"})
+	// namespaceStatusUpdates is a counter of the status updates of the DNSNameResolver
+	// objects, by namespace and result. It is only incremented when perNamespaceMetrics
+	// is enabled.
+	namespaceStatusUpdates = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: pluginName,
+		Name:      "namespace_status_updates_total",
+		Help:      "The count of status updates of DNSNameResolver objects, by namespace and result.",
+	}, []string{"namespace", "result"})
+	// rejectedResponses is a counter of the DNS lookup responses which are not
+	// recorded in the status of the DNSNameResolver objects as they are considered
+	// suspicious, by the reason of the rejection.
+	rejectedResponses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: pluginName,
+		Name:      "rejected_responses_total",
+		Help:      "The count of DNS lookup responses rejected from being recorded, by reason.",
+	}, []string{"reason"})
+	// foreignWrites is a counter of the status writes of the DNSNameResolver objects
+	// which were last written by another CoreDNS instance, as identified by the writer
+	// annotation. It is only incremented when instanceID is configured.
+	foreignWrites = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: pluginName,
+		Name:      "foreign_writes_total",
+		Help:      "The count of status writes of DNSNameResolver objects last written by another instance.",
+	})
+
+	// breakerStateGauge is the state of the circuit breaker around the updates of the
+	// DNSNameResolver objects: 0 for closed, 1 for half open and 2 for open.
+	breakerStateGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: pluginName,
+		Name:      "circuit_breaker_state",
+		Help:      "The state of the circuit breaker around the updates of DNSNameResolver objects: 0 for closed, 1 for half open and 2 for open.",
+	})
+
+	// addressChanges is a counter of the IP addresses added to and removed from the status
+	// of the DNSNameResolver objects by the status writes, by the direction of the change.
+	addressChanges = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: pluginName,
+		Name:      "address_changes_total",
+		Help:      "The count of IP addresses added to and removed from the status of DNSNameResolver objects, by direction.",
+	}, []string{"direction"})
+
+	// maintenanceWindowGauge is whether the maintenance window suppressing the updates of
+	// the DNSNameResolver objects is in effect: 1 if it is and 0 otherwise.
+	maintenanceWindowGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: pluginName,
+		Name:      "maintenance_window",
+		Help:      "Whether the maintenance window suppressing the updates of DNSNameResolver objects is in effect: 1 if it is and 0 otherwise.",
+	})
+
+	// lookupResults is a counter of the DNS lookups of the tracked DNS names, by the result
+	// of the DNS lookup. The increments carry an exemplar identifying the DNS lookup, when
+	// exemplars is enabled.
+	lookupResults = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: pluginName,
+		Name:      "lookup_results_total",
+		Help:      "The count of DNS lookups of tracked DNS names, by result.",
+	}, []string{"result"})
+
+	// evictedNames is the count of the tracked DNS names evicted as maxTrackedNames is reached.
+	evictedNames = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: pluginName,
+		Name:      "evicted_names_total",
+		Help:      "The count of tracked DNS names evicted as the maximum number of tracked DNS names is reached.",
+	})
+
+	// throttledUpdates is the count of the status updates deferred by the rate limit of
+	// maxUpdatesPerObject.
+	throttledUpdates = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: pluginName,
+		Name:      "throttled_updates_total",
+		Help:      "The count of status updates deferred to the next allowed slot by the per object rate limit.",
+	})
+)
+
+const (
+	// errorClassTransient is the class of errors for which the update is retried.
+	errorClassTransient = "transient"
+	// errorClassPermanent is the class of errors for which the update is dropped.
+	errorClassPermanent = "permanent"
+
+	// addressChangeAdded is the direction of the IP addresses added to the status.
+	addressChangeAdded = "added"
+	// addressChangeRemoved is the direction of the IP addresses removed from the status.
+	addressChangeRemoved = "removed"
+
+	// lookupResultSuccess is the result of the DNS lookups whose IP addresses are recorded.
+	lookupResultSuccess = "success"
+	// lookupResultFailure is the result of the failed DNS lookups.
+	lookupResultFailure = "failure"
+
+	// rejectReasonMaxAnswerRecords is the reason of rejecting the responses whose answer
+	// section contains more records than maxAnswerRecords.
+	rejectReasonMaxAnswerRecords = "max_answer_records"
+	// rejectReasonQNameMismatch is the reason of rejecting the responses whose answer section
+	// contains records of DNS names off the CNAME chain of the queried DNS name.
+	rejectReasonQNameMismatch = "qname_mismatch"
+	// rejectReasonCheckingDisabled is the reason of rejecting the responses of the DNS lookups
+	// with the CD (checking disabled) bit set.
+	rejectReasonCheckingDisabled = "checking_disabled"
+)
+
+// updateResourceVersionMetric sets the informerLastSyncResourceVersion metric to the
+// resource version, if the resource version is numeric. Resource versions are opaque
+// strings, thus a non-numeric resource version is ignored and the metric keeps its
+// last value.
+func updateResourceVersionMetric(resourceVersion string) {
+	if resourceVersion == "" {
+		return
+	}
+	value, err := strconv.ParseUint(resourceVersion, 10, 64)
+	if err != nil {
+		log.Debugf("Ignoring non-numeric resource version %q of DNSNameResolver informer", resourceVersion)
+		return
+	}
+	informerLastSyncResourceVersion.Set(float64(value))
+}
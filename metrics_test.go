@@ -0,0 +1,45 @@
+package ocp_dnsnameresolver
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestUpdateResourceVersionMetric(t *testing.T) {
+	tests := []struct {
+		name            string
+		resourceVersion string
+		expectedValue   float64
+	}{
+		{
+			name:            "Numeric resource version is set",
+			resourceVersion: "12345",
+			expectedValue:   12345,
+		},
+		{
+			name:            "Empty resource version is ignored",
+			resourceVersion: "",
+			expectedValue:   12345,
+		},
+		{
+			name:            "Non-numeric resource version is ignored",
+			resourceVersion: "abc123",
+			expectedValue:   12345,
+		},
+		{
+			name:            "Newer numeric resource version is set",
+			resourceVersion: "67890",
+			expectedValue:   67890,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			updateResourceVersionMetric(tc.resourceVersion)
+			if value := testutil.ToFloat64(informerLastSyncResourceVersion); value != tc.expectedValue {
+				t.Fatalf("Expected metric value %v, found %v", tc.expectedValue, value)
+			}
+		})
+	}
+}
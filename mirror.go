@@ -0,0 +1,120 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// mirrorConfigMapPeriod gives the period of writing the summary of the status of the
+	// DNSNameResolver objects to the mirror ConfigMap. The summary is written at most
+	// once per period, and only if it changed, to avoid storms of ConfigMap writes.
+	mirrorConfigMapPeriod = 30 * time.Second
+	// mirrorConfigMapKey is the key of the data of the mirror ConfigMap containing the
+	// JSON encoded summary.
+	mirrorConfigMapKey = "dnsnames.json"
+)
+
+// mirrorSummary returns the JSON encoded summary of the status of the tracked
+// DNSNameResolver objects: a map of the resolved DNS names to their sorted IP
+// addresses. The IP addresses of a DNS name resolved in multiple DNSNameResolver
+// objects, eg. in different namespaces, are merged.
+func (resolver *OCPDNSNameResolver) mirrorSummary(ctx context.Context) (string, error) {
+	summary := make(map[string][]string)
+	for object := range resolver.trackedObjects() {
+		resolverObj, err := resolver.store.get(ctx, object.Namespace, object.Name)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return "", err
+		}
+		for _, resolvedName := range resolverObj.Status.ResolvedNames {
+			dnsName := string(resolvedName.DNSName)
+			for _, resolvedAddress := range resolvedName.ResolvedAddresses {
+				if !slices.Contains(summary[dnsName], resolvedAddress.IP) {
+					summary[dnsName] = append(summary[dnsName], resolvedAddress.IP)
+				}
+			}
+		}
+	}
+	for dnsName := range summary {
+		slices.Sort(summary[dnsName])
+	}
+
+	// The keys of the map are sorted by the encoding, thus the summary is stable.
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// mirrorStatus writes the summary of the status of the tracked DNSNameResolver objects
+// to the mirror ConfigMap, creating the ConfigMap if it does not exist. The write is
+// skipped if the summary did not change since the last write.
+func (resolver *OCPDNSNameResolver) mirrorStatus(ctx context.Context) error {
+	summary, err := resolver.mirrorSummary(ctx)
+	if err != nil {
+		return err
+	}
+	if summary == resolver.mirroredSummary {
+		return nil
+	}
+
+	configMaps := resolver.kubeClient.CoreV1().ConfigMaps(resolver.mirrorConfigMap.Namespace)
+	configMap, err := configMaps.Get(ctx, resolver.mirrorConfigMap.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      resolver.mirrorConfigMap.Name,
+				Namespace: resolver.mirrorConfigMap.Namespace,
+			},
+			Data: map[string]string{mirrorConfigMapKey: summary},
+		}
+		if _, err := configMaps.Create(ctx, configMap, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	case configMap.Data[mirrorConfigMapKey] != summary:
+		if configMap.Data == nil {
+			configMap.Data = make(map[string]string)
+		}
+		configMap.Data[mirrorConfigMapKey] = summary
+		if _, err := configMaps.Update(ctx, configMap, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+
+	resolver.mirroredSummary = summary
+	return nil
+}
+
+// runMirror periodically writes the summary of the status of the tracked
+// DNSNameResolver objects to the mirror ConfigMap, once the DNSNameResolver informer
+// is synced, until the stop channel is closed.
+func (resolver *OCPDNSNameResolver) runMirror(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(mirrorConfigMapPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
+		if !resolver.dnsNameResolverInformer.HasSynced() || resolver.maintenance.active() {
+			continue
+		}
+		if err := resolver.mirrorStatus(context.Background()); err != nil {
+			log.Errorf("Encountered error while writing the summary to ConfigMap %s: %v", resolver.mirrorConfigMap, err)
+		}
+	}
+}
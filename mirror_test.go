@@ -0,0 +1,126 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"maps"
+	"testing"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubefakeclient "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMirrorStatus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.mirrorConfigMap = types.NamespacedName{Namespace: "dns", Name: "mirror"}
+	fakeKubeClient := kubefakeclient.NewSimpleClientset()
+	resolver.kubeClient = fakeKubeClient
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	for _, resolverObj := range []*ocpnetworkapiv1alpha1.DNSNameResolver{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+			Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "other"},
+			Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "wildcard", Namespace: "dns"},
+			Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "*.example.org."},
+		},
+	} {
+		createTrackedResolverObject(t, resolver, fakeNetworkClient, resolverObj)
+	}
+
+	setResolvedAddresses := func(namespace, name string, resolvedNames map[string][]string) {
+		t.Helper()
+		resolverObj, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error getting DNSNameResolver object: %v", err)
+		}
+		resolverObj.Status.ResolvedNames = nil
+		for dnsName, ips := range resolvedNames {
+			resolvedName := ocpnetworkapiv1alpha1.DNSNameResolverResolvedName{DNSName: ocpnetworkapiv1alpha1.DNSName(dnsName)}
+			for _, ip := range ips {
+				resolvedName.ResolvedAddresses = append(resolvedName.ResolvedAddresses, ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{IP: ip, TTLSeconds: 30})
+			}
+			resolverObj.Status.ResolvedNames = append(resolverObj.Status.ResolvedNames, resolvedName)
+		}
+		if _, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers(namespace).UpdateStatus(ctx, resolverObj, metav1.UpdateOptions{}); err != nil {
+			t.Fatalf("Unexpected error updating DNSNameResolver object: %v", err)
+		}
+		getResolverObject(t, resolver, namespace, name, func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+			return len(obj.Status.ResolvedNames) == len(resolvedNames)
+		})
+	}
+	configMapWrites := func() int {
+		writes := 0
+		for _, action := range fakeKubeClient.Actions() {
+			if action.GetVerb() == "create" || action.GetVerb() == "update" {
+				writes++
+			}
+		}
+		return writes
+	}
+	// expectSummary mirrors the status and checks the summary of the mirror ConfigMap, and
+	// the number of writes of the ConfigMap since the last check.
+	expectSummary := func(expectedSummary string, expectedWrites int) {
+		t.Helper()
+		if err := resolver.mirrorStatus(ctx); err != nil {
+			t.Fatalf("Unexpected error mirroring the status: %v", err)
+		}
+		configMap, err := fakeKubeClient.CoreV1().ConfigMaps("dns").Get(ctx, "mirror", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error getting the mirror ConfigMap: %v", err)
+		}
+		if summary := configMap.Data[mirrorConfigMapKey]; summary != expectedSummary {
+			t.Fatalf("Expected summary %s, found %s", expectedSummary, summary)
+		}
+		if writes := configMapWrites(); writes != expectedWrites {
+			t.Fatalf("Expected %d writes of the mirror ConfigMap, found %d: %v", expectedWrites, writes, fakeKubeClient.Actions())
+		}
+		fakeKubeClient.ClearActions()
+	}
+
+	// The ConfigMap is created with the empty summary.
+	expectSummary(`{}`, 1)
+
+	// The IP addresses of the same DNS name in different namespaces are merged.
+	setResolvedAddresses("dns", "regular", map[string][]string{"www.example.com.": {"1.1.1.2", "1.1.1.1"}})
+	setResolvedAddresses("other", "regular", map[string][]string{"www.example.com.": {"1.1.1.1", "1.1.1.3"}})
+	setResolvedAddresses("dns", "wildcard", map[string][]string{"*.example.org.": {"2.2.2.2"}, "www.example.org.": {"2.2.2.3"}})
+	expected := `{"*.example.org.":["2.2.2.2"],"www.example.com.":["1.1.1.1","1.1.1.2","1.1.1.3"],"www.example.org.":["2.2.2.3"]}`
+	expectSummary(expected, 1)
+
+	// The unchanged summary is not written again.
+	expectSummary(expected, 0)
+
+	// The ConfigMap is updated once the summary changes, keeping its other data.
+	configMap, err := fakeKubeClient.CoreV1().ConfigMaps("dns").Get(ctx, "mirror", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error getting the mirror ConfigMap: %v", err)
+	}
+	configMap.Data["other"] = "data"
+	if _, err := fakeKubeClient.CoreV1().ConfigMaps("dns").Update(ctx, configMap, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Unexpected error updating the mirror ConfigMap: %v", err)
+	}
+	fakeKubeClient.ClearActions()
+	setResolvedAddresses("other", "regular", nil)
+	expectSummary(`{"*.example.org.":["2.2.2.2"],"www.example.com.":["1.1.1.1","1.1.1.2"],"www.example.org.":["2.2.2.3"]}`, 1)
+	configMap, err = fakeKubeClient.CoreV1().ConfigMaps("dns").Get(ctx, "mirror", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error getting the mirror ConfigMap: %v", err)
+	}
+	if !maps.Equal(configMap.Data, map[string]string{
+		mirrorConfigMapKey: `{"*.example.org.":["2.2.2.2"],"www.example.com.":["1.1.1.1","1.1.1.2"],"www.example.org.":["2.2.2.3"]}`,
+		"other":            "data",
+	}) {
+		t.Fatalf("Expected the other data of the mirror ConfigMap to be kept, found: %v", configMap.Data)
+	}
+}
@@ -0,0 +1,78 @@
+package ocp_dnsnameresolver
+
+import "sort"
+
+// multiNamespacePolicy determines which DNSNameResolver objects are updated when the DNS
+// name being looked up is matched by DNSNameResolver objects in multiple namespaces.
+type multiNamespacePolicy string
+
+const (
+	// multiNamespacePolicyAll updates the DNSNameResolver objects of all the namespaces.
+	multiNamespacePolicyAll multiNamespacePolicy = "all"
+	// multiNamespacePolicyPriority updates only the DNSNameResolver objects of the most
+	// preferred namespace, according to the namespace preference list.
+	multiNamespacePolicyPriority multiNamespacePolicy = "priority"
+)
+
+// parseMultiNamespacePolicy returns the multiNamespacePolicy corresponding to the given
+// value and whether the value is a valid multiNamespacePolicy.
+func parseMultiNamespacePolicy(value string) (multiNamespacePolicy, bool) {
+	switch policy := multiNamespacePolicy(value); policy {
+	case multiNamespacePolicyAll, multiNamespacePolicyPriority:
+		return policy, true
+	}
+	return "", false
+}
+
+// applyMultiNamespacePolicy filters the namespaces of the regular and the wildcard DNS name
+// info according to the multi namespace policy. With the priority policy, only the entries
+// of the most preferred namespace among the namespaces of both maps are kept. The namespaces
+// are preferred in the order of the preference list, the namespaces which are not in the
+// list come after, in lexicographical order. The given maps are never modified, as they are
+// shared with the informer event handlers; new maps are returned instead whenever filtering
+// is required.
+func applyMultiNamespacePolicy(
+	policy multiNamespacePolicy,
+	preference []string,
+	regularDNSInfo namespaceDNSInfo,
+	wildcardDNSInfo namespaceDNSInfo,
+) (namespaceDNSInfo, namespaceDNSInfo) {
+	if policy != multiNamespacePolicyPriority {
+		return regularDNSInfo, wildcardDNSInfo
+	}
+
+	namespaces := make([]string, 0, len(regularDNSInfo)+len(wildcardDNSInfo))
+	for _, dnsInfo := range []namespaceDNSInfo{regularDNSInfo, wildcardDNSInfo} {
+		for namespace := range dnsInfo {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	if len(namespaces) <= 1 {
+		return regularDNSInfo, wildcardDNSInfo
+	}
+
+	rank := func(namespace string) int {
+		for i, preferred := range preference {
+			if namespace == preferred {
+				return i
+			}
+		}
+		return len(preference)
+	}
+	sort.Slice(namespaces, func(i, j int) bool {
+		if rankI, rankJ := rank(namespaces[i]), rank(namespaces[j]); rankI != rankJ {
+			return rankI < rankJ
+		}
+		return namespaces[i] < namespaces[j]
+	})
+	return onlyNamespace(regularDNSInfo, namespaces[0]), onlyNamespace(wildcardDNSInfo, namespaces[0])
+}
+
+// onlyNamespace returns the entry of dnsInfo for the namespace, if it exists.
+func onlyNamespace(dnsInfo namespaceDNSInfo, namespace string) namespaceDNSInfo {
+	filtered := make(namespaceDNSInfo)
+	if objName, found := dnsInfo[namespace]; found {
+		filtered[namespace] = objName
+	}
+	return filtered
+}
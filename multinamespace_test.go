@@ -0,0 +1,144 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApplyMultiNamespacePolicy(t *testing.T) {
+	regular := namespaceDNSInfo{"ns1": "regular1", "ns2": "regular2"}
+	wildcard := namespaceDNSInfo{"ns2": "wildcard2", "ns3": "wildcard3"}
+
+	tests := []struct {
+		name             string
+		policy           multiNamespacePolicy
+		preference       []string
+		expectedRegular  namespaceDNSInfo
+		expectedWildcard namespaceDNSInfo
+	}{
+		{
+			name:             "Objects of all the namespaces are updated",
+			policy:           multiNamespacePolicyAll,
+			preference:       []string{"ns3"},
+			expectedRegular:  regular,
+			expectedWildcard: wildcard,
+		},
+		{
+			name:             "Objects of the most preferred namespace are updated",
+			policy:           multiNamespacePolicyPriority,
+			preference:       []string{"ns2", "ns1"},
+			expectedRegular:  namespaceDNSInfo{"ns2": "regular2"},
+			expectedWildcard: namespaceDNSInfo{"ns2": "wildcard2"},
+		},
+		{
+			name:             "Preferred namespace without any object is skipped",
+			policy:           multiNamespacePolicyPriority,
+			preference:       []string{"ns4", "ns3"},
+			expectedRegular:  namespaceDNSInfo{},
+			expectedWildcard: namespaceDNSInfo{"ns3": "wildcard3"},
+		},
+		{
+			name:             "Namespaces which are not preferred are ordered lexicographically",
+			policy:           multiNamespacePolicyPriority,
+			expectedRegular:  namespaceDNSInfo{"ns1": "regular1"},
+			expectedWildcard: namespaceDNSInfo{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			gotRegular, gotWildcard := applyMultiNamespacePolicy(tc.policy, tc.preference, regular, wildcard)
+			if diff := cmp.Diff(tc.expectedRegular, gotRegular); diff != "" {
+				t.Fatalf("Unexpected regular DNS info (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedWildcard, gotWildcard); diff != "" {
+				t.Fatalf("Unexpected wildcard DNS info (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	// The given maps are not modified.
+	if len(regular) != 2 || len(wildcard) != 2 {
+		t.Fatalf("Expected the given DNS infos not to be modified, found %v and %v", regular, wildcard)
+	}
+}
+
+func TestServeDNSMultiNamespace(t *testing.T) {
+	tests := []struct {
+		name               string
+		policy             multiNamespacePolicy
+		preference         []string
+		expectedNamespaces []string
+	}{
+		{
+			name:               "Objects of all the namespaces are updated",
+			policy:             multiNamespacePolicyAll,
+			expectedNamespaces: []string{"ns1", "ns2", "ns3"},
+		},
+		{
+			name:               "Object of the most preferred namespace is updated",
+			policy:             multiNamespacePolicyPriority,
+			preference:         []string{"ns3", "ns1"},
+			expectedNamespaces: []string{"ns3"},
+		},
+		{
+			name:               "Object of the first namespace is updated without preference",
+			policy:             multiNamespacePolicyPriority,
+			expectedNamespaces: []string{"ns1"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.multiNamespacePolicy = tc.policy
+			resolver.namespacePriority = tc.preference
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			for _, namespace := range []string{"ns1", "ns2", "ns3"} {
+				createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "regular",
+						Namespace: namespace,
+					},
+					Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+						Name: "www.example.com.",
+					},
+				})
+			}
+
+			query := test.Case{
+				Qname: "www.example.com.",
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A("www.example.com. 30 IN A 1.1.1.1"),
+				},
+			}
+			resolver.Next = fakeNextPluginHandler(query)
+			resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+			var namespaces []string
+			for _, action := range fakeNetworkClient.Actions() {
+				if action.GetVerb() == "update" && action.GetSubresource() == "status" {
+					namespaces = append(namespaces, action.GetNamespace())
+				}
+			}
+			sort.Strings(namespaces)
+			if diff := cmp.Diff(tc.expectedNamespaces, namespaces); diff != "" {
+				t.Fatalf("Unexpected namespaces of the updated objects (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
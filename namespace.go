@@ -1,11 +1,85 @@
 package ocp_dnsnameresolver
 
+import (
+	"regexp"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// filterOperator determines how the `namespaces` and the `labelSelector`
+// configurations are combined when both are specified.
+type filterOperator string
+
+const (
+	// filterOperatorAnd requires a DNSNameResolver object to be in one of the
+	// configured namespaces and to match the label selector.
+	filterOperatorAnd filterOperator = "and"
+	// filterOperatorOr requires a DNSNameResolver object to be in one of the
+	// configured namespaces or to match the label selector.
+	filterOperatorOr filterOperator = "or"
+)
+
+// parseFilterOperator returns the filterOperator corresponding to the given
+// value and whether the value is a valid filterOperator.
+func parseFilterOperator(value string) (filterOperator, bool) {
+	switch operator := filterOperator(value); operator {
+	case filterOperatorAnd, filterOperatorOr:
+		return operator, true
+	}
+	return "", false
+}
+
+// compileNamespaceRegex compiles the regular expression of the `namespaceRegex`
+// configuration. The regular expression is anchored, so that it matches the whole
+// namespace name.
+func compileNamespaceRegex(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+// namespacesConfigured returns true when the `namespaces`, the `namespaceRegex` or the
+// `namespaceSelector` configuration is specified.
+func (resolver *OCPDNSNameResolver) namespacesConfigured() bool {
+	return len(resolver.namespaces) > 0 || resolver.namespaceRegex != nil || resolver.namespaceSelector != nil
+}
+
 // configuredNamespace returns true when the given namespace is specified in the
-// `namespaces` configuration or if the `namespaces` configuration is omitted.
+// `namespaces` configuration, when it matches the `namespaceRegex` configuration,
+// when its labels match the `namespaceSelector` configuration, or if all the
+// configurations are omitted.
 func (resolver *OCPDNSNameResolver) configuredNamespace(namespace string) bool {
-	_, ok := resolver.namespaces[namespace]
-	if len(resolver.namespaces) > 0 && !ok {
-		return false
+	if !resolver.namespacesConfigured() {
+		return true
+	}
+	if _, ok := resolver.namespaces[namespace]; ok {
+		return true
+	}
+	if resolver.namespaceRegex != nil && resolver.namespaceRegex.MatchString(namespace) {
+		return true
+	}
+	return resolver.namespaceSelector != nil && resolver.selectedNamespace(namespace)
+}
+
+// configuredLabels returns true when the given labels match the `labelSelector`
+// configuration or if the `labelSelector` configuration is omitted.
+func (resolver *OCPDNSNameResolver) configuredLabels(objLabels map[string]string) bool {
+	if resolver.labelSelector == nil {
+		return true
+	}
+	return resolver.labelSelector.Matches(labels.Set(objLabels))
+}
+
+// configuredObject returns true when the DNSNameResolver object should be
+// monitored according to the `namespaces`, the `namespaceSelector` and the
+// `labelSelector` configurations.
+// If both the configurations are specified then they are combined using the
+// configured filter operator. If only one of them is specified then only that
+// configuration is checked.
+func (resolver *OCPDNSNameResolver) configuredObject(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+	inNamespace := resolver.configuredNamespace(resolverObj.Namespace)
+	matchesLabels := resolver.configuredLabels(resolverObj.Labels)
+	if resolver.namespacesConfigured() && resolver.labelSelector != nil && resolver.filterOperator == filterOperatorOr {
+		return inNamespace || matchesLabels
 	}
-	return true
+	return inNamespace && matchesLabels
 }
@@ -1,12 +1,25 @@
 package ocp_dnsnameresolver
 
-import "testing"
+import (
+	"regexp"
+	"testing"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
 
 func TestConfiguredNamespace(t *testing.T) {
+	namespaceRegex, err := compileNamespaceRegex("team-.*")
+	if err != nil {
+		t.Fatalf("error compiling namespace regex: %v", err)
+	}
+
 	tests := []struct {
-		expected      bool
-		namespaces    map[string]struct{}
-		testNamespace string
+		expected       bool
+		namespaces     map[string]struct{}
+		namespaceRegex *regexp.Regexp
+		testNamespace  string
 	}{
 		{
 			expected:      true,
@@ -28,14 +41,185 @@ func TestConfiguredNamespace(t *testing.T) {
 			namespaces:    map[string]struct{}{},
 			testNamespace: "nsnoexist",
 		},
+		{
+			expected:       true,
+			namespaces:     map[string]struct{}{},
+			namespaceRegex: namespaceRegex,
+			testNamespace:  "team-dns",
+		},
+		{
+			expected:       false,
+			namespaces:     map[string]struct{}{},
+			namespaceRegex: namespaceRegex,
+			testNamespace:  "nsnoexist",
+		},
+		{
+			// The regular expression must match the whole namespace name.
+			expected:       false,
+			namespaces:     map[string]struct{}{},
+			namespaceRegex: namespaceRegex,
+			testNamespace:  "my-team-dns",
+		},
+		{
+			// The namespaces matching the regular expression are monitored in addition
+			// to the explicit ones.
+			expected:       true,
+			namespaces:     map[string]struct{}{"foobar": {}},
+			namespaceRegex: namespaceRegex,
+			testNamespace:  "foobar",
+		},
+		{
+			expected:       true,
+			namespaces:     map[string]struct{}{"foobar": {}},
+			namespaceRegex: namespaceRegex,
+			testNamespace:  "team-dns",
+		},
 	}
 
 	resolver := OCPDNSNameResolver{}
 	for i, test := range tests {
 		resolver.namespaces = test.namespaces
+		resolver.namespaceRegex = test.namespaceRegex
 		actual := resolver.configuredNamespace(test.testNamespace)
 		if actual != test.expected {
 			t.Errorf("Test %d failed. Namespace %s was expected to be configured", i, test.testNamespace)
 		}
 	}
 }
+
+func TestConfiguredObject(t *testing.T) {
+	selector, err := labels.Parse("team=dns")
+	if err != nil {
+		t.Fatalf("error parsing label selector: %v", err)
+	}
+	namespaces := map[string]struct{}{"foobar": {}}
+
+	tests := []struct {
+		name           string
+		namespaces     map[string]struct{}
+		labelSelector  labels.Selector
+		filterOperator filterOperator
+		testNamespace  string
+		testLabels     map[string]string
+		expected       bool
+	}{
+		// Neither namespaces nor label selector configured.
+		{
+			name:           "No filter configured",
+			namespaces:     map[string]struct{}{},
+			filterOperator: filterOperatorAnd,
+			testNamespace:  "nsnoexist",
+			expected:       true,
+		},
+		// Only one of namespaces and label selector configured.
+		{
+			name:           "Only namespaces configured, namespace not matching",
+			namespaces:     namespaces,
+			filterOperator: filterOperatorOr,
+			testNamespace:  "nsnoexist",
+			testLabels:     map[string]string{"team": "dns"},
+			expected:       false,
+		},
+		{
+			name:           "Only label selector configured, labels not matching",
+			namespaces:     map[string]struct{}{},
+			labelSelector:  selector,
+			filterOperator: filterOperatorOr,
+			testNamespace:  "foobar",
+			testLabels:     map[string]string{"team": "network"},
+			expected:       false,
+		},
+		// Both namespaces and label selector configured with AND.
+		{
+			name:           "AND: namespace and labels matching",
+			namespaces:     namespaces,
+			labelSelector:  selector,
+			filterOperator: filterOperatorAnd,
+			testNamespace:  "foobar",
+			testLabels:     map[string]string{"team": "dns"},
+			expected:       true,
+		},
+		{
+			name:           "AND: namespace matching, labels not matching",
+			namespaces:     namespaces,
+			labelSelector:  selector,
+			filterOperator: filterOperatorAnd,
+			testNamespace:  "foobar",
+			testLabels:     map[string]string{"team": "network"},
+			expected:       false,
+		},
+		{
+			name:           "AND: namespace not matching, labels matching",
+			namespaces:     namespaces,
+			labelSelector:  selector,
+			filterOperator: filterOperatorAnd,
+			testNamespace:  "nsnoexist",
+			testLabels:     map[string]string{"team": "dns"},
+			expected:       false,
+		},
+		{
+			name:           "AND: namespace and labels not matching",
+			namespaces:     namespaces,
+			labelSelector:  selector,
+			filterOperator: filterOperatorAnd,
+			testNamespace:  "nsnoexist",
+			expected:       false,
+		},
+		// Both namespaces and label selector configured with OR.
+		{
+			name:           "OR: namespace and labels matching",
+			namespaces:     namespaces,
+			labelSelector:  selector,
+			filterOperator: filterOperatorOr,
+			testNamespace:  "foobar",
+			testLabels:     map[string]string{"team": "dns"},
+			expected:       true,
+		},
+		{
+			name:           "OR: namespace matching, labels not matching",
+			namespaces:     namespaces,
+			labelSelector:  selector,
+			filterOperator: filterOperatorOr,
+			testNamespace:  "foobar",
+			testLabels:     map[string]string{"team": "network"},
+			expected:       true,
+		},
+		{
+			name:           "OR: namespace not matching, labels matching",
+			namespaces:     namespaces,
+			labelSelector:  selector,
+			filterOperator: filterOperatorOr,
+			testNamespace:  "nsnoexist",
+			testLabels:     map[string]string{"team": "dns"},
+			expected:       true,
+		},
+		{
+			name:           "OR: namespace and labels not matching",
+			namespaces:     namespaces,
+			labelSelector:  selector,
+			filterOperator: filterOperatorOr,
+			testNamespace:  "nsnoexist",
+			expected:       false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := OCPDNSNameResolver{
+				namespaces:     tc.namespaces,
+				labelSelector:  tc.labelSelector,
+				filterOperator: tc.filterOperator,
+			}
+			resolverObj := &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: tc.testNamespace,
+					Labels:    tc.testLabels,
+				},
+			}
+			if actual := resolver.configuredObject(resolverObj); actual != tc.expected {
+				t.Fatalf("Expected object in namespace %s with labels %v to be configured: %t, found: %t", tc.testNamespace, tc.testLabels, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package ocp_dnsnameresolver
+
+import (
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// watchNamespaces returns true when the namespaces are watched, i.e. when
+// pruneDeletedNamespaces is enabled or namespaceSelector is configured.
+func (resolver *OCPDNSNameResolver) watchNamespaces() bool {
+	return resolver.pruneDeletedNamespaces || resolver.namespaceSelector != nil
+}
+
+// initNamespaceInformer initializes the namespace informer, when pruneDeletedNamespaces is
+// enabled or namespaceSelector is configured. The namespaces being deleted are pruned from
+// the tracked DNS names, as their DNSNameResolver objects are about to be deleted, so that
+// the DNS lookups arriving before the delete events of the objects do not try to update
+// them. The namespaces whose labels match the namespaceSelector are selected, and
+// deselected once their labels do not match anymore.
+func (resolver *OCPDNSNameResolver) initNamespaceInformer(kubeClient kubernetes.Interface) error {
+	resolver.namespaceInformer = informers.NewSharedInformerFactory(kubeClient, defaultResyncPeriod).Core().V1().Namespaces().Informer()
+	_, err := resolver.namespaceInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if namespace, ok := obj.(*corev1.Namespace); ok {
+				resolver.observeNamespace(namespace)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if namespace, ok := newObj.(*corev1.Namespace); ok {
+				resolver.observeNamespace(namespace)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			namespace, ok := obj.(*corev1.Namespace)
+			if !ok {
+				return
+			}
+			if resolver.pruneDeletedNamespaces {
+				resolver.pruneNamespace(namespace.Name)
+				// A namespace with the same name may be created again.
+				resolver.terminatingLock.Lock()
+				delete(resolver.terminatingNamespaces, namespace.Name)
+				resolver.terminatingLock.Unlock()
+			}
+			if resolver.namespaceSelector != nil {
+				resolver.selectNamespace(namespace.Name, false)
+			}
+		},
+	})
+	return err
+}
+
+// observeNamespace handles the namespace added or updated in the namespace informer.
+func (resolver *OCPDNSNameResolver) observeNamespace(namespace *corev1.Namespace) {
+	if resolver.pruneDeletedNamespaces && namespace.DeletionTimestamp != nil {
+		resolver.pruneNamespace(namespace.Name)
+	}
+	if resolver.namespaceSelector != nil {
+		resolver.selectNamespace(namespace.Name, resolver.namespaceSelector.Matches(labels.Set(namespace.Labels)))
+	}
+}
+
+// selectNamespace selects or deselects the namespace for the namespaceSelector. If the
+// selection changes then the DNSNameResolver objects of the namespace already observed by
+// the DNSNameResolver informer are added to or removed from the tracked DNS names,
+// depending on whether they are still configured to be monitored.
+func (resolver *OCPDNSNameResolver) selectNamespace(namespace string, selected bool) {
+	resolver.selectedLock.Lock()
+	_, wasSelected := resolver.selectedNamespaces[namespace]
+	if selected {
+		resolver.selectedNamespaces[namespace] = struct{}{}
+	} else {
+		delete(resolver.selectedNamespaces, namespace)
+	}
+	resolver.selectedLock.Unlock()
+	if selected == wasSelected {
+		return
+	}
+
+	if selected {
+		log.Infof("Tracking the DNSNameResolver objects of namespace %s matching the namespace selector", namespace)
+	} else {
+		log.Infof("Dropping the DNSNameResolver objects of namespace %s not matching the namespace selector anymore", namespace)
+	}
+	if resolver.dnsNameResolverInformer == nil {
+		return
+	}
+	objs, err := resolver.dnsNameResolverInformer.GetIndexer().ByIndex(cache.NamespaceIndex, namespace)
+	if err != nil {
+		log.Errorf("Encountered error while listing the DNSNameResolver objects of namespace %s: %v", namespace, err)
+		return
+	}
+	for _, obj := range objs {
+		resolverObj, ok := obj.(*ocpnetworkapiv1alpha1.DNSNameResolver)
+		if !ok {
+			continue
+		}
+		configured := resolver.configuredObject(resolverObj)
+		if selected && configured {
+			resolver.addResolverObject(resolverObj)
+		} else if !selected && !configured {
+			resolver.deleteResolverObject(resolverObj)
+		}
+	}
+}
+
+// selectedNamespace returns true if the labels of the namespace match the
+// namespaceSelector, as last observed by the namespace informer.
+func (resolver *OCPDNSNameResolver) selectedNamespace(namespace string) bool {
+	resolver.selectedLock.Lock()
+	defer resolver.selectedLock.Unlock()
+	_, exists := resolver.selectedNamespaces[namespace]
+	return exists
+}
+
+// pruneNamespace drops the DNSNameResolver objects of the namespace being deleted from the
+// tracked DNS names. The DNSNameResolver objects of the namespace are not tracked anymore
+// until the namespace is deleted.
+func (resolver *OCPDNSNameResolver) pruneNamespace(namespace string) {
+	resolver.terminatingLock.Lock()
+	_, pruned := resolver.terminatingNamespaces[namespace]
+	resolver.terminatingNamespaces[namespace] = struct{}{}
+	resolver.terminatingLock.Unlock()
+	if !pruned {
+		log.Infof("Dropping the DNSNameResolver objects of namespace %s being deleted", namespace)
+	}
+
+	resolver.regularMapLock.Lock()
+	resolver.dropNamespace(resolver.regularDNSInfo, namespace)
+	resolver.regularMapLock.Unlock()
+
+	resolver.wildcardMapLock.Lock()
+	resolver.dropNamespace(resolver.wildcardDNSInfo, namespace)
+	resolver.wildcardMapLock.Unlock()
+
+	resolver.forgetEvictedNamespace(namespace)
+
+	resolver.regexMapLock.Lock()
+	for key := range resolver.regexDNSInfo {
+		if key.Namespace == namespace {
+			delete(resolver.regexDNSInfo, key)
+		}
+	}
+	resolver.regexMapLock.Unlock()
+}
+
+// dropNamespace drops the namespace from the given DNS name info map. The DNS names left
+// without any namespace are dropped as well, and sent to the webhook as not tracked
+// anymore. The caller must hold the lock of the map.
+func (resolver *OCPDNSNameResolver) dropNamespace(dnsInfo map[string]namespaceDNSInfo, namespace string) {
+	for dnsName, dnsInfoMap := range dnsInfo {
+		if _, exists := dnsInfoMap[namespace]; !exists {
+			continue
+		}
+		delete(dnsInfoMap, namespace)
+		if len(dnsInfoMap) == 0 {
+			delete(dnsInfo, dnsName)
+			resolver.forgetTrackedName(dnsName)
+			resolver.notifyWebhook(webhookActionUntracked, dnsName)
+		}
+	}
+}
+
+// terminatingNamespace returns true if the namespace is being deleted, when
+// pruneDeletedNamespaces is enabled.
+func (resolver *OCPDNSNameResolver) terminatingNamespace(namespace string) bool {
+	resolver.terminatingLock.Lock()
+	defer resolver.terminatingLock.Unlock()
+	_, exists := resolver.terminatingNamespaces[namespace]
+	return exists
+}
@@ -0,0 +1,168 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubefakeclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestPruneDeletedNamespaces(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.pruneDeletedNamespaces = true
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	fakeKubeClient := kubefakeclient.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dns"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "doomed"}},
+	)
+	if err := resolver.initNamespaceInformer(fakeKubeClient); err != nil {
+		t.Fatalf("error initializing namespace informer: %v", err)
+	}
+	go resolver.namespaceInformer.Run(ctx.Done())
+	cache.WaitForCacheSync(ctx.Done(), resolver.namespaceInformer.HasSynced)
+
+	for _, namespace := range []string{"dns", "doomed"} {
+		for name, dnsName := range map[string]string{"regular": "www.example.com.", "wildcard": "*.example.com."} {
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+				Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: ocpnetworkapiv1alpha1.DNSName(dnsName)},
+			})
+		}
+	}
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "only", Namespace: "doomed"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "only.example.org."},
+	})
+
+	// Start the deletion of the namespace.
+	now := metav1.Now()
+	if _, err := fakeKubeClient.CoreV1().Namespaces().Update(ctx, &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "doomed", DeletionTimestamp: &now},
+	}, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Unexpected error updating namespace: %v", err)
+	}
+	if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, time.Minute, true, func(ctx context.Context) (bool, error) {
+		return !isTracked(resolver, "doomed", "regular", "www.example.com.") &&
+			!isTracked(resolver, "doomed", "wildcard", "*.example.com.") &&
+			!isTracked(resolver, "doomed", "only", "only.example.org."), nil
+	}); err != nil {
+		t.Fatalf("Expected the DNSNameResolver objects of the namespace being deleted to be pruned: %v", err)
+	}
+	if !isTracked(resolver, "dns", "regular", "www.example.com.") || !isTracked(resolver, "dns", "wildcard", "*.example.com.") {
+		t.Fatalf("Expected the DNSNameResolver objects of the other namespace to be tracked")
+	}
+	resolver.regularMapLock.Lock()
+	_, exists := resolver.regularDNSInfo["only.example.org."]
+	resolver.regularMapLock.Unlock()
+	if exists {
+		t.Fatalf("Expected the DNS name left without any namespace to be dropped")
+	}
+
+	// The DNSNameResolver objects of the namespace being deleted are not tracked anymore.
+	if _, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("doomed").Create(ctx, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "late", Namespace: "doomed"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "late.example.org."},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Unexpected error creating DNSNameResolver object: %v", err)
+	}
+	getResolverObject(t, resolver, "doomed", "late", func(*ocpnetworkapiv1alpha1.DNSNameResolver) bool { return true })
+	if isTracked(resolver, "doomed", "late", "late.example.org.") {
+		t.Fatalf("Expected the DNSNameResolver object of the namespace being deleted not to be tracked")
+	}
+
+	// Once the namespace is deleted, a namespace with the same name can be tracked again.
+	if err := fakeKubeClient.CoreV1().Namespaces().Delete(ctx, "doomed", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Unexpected error deleting namespace: %v", err)
+	}
+	if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, time.Minute, true, func(ctx context.Context) (bool, error) {
+		return !resolver.terminatingNamespace("doomed"), nil
+	}); err != nil {
+		t.Fatalf("Expected the deleted namespace not to be terminating anymore: %v", err)
+	}
+}
+
+func TestNamespaceSelector(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.namespaceSelector = labels.SelectorFromSet(labels.Set{"dns-tracking": "enabled"})
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	fakeKubeClient := kubefakeclient.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dns", Labels: map[string]string{"dns-tracking": "enabled"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}},
+	)
+	if err := resolver.initNamespaceInformer(fakeKubeClient); err != nil {
+		t.Fatalf("error initializing namespace informer: %v", err)
+	}
+	go resolver.namespaceInformer.Run(ctx.Done())
+	cache.WaitForCacheSync(ctx.Done(), resolver.namespaceInformer.HasSynced)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+	})
+	for name, dnsName := range map[string]string{"regular": "www.example.com.", "wildcard": "*.example.org."} {
+		if _, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("other").Create(ctx, &ocpnetworkapiv1alpha1.DNSNameResolver{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "other"},
+			Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: ocpnetworkapiv1alpha1.DNSName(dnsName)},
+		}, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("Unexpected error creating DNSNameResolver object: %v", err)
+		}
+		getResolverObject(t, resolver, "other", name, func(*ocpnetworkapiv1alpha1.DNSNameResolver) bool { return true })
+	}
+	if isTracked(resolver, "other", "regular", "www.example.com.") || isTracked(resolver, "other", "wildcard", "*.example.org.") {
+		t.Fatalf("Expected the DNSNameResolver objects of the namespace not matching the selector not to be tracked")
+	}
+
+	// Adding the label to the namespace tracks its existing DNSNameResolver objects.
+	if _, err := fakeKubeClient.CoreV1().Namespaces().Update(ctx, &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Labels: map[string]string{"dns-tracking": "enabled"}},
+	}, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Unexpected error updating namespace: %v", err)
+	}
+	if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, time.Minute, true, func(ctx context.Context) (bool, error) {
+		return isTracked(resolver, "other", "regular", "www.example.com.") && isTracked(resolver, "other", "wildcard", "*.example.org."), nil
+	}); err != nil {
+		t.Fatalf("Expected the DNSNameResolver objects of the namespace labeled at runtime to be tracked: %v", err)
+	}
+
+	// Removing the label from the namespace drops its DNSNameResolver objects.
+	if _, err := fakeKubeClient.CoreV1().Namespaces().Update(ctx, &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "dns"},
+	}, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Unexpected error updating namespace: %v", err)
+	}
+	if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, time.Minute, true, func(ctx context.Context) (bool, error) {
+		return !isTracked(resolver, "dns", "regular", "www.example.com."), nil
+	}); err != nil {
+		t.Fatalf("Expected the DNSNameResolver objects of the namespace unlabeled at runtime not to be tracked: %v", err)
+	}
+	if !isTracked(resolver, "other", "regular", "www.example.com.") {
+		t.Fatalf("Expected the DNSNameResolver objects of the namespace still matching the selector to be tracked")
+	}
+
+	// The DNSNameResolver objects created in the unlabeled namespace are not tracked anymore.
+	if _, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("dns").Create(ctx, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "late", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "late.example.org."},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Unexpected error creating DNSNameResolver object: %v", err)
+	}
+	getResolverObject(t, resolver, "dns", "late", func(*ocpnetworkapiv1alpha1.DNSNameResolver) bool { return true })
+	if isTracked(resolver, "dns", "late", "late.example.org.") {
+		t.Fatalf("Expected the DNSNameResolver object of the unlabeled namespace not to be tracked")
+	}
+}
@@ -0,0 +1,151 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// negativeSweepPeriod gives the maximum period of clearing the negative results older
+	// than negativeMaxAge. The period is shortened to negativeMaxAge if it is shorter.
+	negativeSweepPeriod = 30 * time.Second
+
+	// negativeRefreshInterval gives the age above which the negative result of a DNS name is
+	// refreshed by its next NXDOMAIN response, so that the repeated NXDOMAIN responses do
+	// not update the DNSNameResolver objects on each DNS lookup. The interval is shortened
+	// to negativeMaxAge if it is shorter.
+	negativeRefreshInterval = time.Minute
+
+	// maxNegativeResults gives the maximum number of negative results recorded per
+	// DNSNameResolver object, eg. for a wildcard DNSNameResolver object matching many
+	// non-existent DNS names. The oldest negative results are dropped beyond it.
+	maxNegativeResults = 100
+
+	// negativeResultsAnnotation is the annotation used for storing the time of the last
+	// NXDOMAIN response of the DNS names matching a DNSNameResolver object. The
+	// DNSNameResolver status does not have a field for negative results, thus they are
+	// stored in the annotation as a JSON encoded map. The entry of a DNS name is removed
+	// once the DNS name is successfully resolved again.
+	// key: DNS name, value: RFC 3339 timestamp of the last NXDOMAIN response.
+	negativeResultsAnnotation = "dnsnameresolver.openshift.io/negative-results"
+)
+
+// updateNegativeResults updates the negative results annotation of the DNSNameResolver
+// objects corresponding to the regular and the wildcard DNS names. If negative is true
+// then the time of the NXDOMAIN response is recorded for the DNS name, unless its negative
+// result is more recent than the refresh interval, otherwise the entry of the DNS name is
+// removed, if it exists.
+func (resolver *OCPDNSNameResolver) updateNegativeResults(
+	ctx context.Context,
+	regularDNSInfo namespaceDNSInfo,
+	wildcardDNSInfo namespaceDNSInfo,
+	dnsName string,
+	negative bool,
+) {
+	updateAnnotationEntries(ctx, resolver, regularDNSInfo, wildcardDNSInfo, negativeResultsAnnotation, "negative results",
+		func(negativeResults map[string]string) bool {
+			if negative {
+				now := time.Now().UTC()
+				// If the negative result of the DNS name is recent then skip the update call.
+				if timestamp, exists := negativeResults[dnsName]; exists {
+					refreshInterval := negativeRefreshInterval
+					if resolver.negativeMaxAge > 0 {
+						refreshInterval = min(resolver.negativeMaxAge, negativeRefreshInterval)
+					}
+					if lastNegative, err := time.Parse(time.RFC3339, timestamp); err == nil && now.Sub(lastNegative) < refreshInterval {
+						return false
+					}
+				}
+				negativeResults[dnsName] = now.Format(time.RFC3339)
+				dropOldestNegativeResults(negativeResults, maxNegativeResults)
+				return true
+			}
+			// If there is no negative result of the DNS name then skip the update call.
+			if _, exists := negativeResults[dnsName]; !exists {
+				return false
+			}
+			delete(negativeResults, dnsName)
+			return true
+		})
+}
+
+// dropOldestNegativeResults drops the oldest negative results beyond the maximum number of
+// negative results. The negative results with an invalid timestamp are dropped first.
+func dropOldestNegativeResults(negativeResults map[string]string, max int) {
+	if len(negativeResults) <= max {
+		return
+	}
+	lastNegative := func(dnsName string) time.Time {
+		// The zero time is returned for an invalid timestamp.
+		timestamp, _ := time.Parse(time.RFC3339, negativeResults[dnsName])
+		return timestamp
+	}
+	dnsNames := sortedKeys(negativeResults)
+	sort.SliceStable(dnsNames, func(i, j int) bool {
+		return lastNegative(dnsNames[i]).Before(lastNegative(dnsNames[j]))
+	})
+	for _, dnsName := range dnsNames[:len(dnsNames)-max] {
+		delete(negativeResults, dnsName)
+	}
+}
+
+// sweepNegativeResults clears the negative results of the tracked DNSNameResolver objects
+// which are older than negativeMaxAge at the given time, so that the negative results of
+// the DNS names which resolve again do not linger until the next DNS lookup. The negative
+// results with an invalid timestamp are cleared as well.
+func (resolver *OCPDNSNameResolver) sweepNegativeResults(ctx context.Context, now time.Time) {
+	for object := range resolver.trackedObjects() {
+		// Retry the update of the DNSNameResolver object if there's a conflict or a transient error during the update.
+		resolver.retrier.retryUpdate(object.Namespace, object.Name, "negative results", func() error {
+			// Fetch a copy of the DNSNameResolver object. All the updates will be applied to the copied object.
+			resolverObj, err := resolver.store.get(ctx, object.Namespace, object.Name)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					return nil
+				}
+				return err
+			}
+
+			value, exists := resolverObj.Annotations[negativeResultsAnnotation]
+			if !exists {
+				return nil
+			}
+			negativeResults := make(map[string]string)
+			if err := json.Unmarshal([]byte(value), &negativeResults); err != nil {
+				log.Warningf("Clearing invalid value of annotation %s of DNSNameResolver object %s/%s: %v",
+					negativeResultsAnnotation, object.Namespace, object.Name, err)
+				negativeResults = nil
+			}
+
+			cleared := false
+			for dnsName, timestamp := range negativeResults {
+				lastNegative, err := time.Parse(time.RFC3339, timestamp)
+				if err != nil || now.Sub(lastNegative) >= resolver.negativeMaxAge {
+					delete(negativeResults, dnsName)
+					cleared = true
+				}
+			}
+			// If there are no aged negative results then skip the update call.
+			if negativeResults != nil && !cleared {
+				return nil
+			}
+
+			if len(negativeResults) == 0 {
+				removeAnnotation(resolverObj, negativeResultsAnnotation)
+			} else {
+				value, err := json.Marshal(negativeResults)
+				if err != nil {
+					return err
+				}
+				setAnnotation(resolverObj, negativeResultsAnnotation, string(value))
+			}
+
+			// Update the DNSNameResolver object.
+			return resolver.store.update(ctx, resolverObj)
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// getNegativeResults returns the negative results stored in the annotation of the DNSNameResolver object.
+func getNegativeResults(t *testing.T, resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) map[string]string {
+	t.Helper()
+
+	negativeResults := make(map[string]string)
+	if value, exists := resolverObj.Annotations[negativeResultsAnnotation]; exists {
+		if err := json.Unmarshal([]byte(value), &negativeResults); err != nil {
+			t.Fatalf("Invalid value of annotation %s: %v", negativeResultsAnnotation, err)
+		}
+	}
+	return negativeResults
+}
+
+func TestServeDNSRecordNegative(t *testing.T) {
+	nxdomainQuery := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+	}
+	servfailQuery := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeServerFailure,
+	}
+	successQuery := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("www.example.com. 30 IN A 1.1.1.1"),
+		},
+	}
+
+	tests := []struct {
+		name             string
+		recordNegative   bool
+		queries          []test.Case
+		expectedNegative bool
+	}{
+		{
+			name:             "NXDOMAIN is not recorded by default",
+			queries:          []test.Case{nxdomainQuery},
+			expectedNegative: false,
+		},
+		{
+			name:             "NXDOMAIN is recorded with recordNegative",
+			recordNegative:   true,
+			queries:          []test.Case{nxdomainQuery},
+			expectedNegative: true,
+		},
+		{
+			name:             "Other failures are not recorded as negative results",
+			recordNegative:   true,
+			queries:          []test.Case{servfailQuery},
+			expectedNegative: false,
+		},
+		{
+			name:             "Negative result is removed once the DNS name is resolved",
+			recordNegative:   true,
+			queries:          []test.Case{nxdomainQuery, successQuery},
+			expectedNegative: false,
+		},
+		{
+			name:             "Negative result is kept after other failures",
+			recordNegative:   true,
+			queries:          []test.Case{nxdomainQuery, servfailQuery},
+			expectedNegative: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.recordNegative = tc.recordNegative
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			})
+
+			var expectedNegative bool
+			for _, query := range tc.queries {
+				resolver.Next = fakeNextPluginHandler(query)
+				resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+				// Wait for the informer cache to observe the update before the next query.
+				expectedNegative = query.Rcode == dns.RcodeNameError || (expectedNegative && query.Rcode != dns.RcodeSuccess)
+				expected := expectedNegative && tc.recordNegative
+				getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+					_, found := getNegativeResults(t, obj)["www.example.com."]
+					return found == expected
+				})
+			}
+
+			resolverObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+				return true
+			})
+			negativeResults := getNegativeResults(t, resolverObj)
+			if _, found := negativeResults["www.example.com."]; found != tc.expectedNegative {
+				t.Fatalf("Expected negative result recorded to be %t, found annotation value: %v", tc.expectedNegative, resolverObj.Annotations)
+			}
+			if !tc.expectedNegative && len(resolverObj.Annotations[negativeResultsAnnotation]) != 0 {
+				t.Fatalf("Expected annotation %s to be removed, found: %v", negativeResultsAnnotation, resolverObj.Annotations)
+			}
+		})
+	}
+}
+
+func TestSweepNegativeResults(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.recordNegative = true
+	resolver.negativeMaxAge = 5 * time.Minute
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	now := time.Now()
+	aged := now.Add(-10 * time.Minute).UTC().Format(time.RFC3339)
+	recent := now.Add(-time.Minute).UTC().Format(time.RFC3339)
+	for name, annotation := range map[string]string{
+		"mixed":   `{"a.example.com.":"` + aged + `","b.example.com.":"` + recent + `"}`,
+		"aged":    `{"a.example.com.":"` + aged + `"}`,
+		"recent":  `{"b.example.com.":"` + recent + `"}`,
+		"invalid": `not json`,
+	} {
+		createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "dns",
+				Annotations: map[string]string{negativeResultsAnnotation: annotation},
+			},
+			Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+				Name: ocpnetworkapiv1alpha1.DNSName("*." + name + ".example.com."),
+			},
+		})
+	}
+
+	resolver.sweepNegativeResults(ctx, now)
+
+	for name, expected := range map[string]map[string]string{
+		"mixed":   {"b.example.com.": recent},
+		"aged":    {},
+		"recent":  {"b.example.com.": recent},
+		"invalid": {},
+	} {
+		resolverObj := getResolverObject(t, resolver, "dns", name, func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+			value, exists := obj.Annotations[negativeResultsAnnotation]
+			if !exists {
+				return len(expected) == 0
+			}
+			negativeResults := make(map[string]string)
+			return json.Unmarshal([]byte(value), &negativeResults) == nil && len(negativeResults) == len(expected)
+		})
+		if diff := cmp.Diff(expected, getNegativeResults(t, resolverObj)); diff != "" {
+			t.Fatalf("Negative results of %s did not match the expected ones:\nDiff: %s", name, diff)
+		}
+		if _, exists := resolverObj.Annotations[negativeResultsAnnotation]; exists && len(expected) == 0 {
+			t.Fatalf("Expected annotation %s of %s to be removed", negativeResultsAnnotation, name)
+		}
+	}
+}
+
+func TestServeDNSRecordNegativeRefresh(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.recordNegative = true
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "regular",
+			Namespace: "dns",
+		},
+		Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+			Name: "www.example.com.",
+		},
+	})
+
+	countAnnotationUpdates := func() int {
+		count := 0
+		for _, action := range fakeNetworkClient.Actions() {
+			if action.GetVerb() == "update" && action.GetSubresource() == "" {
+				count++
+			}
+		}
+		return count
+	}
+
+	query := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+	}
+	resolver.Next = fakeNextPluginHandler(query)
+	resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+	getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		_, found := getNegativeResults(t, obj)["www.example.com."]
+		return found
+	})
+	if count := countAnnotationUpdates(); count != 1 {
+		t.Fatalf("Expected 1 update of the negative results, found %d", count)
+	}
+
+	// The repeated NXDOMAIN responses within the refresh interval do not update the object.
+	for i := 0; i < 3; i++ {
+		resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+	}
+	if count := countAnnotationUpdates(); count != 1 {
+		t.Fatalf("Expected the negative result not to be refreshed within %v, found %d updates", negativeRefreshInterval, count)
+	}
+}
+
+func TestDropOldestNegativeResults(t *testing.T) {
+	now := time.Now().UTC()
+	negativeResults := map[string]string{
+		"a.example.com.": now.Add(-3 * time.Minute).Format(time.RFC3339),
+		"b.example.com.": now.Add(-time.Minute).Format(time.RFC3339),
+		"c.example.com.": "invalid",
+		"d.example.com.": now.Format(time.RFC3339),
+		"e.example.com.": now.Add(-2 * time.Minute).Format(time.RFC3339),
+	}
+
+	dropOldestNegativeResults(negativeResults, 3)
+
+	expected := map[string]string{
+		"b.example.com.": now.Add(-time.Minute).Format(time.RFC3339),
+		"d.example.com.": now.Format(time.RFC3339),
+		"e.example.com.": now.Add(-2 * time.Minute).Format(time.RFC3339),
+	}
+	if diff := cmp.Diff(expected, negativeResults); diff != "" {
+		t.Fatalf("Negative results did not match the expected ones:\nDiff: %s", diff)
+	}
+}
@@ -0,0 +1,74 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"sync"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+)
+
+const (
+	// namespaceLabelOverflow is the namespace label of the status updates of the namespaces
+	// beyond the maximum number of the namespace labels. It is not a valid namespace name,
+	// thus it never collides with the label of a namespace.
+	namespaceLabelOverflow = "_other"
+	// namespaceUpdateSuccess and namespaceUpdateFailure are the results of the status updates.
+	namespaceUpdateSuccess = "success"
+	namespaceUpdateFailure = "failure"
+)
+
+// namespaceLabels limits the number of the distinct namespace labels of the per namespace
+// metrics, so that a large number of namespaces does not make the metrics grow without
+// bound. The namespaces are given their own label in the order they are first seen, and
+// the namespaces beyond the maximum share the namespaceLabelOverflow label.
+type namespaceLabels struct {
+	max    int
+	labels map[string]struct{}
+	lock   sync.Mutex
+}
+
+// newNamespaceLabels returns a namespaceLabels allowing at most max distinct namespace labels.
+func newNamespaceLabels(max int) *namespaceLabels {
+	return &namespaceLabels{
+		max:    max,
+		labels: make(map[string]struct{}),
+	}
+}
+
+// label returns the label of the namespace.
+func (labels *namespaceLabels) label(namespace string) string {
+	labels.lock.Lock()
+	defer labels.lock.Unlock()
+	if _, exists := labels.labels[namespace]; exists {
+		return namespace
+	}
+	if len(labels.labels) >= labels.max {
+		return namespaceLabelOverflow
+	}
+	labels.labels[namespace] = struct{}{}
+	return namespace
+}
+
+// namespaceMetricsStore is a resolverStore counting the status updates of the
+// DNSNameResolver objects issued through the wrapped resolverStore by namespace and
+// result, when perNamespaceMetrics is enabled. It only wraps the store of version
+// v1alpha1, innermost of the decorators, so that only the status updates reaching the
+// API server are counted: the status updates rejected by the circuit breaker, suppressed
+// during the maintenance window, throttled or buffered until the next flush are not.
+type namespaceMetricsStore struct {
+	resolverStore
+	labels *namespaceLabels
+}
+
+var _ resolverStore = &namespaceMetricsStore{}
+
+// updateStatus implements resolverStore.
+func (store *namespaceMetricsStore) updateStatus(ctx context.Context, resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) error {
+	err := store.resolverStore.updateStatus(ctx, resolverObj)
+	result := namespaceUpdateSuccess
+	if err != nil {
+		result = namespaceUpdateFailure
+	}
+	namespaceStatusUpdates.WithLabelValues(store.labels.label(resolverObj.Namespace), result).Inc()
+	return err
+}
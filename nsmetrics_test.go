@@ -0,0 +1,104 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestNamespaceLabels(t *testing.T) {
+	labels := newNamespaceLabels(2)
+	steps := []struct {
+		namespace     string
+		expectedLabel string
+	}{
+		{"ns-a", "ns-a"},
+		{"ns-b", "ns-b"},
+		// The namespaces beyond the maximum share the overflow label.
+		{"ns-c", namespaceLabelOverflow},
+		// The namespaces already labelled keep their label.
+		{"ns-a", "ns-a"},
+		{"ns-d", namespaceLabelOverflow},
+	}
+	for i, step := range steps {
+		if label := labels.label(step.namespace); label != step.expectedLabel {
+			t.Errorf("Step %d: Expected label %q for namespace %s, found %q", i, step.expectedLabel, step.namespace, label)
+		}
+	}
+}
+
+func TestServeDNSPerNamespaceMetrics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.perNamespaceMetrics = true
+	resolver.maxNamespaceLabels = 2
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	// Each namespace tracks its own DNS name, so that the namespaces are labelled in the
+	// order of the DNS lookups.
+	namespaces := []string{"metrics-a", "metrics-b", "metrics-c"}
+	for _, namespace := range namespaces {
+		createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+			ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: namespace},
+			Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: ocpnetworkapiv1alpha1.DNSName(namespace + ".example.com.")},
+		})
+	}
+
+	// Forbid the status updates in one of the namespaces.
+	fakeNetworkClient.PrependReactor("update", "dnsnameresolvers", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "status" || action.GetNamespace() != "metrics-b" {
+			return false, nil, nil
+		}
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "network.openshift.io", Resource: "dnsnameresolvers"},
+			"regular", nil)
+	})
+
+	counts := func() map[[2]string]float64 {
+		result := make(map[[2]string]float64)
+		for _, namespace := range append(namespaces, namespaceLabelOverflow) {
+			for _, outcome := range []string{namespaceUpdateSuccess, namespaceUpdateFailure} {
+				result[[2]string{namespace, outcome}] = testutil.ToFloat64(namespaceStatusUpdates.WithLabelValues(namespace, outcome))
+			}
+		}
+		return result
+	}
+	before := counts()
+
+	for _, namespace := range namespaces {
+		dnsName := namespace + ".example.com."
+		query := test.Case{
+			Qname:  dnsName,
+			Qtype:  dns.TypeA,
+			Rcode:  dns.RcodeSuccess,
+			Answer: []dns.RR{test.A(dnsName + " 30 IN A 1.1.1.1")},
+		}
+		resolver.Next = fakeNextPluginHandler(query)
+		resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+	}
+
+	after := counts()
+	expected := map[[2]string]float64{
+		{"metrics-a", namespaceUpdateSuccess}: 1,
+		{"metrics-b", namespaceUpdateFailure}: 1,
+		// The namespace beyond the maximum number of namespace labels is counted with the
+		// overflow label.
+		{namespaceLabelOverflow, namespaceUpdateSuccess}: 1,
+	}
+	for key, value := range after {
+		if delta := value - before[key]; delta != expected[key] {
+			t.Errorf("Expected %v status updates of namespace %s with result %s, found %v", expected[key], key[0], key[1], delta)
+		}
+	}
+}
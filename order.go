@@ -0,0 +1,85 @@
+package ocp_dnsnameresolver
+
+import (
+	"net/netip"
+	"slices"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+)
+
+// addressOrder determines the order of the IP addresses of the resolved names in the
+// status of the DNSNameResolver objects. Some consumers only use the first IP address.
+type addressOrder string
+
+const (
+	// addressOrderNone keeps the IP addresses in the order in which they are added.
+	addressOrderNone addressOrder = "none"
+	// addressOrderV4First orders the IPv4 addresses before the IPv6 addresses.
+	addressOrderV4First addressOrder = "v4first"
+	// addressOrderV6First orders the IPv6 addresses before the IPv4 addresses.
+	addressOrderV6First addressOrder = "v6first"
+)
+
+// parseAddressOrder returns the addressOrder corresponding to the given value and
+// whether the value is a valid addressOrder.
+func parseAddressOrder(value string) (addressOrder, bool) {
+	switch order := addressOrder(value); order {
+	case addressOrderNone, addressOrderV4First, addressOrderV6First:
+		return order, true
+	}
+	return "", false
+}
+
+// compareAddresses gives the total order of the IP addresses for the addressOrder: the
+// IP addresses are grouped by family, the preferred family first, and are then ordered
+// by value within the family. The IP addresses which cannot be parsed are ordered last,
+// by their string value.
+func compareAddresses(order addressOrder, a, b string) int {
+	addrA, errA := netip.ParseAddr(a)
+	addrB, errB := netip.ParseAddr(b)
+	switch {
+	case errA != nil && errB != nil:
+		if a < b {
+			return -1
+		} else if a > b {
+			return 1
+		}
+		return 0
+	case errA != nil:
+		return 1
+	case errB != nil:
+		return -1
+	}
+
+	if addrA.Is4() != addrB.Is4() {
+		if addrA.Is4() == (order == addressOrderV4First) {
+			return -1
+		}
+		return 1
+	}
+	return addrA.Compare(addrB)
+}
+
+// orderResolvedAddresses orders the IP addresses of all the resolved names of the
+// DNSNameResolver object according to the configured addressOrder. It returns true
+// if the order of any of the IP addresses changed. Nothing is done for
+// addressOrderNone.
+func (resolver *OCPDNSNameResolver) orderResolvedAddresses(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+	if resolver.addressOrder == addressOrderNone {
+		return false
+	}
+
+	compare := func(a, b ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress) int {
+		return compareAddresses(resolver.addressOrder, a.IP, b.IP)
+	}
+	changed := false
+	for i := range resolverObj.Status.ResolvedNames {
+		resolvedAddresses := resolverObj.Status.ResolvedNames[i].ResolvedAddresses
+		if slices.IsSortedFunc(resolvedAddresses, compare) {
+			continue
+		}
+		slices.SortStableFunc(resolvedAddresses, compare)
+		changed = true
+	}
+	return changed
+}
@@ -0,0 +1,84 @@
+package ocp_dnsnameresolver
+
+import (
+	"reflect"
+	"testing"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+)
+
+func TestOrderResolvedAddresses(t *testing.T) {
+	addresses := []string{"2001:db8::2", "10.0.0.2", "2001:db8::1", "9.0.0.1", "10.0.0.10"}
+
+	tests := []struct {
+		name              string
+		order             addressOrder
+		addresses         []string
+		expectedAddresses []string
+		expectedChanged   bool
+	}{
+		{
+			name:              "IP addresses are kept in order with none",
+			order:             addressOrderNone,
+			addresses:         addresses,
+			expectedAddresses: addresses,
+			expectedChanged:   false,
+		},
+		{
+			name:              "IPv4 addresses are ordered first with v4first",
+			order:             addressOrderV4First,
+			addresses:         addresses,
+			expectedAddresses: []string{"9.0.0.1", "10.0.0.2", "10.0.0.10", "2001:db8::1", "2001:db8::2"},
+			expectedChanged:   true,
+		},
+		{
+			name:              "IPv6 addresses are ordered first with v6first",
+			order:             addressOrderV6First,
+			addresses:         addresses,
+			expectedAddresses: []string{"2001:db8::1", "2001:db8::2", "9.0.0.1", "10.0.0.2", "10.0.0.10"},
+			expectedChanged:   true,
+		},
+		{
+			name:              "Ordered IP addresses are not changed",
+			order:             addressOrderV4First,
+			addresses:         []string{"9.0.0.1", "10.0.0.2", "2001:db8::1"},
+			expectedAddresses: []string{"9.0.0.1", "10.0.0.2", "2001:db8::1"},
+			expectedChanged:   false,
+		},
+		{
+			name:              "Invalid IP addresses are ordered last",
+			order:             addressOrderV6First,
+			addresses:         []string{"invalid", "10.0.0.1", "2001:db8::1"},
+			expectedAddresses: []string{"2001:db8::1", "10.0.0.1", "invalid"},
+			expectedChanged:   true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := New()
+			resolver.addressOrder = tc.order
+
+			resolvedName := ocpnetworkapiv1alpha1.DNSNameResolverResolvedName{DNSName: "www.example.com."}
+			for _, ip := range tc.addresses {
+				resolvedName.ResolvedAddresses = append(resolvedName.ResolvedAddresses, ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{IP: ip})
+			}
+			resolverObj := &ocpnetworkapiv1alpha1.DNSNameResolver{
+				Status: ocpnetworkapiv1alpha1.DNSNameResolverStatus{
+					ResolvedNames: []ocpnetworkapiv1alpha1.DNSNameResolverResolvedName{resolvedName},
+				},
+			}
+
+			if changed := resolver.orderResolvedAddresses(resolverObj); changed != tc.expectedChanged {
+				t.Fatalf("Expected changed to be %t, found %t", tc.expectedChanged, changed)
+			}
+			var ips []string
+			for _, resolvedAddress := range resolverObj.Status.ResolvedNames[0].ResolvedAddresses {
+				ips = append(ips, resolvedAddress.IP)
+			}
+			if !reflect.DeepEqual(ips, tc.expectedAddresses) {
+				t.Fatalf("Expected IP addresses %v, found %v", tc.expectedAddresses, ips)
+			}
+		})
+	}
+}
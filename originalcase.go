@@ -0,0 +1,110 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"strings"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+)
+
+const (
+	// originalCaseAnnotation is the annotation used for storing the DNS names of the resolved
+	// names as received in the DNS lookup responses, when preserveCase is enabled, as a JSON
+	// encoded map from the lowercased DNS names to the DNS names with their original case.
+	// The DNSNameResolver status only accepts lowercased DNS names.
+	originalCaseAnnotation = "dnsnameresolver.openshift.io/original-case"
+)
+
+// originalCaseEntries returns the entries of the original case annotation of the
+// DNSNameResolver object once the DNS name with its display name is recorded: the entries
+// of the DNS names without a resolved name entry in the status are dropped, and the entry
+// of the DNS name is only kept if its display name is not lowercased. An invalid value of
+// the annotation is overwritten.
+func originalCaseEntries(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver, status *ocpnetworkapiv1alpha1.DNSNameResolverStatus, dnsName, displayName string) map[string]string {
+	entries := make(map[string]string)
+	if value, exists := resolverObj.Annotations[originalCaseAnnotation]; exists {
+		if err := json.Unmarshal([]byte(value), &entries); err != nil {
+			entries = make(map[string]string)
+		}
+	}
+
+	resolvedNames := make(map[string]struct{}, len(status.ResolvedNames))
+	for _, resolvedName := range status.ResolvedNames {
+		resolvedNames[string(resolvedName.DNSName)] = struct{}{}
+	}
+	for entry := range entries {
+		if _, exists := resolvedNames[entry]; !exists {
+			delete(entries, entry)
+		}
+	}
+
+	if _, exists := resolvedNames[dnsName]; exists && displayName != strings.ToLower(displayName) {
+		entries[dnsName] = displayName
+	} else {
+		delete(entries, dnsName)
+	}
+	return entries
+}
+
+// currentOriginalCaseEntries returns the entries of the original case annotation of the
+// DNSNameResolver object, or nil if the annotation is not set or is invalid.
+func currentOriginalCaseEntries(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) map[string]string {
+	value, exists := resolverObj.Annotations[originalCaseAnnotation]
+	if !exists {
+		return nil
+	}
+	entries := make(map[string]string)
+	if err := json.Unmarshal([]byte(value), &entries); err != nil {
+		return nil
+	}
+	return entries
+}
+
+// updateOriginalCase records the display name of the DNS name, i.e. the DNS name as received
+// in the DNS lookup response, in the original case annotation of the DNSNameResolver object,
+// once a new resolved name entry of the DNS name is written to its status. The object is the
+// one whose status was written. Nothing is done if preserveCase is not enabled.
+func (resolver *OCPDNSNameResolver) updateOriginalCase(ctx context.Context, resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver, dnsName, displayName string) {
+	if !resolver.preserveCase {
+		return
+	}
+	status := resolverObj.Status.DeepCopy()
+
+	// If the annotation already matches then skip the update call.
+	entries := originalCaseEntries(resolverObj, status, dnsName, displayName)
+	if maps.Equal(entries, currentOriginalCaseEntries(resolverObj)) {
+		return
+	}
+
+	// Retry the update of the DNSNameResolver object if there's a conflict or a transient error during the update.
+	resolver.retrier.retryUpdate(resolverObj.Namespace, resolverObj.Name, "original case annotation", func() error {
+		// Fetch a copy of the DNSNameResolver object. All the updates will be applied to the copied object.
+		newResolverObj, err := resolver.store.get(ctx, resolverObj.Namespace, resolverObj.Name)
+		if err != nil {
+			return err
+		}
+
+		// The entries are pruned against the written status, as the fetched object may lag it.
+		entries := originalCaseEntries(newResolverObj, status, dnsName, displayName)
+		if maps.Equal(entries, currentOriginalCaseEntries(newResolverObj)) {
+			return nil
+		}
+		if len(entries) == 0 {
+			removeAnnotation(newResolverObj, originalCaseAnnotation)
+		} else {
+			value, err := json.Marshal(entries)
+			if err != nil {
+				return err
+			}
+			setAnnotation(newResolverObj, originalCaseAnnotation, string(value))
+		}
+		// The status is not written by the update, it is set to the written status so that the
+		// object does not carry a status older than its original case annotation.
+		status.DeepCopyInto(&newResolverObj.Status)
+
+		// Update the DNSNameResolver object.
+		return resolver.store.update(ctx, newResolverObj)
+	})
+}
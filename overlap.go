@@ -0,0 +1,63 @@
+package ocp_dnsnameresolver
+
+// overlapPolicy determines which DNSNameResolver objects are updated when a
+// namespace contains both a regular DNSNameResolver object matching the DNS
+// name being looked up and a wildcard DNSNameResolver object matching the
+// same DNS name.
+type overlapPolicy string
+
+const (
+	// overlapPolicyBoth updates both the regular and the wildcard
+	// DNSNameResolver objects.
+	overlapPolicyBoth overlapPolicy = "both"
+	// overlapPolicyExactOnly updates only the regular DNSNameResolver object.
+	overlapPolicyExactOnly overlapPolicy = "exact-only"
+	// overlapPolicyWildcardFirst updates only the wildcard DNSNameResolver
+	// object.
+	overlapPolicyWildcardFirst overlapPolicy = "wildcard-first"
+)
+
+// parseOverlapPolicy returns the overlapPolicy corresponding to the given
+// value and whether the value is a valid overlapPolicy.
+func parseOverlapPolicy(value string) (overlapPolicy, bool) {
+	switch policy := overlapPolicy(value); policy {
+	case overlapPolicyBoth, overlapPolicyExactOnly, overlapPolicyWildcardFirst:
+		return policy, true
+	}
+	return "", false
+}
+
+// applyOverlapPolicy filters the namespaces of the regular and the wildcard
+// DNS name info according to the overlap policy. Only the namespaces present
+// in both regularDNSInfo and wildcardDNSInfo are affected by the policy. The
+// given maps are never modified, as they are shared with the informer event
+// handlers; new maps are returned instead whenever filtering is required.
+func applyOverlapPolicy(
+	policy overlapPolicy,
+	regularDNSInfo namespaceDNSInfo,
+	wildcardDNSInfo namespaceDNSInfo,
+) (namespaceDNSInfo, namespaceDNSInfo) {
+	switch policy {
+	case overlapPolicyExactOnly:
+		return regularDNSInfo, excludeNamespaces(wildcardDNSInfo, regularDNSInfo)
+	case overlapPolicyWildcardFirst:
+		return excludeNamespaces(regularDNSInfo, wildcardDNSInfo), wildcardDNSInfo
+	default:
+		return regularDNSInfo, wildcardDNSInfo
+	}
+}
+
+// excludeNamespaces returns the entries of dnsInfo whose namespaces are not
+// present in excluded.
+func excludeNamespaces(dnsInfo, excluded namespaceDNSInfo) namespaceDNSInfo {
+	if len(dnsInfo) == 0 || len(excluded) == 0 {
+		return dnsInfo
+	}
+	filtered := make(namespaceDNSInfo)
+	for namespace, objName := range dnsInfo {
+		if _, found := excluded[namespace]; !found {
+			filtered[namespace] = objName
+		}
+	}
+	return filtered
+}
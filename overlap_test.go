@@ -0,0 +1,55 @@
+package ocp_dnsnameresolver
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestApplyOverlapPolicy(t *testing.T) {
+	regular := namespaceDNSInfo{"ns1": "regular1", "ns2": "regular2"}
+	wildcard := namespaceDNSInfo{"ns2": "wildcard2", "ns3": "wildcard3"}
+
+	tests := []struct {
+		name             string
+		policy           overlapPolicy
+		expectedRegular  namespaceDNSInfo
+		expectedWildcard namespaceDNSInfo
+	}{
+		{
+			name:             "Both regular and wildcard objects are updated",
+			policy:           overlapPolicyBoth,
+			expectedRegular:  namespaceDNSInfo{"ns1": "regular1", "ns2": "regular2"},
+			expectedWildcard: namespaceDNSInfo{"ns2": "wildcard2", "ns3": "wildcard3"},
+		},
+		{
+			name:             "Only regular object is updated in the overlapping namespace",
+			policy:           overlapPolicyExactOnly,
+			expectedRegular:  namespaceDNSInfo{"ns1": "regular1", "ns2": "regular2"},
+			expectedWildcard: namespaceDNSInfo{"ns3": "wildcard3"},
+		},
+		{
+			name:             "Only wildcard object is updated in the overlapping namespace",
+			policy:           overlapPolicyWildcardFirst,
+			expectedRegular:  namespaceDNSInfo{"ns1": "regular1"},
+			expectedWildcard: namespaceDNSInfo{"ns2": "wildcard2", "ns3": "wildcard3"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actualRegular, actualWildcard := applyOverlapPolicy(tc.policy, regular, wildcard)
+			if diff := cmp.Diff(tc.expectedRegular, actualRegular); diff != "" {
+				t.Fatalf("regular DNS info did not match the expected value:\nDiff: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedWildcard, actualWildcard); diff != "" {
+				t.Fatalf("wildcard DNS info did not match the expected value:\nDiff: %s", diff)
+			}
+		})
+	}
+
+	// The input maps should never be modified.
+	if len(regular) != 2 || len(wildcard) != 2 {
+		t.Fatalf("input DNS info maps were modified: regular: %v, wildcard: %v", regular, wildcard)
+	}
+}
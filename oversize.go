@@ -0,0 +1,91 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// etcdRequestTooLargeMessage is the message of the error returned by etcd, and relayed by
+// the API server, when the object exceeds the size limit of etcd.
+const etcdRequestTooLargeMessage = "etcdserver: request is too large"
+
+// isRequestTooLargeError checks if the error returned by the API server is due to the size
+// of the object exceeding the size limit of the API server or of etcd.
+func isRequestTooLargeError(err error) bool {
+	return apierrors.IsRequestEntityTooLargeError(err) || strings.Contains(err.Error(), etcdRequestTooLargeMessage)
+}
+
+// updateStatusTruncating updates the status of the DNSNameResolver object. If the update
+// fails as the object is too large, the IP addresses of the resolved name of the DNS name
+// are truncated to half of their number and the update is retried once. The truncation is
+// logged. The error of the last update is returned.
+func (resolver *OCPDNSNameResolver) updateStatusTruncating(ctx context.Context, resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver, dnsName string) error {
+	err := resolver.store.updateStatus(ctx, resolverObj)
+	if err == nil || !isRequestTooLargeError(err) {
+		return err
+	}
+
+	total, kept := truncateResolvedAddresses(resolverObj, dnsName)
+	if kept == total {
+		return err
+	}
+	log.Warningf("Truncating the IP addresses of DNS name %s in the status of DNSNameResolver object %s/%s from %d to %d as the object is too large: %v",
+		dnsName, resolverObj.Namespace, resolverObj.Name, total, kept, err)
+	return resolver.store.updateStatus(ctx, resolverObj)
+}
+
+// truncateResolvedAddresses truncates the IP addresses of the resolved name of the DNS name
+// in the status of the DNSNameResolver object to half of their number. The IP addresses
+// with the latest next lookup time (last lookup time + TTL) are kept, in their current
+// order. The number of the IP addresses before and after the truncation is returned. A
+// resolved name with a single IP address is not truncated.
+func truncateResolvedAddresses(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver, dnsName string) (int, int) {
+	for index, resolvedName := range resolverObj.Status.ResolvedNames {
+		if !strings.EqualFold(string(resolvedName.DNSName), dnsName) {
+			continue
+		}
+
+		total := len(resolvedName.ResolvedAddresses)
+		kept := total / 2
+		if kept == 0 {
+			return total, total
+		}
+
+		// Sort the indices of the IP addresses by their next lookup time, latest first.
+		indices := make([]int, total)
+		for i := range indices {
+			indices[i] = i
+		}
+		sort.SliceStable(indices, func(i, j int) bool {
+			return nextLookupTime(resolvedName.ResolvedAddresses[indices[i]]).After(
+				nextLookupTime(resolvedName.ResolvedAddresses[indices[j]]))
+		})
+		keep := make(map[int]bool, kept)
+		for _, i := range indices[:kept] {
+			keep[i] = true
+		}
+
+		resolvedAddresses := make([]ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress, 0, kept)
+		for i, resolvedAddress := range resolvedName.ResolvedAddresses {
+			if keep[i] {
+				resolvedAddresses = append(resolvedAddresses, resolvedAddress)
+			}
+		}
+		resolverObj.Status.ResolvedNames[index].ResolvedAddresses = resolvedAddresses
+		return total, kept
+	}
+	return 0, 0
+}
+
+// nextLookupTime returns the next lookup time (last lookup time + TTL) of the IP address.
+func nextLookupTime(resolvedAddress ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress) time.Time {
+	if resolvedAddress.LastLookupTime == nil {
+		return time.Time{}
+	}
+	return resolvedAddress.LastLookupTime.Add(time.Duration(resolvedAddress.TTLSeconds) * time.Second)
+}
@@ -0,0 +1,114 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestServeDNSRequestTooLarge(t *testing.T) {
+	tests := []struct {
+		name              string
+		err               error
+		answer            []dns.RR
+		expectedAddresses []string
+	}{
+		{
+			name: "Addresses are truncated on request entity too large error",
+			err:  apierrors.NewRequestEntityTooLargeError("limit is 3145728"),
+			answer: []dns.RR{
+				test.A("www.example.com. 30 IN A 1.1.1.1"),
+				test.A("www.example.com. 10 IN A 1.1.1.2"),
+				test.A("www.example.com. 40 IN A 1.1.1.3"),
+				test.A("www.example.com. 20 IN A 1.1.1.4"),
+			},
+			expectedAddresses: []string{"1.1.1.1", "1.1.1.3"},
+		},
+		{
+			name: "Addresses are truncated on etcd request too large error",
+			err:  apierrors.NewInternalError(errors.New(etcdRequestTooLargeMessage)),
+			answer: []dns.RR{
+				test.A("www.example.com. 30 IN A 1.1.1.1"),
+				test.A("www.example.com. 10 IN A 1.1.1.2"),
+				test.A("www.example.com. 40 IN A 1.1.1.3"),
+			},
+			expectedAddresses: []string{"1.1.1.3"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			})
+
+			// The status updates are rejected while the object has more than half of the IP
+			// addresses of the DNS lookup.
+			rejectedUpdates := 0
+			fakeNetworkClient.PrependReactor("update", "dnsnameresolvers", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				if action.GetSubresource() != "status" {
+					return false, nil, nil
+				}
+				resolverObj := action.(clienttesting.UpdateAction).GetObject().(*ocpnetworkapiv1alpha1.DNSNameResolver)
+				if len(resolverObj.Status.ResolvedNames) > 0 && len(resolverObj.Status.ResolvedNames[0].ResolvedAddresses) > len(tc.answer)/2 {
+					rejectedUpdates++
+					return true, nil, tc.err
+				}
+				return false, nil, nil
+			})
+
+			query := test.Case{
+				Qname:  "www.example.com.",
+				Qtype:  dns.TypeA,
+				Rcode:  dns.RcodeSuccess,
+				Answer: tc.answer,
+			}
+			resolver.Next = fakeNextPluginHandler(query)
+			resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+			// The oversize update is retried once with the truncated IP addresses.
+			if rejectedUpdates != 1 {
+				t.Fatalf("Expected 1 rejected status update, found %d", rejectedUpdates)
+			}
+			if count := countStatusUpdates(fakeNetworkClient); count != 2 {
+				t.Fatalf("Expected 2 status updates, found %d", count)
+			}
+
+			resolverObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+				return len(obj.Status.ResolvedNames) > 0
+			})
+			// The IP addresses of a new resolved name are added in no particular order.
+			var addresses []string
+			for _, resolvedAddress := range resolverObj.Status.ResolvedNames[0].ResolvedAddresses {
+				addresses = append(addresses, resolvedAddress.IP)
+			}
+			sort.Strings(addresses)
+			if !reflect.DeepEqual(addresses, tc.expectedAddresses) {
+				t.Fatalf("Expected IP addresses %v, found %v", tc.expectedAddresses, addresses)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// namespacePacer spaces out the status updates of the DNSNameResolver objects within
+// the same namespace by the configured namespacePacing, to respect the per-namespace
+// quotas of the API server, while the updates in different namespaces proceed in
+// parallel.
+type namespacePacer struct {
+	// nextUpdate gives the earliest time of the next update in each namespace.
+	nextUpdate map[string]time.Time
+	lock       sync.Mutex
+}
+
+// newNamespacePacer returns an initialized namespacePacer.
+func newNamespacePacer() *namespacePacer {
+	return &namespacePacer{
+		nextUpdate: make(map[string]time.Time),
+	}
+}
+
+// reserve reserves the next update slot in the namespace at the given time and returns
+// the delay until the slot. The slots of the namespace are spaced by the interval, in
+// the order of the reservations.
+func (pacer *namespacePacer) reserve(namespace string, now time.Time, interval time.Duration) time.Duration {
+	pacer.lock.Lock()
+	defer pacer.lock.Unlock()
+
+	// Drop the namespaces without any pending slot, so that the map does not grow
+	// with the namespaces which are not updated anymore.
+	for ns, next := range pacer.nextUpdate {
+		if !next.After(now) {
+			delete(pacer.nextUpdate, ns)
+		}
+	}
+
+	slot := now
+	if next, exists := pacer.nextUpdate[namespace]; exists && next.After(now) {
+		slot = next
+	}
+	pacer.nextUpdate[namespace] = slot.Add(interval)
+	return slot.Sub(now)
+}
+
+// paceUpdate waits for the next update slot in the namespace, if namespacePacing is
+// configured. It returns false if the context is done before the slot, in which case
+// the update should be dropped.
+func (resolver *OCPDNSNameResolver) paceUpdate(ctx context.Context, namespace string) bool {
+	if resolver.namespacePacing <= 0 {
+		return true
+	}
+	delay := resolver.namespacePacer.reserve(namespace, time.Now(), resolver.namespacePacing)
+	if delay <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,68 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNamespacePacerReserve(t *testing.T) {
+	start := time.Now()
+	interval := time.Second
+	pacer := newNamespacePacer()
+
+	steps := []struct {
+		elapsed       time.Duration
+		namespace     string
+		expectedDelay time.Duration
+	}{
+		// The first updates of both namespaces proceed in parallel.
+		{0, "ns1", 0},
+		{0, "ns2", 0},
+		// The next updates within the same namespace are spaced by the interval, in order.
+		{0, "ns1", time.Second},
+		{0, "ns1", 2 * time.Second},
+		{100 * time.Millisecond, "ns2", 900 * time.Millisecond},
+		{500 * time.Millisecond, "ns1", 2500 * time.Millisecond},
+		// Once the pending slots of a namespace are over, the update proceeds immediately.
+		{5 * time.Second, "ns1", 0},
+		{5 * time.Second, "ns2", 0},
+		{5500 * time.Millisecond, "ns2", 500 * time.Millisecond},
+	}
+	for i, step := range steps {
+		if delay := pacer.reserve(step.namespace, start.Add(step.elapsed), interval); delay != step.expectedDelay {
+			t.Fatalf("Step %d: Expected delay %s for namespace %s, found %s", i, step.expectedDelay, step.namespace, delay)
+		}
+	}
+}
+
+func TestPaceUpdate(t *testing.T) {
+	resolver := New()
+	resolver.namespacePacing = 100 * time.Millisecond
+
+	start := time.Now()
+	done := make(chan string, 4)
+	for _, namespace := range []string{"ns1", "ns1", "ns2", "ns2"} {
+		go func(namespace string) {
+			if resolver.paceUpdate(context.Background(), namespace) {
+				done <- namespace
+			}
+		}(namespace)
+	}
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+	// Both namespaces are paced in parallel, thus both pairs of updates take one interval,
+	// while the updates would take three intervals if the namespaces were not parallel.
+	if elapsed := time.Since(start); elapsed < resolver.namespacePacing || elapsed >= 3*resolver.namespacePacing {
+		t.Fatalf("Expected the updates to take one interval of %s, found %s", resolver.namespacePacing, elapsed)
+	}
+
+	// The update is dropped when the context is done before its slot.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	resolver.paceUpdate(ctx, "ns1")
+	if resolver.paceUpdate(ctx, "ns1") {
+		t.Fatalf("Expected the update to be dropped once the context is done")
+	}
+}
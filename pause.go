@@ -0,0 +1,17 @@
+package ocp_dnsnameresolver
+
+import (
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+)
+
+const (
+	// pauseAnnotation is the annotation used for temporarily pausing the status updates
+	// of a DNSNameResolver object, eg. during maintenance. The status of the object is
+	// not updated while the value of the annotation is "true".
+	pauseAnnotation = "dnsnameresolver.openshift.io/pause"
+)
+
+// isPaused returns whether the status updates of the DNSNameResolver object are paused.
+func isPaused(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+	return resolverObj.Annotations[pauseAnnotation] == "true"
+}
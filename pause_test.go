@@ -0,0 +1,76 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestServeDNSPauseAnnotation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "regular",
+			Namespace: "dns",
+			Annotations: map[string]string{
+				pauseAnnotation: "true",
+			},
+		},
+		Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+			Name: "www.example.com.",
+		},
+	})
+
+	query := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("www.example.com. 30 IN A 1.1.1.1"),
+		},
+	}
+	resolver.Next = fakeNextPluginHandler(query)
+
+	// The DNS lookup should still be served while the status updates are paused.
+	rec := dnstest.NewRecorder(&test.ResponseWriter{})
+	resolver.ServeDNS(context.TODO(), rec, query.Msg())
+	if rec.Msg == nil || len(rec.Msg.Answer) != 1 {
+		t.Fatalf("Expected the DNS lookup to be served, found response: %v", rec.Msg)
+	}
+	if count := countStatusUpdates(fakeNetworkClient); count != 0 {
+		t.Fatalf("Expected no status updates while paused, found %d", count)
+	}
+
+	// Remove the pause annotation and wait for the informer cache to observe the change.
+	resolverObj, err := resolver.store.get(ctx, "dns", "regular")
+	if err != nil {
+		t.Fatalf("Failed to get DNSNameResolver object: %v", err)
+	}
+	delete(resolverObj.Annotations, pauseAnnotation)
+	if err := resolver.store.update(ctx, resolverObj); err != nil {
+		t.Fatalf("Failed to update DNSNameResolver object: %v", err)
+	}
+	if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 2*time.Second, true, func(ctx context.Context) (bool, error) {
+		obj, err := resolver.store.get(ctx, "dns", "regular")
+		return err == nil && !isPaused(obj), nil
+	}); err != nil {
+		t.Fatalf("Pause annotation removal not observed: %v", err)
+	}
+
+	resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+	if count := countStatusUpdates(fakeNetworkClient); count != 1 {
+		t.Fatalf("Expected 1 status update once resumed, found %d", count)
+	}
+}
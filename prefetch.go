@@ -0,0 +1,143 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/miekg/dns"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// prefetchConcurrency gives the maximum number of concurrent DNS lookups while prefetching.
+	prefetchConcurrency = 5
+	// prefetchQPS gives the maximum number of DNS lookups per second while prefetching.
+	prefetchQPS = 20
+)
+
+// prefetchOnSync waits for the DNSNameResolver informer to sync and then prefetches the IP
+// addresses of the tracked DNS names.
+func (resolver *OCPDNSNameResolver) prefetchOnSync(stopCh <-chan struct{}) {
+	if !cache.WaitForCacheSync(stopCh, resolver.dnsNameResolverInformer.HasSynced) {
+		return
+	}
+	resolver.prefetch(stopCh)
+}
+
+// prefetch actively looks up the A and AAAA records of the tracked regular DNS names through
+// the plugin chain, so that the status of the DNSNameResolver objects is populated without
+// waiting for the first DNS lookup of a client. The responses are recorded like the ones of
+// the DNS lookups of the clients. Wildcard DNS names
+// cannot be looked up, thus they are populated by the lookups of the matching regular DNS
+// names only. The concurrency and the rate of the DNS lookups are bounded.
+func (resolver *OCPDNSNameResolver) prefetch(stopCh <-chan struct{}) {
+	// Take a snapshot of the tracked regular DNS names.
+	resolver.regularMapLock.Lock()
+	dnsNames := make([]string, 0, len(resolver.regularDNSInfo))
+	for dnsName := range resolver.regularDNSInfo {
+		dnsNames = append(dnsNames, dnsName)
+	}
+	resolver.regularMapLock.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	log.Infof("prefetching the IP addresses of %d DNS names", len(dnsNames))
+
+	rateTicker := time.NewTicker(time.Second / prefetchQPS)
+	defer rateTicker.Stop()
+	semaphore := make(chan struct{}, prefetchConcurrency)
+	var wg sync.WaitGroup
+
+	for _, dnsName := range dnsNames {
+		for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+			select {
+			case <-ctx.Done():
+				wg.Wait()
+				return
+			case <-rateTicker.C:
+			}
+			semaphore <- struct{}{}
+
+			wg.Add(1)
+			go func(dnsName string, qtype uint16) {
+				defer wg.Done()
+				defer func() { <-semaphore }()
+
+				resolver.lookup(ctx, dnsName, qtype, "prefetching")
+			}(dnsName, qtype)
+		}
+	}
+	wg.Wait()
+}
+
+// lookup looks up the records of the given type of the DNS name through the plugin chain
+// and records the response in the DNSNameResolver objects matching the DNS name, discarding
+// the response. Unlike the DNS lookups of the clients, the lookup is neither counted for
+// minQueries nor coalesced, and it is not subject to allowedClientCIDRs, as it is made by
+// the plugin itself. The purpose is used for logging the errors.
+func (resolver *OCPDNSNameResolver) lookup(ctx context.Context, dnsName string, qtype uint16, purpose string) {
+	// The DNS names of the internal zones and, if externalZones is configured, of the zones
+	// other than the external zones are never recorded.
+	if resolver.inInternalZone(dnsName) || !resolver.inExternalZone(dnsName) {
+		return
+	}
+	regularDnsInfo, wildcardDnsInfo := resolver.matchResolverObjects(dnsName)
+	if len(regularDnsInfo) == 0 && len(wildcardDnsInfo) == 0 {
+		return
+	}
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(dnsName, qtype)
+	w := &prefetchResponseWriter{}
+	rw := dnstest.NewRecorder(w)
+	status, err := plugin.NextOrFailure(resolver.Name(), resolver.Next, ctx, rw, msg)
+	if _, err := resolver.recordResponse(ctx, w, msg, rw, status, err, regularDnsInfo, wildcardDnsInfo); err != nil {
+		log.Warningf("Encountered error while %s DNS name %s of type %s: %v", purpose, dnsName, dns.TypeToString[qtype], err)
+	}
+}
+
+// prefetchResponseWriter is a dns.ResponseWriter which discards the responses of the
+// DNS lookups performed by the plugin itself, eg. while prefetching.
+type prefetchResponseWriter struct{}
+
+var _ dns.ResponseWriter = &prefetchResponseWriter{}
+
+// LocalAddr implements dns.ResponseWriter.
+func (w *prefetchResponseWriter) LocalAddr() net.Addr {
+	return &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 53}
+}
+
+// RemoteAddr implements dns.ResponseWriter.
+func (w *prefetchResponseWriter) RemoteAddr() net.Addr {
+	return &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 0}
+}
+
+// WriteMsg implements dns.ResponseWriter.
+func (w *prefetchResponseWriter) WriteMsg(*dns.Msg) error { return nil }
+
+// Write implements dns.ResponseWriter.
+func (w *prefetchResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+
+// Close implements dns.ResponseWriter.
+func (w *prefetchResponseWriter) Close() error { return nil }
+
+// TsigStatus implements dns.ResponseWriter.
+func (w *prefetchResponseWriter) TsigStatus() error { return nil }
+
+// TsigTimersOnly implements dns.ResponseWriter.
+func (w *prefetchResponseWriter) TsigTimersOnly(bool) {}
+
+// Hijack implements dns.ResponseWriter.
+func (w *prefetchResponseWriter) Hijack() {}
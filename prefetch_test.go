@@ -0,0 +1,182 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPrefetch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	objects := []struct {
+		name    string
+		dnsName string
+	}{
+		{"regular1", "www1.example.com."},
+		{"regular2", "www2.example.com."},
+		{"regular3", "www3.example.com."},
+		{"wildcard", "*.example.com."},
+	}
+	for _, object := range objects {
+		createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      object.name,
+				Namespace: "dns",
+			},
+			Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+				Name: ocpnetworkapiv1alpha1.DNSName(object.dnsName),
+			},
+		})
+	}
+
+	// The next plugin returns an A record for each DNS name and tracks the number of
+	// concurrent DNS lookups.
+	var lock sync.Mutex
+	lookups := make(map[string]int)
+	var inFlight, maxInFlight int32
+	resolver.Next = plugin.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+				break
+			}
+		}
+
+		qname := r.Question[0].Name
+		lock.Lock()
+		lookups[qname]++
+		lock.Unlock()
+
+		m := new(dns.Msg)
+		m.SetReply(r)
+		if r.Question[0].Qtype == dns.TypeA {
+			m.Answer = append(m.Answer, test.A(qname+" 30 IN A 1.1.1.1"))
+		}
+		w.WriteMsg(m)
+		return dns.RcodeSuccess, nil
+	})
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	start := time.Now()
+	resolver.prefetch(stopCh)
+
+	// Each regular DNS name should be looked up for both A and AAAA records, and the
+	// wildcard DNS name should not be looked up.
+	for _, object := range objects {
+		expectedLookups := 2
+		if isWildcard(object.dnsName) {
+			expectedLookups = 0
+		}
+		if lookups[object.dnsName] != expectedLookups {
+			t.Fatalf("Expected %d lookups of DNS name %s, found %d", expectedLookups, object.dnsName, lookups[object.dnsName])
+		}
+	}
+	if maxInFlight > prefetchConcurrency {
+		t.Fatalf("Expected at most %d concurrent lookups, found %d", prefetchConcurrency, maxInFlight)
+	}
+	if elapsed, minElapsed := time.Since(start), 5*time.Second/prefetchQPS; elapsed < minElapsed {
+		t.Fatalf("Expected the lookups to be rate limited to take at least %s, took %s", minElapsed, elapsed)
+	}
+
+	// The status of the DNSNameResolver objects should be populated by the prefetch. The
+	// wildcard DNS name's object is updated concurrently for multiple regular DNS names,
+	// thus only at least one resolved name is expected in its status.
+	for _, object := range objects {
+		resolverObj := getResolverObject(t, resolver, "dns", object.name, func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+			return len(obj.Status.ResolvedNames) > 0
+		})
+		if len(resolverObj.Status.ResolvedNames) == 0 {
+			t.Fatalf("Expected resolved names in status of %s, found none", object.name)
+		}
+	}
+}
+
+func TestPrefetchStop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "regular",
+			Namespace: "dns",
+		},
+		Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+			Name: "www.example.com.",
+		},
+	})
+
+	var lookups int32
+	resolver.Next = plugin.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+		atomic.AddInt32(&lookups, 1)
+		return dns.RcodeSuccess, nil
+	})
+
+	// No lookups should be performed once stopped.
+	stopCh := make(chan struct{})
+	close(stopCh)
+	resolver.prefetch(stopCh)
+	if lookups != 0 {
+		t.Fatalf("Expected no lookups after stop, found %d", lookups)
+	}
+}
+
+func TestPrefetchBypassesClientPolicies(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The DNS lookups of the prefetch are neither counted for minQueries nor subject to
+	// allowedClientCIDRs.
+	resolver := New()
+	resolver.minQueries = 3
+	resolver.allowedClientCIDRs = []netip.Prefix{netip.MustParsePrefix("10.240.0.0/16")}
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "regular",
+			Namespace: "dns",
+		},
+		Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+			Name: "www.example.com.",
+		},
+	})
+
+	resolver.Next = fakeNextPluginHandler(test.Case{
+		Qname:  "www.example.com.",
+		Qtype:  dns.TypeA,
+		Rcode:  dns.RcodeSuccess,
+		Answer: []dns.RR{test.A("www.example.com. 30 IN A 1.1.1.1")},
+	})
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	resolver.prefetch(stopCh)
+
+	resolverObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		return len(obj.Status.ResolvedNames) == 1
+	})
+	if len(resolverObj.Status.ResolvedNames) != 1 {
+		t.Fatalf("Expected the status to be populated by the prefetch, found %v", resolverObj.Status)
+	}
+	if reached := resolver.queryCounter.record("www.example.com.", time.Now(), resolver.minQueries, resolver.minQueriesWindow); reached {
+		t.Fatalf("Expected the prefetch not to be counted for minQueries")
+	}
+}
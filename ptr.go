@@ -0,0 +1,62 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	// ptrNamesAnnotation is the annotation used for storing the host names of the PTR
+	// records of the reverse DNS names matching a DNSNameResolver object, i.e. the DNS
+	// names of the reverse zones in-addr.arpa. and ip6.arpa. The DNSNameResolver status
+	// only has fields for IP addresses, thus the host names are stored in the annotation
+	// as a JSON encoded map.
+	// key: IP address, value: sorted list of host names.
+	ptrNamesAnnotation = "dnsnameresolver.openshift.io/ptr-names"
+)
+
+// getPTRNames returns the lowercased host names of the PTR records of the reverse DNS
+// name in the answer section of the DNS response, sorted to keep the annotation value
+// stable.
+func getPTRNames(reverseName string, msg *dns.Msg) []string {
+	if msg == nil {
+		return nil
+	}
+	nameSet := make(map[string]struct{})
+	for _, answer := range msg.Answer {
+		if rec, ok := answer.(*dns.PTR); ok && strings.EqualFold(rec.Hdr.Name, reverseName) {
+			nameSet[strings.ToLower(rec.Ptr)] = struct{}{}
+		}
+	}
+	names := make([]string, 0, len(nameSet))
+	for name := range nameSet {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// updatePTRNames updates the PTR names annotation of the DNSNameResolver objects
+// corresponding to the regular and the wildcard reverse DNS names, with the host names
+// of the IP address.
+func (resolver *OCPDNSNameResolver) updatePTRNames(
+	ctx context.Context,
+	regularDNSInfo namespaceDNSInfo,
+	wildcardDNSInfo namespaceDNSInfo,
+	ip string,
+	names []string,
+) {
+	updateAnnotationEntries(ctx, resolver, regularDNSInfo, wildcardDNSInfo, ptrNamesAnnotation, "PTR names",
+		func(ptrNames map[string][]string) bool {
+			// If there are no changes to the PTR names of the IP address then skip the update call.
+			if existingNames, exists := ptrNames[ip]; exists && slices.Equal(existingNames, names) {
+				return false
+			}
+			ptrNames[ip] = names
+			return true
+		})
+}
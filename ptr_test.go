@@ -0,0 +1,122 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetPTRNames(t *testing.T) {
+	msg := new(dns.Msg)
+	msg.Answer = []dns.RR{
+		test.PTR("4.3.2.1.in-addr.arpa. 30 IN PTR WWW2.example.com."),
+		test.PTR("4.3.2.1.in-addr.arpa. 30 IN PTR www1.example.com."),
+		test.PTR("4.3.2.1.in-addr.arpa. 30 IN PTR www1.example.com."),
+		test.PTR("5.3.2.1.in-addr.arpa. 30 IN PTR www3.example.com."),
+	}
+
+	expectedNames := []string{"www1.example.com.", "www2.example.com."}
+	if diff := cmp.Diff(expectedNames, getPTRNames("4.3.2.1.in-addr.arpa.", msg)); diff != "" {
+		t.Fatalf("PTR names did not match the expected names:\nDiff: %s", diff)
+	}
+	if names := getPTRNames("4.3.2.1.in-addr.arpa.", nil); len(names) != 0 {
+		t.Fatalf("Expected no PTR names for nil message, found: %v", names)
+	}
+}
+
+func TestServeDNSRecordPTR(t *testing.T) {
+	tests := []struct {
+		name               string
+		recordPTR          bool
+		query              test.Case
+		expectedAnnotation map[string][]string
+	}{
+		{
+			name:      "PTR names are not recorded when recordPTR is disabled",
+			recordPTR: false,
+			query: test.Case{
+				Qname:  "4.3.2.1.in-addr.arpa.",
+				Qtype:  dns.TypePTR,
+				Rcode:  dns.RcodeSuccess,
+				Answer: []dns.RR{test.PTR("4.3.2.1.in-addr.arpa. 30 IN PTR www.example.com.")},
+			},
+		},
+		{
+			name:      "PTR names of an IPv4 address are recorded when recordPTR is enabled",
+			recordPTR: true,
+			query: test.Case{
+				Qname:  "4.3.2.1.in-addr.arpa.",
+				Qtype:  dns.TypePTR,
+				Rcode:  dns.RcodeSuccess,
+				Answer: []dns.RR{test.PTR("4.3.2.1.in-addr.arpa. 30 IN PTR www.example.com.")},
+			},
+			expectedAnnotation: map[string][]string{
+				"1.2.3.4": {"www.example.com."},
+			},
+		},
+		{
+			name:      "PTR names of an IPv6 address are recorded when recordPTR is enabled",
+			recordPTR: true,
+			query: test.Case{
+				Qname: "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.",
+				Qtype: dns.TypePTR,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.PTR("1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa. 30 IN PTR www.example.com."),
+				},
+			},
+			expectedAnnotation: map[string][]string{
+				"2001:db8::1": {"www.example.com."},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.recordPTR = tc.recordPTR
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "ptr",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: ocpnetworkapiv1alpha1.DNSName(tc.query.Qname),
+				},
+			})
+
+			resolver.Next = fakeNextPluginHandler(tc.query)
+			resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), tc.query.Msg())
+
+			resolverObj := getResolverObject(t, resolver, "dns", "ptr", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+				_, exists := obj.Annotations[ptrNamesAnnotation]
+				return exists == tc.recordPTR
+			})
+
+			var actualAnnotation map[string][]string
+			if value, exists := resolverObj.Annotations[ptrNamesAnnotation]; exists {
+				if err := json.Unmarshal([]byte(value), &actualAnnotation); err != nil {
+					t.Fatalf("error parsing annotation %s: %v", ptrNamesAnnotation, err)
+				}
+			}
+			if diff := cmp.Diff(tc.expectedAnnotation, actualAnnotation); diff != "" {
+				t.Fatalf("PTR names annotation did not match the expected value:\nDiff: %s", diff)
+			}
+			if len(resolverObj.Status.ResolvedNames) != 0 {
+				t.Fatalf("Expected no resolved names for PTR lookup, found: %v", resolverObj.Status.ResolvedNames)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package ocp_dnsnameresolver
+
+import (
+	"sync"
+	"time"
+)
+
+// queryCountSweepSize gives the number of counted DNS names above which the DNS names
+// whose DNS lookups are all outside the window are dropped by the queryCounter, at most
+// once per window.
+const queryCountSweepSize = 1024
+
+// queryCounter counts the DNS lookups of the DNS names within a sliding window.
+// It is used to start recording the status of a DNS name only after the DNS
+// name has been looked up a minimum number of times within the window.
+type queryCounter struct {
+	// queryTimes stores the times of the DNS lookups of the DNS names within the
+	// window. At most minQueries times are stored per DNS name, as the older
+	// lookups do not change the result of the check.
+	// key: DNS name, value: times of the DNS lookups, oldest first.
+	queryTimes map[string][]time.Time
+	// lastSweep is the time the DNS names outside the window were last dropped.
+	lastSweep time.Time
+	lock      sync.Mutex
+}
+
+// newQueryCounter returns an initialized queryCounter.
+func newQueryCounter() *queryCounter {
+	return &queryCounter{
+		queryTimes: make(map[string][]time.Time),
+	}
+}
+
+// record records a DNS lookup of the DNS name at the given time and returns true if
+// the DNS name has been looked up at least minQueries times within the window.
+func (counter *queryCounter) record(dnsName string, now time.Time, minQueries int, window time.Duration) bool {
+	if minQueries <= 1 {
+		return true
+	}
+
+	counter.lock.Lock()
+	defer counter.lock.Unlock()
+
+	// Drop the DNS names whose DNS lookups are all outside the window, so that the DNS
+	// names which are not looked up anymore do not accumulate. The sweep is done at most
+	// once per window, as the DNS names counted since the last sweep cannot be dropped
+	// before, so that the DNS lookups of new DNS names do not scan the map every time.
+	times, exists := counter.queryTimes[dnsName]
+	if !exists && len(counter.queryTimes) >= queryCountSweepSize && now.Sub(counter.lastSweep) >= window {
+		counter.lastSweep = now
+		for otherName, otherTimes := range counter.queryTimes {
+			if !otherTimes[len(otherTimes)-1].After(now.Add(-window)) {
+				delete(counter.queryTimes, otherName)
+			}
+		}
+	}
+
+	// Drop the DNS lookups which are outside the window.
+	start := 0
+	for start < len(times) && !times[start].After(now.Add(-window)) {
+		start++
+	}
+	times = append(times[start:], now)
+	if len(times) > minQueries {
+		times = times[len(times)-minQueries:]
+	}
+	counter.queryTimes[dnsName] = times
+
+	return len(times) >= minQueries
+}
@@ -0,0 +1,160 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestQueryCounter(t *testing.T) {
+	start := time.Now()
+
+	tests := []struct {
+		name           string
+		minQueries     int
+		queryOffsets   []time.Duration
+		expectedResult bool
+	}{
+		{
+			name:           "Threshold disabled",
+			minQueries:     1,
+			queryOffsets:   []time.Duration{0},
+			expectedResult: true,
+		},
+		{
+			name:           "Below threshold within window",
+			minQueries:     3,
+			queryOffsets:   []time.Duration{0, time.Second},
+			expectedResult: false,
+		},
+		{
+			name:           "Threshold met within window",
+			minQueries:     3,
+			queryOffsets:   []time.Duration{0, time.Second, 2 * time.Second},
+			expectedResult: true,
+		},
+		{
+			name:           "Above threshold within window",
+			minQueries:     3,
+			queryOffsets:   []time.Duration{0, time.Second, 2 * time.Second, 3 * time.Second},
+			expectedResult: true,
+		},
+		{
+			name:           "Threshold not met as older queries are outside window",
+			minQueries:     3,
+			queryOffsets:   []time.Duration{0, time.Second, 61 * time.Second},
+			expectedResult: false,
+		},
+		{
+			name:           "Threshold met again within window after older queries expire",
+			minQueries:     2,
+			queryOffsets:   []time.Duration{0, 2 * time.Minute, 2*time.Minute + time.Second},
+			expectedResult: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			counter := newQueryCounter()
+			var result bool
+			for _, offset := range tc.queryOffsets {
+				result = counter.record("www.example.com.", start.Add(offset), tc.minQueries, time.Minute)
+			}
+			if result != tc.expectedResult {
+				t.Fatalf("Expected result %t, found %t", tc.expectedResult, result)
+			}
+			if len(counter.queryTimes["www.example.com."]) > tc.minQueries {
+				t.Fatalf("Expected at most %d query times to be stored, found %d", tc.minQueries, len(counter.queryTimes["www.example.com."]))
+			}
+		})
+	}
+}
+
+func TestQueryCounterSweep(t *testing.T) {
+	start := time.Now()
+	counter := newQueryCounter()
+
+	// Fill the counter up to the sweep size with DNS names looked up at the start, and a
+	// DNS name looked up later.
+	for i := 0; i < queryCountSweepSize-1; i++ {
+		counter.record(fmt.Sprintf("www%d.example.com.", i), start, 3, time.Minute)
+	}
+	counter.record("recent.example.com.", start.Add(90*time.Second), 3, time.Minute)
+
+	// The DNS names whose DNS lookups are all outside the window are dropped once a new
+	// DNS name is counted.
+	counter.record("www.example.com.", start.Add(2*time.Minute), 3, time.Minute)
+	if len(counter.queryTimes) != 2 {
+		t.Fatalf("Expected the DNS names outside the window to be dropped, found %d DNS names", len(counter.queryTimes))
+	}
+	if _, exists := counter.queryTimes["recent.example.com."]; !exists {
+		t.Fatalf("Expected the DNS name looked up within the window to be kept")
+	}
+
+	// The DNS names are not swept again within the window of the last sweep.
+	for i := 0; i < queryCountSweepSize; i++ {
+		counter.record(fmt.Sprintf("www%d.example.com.", i), start.Add(2*time.Minute), 3, time.Minute)
+	}
+	counter.record("other.example.com.", start.Add(2*time.Minute+30*time.Second), 3, time.Minute)
+	if len(counter.queryTimes) != queryCountSweepSize+3 {
+		t.Fatalf("Expected no sweep within the window of the last sweep, found %d DNS names", len(counter.queryTimes))
+	}
+	counter.record("another.example.com.", start.Add(3*time.Minute+20*time.Second), 3, time.Minute)
+	if len(counter.queryTimes) != 2 {
+		t.Fatalf("Expected the DNS names outside the window to be dropped, found %d DNS names", len(counter.queryTimes))
+	}
+}
+
+func TestServeDNSMinQueries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.minQueries = 3
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "regular",
+			Namespace: "dns",
+		},
+		Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+			Name: "www.example.com.",
+		},
+	})
+
+	query := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("www.example.com. 30 IN A 1.1.1.1"),
+		},
+	}
+	resolver.Next = fakeNextPluginHandler(query)
+
+	// The status should not be updated while the number of queries is below the threshold.
+	for i := 0; i < 2; i++ {
+		w := dnstest.NewRecorder(&test.ResponseWriter{})
+		resolver.ServeDNS(context.TODO(), w, query.Msg())
+		if w.Msg == nil || len(w.Msg.Answer) != 1 {
+			t.Fatalf("Expected the response of the next plugin to be served, found: %v", w.Msg)
+		}
+		if count := countStatusUpdates(fakeNetworkClient); count != 0 {
+			t.Fatalf("Expected no status updates below the threshold, found %d", count)
+		}
+	}
+
+	// The status should be updated once the threshold is met.
+	resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+	if count := countStatusUpdates(fakeNetworkClient); count != 1 {
+		t.Fatalf("Expected 1 status update once the threshold is met, found %d", count)
+	}
+}
@@ -0,0 +1,45 @@
+package ocp_dnsnameresolver
+
+import (
+	"time"
+)
+
+const (
+	// readyDebounce gives the duration for which the DNSNameResolver informer should be
+	// continuously synced before the plugin reports ready. It avoids flapping readiness
+	// when the informer is briefly synced on slow API servers.
+	readyDebounce = 2 * time.Second
+)
+
+// Ready implements the ready.Readiness interface. The plugin is ready once the
+// DNSNameResolver informer is synced for at least readyDebounce. With lazyStart, the
+// plugin is ready until the informer is started, as the DNS lookups are served without
+// the informer, and the informer is only started by a DNS lookup.
+func (resolver *OCPDNSNameResolver) Ready() bool {
+	if resolver.lazyStart {
+		resolver.stopLock.Lock()
+		started := resolver.started
+		resolver.stopLock.Unlock()
+		if !started {
+			return true
+		}
+	}
+	return resolver.ready(time.Now())
+}
+
+// ready returns whether the DNSNameResolver informer has been synced continuously
+// for at least readyDebounce at the given time. The informer is considered synced
+// continuously as long as it is synced each time the readiness is checked.
+func (resolver *OCPDNSNameResolver) ready(now time.Time) bool {
+	resolver.readyLock.Lock()
+	defer resolver.readyLock.Unlock()
+
+	if resolver.dnsNameResolverInformer == nil || !resolver.dnsNameResolverInformer.HasSynced() {
+		resolver.syncedSince = time.Time{}
+		return false
+	}
+	if resolver.syncedSince.IsZero() {
+		resolver.syncedSince = now
+	}
+	return now.Sub(resolver.syncedSince) >= readyDebounce
+}
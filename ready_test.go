@@ -0,0 +1,122 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	"k8s.io/client-go/tools/cache"
+)
+
+// fakeSyncInformer is a fake informer whose HasSynced returns the configured value.
+type fakeSyncInformer struct {
+	cache.SharedIndexInformer
+	synced bool
+}
+
+func (informer *fakeSyncInformer) HasSynced() bool {
+	return informer.synced
+}
+
+func TestReady(t *testing.T) {
+	start := time.Now()
+	steps := []struct {
+		elapsed       time.Duration
+		synced        bool
+		expectedReady bool
+	}{
+		{0, false, false},
+		// The informer is synced, but not for long enough.
+		{time.Second, true, false},
+		{2 * time.Second, true, false},
+		// The informer is flapping.
+		{2500 * time.Millisecond, false, false},
+		{3 * time.Second, true, false},
+		{4 * time.Second, true, false},
+		// The informer is synced continuously for readyDebounce.
+		{5 * time.Second, true, true},
+		{10 * time.Second, true, true},
+		// The informer is not synced anymore.
+		{11 * time.Second, false, false},
+	}
+
+	resolver := New()
+	informer := &fakeSyncInformer{}
+	resolver.dnsNameResolverInformer = informer
+	for i, step := range steps {
+		informer.synced = step.synced
+		if ready := resolver.ready(start.Add(step.elapsed)); ready != step.expectedReady {
+			t.Fatalf("Step %d: Expected ready %t, found %t", i, step.expectedReady, ready)
+		}
+	}
+}
+
+func TestReadyWithoutInformer(t *testing.T) {
+	if New().Ready() {
+		t.Fatalf("Expected not ready without informer")
+	}
+}
+
+func TestServeDNSFailClosed(t *testing.T) {
+	tests := []struct {
+		name          string
+		failClosed    bool
+		synced        bool
+		qname         string
+		expectedRcode int
+	}{
+		{
+			name:          "Lookups are passed through before the sync by default",
+			failClosed:    false,
+			synced:        false,
+			qname:         "www.example.com.",
+			expectedRcode: dns.RcodeSuccess,
+		},
+		{
+			name:          "Lookups fail before the sync with failClosed",
+			failClosed:    true,
+			synced:        false,
+			qname:         "www.example.com.",
+			expectedRcode: dns.RcodeServerFailure,
+		},
+		{
+			name:          "Lookups of the internal zones are passed through before the sync with failClosed",
+			failClosed:    true,
+			synced:        false,
+			qname:         "kubernetes.default.svc.cluster.local.",
+			expectedRcode: dns.RcodeSuccess,
+		},
+		{
+			name:          "Lookups are passed through after the sync with failClosed",
+			failClosed:    true,
+			synced:        true,
+			qname:         "www.example.com.",
+			expectedRcode: dns.RcodeSuccess,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := New()
+			resolver.failClosed = tc.failClosed
+			resolver.internalZones = []string{"cluster.local."}
+			resolver.dnsNameResolverInformer = &fakeSyncInformer{synced: tc.synced}
+			resolver.Next = fakeNextPluginHandler(test.Case{
+				Qname: tc.qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+			})
+
+			rcode, err := resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), test.Case{Qname: tc.qname, Qtype: dns.TypeA}.Msg())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if rcode != tc.expectedRcode {
+				t.Fatalf("Expected rcode %s, found %s", dns.RcodeToString[tc.expectedRcode], dns.RcodeToString[rcode])
+			}
+		})
+	}
+}
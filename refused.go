@@ -0,0 +1,149 @@
+package ocp_dnsnameresolver
+
+import (
+	"sync"
+
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// blockedMessage is the message of the Blocked condition of the refused DNS names.
+const blockedMessage = "The DNS lookup is refused by the upstream resolver"
+
+// blocksDNSName returns whether the failure of the DNS lookup with the rcode blocks the
+// DNS name, which is the case of the REFUSED rcode when refusedIsBlock is enabled.
+func (resolver *OCPDNSNameResolver) blocksDNSName(rcode int) bool {
+	return resolver.refusedIsBlock && rcode == dns.RcodeRefused
+}
+
+// blockedFailures counts the failures of the blocked resolved names not written to their
+// status yet, when refusedIsBlock is enabled. The status of a blocked resolved name is not
+// written again on each refused DNS lookup, thus its failures are kept in memory until the
+// failure threshold is reached, and are lost on a restart.
+type blockedFailures struct {
+	counts map[failureKey]int32
+	lock   sync.Mutex
+}
+
+// newBlockedFailures returns an initialized blockedFailures.
+func newBlockedFailures() *blockedFailures {
+	return &blockedFailures{
+		counts: make(map[failureKey]int32),
+	}
+}
+
+// add adds the failures to the count of the resolved name and returns the new count.
+func (failures *blockedFailures) add(key failureKey, increment int32) int32 {
+	failures.lock.Lock()
+	defer failures.lock.Unlock()
+	failures.counts[key] += increment
+	return failures.counts[key]
+}
+
+// written subtracts the failures written to the status from the count of the resolved
+// name, keeping the failures counted meanwhile.
+func (failures *blockedFailures) written(key failureKey, count int32) {
+	failures.lock.Lock()
+	defer failures.lock.Unlock()
+	failures.counts[key] -= count
+	if failures.counts[key] <= 0 {
+		delete(failures.counts, key)
+	}
+}
+
+// reset drops the count of the resolved name, eg. once the DNS name is resolved.
+func (failures *blockedFailures) reset(key failureKey) {
+	failures.lock.Lock()
+	defer failures.lock.Unlock()
+	delete(failures.counts, key)
+}
+
+// resetBlockedFailures drops the failures of the blocked resolved names of the DNS name in
+// the DNSNameResolver objects, once the DNS name is resolved.
+func (resolver *OCPDNSNameResolver) resetBlockedFailures(namespaceDNS namespaceDNSInfo, dnsName string) {
+	if !resolver.refusedIsBlock {
+		return
+	}
+	for namespace, objName := range namespaceDNS {
+		resolver.blockedFailures.reset(failureKey{object: types.NamespacedName{Namespace: namespace, Name: objName}, dnsName: dnsName})
+	}
+}
+
+// isBlocked returns whether the resolved name is already blocked for the rcode, i.e. has no
+// IP address, and its Blocked and Degraded conditions are true for the rcode.
+func isBlocked(resolvedName *ocpnetworkapiv1alpha1.DNSNameResolverResolvedName, rcode int) bool {
+	return len(resolvedName.ResolvedAddresses) == 0 &&
+		meta.IsStatusConditionTrue(resolvedName.Conditions, ConditionBlocked) &&
+		len(resolvedName.Conditions) > 0 &&
+		resolvedName.Conditions[0].Status == metav1.ConditionTrue &&
+		resolvedName.Conditions[0].Reason == dns.RcodeToString[rcode]
+}
+
+// blockResolvedName clears the IP addresses of the resolved name at the index in the
+// status of the DNSNameResolver object, regardless of their TTLs, and sets its Blocked
+// condition to true. The failures not written to the status yet are added to the failures
+// of the resolved name and reflected in the Degraded condition. Once the failure threshold
+// is reached, the resolved name is to be removed. A resolved name already blocked is not
+// updated below the failure threshold, so that the refused DNS lookups do not rewrite its
+// status, and its failures are kept by the caller until they are written. It returns
+// whether the resolved name is to be removed and whether it is updated.
+func blockResolvedName(
+	index int,
+	resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver,
+	currentTime metav1.Time,
+	failureThreshold int32,
+	rcode int,
+	failures int32,
+) (removeResolvedName bool, statusUpdated bool) {
+	resolvedName := &resolverObj.Status.ResolvedNames[index]
+	if resolvedName.ResolutionFailures+failures >= failureThreshold {
+		return true, true
+	}
+	if isBlocked(resolvedName, rcode) {
+		return false, false
+	}
+	resolvedName.ResolvedAddresses = []ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{}
+	resolvedName.ResolutionFailures += failures
+
+	// The Degraded condition is expected to be the first condition of the resolved name.
+	if len(resolvedName.Conditions) == 0 {
+		resolvedName.Conditions = []metav1.Condition{
+			{
+				Type:               ConditionDegraded,
+				Status:             metav1.ConditionTrue,
+				LastTransitionTime: currentTime,
+				Reason:             dns.RcodeToString[rcode],
+				Message:            rcodeMessage[rcode],
+			},
+		}
+	} else if resolvedName.Conditions[0].Status != metav1.ConditionTrue ||
+		resolvedName.Conditions[0].Reason != dns.RcodeToString[rcode] {
+		resolvedName.Conditions[0].Status = metav1.ConditionTrue
+		resolvedName.Conditions[0].LastTransitionTime = currentTime
+		resolvedName.Conditions[0].Reason = dns.RcodeToString[rcode]
+		resolvedName.Conditions[0].Message = rcodeMessage[rcode]
+	}
+	meta.SetStatusCondition(&resolvedName.Conditions, metav1.Condition{
+		Type:               ConditionBlocked,
+		Status:             metav1.ConditionTrue,
+		LastTransitionTime: currentTime,
+		Reason:             dns.RcodeToString[rcode],
+		Message:            blockedMessage,
+	})
+	return false, true
+}
+
+// unblockResolvedName removes the Blocked condition of the resolved name at the index in
+// the status of the DNSNameResolver object, once the DNS name is resolved again. It
+// returns whether the condition is removed.
+func unblockResolvedName(index int, resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+	conditions := &resolverObj.Status.ResolvedNames[index].Conditions
+	if meta.FindStatusCondition(*conditions, ConditionBlocked) == nil {
+		return false
+	}
+	meta.RemoveStatusCondition(conditions, ConditionBlocked)
+	return true
+}
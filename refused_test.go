@@ -0,0 +1,178 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestServeDNSRefusedIsBlock(t *testing.T) {
+	tests := []struct {
+		name           string
+		refusedIsBlock bool
+		rcode          int
+		expectBlocked  bool
+	}{
+		{
+			name:           "REFUSED with refusedIsBlock clears the IP addresses",
+			refusedIsBlock: true,
+			rcode:          dns.RcodeRefused,
+			expectBlocked:  true,
+		},
+		{
+			name:           "SERVFAIL with refusedIsBlock keeps the IP addresses",
+			refusedIsBlock: true,
+			rcode:          dns.RcodeServerFailure,
+		},
+		{
+			name:  "REFUSED without refusedIsBlock keeps the IP addresses",
+			rcode: dns.RcodeRefused,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.refusedIsBlock = tc.refusedIsBlock
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+				Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+			})
+
+			serve := func(rcode int, answer ...dns.RR) {
+				t.Helper()
+				query := test.Case{Qname: "www.example.com.", Qtype: dns.TypeA, Rcode: rcode, Answer: answer}
+				resolver.Next = fakeNextPluginHandler(query)
+				resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+			}
+
+			serve(dns.RcodeSuccess, test.A("www.example.com. 3600 IN A 1.1.1.1"))
+			getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+				return len(resolvedIPs(obj, "www.example.com.")) == 1
+			})
+
+			// The failure is counted in both the cases, only the IP addresses of the
+			// blocked DNS name are cleared although their TTLs have not expired.
+			serve(tc.rcode)
+			resolverObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+				return len(obj.Status.ResolvedNames) == 1 && obj.Status.ResolvedNames[0].ResolutionFailures == 1
+			})
+			if len(resolverObj.Status.ResolvedNames) != 1 {
+				t.Fatalf("Expected the resolved name to be kept, found %v", resolverObj.Status.ResolvedNames)
+			}
+			resolvedName := resolverObj.Status.ResolvedNames[0]
+			if resolvedName.ResolutionFailures != 1 {
+				t.Fatalf("Expected 1 resolution failure, found %d", resolvedName.ResolutionFailures)
+			}
+			if !meta.IsStatusConditionTrue(resolvedName.Conditions, ConditionDegraded) {
+				t.Fatalf("Expected the Degraded condition to be true, found %v", resolvedName.Conditions)
+			}
+			blocked := meta.FindStatusCondition(resolvedName.Conditions, ConditionBlocked)
+			if !tc.expectBlocked {
+				if len(resolvedName.ResolvedAddresses) != 1 {
+					t.Fatalf("Expected the IP addresses to be kept, found %v", resolvedName.ResolvedAddresses)
+				}
+				if blocked != nil {
+					t.Fatalf("Expected no Blocked condition, found %v", blocked)
+				}
+				return
+			}
+			if len(resolvedName.ResolvedAddresses) != 0 {
+				t.Fatalf("Expected the IP addresses to be cleared, found %v", resolvedName.ResolvedAddresses)
+			}
+			if blocked == nil || blocked.Status != metav1.ConditionTrue || blocked.Reason != dns.RcodeToString[dns.RcodeRefused] {
+				t.Fatalf("Expected the Blocked condition to be true with reason REFUSED, found %v", blocked)
+			}
+
+			// The Blocked condition is removed once the DNS name is resolved again.
+			serve(dns.RcodeSuccess, test.A("www.example.com. 3600 IN A 1.1.1.2"))
+			resolverObj = getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+				return len(resolvedIPs(obj, "www.example.com.")) == 1
+			})
+			resolvedName = resolverObj.Status.ResolvedNames[0]
+			if ips := resolvedIPs(resolverObj, "www.example.com."); len(ips) != 1 || ips[0] != "1.1.1.2" {
+				t.Fatalf("Expected the new IP address to be recorded, found %v", ips)
+			}
+			if meta.FindStatusCondition(resolvedName.Conditions, ConditionBlocked) != nil {
+				t.Fatalf("Expected the Blocked condition to be removed, found %v", resolvedName.Conditions)
+			}
+			if meta.IsStatusConditionTrue(resolvedName.Conditions, ConditionDegraded) {
+				t.Fatalf("Expected the Degraded condition to be false, found %v", resolvedName.Conditions)
+			}
+		})
+	}
+}
+
+func TestServeDNSRefusedIsBlockFailureThreshold(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.refusedIsBlock = true
+	resolver.failureThreshold = 3
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+	})
+
+	serve := func(rcode int, answer ...dns.RR) {
+		t.Helper()
+		query := test.Case{Qname: "www.example.com.", Qtype: dns.TypeA, Rcode: rcode, Answer: answer}
+		resolver.Next = fakeNextPluginHandler(query)
+		resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+	}
+
+	serve(dns.RcodeSuccess, test.A("www.example.com. 3600 IN A 1.1.1.1"))
+	getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		return len(resolvedIPs(obj, "www.example.com.")) == 1
+	})
+
+	// The first REFUSED response blocks the DNS name.
+	serve(dns.RcodeRefused)
+	resolverObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		return len(obj.Status.ResolvedNames) == 1 && meta.IsStatusConditionTrue(obj.Status.ResolvedNames[0].Conditions, ConditionBlocked)
+	})
+	if len(resolverObj.Status.ResolvedNames) != 1 || !meta.IsStatusConditionTrue(resolverObj.Status.ResolvedNames[0].Conditions, ConditionBlocked) {
+		t.Fatalf("Expected the resolved name to be blocked, found %v", resolverObj.Status.ResolvedNames)
+	}
+	if count := countStatusUpdates(fakeNetworkClient); count != 2 {
+		t.Fatalf("Expected 2 status updates, found %d", count)
+	}
+
+	// The next REFUSED response of the already blocked DNS name doesn't rewrite the status.
+	serve(dns.RcodeRefused)
+	if count := countStatusUpdates(fakeNetworkClient); count != 2 {
+		t.Fatalf("Expected no status update of the blocked DNS name, found %d status updates", count)
+	}
+
+	// The resolved name is removed once the REFUSED responses reach the failure threshold.
+	serve(dns.RcodeRefused)
+	resolverObj = getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		return len(obj.Status.ResolvedNames) == 0
+	})
+	if len(resolverObj.Status.ResolvedNames) != 0 {
+		t.Fatalf("Expected the resolved name to be removed at the failure threshold, found %v", resolverObj.Status.ResolvedNames)
+	}
+	if count := countStatusUpdates(fakeNetworkClient); count != 3 {
+		t.Fatalf("Expected 3 status updates, found %d", count)
+	}
+
+	// The REFUSED responses past the failure threshold don't update the status anymore.
+	serve(dns.RcodeRefused)
+	if count := countStatusUpdates(fakeNetworkClient); count != 3 {
+		t.Fatalf("Expected no status update past the failure threshold, found %d status updates", count)
+	}
+}
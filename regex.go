@@ -0,0 +1,98 @@
+package ocp_dnsnameresolver
+
+import (
+	"regexp"
+	"sort"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// regexAnnotation is the annotation used for matching a family of DNS names with a
+	// DNSNameResolver object, in addition to the DNS name of its spec.name field. The
+	// value of the annotation is a regular expression which should match the whole
+	// lowercased and fully qualified DNS name (eg. `[a-z0-9-]+\.apps\.example\.com\.`).
+	regexAnnotation = "dnsnameresolver.openshift.io/regex"
+)
+
+// trackRegex compiles the regular expression of the regex annotation of the
+// DNSNameResolver object and adds it to the regexDNSInfo map. An invalid regular
+// expression is logged and ignored.
+func (resolver *OCPDNSNameResolver) trackRegex(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) {
+	pattern, exists := resolverObj.Annotations[regexAnnotation]
+	if !exists {
+		return
+	}
+	regex, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		log.Warningf("Ignoring invalid value of annotation %s of DNSNameResolver object %s/%s: %v",
+			regexAnnotation, resolverObj.Namespace, resolverObj.Name, err)
+		return
+	}
+
+	resolver.regexMapLock.Lock()
+	defer resolver.regexMapLock.Unlock()
+	resolver.regexDNSInfo[types.NamespacedName{Namespace: resolverObj.Namespace, Name: resolverObj.Name}] = regex
+}
+
+// untrackRegex removes the regular expression of the DNSNameResolver object from
+// the regexDNSInfo map.
+func (resolver *OCPDNSNameResolver) untrackRegex(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) {
+	resolver.regexMapLock.Lock()
+	defer resolver.regexMapLock.Unlock()
+	delete(resolver.regexDNSInfo, types.NamespacedName{Namespace: resolverObj.Namespace, Name: resolverObj.Name})
+}
+
+// matchRegex returns the namespaces and the names of the DNSNameResolver objects whose
+// regular expressions match the DNS name. In a namespace, only one DNSNameResolver
+// object is considered: if the regular expressions of more than one DNSNameResolver
+// object match the DNS name, the object with the lexicographically first name is used.
+func (resolver *OCPDNSNameResolver) matchRegex(dnsName string) namespaceDNSInfo {
+	resolver.regexMapLock.Lock()
+	defer resolver.regexMapLock.Unlock()
+
+	var matches []types.NamespacedName
+	for key, regex := range resolver.regexDNSInfo {
+		if regex.MatchString(dnsName) {
+			matches = append(matches, key)
+		}
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Name < matches[j].Name
+	})
+
+	regexDNSInfo := make(namespaceDNSInfo)
+	for _, key := range matches {
+		if _, exists := regexDNSInfo[key.Namespace]; !exists {
+			regexDNSInfo[key.Namespace] = key.Name
+		}
+	}
+	return regexDNSInfo
+}
+
+// addRegexMatches returns the wildcard DNS name info along with the DNSNameResolver
+// objects matching the DNS name by their regular expressions. The DNSNameResolver
+// objects matching by their regular expressions are only considered in the
+// namespaces without any regular or wildcard DNSNameResolver object matching the
+// DNS name. The given maps are never modified.
+func addRegexMatches(regexDNSInfo, regularDNSInfo, wildcardDNSInfo namespaceDNSInfo) namespaceDNSInfo {
+	if len(regexDNSInfo) == 0 {
+		return wildcardDNSInfo
+	}
+	merged := make(namespaceDNSInfo, len(wildcardDNSInfo)+len(regexDNSInfo))
+	for namespace, objName := range wildcardDNSInfo {
+		merged[namespace] = objName
+	}
+	for namespace, objName := range regexDNSInfo {
+		_, regularExists := regularDNSInfo[namespace]
+		_, wildcardExists := wildcardDNSInfo[namespace]
+		if !regularExists && !wildcardExists {
+			merged[namespace] = objName
+		}
+	}
+	return merged
+}
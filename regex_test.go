@@ -0,0 +1,169 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMatchRegex(t *testing.T) {
+	resolver := New()
+	for _, obj := range []struct {
+		namespace, name, pattern string
+	}{
+		{"ns1", "apps", `[a-z0-9-]+\.apps\.example\.com\.`},
+		{"ns2", "foo", `foo\..*`},
+		{"ns2", "bar", `.*\.apps\.example\.com\.`},
+		{"ns3", "invalid", `[a-z`},
+	} {
+		resolver.trackRegex(&ocpnetworkapiv1alpha1.DNSNameResolver{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        obj.name,
+				Namespace:   obj.namespace,
+				Annotations: map[string]string{regexAnnotation: obj.pattern},
+			},
+		})
+	}
+	// Objects without the annotation are not tracked.
+	resolver.trackRegex(&ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "none",
+			Namespace: "ns4",
+		},
+	})
+	if len(resolver.regexDNSInfo) != 3 {
+		t.Fatalf("Expected 3 tracked regular expressions, found %d", len(resolver.regexDNSInfo))
+	}
+
+	tests := []struct {
+		name     string
+		dnsName  string
+		expected namespaceDNSInfo
+	}{
+		{
+			name:     "No regular expression matches",
+			dnsName:  "www.example.org.",
+			expected: nil,
+		},
+		{
+			name:     "Regular expression should match the whole DNS name",
+			dnsName:  "www.apps.example.com.org.",
+			expected: nil,
+		},
+		{
+			name:     "Regular expressions match in different namespaces",
+			dnsName:  "www.apps.example.com.",
+			expected: namespaceDNSInfo{"ns1": "apps", "ns2": "bar"},
+		},
+		{
+			name:     "Lexicographically first object is used in a namespace",
+			dnsName:  "foo.apps.example.com.",
+			expected: namespaceDNSInfo{"ns1": "apps", "ns2": "bar"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, resolver.matchRegex(tc.dnsName)); diff != "" {
+				t.Fatalf("Unexpected matches (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	resolver.untrackRegex(&ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "bar",
+			Namespace: "ns2",
+		},
+	})
+	if diff := cmp.Diff(namespaceDNSInfo{"ns1": "apps", "ns2": "foo"}, resolver.matchRegex("foo.apps.example.com.")); diff != "" {
+		t.Fatalf("Unexpected matches after untracking (-want +got):\n%s", diff)
+	}
+}
+
+func TestAddRegexMatches(t *testing.T) {
+	regexDNSInfo := namespaceDNSInfo{"ns1": "regex", "ns2": "regex", "ns3": "regex"}
+	regularDNSInfo := namespaceDNSInfo{"ns1": "regular"}
+	wildcardDNSInfo := namespaceDNSInfo{"ns2": "wildcard"}
+
+	expected := namespaceDNSInfo{"ns2": "wildcard", "ns3": "regex"}
+	if diff := cmp.Diff(expected, addRegexMatches(regexDNSInfo, regularDNSInfo, wildcardDNSInfo)); diff != "" {
+		t.Fatalf("Unexpected merged DNS info (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(namespaceDNSInfo{"ns2": "wildcard"}, wildcardDNSInfo); diff != "" {
+		t.Fatalf("Wildcard DNS info should not be modified (-want +got):\n%s", diff)
+	}
+}
+
+func TestServeDNSRegexMatch(t *testing.T) {
+	tests := []struct {
+		name                  string
+		regexMatch            bool
+		expectedStatusUpdates int
+	}{
+		{
+			name:                  "Regex annotation is ignored by default",
+			regexMatch:            false,
+			expectedStatusUpdates: 0,
+		},
+		{
+			name:                  "DNS name matching the regex annotation is recorded with regexMatch",
+			regexMatch:            true,
+			expectedStatusUpdates: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.regexMatch = tc.regexMatch
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regex",
+					Namespace: "dns",
+					Annotations: map[string]string{
+						regexAnnotation: `[a-z0-9-]+\.apps\.example\.com\.`,
+					},
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.apps.example.com.",
+				},
+			})
+
+			query := test.Case{
+				Qname: "foo.apps.example.com.",
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A("foo.apps.example.com. 30 IN A 1.1.1.1"),
+				},
+			}
+			resolver.Next = fakeNextPluginHandler(query)
+			resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+			if count := countStatusUpdates(fakeNetworkClient); count != tc.expectedStatusUpdates {
+				t.Fatalf("Expected %d status updates, found %d", tc.expectedStatusUpdates, count)
+			}
+			if tc.expectedStatusUpdates == 0 {
+				return
+			}
+			resolverObj := getResolverObject(t, resolver, "dns", "regex", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+				return len(obj.Status.ResolvedNames) > 0
+			})
+			if len(resolverObj.Status.ResolvedNames) != 1 || resolverObj.Status.ResolvedNames[0].DNSName != "foo.apps.example.com." {
+				t.Fatalf("Expected resolved name of foo.apps.example.com., found: %v", resolverObj.Status.ResolvedNames)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+
+	configclient "github.com/openshift/client-go/config/clientset/versioned"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// clusterNetworkConfigName is the name of the cluster-scoped Network config object,
+	// whose status gives the service CIDRs of the cluster.
+	clusterNetworkConfigName = "cluster"
+)
+
+// detectServiceCIDRs returns the service CIDRs of the cluster from the status of the
+// Network config object, for excludeServiceCIDR configured without any CIDR.
+func detectServiceCIDRs(ctx context.Context, configClient configclient.Interface) ([]netip.Prefix, error) {
+	network, err := configClient.ConfigV1().Networks().Get(ctx, clusterNetworkConfigName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the network config %s: %w", clusterNetworkConfigName, err)
+	}
+	if len(network.Status.ServiceNetwork) == 0 {
+		return nil, fmt.Errorf("no service network found in the status of the network config %s", clusterNetworkConfigName)
+	}
+
+	var prefixes []netip.Prefix
+	for _, serviceNetwork := range network.Status.ServiceNetwork {
+		prefix, err := parseCIDR(serviceNetwork)
+		if err != nil {
+			return nil, fmt.Errorf("invalid service network %q in the status of the network config %s: %w", serviceNetwork, clusterNetworkConfigName, err)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
+}
+
+// filterServiceAddresses removes the IP addresses which are in any of the service CIDRs
+// from the ipTTLs map, if excludeServiceCIDR is enabled. The removed IP addresses are
+// logged, as an external DNS name resolving into the service network of the cluster may
+// indicate a misrouting.
+func (resolver *OCPDNSNameResolver) filterServiceAddresses(ipTTLs map[string]int32, dnsName string) {
+	if !resolver.excludeServiceCIDR {
+		return
+	}
+	for ip := range ipTTLs {
+		addr, err := netip.ParseAddr(ip)
+		if err != nil || !resolver.serviceAddress(addr) {
+			continue
+		}
+		log.Warningf("Not recording IP address %s of DNS name %s as it is in the service CIDRs of the cluster", ip, dnsName)
+		delete(ipTTLs, ip)
+	}
+}
+
+// serviceAddress returns true when the IP address is in any of the service CIDRs.
+func (resolver *OCPDNSNameResolver) serviceAddress(addr netip.Addr) bool {
+	for _, prefix := range resolver.serviceCIDRs {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	configv1 "github.com/openshift/api/config/v1"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	configfakeclient "github.com/openshift/client-go/config/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestDetectServiceCIDRs(t *testing.T) {
+	tests := []struct {
+		name          string
+		objects       []runtime.Object
+		expectedCIDRs []netip.Prefix
+		expectErr     bool
+	}{
+		{
+			name: "Service networks are detected",
+			objects: []runtime.Object{&configv1.Network{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Status:     configv1.NetworkStatus{ServiceNetwork: []string{"172.30.0.0/16", "fd02::/112"}},
+			}},
+			expectedCIDRs: []netip.Prefix{netip.MustParsePrefix("172.30.0.0/16"), netip.MustParsePrefix("fd02::/112")},
+		},
+		{
+			name:      "Missing network config",
+			expectErr: true,
+		},
+		{
+			name: "Network config without service network",
+			objects: []runtime.Object{&configv1.Network{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+			}},
+			expectErr: true,
+		},
+		{
+			name: "Invalid service network",
+			objects: []runtime.Object{&configv1.Network{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Status:     configv1.NetworkStatus{ServiceNetwork: []string{"172.30.0.0"}},
+			}},
+			expectErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cidrs, err := detectServiceCIDRs(context.TODO(), configfakeclient.NewSimpleClientset(tc.objects...))
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("Expected an error, found service CIDRs %v", cidrs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(cidrs, tc.expectedCIDRs) {
+				t.Fatalf("Expected service CIDRs %v, found %v", tc.expectedCIDRs, cidrs)
+			}
+		})
+	}
+}
+
+func TestServeDNSExcludeServiceCIDR(t *testing.T) {
+	tests := []struct {
+		name                  string
+		excludeServiceCIDR    bool
+		answers               []dns.RR
+		expectedStatusUpdates int
+		expectedIPs           []string
+	}{
+		{
+			name: "Addresses in the service CIDR are recorded by default",
+			answers: []dns.RR{
+				test.A("www.example.com. 30 IN A 172.30.0.10"),
+			},
+			expectedStatusUpdates: 1,
+			expectedIPs:           []string{"172.30.0.10"},
+		},
+		{
+			name:               "Addresses in the service CIDR are excluded",
+			excludeServiceCIDR: true,
+			answers: []dns.RR{
+				test.A("www.example.com. 30 IN A 172.30.0.10"),
+				test.A("www.example.com. 30 IN A 1.1.1.1"),
+			},
+			expectedStatusUpdates: 1,
+			expectedIPs:           []string{"1.1.1.1"},
+		},
+		{
+			name:               "Response with only addresses in the service CIDR is not recorded",
+			excludeServiceCIDR: true,
+			answers: []dns.RR{
+				test.A("www.example.com. 30 IN A 172.30.0.10"),
+				test.A("www.example.com. 30 IN A 172.30.255.1"),
+			},
+			expectedStatusUpdates: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.excludeServiceCIDR = tc.excludeServiceCIDR
+			resolver.serviceCIDRs = []netip.Prefix{netip.MustParsePrefix("172.30.0.0/16")}
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+				Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+			})
+
+			query := test.Case{
+				Qname:  "www.example.com.",
+				Qtype:  dns.TypeA,
+				Rcode:  dns.RcodeSuccess,
+				Answer: tc.answers,
+			}
+			resolver.Next = fakeNextPluginHandler(query)
+			w := dnstest.NewRecorder(&test.ResponseWriter{})
+			resolver.ServeDNS(ctx, w, query.Msg())
+			if w.Msg == nil || len(w.Msg.Answer) != len(tc.answers) {
+				t.Fatalf("Expected the response of the next plugin to be served unfiltered, found: %v", w.Msg)
+			}
+			if count := countStatusUpdates(fakeNetworkClient); count != tc.expectedStatusUpdates {
+				t.Fatalf("Expected %d status updates, found %d", tc.expectedStatusUpdates, count)
+			}
+			if tc.expectedIPs == nil {
+				return
+			}
+			resolverObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+				return len(obj.Status.ResolvedNames) == 1
+			})
+			if ips := resolvedIPs(resolverObj, "www.example.com."); !reflect.DeepEqual(ips, tc.expectedIPs) {
+				t.Fatalf("Expected IP addresses %v, found %v", tc.expectedIPs, ips)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package ocp_dnsnameresolver
+
+import (
+	"time"
+
+	"github.com/miekg/dns"
+	"k8s.io/client-go/tools/cache"
+)
+
+// waitSettle records the time at which the DNSNameResolver informer synced, once it does,
+// for the settle window of settleWindow. It returns once the informer synced or once the
+// stop channel is closed.
+func (resolver *OCPDNSNameResolver) waitSettle(stopCh <-chan struct{}) {
+	if !cache.WaitForCacheSync(stopCh, resolver.dnsNameResolverInformer.HasSynced) {
+		return
+	}
+	resolver.settleLock.Lock()
+	resolver.syncedAt = time.Now()
+	resolver.settleLock.Unlock()
+}
+
+// settleRemaining returns the remaining duration of the settle window at the given time,
+// or zero if settleWindow is not configured or if the window is over. The settle window
+// starts once the DNSNameResolver informer synced: right after the sync, the events of
+// the DNSNameResolver objects may still be delivered, thus a DNS name missing from the
+// tracked DNS names may just not be tracked yet. The whole window remains while the
+// informer is not synced.
+func (resolver *OCPDNSNameResolver) settleRemaining(now time.Time) time.Duration {
+	if resolver.settleWindow <= 0 {
+		return 0
+	}
+	resolver.settleLock.Lock()
+	syncedAt := resolver.syncedAt
+	resolver.settleLock.Unlock()
+	if syncedAt.IsZero() {
+		return resolver.settleWindow
+	}
+	if remaining := syncedAt.Add(resolver.settleWindow).Sub(now); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// settleResponseWriter is a dns.ResponseWriter which caps the TTLs of the records of the
+// responses of the DNS names missing from the tracked DNS names during the settle window.
+// The responses are thus not cached beyond the settle window, neither by the clients nor
+// by the cache plugin, including the negative responses whose TTL is given by the SOA
+// record of the authority section. Once the window is over, the DNS lookups of the DNS
+// names which were just not tracked yet reach the plugin again, and are recorded.
+type settleResponseWriter struct {
+	dns.ResponseWriter
+	maxTTL uint32
+}
+
+// WriteMsg implements dns.ResponseWriter.
+func (w *settleResponseWriter) WriteMsg(res *dns.Msg) error {
+	// The records may be shared with the plugins in the chain, eg. the cache plugin.
+	res = res.Copy()
+	for _, section := range [][]dns.RR{res.Answer, res.Ns, res.Extra} {
+		for _, rr := range section {
+			// The TTL field of the OPT pseudo record holds the extended flags.
+			if rr.Header().Rrtype == dns.TypeOPT {
+				continue
+			}
+			if rr.Header().Ttl > w.maxTTL {
+				rr.Header().Ttl = w.maxTTL
+			}
+		}
+	}
+	return w.ResponseWriter.WriteMsg(res)
+}
@@ -0,0 +1,103 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestServeDNSSettleWindow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.settleWindow = time.Minute
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	// The whole window remains until the informer sync is recorded.
+	if remaining := resolver.settleRemaining(time.Now()); remaining != time.Minute {
+		t.Fatalf("Expected the whole settle window to remain before the sync, found %s", remaining)
+	}
+	resolver.waitSettle(ctx.Done())
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+	})
+
+	// lookup serves the DNS lookup and returns the TTLs of the records of the response.
+	lookup := func(tc test.Case) []uint32 {
+		t.Helper()
+		resolver.Next = fakeNextPluginHandler(tc)
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		resolver.ServeDNS(ctx, rec, tc.Msg())
+		if rec.Msg == nil {
+			t.Fatalf("Expected the DNS lookup of %s to be served", tc.Qname)
+		}
+		var ttls []uint32
+		for _, rr := range append(append(rec.Msg.Answer, rec.Msg.Ns...), rec.Msg.Extra...) {
+			if rr.Header().Rrtype != dns.TypeOPT {
+				ttls = append(ttls, rr.Header().Ttl)
+			}
+		}
+		return ttls
+	}
+	untracked := test.Case{
+		Qname: "www.example.org.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("www.example.org. 300 IN A 1.1.1.1"),
+		},
+	}
+	negative := test.Case{
+		Qname: "missing.example.org.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+		Ns: []dns.RR{
+			test.SOA("example.org. 300 IN SOA ns.example.org. hostmaster.example.org. 1 7200 3600 1209600 300"),
+		},
+	}
+	tracked := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("www.example.com. 300 IN A 2.2.2.2"),
+		},
+	}
+
+	// During the settle window, the responses of the untracked DNS names are not cached
+	// beyond the window, including the negative responses.
+	for _, tc := range []test.Case{untracked, negative} {
+		for _, ttl := range lookup(tc) {
+			if ttl > 60 {
+				t.Fatalf("Expected the TTLs of the response for %s to be capped to the settle window, found %d", tc.Qname, ttl)
+			}
+		}
+	}
+	// The responses of the tracked DNS names are served as is.
+	for _, ttl := range lookup(tracked) {
+		if ttl != 300 {
+			t.Fatalf("Expected the TTLs of the response for tracked %s to be kept, found %d", tracked.Qname, ttl)
+		}
+	}
+
+	// Once the window is over, the responses of the untracked DNS names are served as is.
+	resolver.settleLock.Lock()
+	resolver.syncedAt = time.Now().Add(-2 * time.Minute)
+	resolver.settleLock.Unlock()
+	for _, tc := range []test.Case{untracked, negative} {
+		for _, ttl := range lookup(tc) {
+			if ttl != 300 {
+				t.Fatalf("Expected the TTLs of the response for %s to be kept after the settle window, found %d", tc.Qname, ttl)
+			}
+		}
+	}
+}
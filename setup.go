@@ -1,24 +1,125 @@
 package ocp_dnsnameresolver
 
 import (
+	"errors"
+	"math"
+	"net"
+	"net/url"
+	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
 	clog "github.com/coredns/coredns/plugin/pkg/log"
+	"github.com/miekg/dns"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 const (
 	pluginName = "ocp_dnsnameresolver"
 
-	namespacesField       = "namespaces"
-	minTTLField           = "minTTL"
-	failureThresholdField = "failureThreshold"
+	namespacesField               = "namespaces"
+	internalZonesField            = "internalZones"
+	externalZonesField            = "externalZones"
+	labelSelectorField            = "labelSelector"
+	namespaceSelectorField        = "namespaceSelector"
+	namespaceRegexField           = "namespaceRegex"
+	filterOperatorField           = "filterOperator"
+	minTTLField                   = "minTTL"
+	failureTTLField               = "failureTTL"
+	zoneTTLField                  = "zoneTTL"
+	ttlJitterField                = "ttlJitter"
+	minRemainingTTLField          = "minRemainingTTL"
+	failureThresholdField         = "failureThreshold"
+	failureThresholdV4Field       = "failureThresholdV4"
+	failureThresholdV6Field       = "failureThresholdV6"
+	failureWeightField            = "failureWeight"
+	minQueriesField               = "minQueries"
+	minQueriesWindowField         = "minQueriesWindow"
+	accumulateWindowField         = "accumulateWindow"
+	maxRecordAgeField             = "maxRecordAge"
+	mergeWindowField              = "mergeWindow"
+	negativeMaxAgeField           = "negativeMaxAge"
+	settleWindowField             = "settleWindow"
+	wildcardFlushIntervalField    = "wildcardFlushInterval"
+	reconcileIntervalField        = "reconcileInterval"
+	summaryEventIntervalField     = "summaryEventInterval"
+	flushIntervalField            = "flushInterval"
+	maxUpdatesPerObjectField      = "maxUpdatesPerObject"
+	staleGraceField               = "staleGrace"
+	namespacePacingField          = "namespacePacing"
+	queryCoalesceWindowField      = "queryCoalesceWindow"
+	failureLogIntervalField       = "failureLogInterval"
+	breakerThresholdField         = "breakerThreshold"
+	breakerCooldownField          = "breakerCooldown"
+	maxAnswerRecordsField         = "maxAnswerRecords"
+	maxTrackedNamesField          = "maxTrackedNames"
+	maxCNAMEDepthField            = "maxCNAMEDepth"
+	allowedCIDRsField             = "allowedCIDRs"
+	excludeServiceCIDRField       = "excludeServiceCIDR"
+	allowedClientCIDRsField       = "allowedClientCIDRs"
+	listPageSizeField             = "listPageSize"
+	shutdownTimeoutField          = "shutdownTimeout"
+	overlapPolicyField            = "overlapPolicy"
+	truncatedPolicyField          = "truncatedPolicy"
+	partialChainPolicyField       = "partialChainPolicy"
+	fanOutOrderField              = "fanOutOrder"
+	conflictResolutionField       = "conflictResolution"
+	addressOrderField             = "addressOrder"
+	wildcardNamespaceScopeField   = "wildcardNamespaceScope"
+	wildcardSpecificityField      = "wildcardSpecificity"
+	multiNamespaceField           = "multiNamespace"
+	instanceIDField               = "instanceID"
+	mirrorConfigMapField          = "mirrorConfigMap"
+	maintenanceSentinelField      = "maintenanceSentinel"
+	validateOnlyField             = "validateOnly"
+	recordSRVField                = "recordSRV"
+	preserveCaseField             = "preserveCase"
+	prefetchOnStartField          = "prefetchOnStart"
+	auditLogField                 = "auditLog"
+	recordNegativeField           = "recordNegative"
+	regexMatchField               = "regexMatch"
+	perNamespaceMetricsField      = "perNamespaceMetrics"
+	retryForbiddenField           = "retryForbidden"
+	failClosedField               = "failClosed"
+	rejectApexWildcardField       = "rejectApexWildcard"
+	strictQNameMatchField         = "strictQNameMatch"
+	signalDumpField               = "signalDump"
+	debugAddressField             = "debugAddress"
+	webhookURLField               = "webhookURL"
+	recordUpstreamField           = "recordUpstream"
+	recordWildcardChildrenField   = "recordWildcardChildren"
+	recordPTRField                = "recordPTR"
+	lazyStartField                = "lazyStart"
+	includeAdditionalField        = "includeAdditional"
+	doubleCheckField              = "doubleCheck"
+	pruneDeletedNamespacesField   = "pruneDeletedNamespaces"
+	recordObservedGenerationField = "recordObservedGeneration"
+	rejectInternalWildcardsField  = "rejectInternalWildcards"
+	recordExtendedErrorsField     = "recordExtendedErrors"
+	exemplarsField                = "exemplars"
+	serveStaleOnTimeoutField      = "serveStaleOnTimeout"
+	singleflightField             = "singleflight"
+	useFinalizerField             = "useFinalizer"
+	liveGetField                  = "liveGet"
+	recordFingerprintField        = "recordFingerprint"
+	refusedIsBlockField           = "refusedIsBlock"
+	rejectCDBitField              = "rejectCDBit"
+	diagnosticsField              = "diagnostics"
+	clampTTLField                 = "clampTTL"
 )
 
 var log = clog.NewWithPlugin(pluginName)
 
+// exit is called to stop CoreDNS once the configuration is validated in validate only
+// mode. It is replaced in the tests.
+var exit = os.Exit
+
 func init() { plugin.Register(pluginName, setup) }
 
 func setup(c *caddy.Controller) error {
@@ -27,6 +128,15 @@ func setup(c *caddy.Controller) error {
 		return plugin.Error(pluginName, err)
 	}
 
+	// In validate only mode, the configuration is only parsed and validated. The
+	// informer is not started, and CoreDNS exits with status 0 on its first startup,
+	// i.e. once the whole configuration, including the other plugins, is set up
+	// successfully and before the servers are started. A reload does not exit.
+	if resolver.validateOnly {
+		c.OnFirstStartup(exitValid)
+		return nil
+	}
+
 	onStart, onShut, err := resolver.initPlugin()
 	if err != nil {
 		return plugin.Error(pluginName, err)
@@ -43,8 +153,29 @@ func setup(c *caddy.Controller) error {
 	return nil
 }
 
+// exitValid reports the configuration as valid and exits CoreDNS with status 0.
+func exitValid() error {
+	log.Info("configuration is valid")
+	exit(0)
+	return nil
+}
+
+// Validate parses and validates the plugin configuration, without building the
+// informers or any other runtime state of the plugin. The errors of all the invalid
+// options are returned together.
+func Validate(c *caddy.Controller) error {
+	if _, err := resolverParse(c); err != nil {
+		return plugin.Error(pluginName, err)
+	}
+	return nil
+}
+
+// resolverParse parses and validates the plugin configuration. Parsing does not
+// stop at the first invalid option: the errors of all the invalid options are
+// aggregated and returned together.
 func resolverParse(c *caddy.Controller) (*OCPDNSNameResolver, error) {
 	resolver := New()
+	var errs []error
 
 	i := 0
 	for c.Next() {
@@ -59,46 +190,946 @@ func resolverParse(c *caddy.Controller) (*OCPDNSNameResolver, error) {
 		}
 
 		for c.NextBlock() {
-			switch c.Val() {
-			case namespacesField:
-				args := c.RemainingArgs()
-				if len(args) > 0 {
-					for _, a := range args {
-						resolver.namespaces[a] = struct{}{}
-					}
-				} else {
-					return nil, c.ArgErr()
-				}
-			case minTTLField:
-				args := c.RemainingArgs()
-				if len(args) != 1 {
-					return nil, c.ArgErr()
-				}
-				minTTL, err := strconv.Atoi(args[0])
-				if err != nil {
-					return nil, c.Errf("value of minTTL should be an integer: %s", args[0])
-				}
-				if minTTL <= 0 {
-					return nil, c.Errf("value of minTTL should be greater than 0: %s", args[0])
-				}
-				resolver.minimumTTL = int32(minTTL)
-			case failureThresholdField:
-				args := c.RemainingArgs()
-				if len(args) != 1 {
-					return nil, c.ArgErr()
-				}
-				failureThreshold, err := strconv.Atoi(args[0])
-				if err != nil {
-					return nil, c.Errf("value of failureThreshold should be an integer: %s", args[0])
-				}
-				if failureThreshold <= 0 {
-					return nil, c.Errf("value of failureThreshold should be greater than 0: %s", args[0])
-				}
-				resolver.failureThreshold = int32(failureThreshold)
-			default:
-				return nil, c.Errf("unknown property %q", c.Val())
+			if err := parseOption(c, resolver); err != nil {
+				errs = append(errs, err)
 			}
 		}
 	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
 	return resolver, nil
 }
+
+// parseOption parses and validates the current option of the plugin
+// configuration block and applies it to the resolver.
+func parseOption(c *caddy.Controller, resolver *OCPDNSNameResolver) error {
+	switch c.Val() {
+	case namespacesField:
+		args := c.RemainingArgs()
+		if len(args) > 0 {
+			for _, a := range args {
+				resolver.namespaces[a] = struct{}{}
+			}
+		} else {
+			return c.ArgErr()
+		}
+	case internalZonesField:
+		args := c.RemainingArgs()
+		if len(args) == 0 {
+			return c.ArgErr()
+		}
+		for _, a := range args {
+			for _, zone := range plugin.Host(a).NormalizeExact() {
+				resolver.internalZones = append(resolver.internalZones, strings.ToLower(zone))
+			}
+		}
+	case externalZonesField:
+		args := c.RemainingArgs()
+		if len(args) == 0 {
+			return c.ArgErr()
+		}
+		for _, a := range args {
+			for _, zone := range plugin.Host(a).NormalizeExact() {
+				resolver.externalZones = append(resolver.externalZones, strings.ToLower(zone))
+			}
+		}
+	case labelSelectorField:
+		args := c.RemainingArgs()
+		if len(args) == 0 {
+			return c.ArgErr()
+		}
+		selector, err := labels.Parse(strings.Join(args, " "))
+		if err != nil {
+			return c.Errf("value of labelSelector should be a valid label selector: %v", err)
+		}
+		resolver.labelSelector = selector
+	case namespaceSelectorField:
+		args := c.RemainingArgs()
+		if len(args) == 0 {
+			return c.ArgErr()
+		}
+		selector, err := labels.Parse(strings.Join(args, " "))
+		if err != nil {
+			return c.Errf("value of namespaceSelector should be a valid label selector: %v", err)
+		}
+		resolver.namespaceSelector = selector
+	case namespaceRegexField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		regex, err := compileNamespaceRegex(args[0])
+		if err != nil {
+			return c.Errf("value of namespaceRegex should be a valid regular expression: %v", err)
+		}
+		resolver.namespaceRegex = regex
+	case filterOperatorField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		operator, ok := parseFilterOperator(args[0])
+		if !ok {
+			return c.Errf("value of filterOperator should be one of %s or %s: %s",
+				filterOperatorAnd, filterOperatorOr, args[0])
+		}
+		resolver.filterOperator = operator
+	case minTTLField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		minTTL, err := parseTTLValue(c, minTTLField, args[0])
+		if err != nil {
+			return err
+		}
+		resolver.minimumTTL = minTTL
+	case failureTTLField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		failureTTL, err := parseTTLValue(c, failureTTLField, args[0])
+		if err != nil {
+			return err
+		}
+		resolver.failureTTL = failureTTL
+	case zoneTTLField:
+		args := c.RemainingArgs()
+		if len(args) != 3 {
+			return c.ArgErr()
+		}
+		zones := plugin.Host(args[0]).NormalizeExact()
+		if len(zones) == 0 {
+			return c.Errf("zone of zoneTTL is invalid: %s", args[0])
+		}
+		minTTL, err := strconv.Atoi(args[1])
+		if err != nil {
+			return c.Errf("minimum TTL of zoneTTL should be an integer: %s", args[1])
+		}
+		maxTTL, err := strconv.Atoi(args[2])
+		if err != nil {
+			return c.Errf("maximum TTL of zoneTTL should be an integer: %s", args[2])
+		}
+		if minTTL <= 0 {
+			return c.Errf("minimum TTL of zoneTTL should be greater than 0: %s", args[1])
+		}
+		if maxTTL < minTTL {
+			return c.Errf("maximum TTL of zoneTTL should be greater than or equal to the minimum TTL: %s", args[2])
+		}
+		if maxTTL > math.MaxInt32 {
+			return c.Errf("maximum TTL of zoneTTL should be at most %d seconds: %s", math.MaxInt32, args[2])
+		}
+		if resolver.zoneTTLs == nil {
+			resolver.zoneTTLs = make(map[string]zoneTTLPolicy)
+		}
+		for _, zone := range zones {
+			zone = strings.ToLower(zone)
+			if _, exists := resolver.zoneTTLs[zone]; exists {
+				return c.Errf("zoneTTL is already configured for zone: %s", args[0])
+			}
+			resolver.zoneTTLs[zone] = zoneTTLPolicy{minTTL: int32(minTTL), maxTTL: int32(maxTTL)}
+		}
+	case ttlJitterField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		ttlJitter, err := strconv.Atoi(args[0])
+		if err != nil {
+			return c.Errf("value of ttlJitter should be an integer: %s", args[0])
+		}
+		if ttlJitter < 0 {
+			return c.Errf("value of ttlJitter should be greater than or equal to 0: %s", args[0])
+		}
+		resolver.ttlJitter = int32(ttlJitter)
+	case minRemainingTTLField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		minRemainingTTL, err := strconv.Atoi(args[0])
+		if err != nil {
+			return c.Errf("value of minRemainingTTL should be an integer: %s", args[0])
+		}
+		if minRemainingTTL <= 0 {
+			return c.Errf("value of minRemainingTTL should be greater than 0: %s", args[0])
+		}
+		resolver.minRemainingTTL = uint32(minRemainingTTL)
+	case failureThresholdField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		failureThreshold, err := strconv.Atoi(args[0])
+		if err != nil {
+			return c.Errf("value of failureThreshold should be an integer: %s", args[0])
+		}
+		if failureThreshold <= 0 {
+			return c.Errf("value of failureThreshold should be greater than 0: %s", args[0])
+		}
+		resolver.failureThreshold = int32(failureThreshold)
+	case failureThresholdV4Field, failureThresholdV6Field:
+		property := c.Val()
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		failureThreshold, err := strconv.Atoi(args[0])
+		if err != nil {
+			return c.Errf("value of %s should be an integer: %s", property, args[0])
+		}
+		if failureThreshold <= 0 || failureThreshold > math.MaxInt32 {
+			return c.Errf("value of %s should be greater than 0 and at most %d: %s", property, math.MaxInt32, args[0])
+		}
+		if property == failureThresholdV4Field {
+			resolver.failureThresholdV4 = int32(failureThreshold)
+		} else {
+			resolver.failureThresholdV6 = int32(failureThreshold)
+		}
+	case failureWeightField:
+		args := c.RemainingArgs()
+		if len(args) != 2 {
+			return c.ArgErr()
+		}
+		rcode, ok := dns.StringToRcode[strings.ToUpper(args[0])]
+		if !ok || rcode == dns.RcodeSuccess {
+			return c.Errf("value of failureWeight should be a failure rcode: %s", args[0])
+		}
+		weight, err := strconv.ParseFloat(args[1], 64)
+		if err != nil || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return c.Errf("weight of failureWeight should be a number: %s", args[1])
+		}
+		if weight < 0 {
+			return c.Errf("weight of failureWeight should not be negative: %s", args[1])
+		}
+		if resolver.failureWeights == nil {
+			resolver.failureWeights = make(map[int]float64)
+		}
+		resolver.failureWeights[rcode] = weight
+	case minQueriesField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		minQueries, err := strconv.Atoi(args[0])
+		if err != nil {
+			return c.Errf("value of minQueries should be an integer: %s", args[0])
+		}
+		if minQueries <= 0 {
+			return c.Errf("value of minQueries should be greater than 0: %s", args[0])
+		}
+		resolver.minQueries = minQueries
+	case minQueriesWindowField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		minQueriesWindow, err := time.ParseDuration(args[0])
+		if err != nil {
+			return c.Errf("value of minQueriesWindow should be a duration: %s", args[0])
+		}
+		if minQueriesWindow <= 0 {
+			return c.Errf("value of minQueriesWindow should be greater than 0: %s", args[0])
+		}
+		resolver.minQueriesWindow = minQueriesWindow
+	case accumulateWindowField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		accumulateWindow, err := time.ParseDuration(args[0])
+		if err != nil {
+			return c.Errf("value of accumulateWindow should be a duration: %s", args[0])
+		}
+		if accumulateWindow <= 0 {
+			return c.Errf("value of accumulateWindow should be greater than 0: %s", args[0])
+		}
+		resolver.accumulateWindow = accumulateWindow
+	case maxRecordAgeField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		maxRecordAge, err := time.ParseDuration(args[0])
+		if err != nil {
+			return c.Errf("value of maxRecordAge should be a duration: %s", args[0])
+		}
+		if maxRecordAge <= 0 {
+			return c.Errf("value of maxRecordAge should be greater than 0: %s", args[0])
+		}
+		resolver.maxRecordAge = maxRecordAge
+	case mergeWindowField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		mergeWindow, err := time.ParseDuration(args[0])
+		if err != nil {
+			return c.Errf("value of mergeWindow should be a duration: %s", args[0])
+		}
+		if mergeWindow <= 0 {
+			return c.Errf("value of mergeWindow should be greater than 0: %s", args[0])
+		}
+		resolver.mergeWindow = mergeWindow
+	case settleWindowField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		settleWindow, err := time.ParseDuration(args[0])
+		if err != nil {
+			return c.Errf("value of settleWindow should be a duration: %s", args[0])
+		}
+		if settleWindow <= 0 {
+			return c.Errf("value of settleWindow should be greater than 0: %s", args[0])
+		}
+		resolver.settleWindow = settleWindow
+	case wildcardFlushIntervalField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		wildcardFlushInterval, err := time.ParseDuration(args[0])
+		if err != nil {
+			return c.Errf("value of wildcardFlushInterval should be a duration: %s", args[0])
+		}
+		if wildcardFlushInterval <= 0 {
+			return c.Errf("value of wildcardFlushInterval should be greater than 0: %s", args[0])
+		}
+		resolver.wildcardFlushInterval = wildcardFlushInterval
+	case reconcileIntervalField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		reconcileInterval, err := time.ParseDuration(args[0])
+		if err != nil {
+			return c.Errf("value of reconcileInterval should be a duration: %s", args[0])
+		}
+		if reconcileInterval <= 0 {
+			return c.Errf("value of reconcileInterval should be greater than 0: %s", args[0])
+		}
+		resolver.reconcileInterval = reconcileInterval
+	case summaryEventIntervalField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		summaryEventInterval, err := time.ParseDuration(args[0])
+		if err != nil {
+			return c.Errf("value of summaryEventInterval should be a duration: %s", args[0])
+		}
+		if summaryEventInterval < minSummaryEventInterval {
+			return c.Errf("value of summaryEventInterval should be at least %s: %s", minSummaryEventInterval, args[0])
+		}
+		resolver.summaryEventInterval = summaryEventInterval
+	case flushIntervalField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		flushInterval, err := time.ParseDuration(args[0])
+		if err != nil {
+			return c.Errf("value of flushInterval should be a duration: %s", args[0])
+		}
+		if flushInterval <= 0 {
+			return c.Errf("value of flushInterval should be greater than 0: %s", args[0])
+		}
+		resolver.flushInterval = flushInterval
+	case maxUpdatesPerObjectField:
+		args := c.RemainingArgs()
+		if len(args) != 2 {
+			return c.ArgErr()
+		}
+		maxUpdates, err := strconv.Atoi(args[0])
+		if err != nil {
+			return c.Errf("value of maxUpdatesPerObject should be an integer: %s", args[0])
+		}
+		if maxUpdates <= 0 {
+			return c.Errf("value of maxUpdatesPerObject should be greater than 0: %s", args[0])
+		}
+		updateInterval, err := time.ParseDuration(args[1])
+		if err != nil {
+			return c.Errf("interval of maxUpdatesPerObject should be a duration: %s", args[1])
+		}
+		if updateInterval <= 0 {
+			return c.Errf("interval of maxUpdatesPerObject should be greater than 0: %s", args[1])
+		}
+		resolver.maxUpdatesPerObject = maxUpdates
+		resolver.updateRateInterval = updateInterval
+	case staleGraceField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		staleGrace, err := time.ParseDuration(args[0])
+		if err != nil {
+			return c.Errf("value of staleGrace should be a duration: %s", args[0])
+		}
+		if staleGrace <= 0 {
+			return c.Errf("value of staleGrace should be greater than 0: %s", args[0])
+		}
+		resolver.staleGrace = staleGrace
+	case negativeMaxAgeField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		negativeMaxAge, err := time.ParseDuration(args[0])
+		if err != nil {
+			return c.Errf("value of negativeMaxAge should be a duration: %s", args[0])
+		}
+		if negativeMaxAge <= 0 {
+			return c.Errf("value of negativeMaxAge should be greater than 0: %s", args[0])
+		}
+		resolver.negativeMaxAge = negativeMaxAge
+	case namespacePacingField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		namespacePacing, err := time.ParseDuration(args[0])
+		if err != nil {
+			return c.Errf("value of namespacePacing should be a duration: %s", args[0])
+		}
+		if namespacePacing <= 0 {
+			return c.Errf("value of namespacePacing should be greater than 0: %s", args[0])
+		}
+		resolver.namespacePacing = namespacePacing
+	case queryCoalesceWindowField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		queryCoalesceWindow, err := time.ParseDuration(args[0])
+		if err != nil {
+			return c.Errf("value of queryCoalesceWindow should be a duration: %s", args[0])
+		}
+		if queryCoalesceWindow <= 0 {
+			return c.Errf("value of queryCoalesceWindow should be greater than 0: %s", args[0])
+		}
+		resolver.queryCoalesceWindow = queryCoalesceWindow
+	case failureLogIntervalField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		failureLogInterval, err := time.ParseDuration(args[0])
+		if err != nil {
+			return c.Errf("value of failureLogInterval should be a duration: %s", args[0])
+		}
+		if failureLogInterval <= 0 {
+			return c.Errf("value of failureLogInterval should be greater than 0: %s", args[0])
+		}
+		resolver.failureLogInterval = failureLogInterval
+	case breakerThresholdField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		breakerThreshold, err := strconv.Atoi(args[0])
+		if err != nil {
+			return c.Errf("value of breakerThreshold should be an integer: %s", args[0])
+		}
+		if breakerThreshold <= 0 {
+			return c.Errf("value of breakerThreshold should be greater than 0: %s", args[0])
+		}
+		resolver.breakerThreshold = breakerThreshold
+	case breakerCooldownField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		breakerCooldown, err := time.ParseDuration(args[0])
+		if err != nil {
+			return c.Errf("value of breakerCooldown should be a duration: %s", args[0])
+		}
+		if breakerCooldown <= 0 {
+			return c.Errf("value of breakerCooldown should be greater than 0: %s", args[0])
+		}
+		resolver.breakerCooldown = breakerCooldown
+	case maxAnswerRecordsField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		maxAnswerRecords, err := strconv.Atoi(args[0])
+		if err != nil {
+			return c.Errf("value of maxAnswerRecords should be an integer: %s", args[0])
+		}
+		if maxAnswerRecords <= 0 {
+			return c.Errf("value of maxAnswerRecords should be greater than 0: %s", args[0])
+		}
+		resolver.maxAnswerRecords = maxAnswerRecords
+	case maxTrackedNamesField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		maxTrackedNames, err := strconv.Atoi(args[0])
+		if err != nil {
+			return c.Errf("value of maxTrackedNames should be an integer: %s", args[0])
+		}
+		if maxTrackedNames <= 0 {
+			return c.Errf("value of maxTrackedNames should be greater than 0: %s", args[0])
+		}
+		resolver.maxTrackedNames = maxTrackedNames
+	case maxCNAMEDepthField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		maxCNAMEDepth, err := strconv.Atoi(args[0])
+		if err != nil {
+			return c.Errf("value of maxCNAMEDepth should be an integer: %s", args[0])
+		}
+		if maxCNAMEDepth <= 0 {
+			return c.Errf("value of maxCNAMEDepth should be greater than 0: %s", args[0])
+		}
+		resolver.maxCNAMEDepth = maxCNAMEDepth
+	case allowedCIDRsField:
+		args := c.RemainingArgs()
+		if len(args) == 0 {
+			return c.ArgErr()
+		}
+		for _, a := range args {
+			prefix, err := parseCIDR(a)
+			if err != nil {
+				return c.Errf("value of allowedCIDRs should be a valid CIDR: %s", a)
+			}
+			resolver.allowedCIDRs = append(resolver.allowedCIDRs, prefix)
+		}
+	case excludeServiceCIDRField:
+		for _, a := range c.RemainingArgs() {
+			prefix, err := parseCIDR(a)
+			if err != nil {
+				return c.Errf("value of excludeServiceCIDR should be a valid CIDR: %s", a)
+			}
+			resolver.serviceCIDRs = append(resolver.serviceCIDRs, prefix)
+		}
+		resolver.excludeServiceCIDR = true
+	case allowedClientCIDRsField:
+		args := c.RemainingArgs()
+		if len(args) == 0 {
+			return c.ArgErr()
+		}
+		for _, a := range args {
+			prefix, err := parseCIDR(a)
+			if err != nil {
+				return c.Errf("value of allowedClientCIDRs should be a valid CIDR: %s", a)
+			}
+			resolver.allowedClientCIDRs = append(resolver.allowedClientCIDRs, prefix)
+		}
+	case listPageSizeField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		listPageSize, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return c.Errf("value of listPageSize should be an integer: %s", args[0])
+		}
+		if listPageSize <= 0 {
+			return c.Errf("value of listPageSize should be greater than 0: %s", args[0])
+		}
+		resolver.listPageSize = listPageSize
+	case shutdownTimeoutField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		shutdownTimeout, err := time.ParseDuration(args[0])
+		if err != nil {
+			return c.Errf("value of shutdownTimeout should be a duration: %s", args[0])
+		}
+		if shutdownTimeout <= 0 {
+			return c.Errf("value of shutdownTimeout should be greater than 0: %s", args[0])
+		}
+		resolver.shutdownTimeout = shutdownTimeout
+	case overlapPolicyField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		policy, ok := parseOverlapPolicy(args[0])
+		if !ok {
+			return c.Errf("value of overlapPolicy should be one of %s, %s or %s: %s",
+				overlapPolicyExactOnly, overlapPolicyBoth, overlapPolicyWildcardFirst, args[0])
+		}
+		resolver.overlapPolicy = policy
+	case truncatedPolicyField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		policy, ok := parseTruncatedPolicy(args[0])
+		if !ok {
+			return c.Errf("value of truncatedPolicy should be one of %s or %s: %s",
+				truncatedPolicySkip, truncatedPolicyRecord, args[0])
+		}
+		resolver.truncatedPolicy = policy
+	case partialChainPolicyField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		policy, ok := parsePartialChainPolicy(args[0])
+		if !ok {
+			return c.Errf("value of partialChainPolicy should be one of %s or %s: %s",
+				partialChainPolicyFail, partialChainPolicyRecordPartial, args[0])
+		}
+		resolver.partialChainPolicy = policy
+	case fanOutOrderField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		order, ok := parseFanOutOrder(args[0])
+		if !ok {
+			return c.Errf("value of fanOutOrder should be one of %s or %s: %s",
+				fanOutOrderParallel, fanOutOrderSorted, args[0])
+		}
+		resolver.fanOutOrder = order
+	case conflictResolutionField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		resolution, ok := parseConflictResolution(args[0])
+		if !ok {
+			return c.Errf("value of conflictResolution should be one of %s or %s: %s",
+				conflictResolutionFirst, conflictResolutionOldest, args[0])
+		}
+		resolver.conflictResolution = resolution
+	case addressOrderField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		order, ok := parseAddressOrder(args[0])
+		if !ok {
+			return c.Errf("value of addressOrder should be one of %s, %s or %s: %s",
+				addressOrderNone, addressOrderV4First, addressOrderV6First, args[0])
+		}
+		resolver.addressOrder = order
+	case wildcardNamespaceScopeField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		scope, ok := parseWildcardNamespaceScope(args[0])
+		if !ok {
+			return c.Errf("value of wildcardNamespaceScope should be one of %s or %s: %s",
+				wildcardNamespaceScopeAll, wildcardNamespaceScopeFirst, args[0])
+		}
+		resolver.wildcardNamespaceScope = scope
+	case wildcardSpecificityField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		specificity, ok := parseWildcardSpecificity(args[0])
+		if !ok {
+			return c.Errf("value of wildcardSpecificity should be one of %s or %s: %s",
+				wildcardSpecificityMostSpecific, wildcardSpecificityAll, args[0])
+		}
+		resolver.wildcardSpecificity = specificity
+	case multiNamespaceField:
+		args := c.RemainingArgs()
+		if len(args) == 0 {
+			return c.ArgErr()
+		}
+		policy, ok := parseMultiNamespacePolicy(args[0])
+		if !ok {
+			return c.Errf("value of multiNamespace should be one of %s or %s: %s",
+				multiNamespacePolicyAll, multiNamespacePolicyPriority, args[0])
+		}
+		if policy != multiNamespacePolicyPriority && len(args) > 1 {
+			return c.Errf("namespace preference list of multiNamespace is only valid with %s: %v", multiNamespacePolicyPriority, args[1:])
+		}
+		resolver.multiNamespacePolicy = policy
+		if len(args) > 1 {
+			resolver.namespacePriority = args[1:]
+		}
+	case instanceIDField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		resolver.instanceID = args[0]
+	case debugAddressField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		if _, _, err := net.SplitHostPort(args[0]); err != nil {
+			return c.Errf("value of debugAddress should be a host:port address: %s", args[0])
+		}
+		resolver.debugAddress = args[0]
+	case webhookURLField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		webhookURL, err := url.Parse(args[0])
+		if err != nil || (webhookURL.Scheme != "http" && webhookURL.Scheme != "https") || webhookURL.Host == "" {
+			return c.Errf("value of webhookURL should be an http or https URL: %s", args[0])
+		}
+		resolver.webhookURL = args[0]
+	case mirrorConfigMapField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		namespace, name, found := strings.Cut(args[0], "/")
+		if !found || len(validation.IsDNS1123Label(namespace)) != 0 || len(validation.IsDNS1123Subdomain(name)) != 0 {
+			return c.Errf("value of mirrorConfigMap should be a namespace and a name of a ConfigMap separated by a slash: %s", args[0])
+		}
+		resolver.mirrorConfigMap = types.NamespacedName{Namespace: namespace, Name: name}
+	case maintenanceSentinelField:
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return c.ArgErr()
+		}
+		namespace, name, found := strings.Cut(args[0], "/")
+		if !found || len(validation.IsDNS1123Label(namespace)) != 0 || len(validation.IsDNS1123Subdomain(name)) != 0 {
+			return c.Errf("value of maintenanceSentinel should be a namespace and a name of a DNSNameResolver object separated by a slash: %s", args[0])
+		}
+		resolver.maintenanceSentinel = types.NamespacedName{Namespace: namespace, Name: name}
+	case validateOnlyField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.validateOnly = true
+	case recordSRVField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.recordSRV = true
+	case preserveCaseField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.preserveCase = true
+	case prefetchOnStartField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.prefetchOnStart = true
+	case auditLogField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.auditLog = true
+	case recordNegativeField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.recordNegative = true
+	case regexMatchField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.regexMatch = true
+	case perNamespaceMetricsField:
+		args := c.RemainingArgs()
+		if len(args) > 1 {
+			return c.ArgErr()
+		}
+		resolver.perNamespaceMetrics = true
+		if len(args) == 1 {
+			maxNamespaceLabels, err := strconv.Atoi(args[0])
+			if err != nil {
+				return c.Errf("value of perNamespaceMetrics should be an integer: %s", args[0])
+			}
+			if maxNamespaceLabels <= 0 {
+				return c.Errf("value of perNamespaceMetrics should be greater than 0: %s", args[0])
+			}
+			resolver.maxNamespaceLabels = maxNamespaceLabels
+		}
+	case retryForbiddenField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.retryForbidden = true
+	case failClosedField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.failClosed = true
+	case rejectApexWildcardField:
+		args := c.RemainingArgs()
+		if len(args) > 1 {
+			return c.ArgErr()
+		}
+		resolver.rejectApexWildcard = true
+		if len(args) == 1 {
+			reject, err := strconv.ParseBool(args[0])
+			if err != nil {
+				return c.Errf("value of rejectApexWildcard should be a boolean: %s", args[0])
+			}
+			resolver.rejectApexWildcard = reject
+		}
+	case strictQNameMatchField:
+		args := c.RemainingArgs()
+		if len(args) > 1 {
+			return c.ArgErr()
+		}
+		resolver.strictQNameMatch = true
+		if len(args) == 1 {
+			strict, err := strconv.ParseBool(args[0])
+			if err != nil {
+				return c.Errf("value of strictQNameMatch should be a boolean: %s", args[0])
+			}
+			resolver.strictQNameMatch = strict
+		}
+	case signalDumpField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.signalDump = true
+	case recordUpstreamField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.recordUpstream = true
+	case recordWildcardChildrenField:
+		args := c.RemainingArgs()
+		if len(args) > 1 {
+			return c.ArgErr()
+		}
+		resolver.recordWildcardChildren = true
+		if len(args) == 1 {
+			maxWildcardChildren, err := strconv.Atoi(args[0])
+			if err != nil {
+				return c.Errf("value of recordWildcardChildren should be an integer: %s", args[0])
+			}
+			if maxWildcardChildren <= 0 {
+				return c.Errf("value of recordWildcardChildren should be greater than 0: %s", args[0])
+			}
+			resolver.maxWildcardChildren = maxWildcardChildren
+		}
+	case recordPTRField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.recordPTR = true
+	case lazyStartField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.lazyStart = true
+	case includeAdditionalField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.includeAdditional = true
+	case doubleCheckField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.doubleCheck = true
+	case pruneDeletedNamespacesField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.pruneDeletedNamespaces = true
+	case recordObservedGenerationField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.recordObservedGeneration = true
+	case rejectInternalWildcardsField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.rejectInternalWildcards = true
+	case recordExtendedErrorsField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.recordExtendedErrors = true
+	case exemplarsField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.exemplars = true
+	case serveStaleOnTimeoutField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.serveStaleOnTimeout = true
+	case singleflightField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.singleflight = true
+	case useFinalizerField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.useFinalizer = true
+	case liveGetField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.liveGet = true
+	case recordFingerprintField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.recordFingerprint = true
+	case refusedIsBlockField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.refusedIsBlock = true
+	case rejectCDBitField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.rejectCDBit = true
+	case diagnosticsField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.diagnostics = true
+	case clampTTLField:
+		if len(c.RemainingArgs()) != 0 {
+			return c.ArgErr()
+		}
+		resolver.clampTTL = true
+	default:
+		// Consume the arguments of the unknown property so that parsing
+		// can continue with the next property.
+		property := c.Val()
+		c.RemainingArgs()
+		return c.Errf("unknown property %q", property)
+	}
+	return nil
+}
+
+// parseTTLValue parses the value of the TTL option of the given field, which is either a
+// bare integer of seconds or a duration of whole seconds, eg. 5m.
+func parseTTLValue(c *caddy.Controller, field, value string) (int32, error) {
+	ttl, err := strconv.Atoi(value)
+	if err != nil {
+		duration, durationErr := time.ParseDuration(value)
+		if durationErr != nil {
+			return 0, c.Errf("value of %s should be an integer of seconds or a duration: %s", field, value)
+		}
+		if duration%time.Second != 0 {
+			return 0, c.Errf("value of %s should be a whole number of seconds: %s", field, value)
+		}
+		ttl = int(duration / time.Second)
+	}
+	if ttl <= 0 {
+		return 0, c.Errf("value of %s should be greater than 0: %s", field, value)
+	}
+	if ttl > math.MaxInt32 {
+		return 0, c.Errf("value of %s should be at most %d seconds: %s", field, math.MaxInt32, value)
+	}
+	return int32(ttl), nil
+}
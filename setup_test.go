@@ -1,9 +1,18 @@
 package ocp_dnsnameresolver
 
 import (
+	"net/netip"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/coredns/caddy"
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/miekg/dns"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 func TestSetup(t *testing.T) {
@@ -33,6 +42,18 @@ func TestSetup(t *testing.T) {
 		{`ocp_dnsnameresolver {
 			minTTL 10
 		}`, false, 0, 10, defaultFailureThreshold},
+		{`ocp_dnsnameresolver {
+			minTTL 5
+		}`, false, 0, 5, defaultFailureThreshold},
+		{`ocp_dnsnameresolver {
+			minTTL 5s
+		}`, false, 0, 5, defaultFailureThreshold},
+		{`ocp_dnsnameresolver {
+			minTTL 5m
+		}`, false, 0, 300, defaultFailureThreshold},
+		{`ocp_dnsnameresolver {
+			minTTL 1h30m
+		}`, false, 0, 5400, defaultFailureThreshold},
 		// failureThreshold
 		{`ocp_dnsnameresolver {
 			failureThreshold 1
@@ -50,6 +71,24 @@ func TestSetup(t *testing.T) {
 		{`ocp_dnsnameresolver {
 			minTTL 0
 		}`, true, 0, defaultMinTTL, defaultFailureThreshold},
+		{`ocp_dnsnameresolver {
+			minTTL 0s
+		}`, true, 0, defaultMinTTL, defaultFailureThreshold},
+		{`ocp_dnsnameresolver {
+			minTTL 500ms
+		}`, true, 0, defaultMinTTL, defaultFailureThreshold},
+		{`ocp_dnsnameresolver {
+			minTTL 1500ms
+		}`, true, 0, defaultMinTTL, defaultFailureThreshold},
+		{`ocp_dnsnameresolver {
+			minTTL -5s
+		}`, true, 0, defaultMinTTL, defaultFailureThreshold},
+		{`ocp_dnsnameresolver {
+			minTTL 5x
+		}`, true, 0, defaultMinTTL, defaultFailureThreshold},
+		{`ocp_dnsnameresolver {
+			minTTL 3000000000
+		}`, true, 0, defaultMinTTL, defaultFailureThreshold},
 		{`ocp_dnsnameresolver {
 			failureThreshold
 		}`, true, 0, defaultMinTTL, defaultFailureThreshold},
@@ -93,3 +132,2756 @@ func TestSetup(t *testing.T) {
 		}
 	}
 }
+
+func TestSetupOverlapPolicy(t *testing.T) {
+	tests := []struct {
+		input          string
+		shouldErr      bool
+		expectedPolicy overlapPolicy
+	}{
+		{`ocp_dnsnameresolver`, false, overlapPolicyBoth},
+		{`ocp_dnsnameresolver {
+			overlapPolicy both
+		}`, false, overlapPolicyBoth},
+		{`ocp_dnsnameresolver {
+			overlapPolicy exact-only
+		}`, false, overlapPolicyExactOnly},
+		{`ocp_dnsnameresolver {
+			overlapPolicy wildcard-first
+		}`, false, overlapPolicyWildcardFirst},
+		// fails
+		{`ocp_dnsnameresolver {
+			overlapPolicy
+		}`, true, overlapPolicyBoth},
+		{`ocp_dnsnameresolver {
+			overlapPolicy none
+		}`, true, overlapPolicyBoth},
+		{`ocp_dnsnameresolver {
+			overlapPolicy both exact-only
+		}`, true, overlapPolicyBoth},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.overlapPolicy != test.expectedPolicy {
+			t.Errorf("Test %d: Expected overlapPolicy '%s'. Instead found overlapPolicy '%s' for input '%s'", i, test.expectedPolicy, resolver.overlapPolicy, test.input)
+		}
+	}
+}
+
+func TestSetupAggregatedErrors(t *testing.T) {
+	input := `ocp_dnsnameresolver {
+		namespaces
+		minTTL 0
+		failureThreshold foo
+		overlapPolicy none
+		unknownOption bar
+	}`
+	c := caddy.NewTestController("dns", input)
+	_, err := resolverParse(c)
+	if err == nil {
+		t.Fatalf("Expected error, but did not find error for input '%s'", input)
+	}
+
+	expectedErrs := []string{
+		"Wrong argument count",
+		"value of minTTL should be greater than 0",
+		"value of failureThreshold should be an integer",
+		"value of overlapPolicy should be one of",
+		`unknown property "unknownOption"`,
+	}
+	for _, expectedErr := range expectedErrs {
+		if !strings.Contains(err.Error(), expectedErr) {
+			t.Errorf("Expected error to contain '%s'. Error was: '%v'", expectedErr, err)
+		}
+	}
+}
+
+func TestSetupValidateOnly(t *testing.T) {
+	var exitCodes []int
+	exit = func(code int) {
+		exitCodes = append(exitCodes, code)
+		// Stop the startup of the instance, as the process would.
+		runtime.Goexit()
+	}
+	defer func() { exit = os.Exit }()
+	dnsserver.Directives = append(dnsserver.Directives, pluginName)
+	defer func() { dnsserver.Directives = dnsserver.Directives[:len(dnsserver.Directives)-1] }()
+
+	start := func(corefile string) error {
+		var err error
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, err = caddy.Start(caddy.CaddyfileInput{Contents: []byte(corefile), ServerTypeName: "dns"})
+		}()
+		<-done
+		return err
+	}
+
+	// Validation of a valid configuration should exit with status 0 before the servers
+	// are started.
+	if err := start(`.:0 {
+		ocp_dnsnameresolver {
+			validateOnly
+			minTTL 10
+		}
+	}`); err != nil {
+		t.Fatalf("Expected the configuration to be valid, found error: %v", err)
+	}
+	if len(exitCodes) != 1 || exitCodes[0] != 0 {
+		t.Fatalf("Expected an exit with status 0, found exits %v", exitCodes)
+	}
+
+	// Validation of an invalid configuration should fail the startup without exiting.
+	exitCodes = nil
+	if err := start(`.:0 {
+		ocp_dnsnameresolver {
+			validateOnly
+			minTTL 0
+		}
+	}`); err == nil {
+		t.Fatalf("Expected a configuration error, found none")
+	}
+	if len(exitCodes) != 0 {
+		t.Fatalf("Expected no exit, found exits %v", exitCodes)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(caddy.NewTestController("dns", `ocp_dnsnameresolver {
+		minTTL 10
+	}`)); err != nil {
+		t.Fatalf("Expected no error but found one. Error was: %v", err)
+	}
+	err := Validate(caddy.NewTestController("dns", `ocp_dnsnameresolver {
+		minTTL 0
+		failureThreshold -1
+	}`))
+	if err == nil {
+		t.Fatalf("Expected error, but did not find error")
+	}
+	for _, expectedErr := range []string{minTTLField, failureThresholdField} {
+		if !strings.Contains(err.Error(), expectedErr) {
+			t.Errorf("Expected error to contain '%s'. Error was: '%v'", expectedErr, err)
+		}
+	}
+}
+
+func TestSetupToggles(t *testing.T) {
+	tests := []struct {
+		input     string
+		shouldErr bool
+		enabled   func(*OCPDNSNameResolver) bool
+	}{
+		{`ocp_dnsnameresolver`, false, func(r *OCPDNSNameResolver) bool {
+			return !r.validateOnly && !r.recordSRV && !r.preserveCase && !r.prefetchOnStart && !r.auditLog && !r.recordNegative && !r.regexMatch && !r.retryForbidden && !r.failClosed && !r.signalDump && !r.recordUpstream && !r.recordPTR && !r.lazyStart && !r.includeAdditional && !r.doubleCheck && !r.pruneDeletedNamespaces && !r.recordObservedGeneration && !r.rejectInternalWildcards && !r.recordExtendedErrors && !r.exemplars && !r.serveStaleOnTimeout && !r.singleflight && !r.useFinalizer && !r.liveGet && !r.recordFingerprint && !r.refusedIsBlock && !r.rejectCDBit && !r.diagnostics && !r.clampTTL
+		}},
+		{`ocp_dnsnameresolver {
+			validateOnly
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.validateOnly }},
+		{`ocp_dnsnameresolver {
+			recordSRV
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.recordSRV }},
+		{`ocp_dnsnameresolver {
+			preserveCase
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.preserveCase }},
+		{`ocp_dnsnameresolver {
+			prefetchOnStart
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.prefetchOnStart }},
+		{`ocp_dnsnameresolver {
+			auditLog
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.auditLog }},
+		{`ocp_dnsnameresolver {
+			recordNegative
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.recordNegative }},
+		{`ocp_dnsnameresolver {
+			regexMatch
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.regexMatch }},
+		{`ocp_dnsnameresolver {
+			retryForbidden
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.retryForbidden }},
+		{`ocp_dnsnameresolver {
+			failClosed
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.failClosed }},
+		{`ocp_dnsnameresolver {
+			signalDump
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.signalDump }},
+		{`ocp_dnsnameresolver {
+			recordUpstream
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.recordUpstream }},
+		{`ocp_dnsnameresolver {
+			recordPTR
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.recordPTR }},
+		{`ocp_dnsnameresolver {
+			lazyStart
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.lazyStart }},
+		{`ocp_dnsnameresolver {
+			includeAdditional
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.includeAdditional }},
+		{`ocp_dnsnameresolver {
+			doubleCheck
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.doubleCheck }},
+		{`ocp_dnsnameresolver {
+			pruneDeletedNamespaces
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.pruneDeletedNamespaces }},
+		{`ocp_dnsnameresolver {
+			recordObservedGeneration
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.recordObservedGeneration }},
+		{`ocp_dnsnameresolver {
+			rejectInternalWildcards
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.rejectInternalWildcards }},
+		{`ocp_dnsnameresolver {
+			recordExtendedErrors
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.recordExtendedErrors }},
+		{`ocp_dnsnameresolver {
+			exemplars
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.exemplars }},
+		{`ocp_dnsnameresolver {
+			serveStaleOnTimeout
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.serveStaleOnTimeout }},
+		{`ocp_dnsnameresolver {
+			singleflight
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.singleflight }},
+		{`ocp_dnsnameresolver {
+			useFinalizer
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.useFinalizer }},
+		{`ocp_dnsnameresolver {
+			liveGet
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.liveGet }},
+		{`ocp_dnsnameresolver {
+			recordFingerprint
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.recordFingerprint }},
+		{`ocp_dnsnameresolver {
+			refusedIsBlock
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.refusedIsBlock }},
+		{`ocp_dnsnameresolver {
+			rejectCDBit
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.rejectCDBit }},
+		{`ocp_dnsnameresolver {
+			diagnostics
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.diagnostics }},
+		{`ocp_dnsnameresolver {
+			clampTTL
+		}`, false, func(r *OCPDNSNameResolver) bool { return r.clampTTL }},
+		// fails
+		{`ocp_dnsnameresolver {
+			validateOnly true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			recordSRV true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			preserveCase true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			prefetchOnStart true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			auditLog true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			recordNegative true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			regexMatch true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			retryForbidden true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			failClosed true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			signalDump true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			recordUpstream true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			recordPTR true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			lazyStart true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			includeAdditional true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			doubleCheck true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			pruneDeletedNamespaces true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			recordObservedGeneration true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			rejectInternalWildcards true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			recordExtendedErrors true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			exemplars true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			serveStaleOnTimeout true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			singleflight true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			useFinalizer true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			liveGet true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			recordFingerprint true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			refusedIsBlock true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			rejectCDBit true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			diagnostics true
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			clampTTL true
+		}`, true, nil},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if !test.enabled(resolver) {
+			t.Errorf("Test %d: Expected toggles were not set for input '%s'", i, test.input)
+		}
+	}
+}
+
+func TestSetupFamilyFailureThresholds(t *testing.T) {
+	tests := []struct {
+		input              string
+		shouldErr          bool
+		expectedThreshold4 int32
+		expectedThreshold6 int32
+	}{
+		{`ocp_dnsnameresolver`, false, 0, 0},
+		{`ocp_dnsnameresolver {
+			failureThresholdV4 3
+		}`, false, 3, 0},
+		{`ocp_dnsnameresolver {
+			failureThresholdV4 3
+			failureThresholdV6 2
+		}`, false, 3, 2},
+		// fails
+		{`ocp_dnsnameresolver {
+			failureThresholdV6
+		}`, true, 0, 0},
+		{`ocp_dnsnameresolver {
+			failureThresholdV6 two
+		}`, true, 0, 0},
+		{`ocp_dnsnameresolver {
+			failureThresholdV4 0
+		}`, true, 0, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.failureThresholdV4 != test.expectedThreshold4 || resolver.failureThresholdV6 != test.expectedThreshold6 {
+			t.Errorf("Test %d: Expected failureThresholdV4 %d and failureThresholdV6 %d. Instead found %d and %d for input '%s'",
+				i, test.expectedThreshold4, test.expectedThreshold6, resolver.failureThresholdV4, resolver.failureThresholdV6, test.input)
+		}
+	}
+}
+
+func TestSetupZoneTTL(t *testing.T) {
+	tests := []struct {
+		input            string
+		shouldErr        bool
+		expectedZoneTTLs map[string]zoneTTLPolicy
+	}{
+		{`ocp_dnsnameresolver`, false, nil},
+		{`ocp_dnsnameresolver {
+			zoneTTL example.com 10 60
+		}`, false, map[string]zoneTTLPolicy{"example.com.": {minTTL: 10, maxTTL: 60}}},
+		{`ocp_dnsnameresolver {
+			zoneTTL Example.COM 10 60
+			zoneTTL api.example.com. 1 5
+		}`, false, map[string]zoneTTLPolicy{"example.com.": {minTTL: 10, maxTTL: 60}, "api.example.com.": {minTTL: 1, maxTTL: 5}}},
+		{`ocp_dnsnameresolver {
+			zoneTTL example.com 30 30
+		}`, false, map[string]zoneTTLPolicy{"example.com.": {minTTL: 30, maxTTL: 30}}},
+		// fails
+		{`ocp_dnsnameresolver {
+			zoneTTL example.com 10
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			zoneTTL example.com 0 60
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			zoneTTL example.com 60 10
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			zoneTTL example.com foo 60
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			zoneTTL example.com 10 foo
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			zoneTTL example.com 10 2147483648
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			zoneTTL example.com 10 60
+			zoneTTL example.com. 1 5
+		}`, true, nil},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(resolver.zoneTTLs, test.expectedZoneTTLs) {
+			t.Errorf("Test %d: Expected zoneTTLs '%v'. Instead found zoneTTLs '%v' for input '%s'", i, test.expectedZoneTTLs, resolver.zoneTTLs, test.input)
+		}
+	}
+}
+
+func TestSetupTTLJitter(t *testing.T) {
+	tests := []struct {
+		input             string
+		shouldErr         bool
+		expectedTTLJitter int32
+	}{
+		{`ocp_dnsnameresolver`, false, 0},
+		{`ocp_dnsnameresolver {
+			ttlJitter 0
+		}`, false, 0},
+		{`ocp_dnsnameresolver {
+			ttlJitter 3
+		}`, false, 3},
+		// fails
+		{`ocp_dnsnameresolver {
+			ttlJitter
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			ttlJitter -1
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			ttlJitter foo
+		}`, true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.ttlJitter != test.expectedTTLJitter {
+			t.Errorf("Test %d: Expected ttlJitter '%d'. Instead found ttlJitter '%d' for input '%s'", i, test.expectedTTLJitter, resolver.ttlJitter, test.input)
+		}
+	}
+}
+
+func TestName(t *testing.T) {
+	resolver := New()
+	if resolver.Name() != pluginName {
+		t.Fatalf("Expected Name() to return '%s', found '%s'", pluginName, resolver.Name())
+	}
+
+	// The name should match the directive with which the plugin is registered.
+	registered := false
+	for _, name := range caddy.ListPlugins()["others"] {
+		if name == "dns."+resolver.Name() {
+			registered = true
+			break
+		}
+	}
+	if !registered {
+		t.Fatalf("Expected plugin to be registered with directive '%s'", resolver.Name())
+	}
+}
+
+func TestSetupTruncatedPolicy(t *testing.T) {
+	tests := []struct {
+		input          string
+		shouldErr      bool
+		expectedPolicy truncatedPolicy
+	}{
+		{`ocp_dnsnameresolver`, false, truncatedPolicySkip},
+		{`ocp_dnsnameresolver {
+			truncatedPolicy skip
+		}`, false, truncatedPolicySkip},
+		{`ocp_dnsnameresolver {
+			truncatedPolicy record
+		}`, false, truncatedPolicyRecord},
+		// fails
+		{`ocp_dnsnameresolver {
+			truncatedPolicy
+		}`, true, truncatedPolicySkip},
+		{`ocp_dnsnameresolver {
+			truncatedPolicy retry
+		}`, true, truncatedPolicySkip},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.truncatedPolicy != test.expectedPolicy {
+			t.Errorf("Test %d: Expected truncatedPolicy '%s'. Instead found truncatedPolicy '%s' for input '%s'", i, test.expectedPolicy, resolver.truncatedPolicy, test.input)
+		}
+	}
+}
+
+func TestSetupFanOutOrder(t *testing.T) {
+	tests := []struct {
+		input         string
+		shouldErr     bool
+		expectedOrder fanOutOrder
+	}{
+		{`ocp_dnsnameresolver`, false, fanOutOrderParallel},
+		{`ocp_dnsnameresolver {
+			fanOutOrder parallel
+		}`, false, fanOutOrderParallel},
+		{`ocp_dnsnameresolver {
+			fanOutOrder sorted
+		}`, false, fanOutOrderSorted},
+		// fails
+		{`ocp_dnsnameresolver {
+			fanOutOrder
+		}`, true, fanOutOrderParallel},
+		{`ocp_dnsnameresolver {
+			fanOutOrder random
+		}`, true, fanOutOrderParallel},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.fanOutOrder != test.expectedOrder {
+			t.Errorf("Test %d: Expected fanOutOrder '%s'. Instead found fanOutOrder '%s' for input '%s'", i, test.expectedOrder, resolver.fanOutOrder, test.input)
+		}
+	}
+}
+
+func TestSetupConflictResolution(t *testing.T) {
+	tests := []struct {
+		input              string
+		shouldErr          bool
+		expectedResolution conflictResolution
+	}{
+		{`ocp_dnsnameresolver`, false, conflictResolutionFirst},
+		{`ocp_dnsnameresolver {
+			conflictResolution first
+		}`, false, conflictResolutionFirst},
+		{`ocp_dnsnameresolver {
+			conflictResolution oldest
+		}`, false, conflictResolutionOldest},
+		// fails
+		{`ocp_dnsnameresolver {
+			conflictResolution
+		}`, true, conflictResolutionFirst},
+		{`ocp_dnsnameresolver {
+			conflictResolution newest
+		}`, true, conflictResolutionFirst},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.conflictResolution != test.expectedResolution {
+			t.Errorf("Test %d: Expected conflictResolution '%s'. Instead found conflictResolution '%s' for input '%s'", i, test.expectedResolution, resolver.conflictResolution, test.input)
+		}
+	}
+}
+
+func TestSetupPartialChainPolicy(t *testing.T) {
+	tests := []struct {
+		input          string
+		shouldErr      bool
+		expectedPolicy partialChainPolicy
+	}{
+		{`ocp_dnsnameresolver`, false, partialChainPolicyFail},
+		{`ocp_dnsnameresolver {
+			partialChainPolicy fail
+		}`, false, partialChainPolicyFail},
+		{`ocp_dnsnameresolver {
+			partialChainPolicy record-partial
+		}`, false, partialChainPolicyRecordPartial},
+		// fails
+		{`ocp_dnsnameresolver {
+			partialChainPolicy
+		}`, true, partialChainPolicyFail},
+		{`ocp_dnsnameresolver {
+			partialChainPolicy record
+		}`, true, partialChainPolicyFail},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.partialChainPolicy != test.expectedPolicy {
+			t.Errorf("Test %d: Expected partialChainPolicy '%s'. Instead found partialChainPolicy '%s' for input '%s'", i, test.expectedPolicy, resolver.partialChainPolicy, test.input)
+		}
+	}
+}
+
+func TestSetupAddressOrder(t *testing.T) {
+	tests := []struct {
+		input         string
+		shouldErr     bool
+		expectedOrder addressOrder
+	}{
+		{`ocp_dnsnameresolver`, false, addressOrderNone},
+		{`ocp_dnsnameresolver {
+			addressOrder none
+		}`, false, addressOrderNone},
+		{`ocp_dnsnameresolver {
+			addressOrder v4first
+		}`, false, addressOrderV4First},
+		{`ocp_dnsnameresolver {
+			addressOrder v6first
+		}`, false, addressOrderV6First},
+		// fails
+		{`ocp_dnsnameresolver {
+			addressOrder
+		}`, true, addressOrderNone},
+		{`ocp_dnsnameresolver {
+			addressOrder v4
+		}`, true, addressOrderNone},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.addressOrder != test.expectedOrder {
+			t.Errorf("Test %d: Expected addressOrder '%s'. Instead found addressOrder '%s' for input '%s'", i, test.expectedOrder, resolver.addressOrder, test.input)
+		}
+	}
+}
+
+func TestSetupRejectApexWildcard(t *testing.T) {
+	tests := []struct {
+		input                      string
+		shouldErr                  bool
+		expectedRejectApexWildcard bool
+	}{
+		{`ocp_dnsnameresolver`, false, true},
+		{`ocp_dnsnameresolver {
+			rejectApexWildcard
+		}`, false, true},
+		{`ocp_dnsnameresolver {
+			rejectApexWildcard true
+		}`, false, true},
+		{`ocp_dnsnameresolver {
+			rejectApexWildcard false
+		}`, false, false},
+		// fails
+		{`ocp_dnsnameresolver {
+			rejectApexWildcard no
+		}`, true, false},
+		{`ocp_dnsnameresolver {
+			rejectApexWildcard true false
+		}`, true, false},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.rejectApexWildcard != test.expectedRejectApexWildcard {
+			t.Errorf("Test %d: Expected rejectApexWildcard '%t'. Instead found rejectApexWildcard '%t' for input '%s'", i, test.expectedRejectApexWildcard, resolver.rejectApexWildcard, test.input)
+		}
+	}
+}
+
+func TestSetupStrictQNameMatch(t *testing.T) {
+	tests := []struct {
+		input                    string
+		shouldErr                bool
+		expectedStrictQNameMatch bool
+	}{
+		{`ocp_dnsnameresolver`, false, true},
+		{`ocp_dnsnameresolver {
+			strictQNameMatch
+		}`, false, true},
+		{`ocp_dnsnameresolver {
+			strictQNameMatch true
+		}`, false, true},
+		{`ocp_dnsnameresolver {
+			strictQNameMatch false
+		}`, false, false},
+		// fails
+		{`ocp_dnsnameresolver {
+			strictQNameMatch no
+		}`, true, false},
+		{`ocp_dnsnameresolver {
+			strictQNameMatch true false
+		}`, true, false},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.strictQNameMatch != test.expectedStrictQNameMatch {
+			t.Errorf("Test %d: Expected strictQNameMatch '%t'. Instead found strictQNameMatch '%t' for input '%s'", i, test.expectedStrictQNameMatch, resolver.strictQNameMatch, test.input)
+		}
+	}
+}
+
+func TestSetupRecordWildcardChildren(t *testing.T) {
+	tests := []struct {
+		input                          string
+		shouldErr                      bool
+		expectedRecordWildcardChildren bool
+		expectedMaxWildcardChildren    int
+	}{
+		{`ocp_dnsnameresolver`, false, false, defaultMaxWildcardChildren},
+		{`ocp_dnsnameresolver {
+			recordWildcardChildren
+		}`, false, true, defaultMaxWildcardChildren},
+		{`ocp_dnsnameresolver {
+			recordWildcardChildren 10
+		}`, false, true, 10},
+		// fails
+		{`ocp_dnsnameresolver {
+			recordWildcardChildren 0
+		}`, true, false, 0},
+		{`ocp_dnsnameresolver {
+			recordWildcardChildren foo
+		}`, true, false, 0},
+		{`ocp_dnsnameresolver {
+			recordWildcardChildren 10 20
+		}`, true, false, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.recordWildcardChildren != test.expectedRecordWildcardChildren {
+			t.Errorf("Test %d: Expected recordWildcardChildren '%t'. Instead found recordWildcardChildren '%t' for input '%s'", i, test.expectedRecordWildcardChildren, resolver.recordWildcardChildren, test.input)
+		}
+		if resolver.maxWildcardChildren != test.expectedMaxWildcardChildren {
+			t.Errorf("Test %d: Expected maximum number of wildcard children '%d'. Instead found '%d' for input '%s'", i, test.expectedMaxWildcardChildren, resolver.maxWildcardChildren, test.input)
+		}
+	}
+}
+
+func TestSetupMinQueries(t *testing.T) {
+	tests := []struct {
+		input                    string
+		shouldErr                bool
+		expectedMinQueries       int
+		expectedMinQueriesWindow time.Duration
+	}{
+		{`ocp_dnsnameresolver`, false, defaultMinQueries, defaultMinQueriesWindow},
+		{`ocp_dnsnameresolver {
+			minQueries 3
+		}`, false, 3, defaultMinQueriesWindow},
+		{`ocp_dnsnameresolver {
+			minQueries 3
+			minQueriesWindow 30s
+		}`, false, 3, 30 * time.Second},
+		// fails
+		{`ocp_dnsnameresolver {
+			minQueries 0
+		}`, true, 0, 0},
+		{`ocp_dnsnameresolver {
+			minQueries foo
+		}`, true, 0, 0},
+		{`ocp_dnsnameresolver {
+			minQueriesWindow 30
+		}`, true, 0, 0},
+		{`ocp_dnsnameresolver {
+			minQueriesWindow -1s
+		}`, true, 0, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.minQueries != test.expectedMinQueries {
+			t.Errorf("Test %d: Expected minQueries '%d'. Instead found minQueries '%d' for input '%s'", i, test.expectedMinQueries, resolver.minQueries, test.input)
+		}
+		if resolver.minQueriesWindow != test.expectedMinQueriesWindow {
+			t.Errorf("Test %d: Expected minQueriesWindow '%s'. Instead found minQueriesWindow '%s' for input '%s'", i, test.expectedMinQueriesWindow, resolver.minQueriesWindow, test.input)
+		}
+	}
+}
+
+func TestSetupLabelSelector(t *testing.T) {
+	tests := []struct {
+		input                  string
+		shouldErr              bool
+		expectedLabelSelector  string
+		expectedFilterOperator filterOperator
+	}{
+		{`ocp_dnsnameresolver`, false, "", filterOperatorAnd},
+		{`ocp_dnsnameresolver {
+			labelSelector team=dns
+		}`, false, "team=dns", filterOperatorAnd},
+		{`ocp_dnsnameresolver {
+			labelSelector team=dns,env in (prod, staging)
+		}`, false, "env in (prod,staging),team=dns", filterOperatorAnd},
+		{`ocp_dnsnameresolver {
+			namespaces foo
+			labelSelector team=dns
+			filterOperator or
+		}`, false, "team=dns", filterOperatorOr},
+		// fails
+		{`ocp_dnsnameresolver {
+			labelSelector
+		}`, true, "", filterOperatorAnd},
+		{`ocp_dnsnameresolver {
+			labelSelector team==dns==
+		}`, true, "", filterOperatorAnd},
+		{`ocp_dnsnameresolver {
+			filterOperator xor
+		}`, true, "", filterOperatorAnd},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		labelSelector := ""
+		if resolver.labelSelector != nil {
+			labelSelector = resolver.labelSelector.String()
+		}
+		if labelSelector != test.expectedLabelSelector {
+			t.Errorf("Test %d: Expected labelSelector '%s'. Instead found labelSelector '%s' for input '%s'", i, test.expectedLabelSelector, labelSelector, test.input)
+		}
+		if resolver.filterOperator != test.expectedFilterOperator {
+			t.Errorf("Test %d: Expected filterOperator '%s'. Instead found filterOperator '%s' for input '%s'", i, test.expectedFilterOperator, resolver.filterOperator, test.input)
+		}
+	}
+}
+
+func TestSetupNamespaceSelector(t *testing.T) {
+	tests := []struct {
+		input                     string
+		shouldErr                 bool
+		expectedNamespaceSelector string
+	}{
+		{`ocp_dnsnameresolver`, false, ""},
+		{`ocp_dnsnameresolver {
+			namespaceSelector dns-tracking=enabled
+		}`, false, "dns-tracking=enabled"},
+		{`ocp_dnsnameresolver {
+			namespaceSelector dns-tracking in (enabled, true)
+		}`, false, "dns-tracking in (enabled,true)"},
+		// fails
+		{`ocp_dnsnameresolver {
+			namespaceSelector
+		}`, true, ""},
+		{`ocp_dnsnameresolver {
+			namespaceSelector dns-tracking==enabled==
+		}`, true, ""},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		namespaceSelector := ""
+		if resolver.namespaceSelector != nil {
+			namespaceSelector = resolver.namespaceSelector.String()
+		}
+		if namespaceSelector != test.expectedNamespaceSelector {
+			t.Errorf("Test %d: Expected namespaceSelector '%s'. Instead found namespaceSelector '%s' for input '%s'", i, test.expectedNamespaceSelector, namespaceSelector, test.input)
+		}
+	}
+}
+
+func TestSetupNamespaceRegex(t *testing.T) {
+	tests := []struct {
+		input                  string
+		shouldErr              bool
+		expectedNamespaceRegex string
+	}{
+		{`ocp_dnsnameresolver`, false, ""},
+		{`ocp_dnsnameresolver {
+			namespaceRegex team-.*
+		}`, false, "^(?:team-.*)$"},
+		{`ocp_dnsnameresolver {
+			namespaceRegex (dev|prod)-[a-z]+
+		}`, false, "^(?:(dev|prod)-[a-z]+)$"},
+		// fails
+		{`ocp_dnsnameresolver {
+			namespaceRegex
+		}`, true, ""},
+		{`ocp_dnsnameresolver {
+			namespaceRegex team-(
+		}`, true, ""},
+		{`ocp_dnsnameresolver {
+			namespaceRegex team-.* dev-.*
+		}`, true, ""},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		namespaceRegex := ""
+		if resolver.namespaceRegex != nil {
+			namespaceRegex = resolver.namespaceRegex.String()
+		}
+		if namespaceRegex != test.expectedNamespaceRegex {
+			t.Errorf("Test %d: Expected namespaceRegex '%s'. Instead found namespaceRegex '%s' for input '%s'", i, test.expectedNamespaceRegex, namespaceRegex, test.input)
+		}
+	}
+}
+
+func TestSetupWildcardNamespaceScope(t *testing.T) {
+	tests := []struct {
+		input         string
+		shouldErr     bool
+		expectedScope wildcardNamespaceScope
+	}{
+		{`ocp_dnsnameresolver`, false, wildcardNamespaceScopeAll},
+		{`ocp_dnsnameresolver {
+			wildcardNamespaceScope all
+		}`, false, wildcardNamespaceScopeAll},
+		{`ocp_dnsnameresolver {
+			wildcardNamespaceScope first
+		}`, false, wildcardNamespaceScopeFirst},
+		// fails
+		{`ocp_dnsnameresolver {
+			wildcardNamespaceScope
+		}`, true, wildcardNamespaceScopeAll},
+		{`ocp_dnsnameresolver {
+			wildcardNamespaceScope last
+		}`, true, wildcardNamespaceScopeAll},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.wildcardNamespaceScope != test.expectedScope {
+			t.Errorf("Test %d: Expected wildcardNamespaceScope '%s'. Instead found wildcardNamespaceScope '%s' for input '%s'", i, test.expectedScope, resolver.wildcardNamespaceScope, test.input)
+		}
+	}
+}
+
+func TestSetupWildcardSpecificity(t *testing.T) {
+	tests := []struct {
+		input               string
+		shouldErr           bool
+		expectedSpecificity wildcardSpecificity
+	}{
+		{`ocp_dnsnameresolver`, false, wildcardSpecificityMostSpecific},
+		{`ocp_dnsnameresolver {
+			wildcardSpecificity most-specific
+		}`, false, wildcardSpecificityMostSpecific},
+		{`ocp_dnsnameresolver {
+			wildcardSpecificity all
+		}`, false, wildcardSpecificityAll},
+		// fails
+		{`ocp_dnsnameresolver {
+			wildcardSpecificity
+		}`, true, wildcardSpecificityMostSpecific},
+		{`ocp_dnsnameresolver {
+			wildcardSpecificity least-specific
+		}`, true, wildcardSpecificityMostSpecific},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.wildcardSpecificity != test.expectedSpecificity {
+			t.Errorf("Test %d: Expected wildcardSpecificity '%s'. Instead found wildcardSpecificity '%s' for input '%s'", i, test.expectedSpecificity, resolver.wildcardSpecificity, test.input)
+		}
+	}
+}
+
+func TestSetupMultiNamespace(t *testing.T) {
+	tests := []struct {
+		input              string
+		shouldErr          bool
+		expectedPolicy     multiNamespacePolicy
+		expectedPreference []string
+	}{
+		{`ocp_dnsnameresolver`, false, multiNamespacePolicyAll, nil},
+		{`ocp_dnsnameresolver {
+			multiNamespace all
+		}`, false, multiNamespacePolicyAll, nil},
+		{`ocp_dnsnameresolver {
+			multiNamespace priority
+		}`, false, multiNamespacePolicyPriority, nil},
+		{`ocp_dnsnameresolver {
+			multiNamespace priority ns2 ns1
+		}`, false, multiNamespacePolicyPriority, []string{"ns2", "ns1"}},
+		// fails
+		{`ocp_dnsnameresolver {
+			multiNamespace
+		}`, true, multiNamespacePolicyAll, nil},
+		{`ocp_dnsnameresolver {
+			multiNamespace first
+		}`, true, multiNamespacePolicyAll, nil},
+		{`ocp_dnsnameresolver {
+			multiNamespace all ns1
+		}`, true, multiNamespacePolicyAll, nil},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.multiNamespacePolicy != test.expectedPolicy {
+			t.Errorf("Test %d: Expected multiNamespace policy '%s'. Instead found multiNamespace policy '%s' for input '%s'", i, test.expectedPolicy, resolver.multiNamespacePolicy, test.input)
+		}
+		if !reflect.DeepEqual(resolver.namespacePriority, test.expectedPreference) {
+			t.Errorf("Test %d: Expected namespace preference '%v'. Instead found namespace preference '%v' for input '%s'", i, test.expectedPreference, resolver.namespacePriority, test.input)
+		}
+	}
+}
+
+func TestSetupMaxAnswerRecords(t *testing.T) {
+	tests := []struct {
+		input                    string
+		shouldErr                bool
+		expectedMaxAnswerRecords int
+	}{
+		{`ocp_dnsnameresolver`, false, 0},
+		{`ocp_dnsnameresolver {
+			maxAnswerRecords 50
+		}`, false, 50},
+		// fails
+		{`ocp_dnsnameresolver {
+			maxAnswerRecords
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			maxAnswerRecords 0
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			maxAnswerRecords foo
+		}`, true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.maxAnswerRecords != test.expectedMaxAnswerRecords {
+			t.Errorf("Test %d: Expected maxAnswerRecords '%d'. Instead found maxAnswerRecords '%d' for input '%s'", i, test.expectedMaxAnswerRecords, resolver.maxAnswerRecords, test.input)
+		}
+	}
+}
+
+func TestSetupMaxTrackedNames(t *testing.T) {
+	tests := []struct {
+		input                   string
+		shouldErr               bool
+		expectedMaxTrackedNames int
+	}{
+		{`ocp_dnsnameresolver`, false, 0},
+		{`ocp_dnsnameresolver {
+			maxTrackedNames 50
+		}`, false, 50},
+		// fails
+		{`ocp_dnsnameresolver {
+			maxTrackedNames
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			maxTrackedNames 0
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			maxTrackedNames foo
+		}`, true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.maxTrackedNames != test.expectedMaxTrackedNames {
+			t.Errorf("Test %d: Expected maxTrackedNames '%d'. Instead found maxTrackedNames '%d' for input '%s'", i, test.expectedMaxTrackedNames, resolver.maxTrackedNames, test.input)
+		}
+	}
+}
+
+func TestSetupMinRemainingTTL(t *testing.T) {
+	tests := []struct {
+		input                   string
+		shouldErr               bool
+		expectedMinRemainingTTL uint32
+	}{
+		{`ocp_dnsnameresolver`, false, 0},
+		{`ocp_dnsnameresolver {
+			minRemainingTTL 50
+		}`, false, 50},
+		// fails
+		{`ocp_dnsnameresolver {
+			minRemainingTTL
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			minRemainingTTL 0
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			minRemainingTTL foo
+		}`, true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.minRemainingTTL != test.expectedMinRemainingTTL {
+			t.Errorf("Test %d: Expected minRemainingTTL '%d'. Instead found minRemainingTTL '%d' for input '%s'", i, test.expectedMinRemainingTTL, resolver.minRemainingTTL, test.input)
+		}
+	}
+}
+
+func TestSetupMaxCNAMEDepth(t *testing.T) {
+	tests := []struct {
+		input                 string
+		shouldErr             bool
+		expectedMaxCNAMEDepth int
+	}{
+		{`ocp_dnsnameresolver`, false, defaultMaxCNAMEDepth},
+		{`ocp_dnsnameresolver {
+			maxCNAMEDepth 50
+		}`, false, 50},
+		// fails
+		{`ocp_dnsnameresolver {
+			maxCNAMEDepth
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			maxCNAMEDepth 0
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			maxCNAMEDepth foo
+		}`, true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.maxCNAMEDepth != test.expectedMaxCNAMEDepth {
+			t.Errorf("Test %d: Expected maxCNAMEDepth '%d'. Instead found maxCNAMEDepth '%d' for input '%s'", i, test.expectedMaxCNAMEDepth, resolver.maxCNAMEDepth, test.input)
+		}
+	}
+}
+
+func TestSetupInternalZones(t *testing.T) {
+	tests := []struct {
+		input                 string
+		shouldErr             bool
+		expectedInternalZones []string
+	}{
+		{`ocp_dnsnameresolver`, false, nil},
+		{`ocp_dnsnameresolver {
+			internalZones cluster.local
+		}`, false, []string{"cluster.local."}},
+		{`ocp_dnsnameresolver {
+			internalZones cluster.local. svc.example.com
+		}`, false, []string{"cluster.local.", "svc.example.com."}},
+		// fails
+		{`ocp_dnsnameresolver {
+			internalZones
+		}`, true, nil},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(resolver.internalZones, test.expectedInternalZones) {
+			t.Errorf("Test %d: Expected internalZones '%v'. Instead found internalZones '%v' for input '%s'", i, test.expectedInternalZones, resolver.internalZones, test.input)
+		}
+	}
+}
+
+func TestSetupExternalZones(t *testing.T) {
+	tests := []struct {
+		input                 string
+		shouldErr             bool
+		expectedExternalZones []string
+	}{
+		{`ocp_dnsnameresolver`, false, nil},
+		{`ocp_dnsnameresolver {
+			externalZones example.com
+		}`, false, []string{"example.com."}},
+		{`ocp_dnsnameresolver {
+			externalZones example.com. example.org
+		}`, false, []string{"example.com.", "example.org."}},
+		// fails
+		{`ocp_dnsnameresolver {
+			externalZones
+		}`, true, nil},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(resolver.externalZones, test.expectedExternalZones) {
+			t.Errorf("Test %d: Expected externalZones '%v'. Instead found externalZones '%v' for input '%s'", i, test.expectedExternalZones, resolver.externalZones, test.input)
+		}
+	}
+}
+
+func TestSetupListPageSize(t *testing.T) {
+	tests := []struct {
+		input                string
+		shouldErr            bool
+		expectedListPageSize int64
+	}{
+		{`ocp_dnsnameresolver`, false, 0},
+		{`ocp_dnsnameresolver {
+			listPageSize 100
+		}`, false, 100},
+		// fails
+		{`ocp_dnsnameresolver {
+			listPageSize
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			listPageSize 0
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			listPageSize foo
+		}`, true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.listPageSize != test.expectedListPageSize {
+			t.Errorf("Test %d: Expected listPageSize '%d'. Instead found listPageSize '%d' for input '%s'", i, test.expectedListPageSize, resolver.listPageSize, test.input)
+		}
+	}
+}
+
+func TestSetupAccumulateWindow(t *testing.T) {
+	tests := []struct {
+		input                    string
+		shouldErr                bool
+		expectedAccumulateWindow time.Duration
+	}{
+		{`ocp_dnsnameresolver`, false, 0},
+		{`ocp_dnsnameresolver {
+			accumulateWindow 2s
+		}`, false, 2 * time.Second},
+		// fails
+		{`ocp_dnsnameresolver {
+			accumulateWindow
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			accumulateWindow 2
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			accumulateWindow 0s
+		}`, true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.accumulateWindow != test.expectedAccumulateWindow {
+			t.Errorf("Test %d: Expected accumulateWindow '%s'. Instead found accumulateWindow '%s' for input '%s'", i, test.expectedAccumulateWindow, resolver.accumulateWindow, test.input)
+		}
+	}
+}
+
+func TestSetupShutdownTimeout(t *testing.T) {
+	tests := []struct {
+		input                   string
+		shouldErr               bool
+		expectedShutdownTimeout time.Duration
+	}{
+		{`ocp_dnsnameresolver`, false, defaultShutdownTimeout},
+		{`ocp_dnsnameresolver {
+			shutdownTimeout 10s
+		}`, false, 10 * time.Second},
+		// fails
+		{`ocp_dnsnameresolver {
+			shutdownTimeout
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			shutdownTimeout 10
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			shutdownTimeout -1s
+		}`, true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.shutdownTimeout != test.expectedShutdownTimeout {
+			t.Errorf("Test %d: Expected shutdownTimeout '%s'. Instead found shutdownTimeout '%s' for input '%s'", i, test.expectedShutdownTimeout, resolver.shutdownTimeout, test.input)
+		}
+	}
+}
+
+func TestSetupAllowedCIDRs(t *testing.T) {
+	tests := []struct {
+		input                string
+		shouldErr            bool
+		expectedAllowedCIDRs []string
+	}{
+		{`ocp_dnsnameresolver`, false, nil},
+		{`ocp_dnsnameresolver {
+			allowedCIDRs 10.0.0.0/8
+		}`, false, []string{"10.0.0.0/8"}},
+		{`ocp_dnsnameresolver {
+			allowedCIDRs 192.168.1.10/24 2001:db8::/32
+		}`, false, []string{"192.168.1.0/24", "2001:db8::/32"}},
+		// fails
+		{`ocp_dnsnameresolver {
+			allowedCIDRs
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			allowedCIDRs 10.0.0.1
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			allowedCIDRs foo
+		}`, true, nil},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		var allowedCIDRs []string
+		for _, prefix := range resolver.allowedCIDRs {
+			allowedCIDRs = append(allowedCIDRs, prefix.String())
+		}
+		if !reflect.DeepEqual(allowedCIDRs, test.expectedAllowedCIDRs) {
+			t.Errorf("Test %d: Expected allowedCIDRs '%v'. Instead found allowedCIDRs '%v' for input '%s'", i, test.expectedAllowedCIDRs, allowedCIDRs, test.input)
+		}
+	}
+}
+
+func TestSetupAllowedClientCIDRs(t *testing.T) {
+	tests := []struct {
+		input                      string
+		shouldErr                  bool
+		expectedAllowedClientCIDRs []string
+	}{
+		{`ocp_dnsnameresolver`, false, nil},
+		{`ocp_dnsnameresolver {
+			allowedClientCIDRs 10.0.0.0/8
+		}`, false, []string{"10.0.0.0/8"}},
+		{`ocp_dnsnameresolver {
+			allowedClientCIDRs 192.168.1.10/24 2001:db8::/32
+		}`, false, []string{"192.168.1.0/24", "2001:db8::/32"}},
+		// fails
+		{`ocp_dnsnameresolver {
+			allowedClientCIDRs
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			allowedClientCIDRs 10.0.0.1
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			allowedClientCIDRs foo
+		}`, true, nil},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		var allowedClientCIDRs []string
+		for _, prefix := range resolver.allowedClientCIDRs {
+			allowedClientCIDRs = append(allowedClientCIDRs, prefix.String())
+		}
+		if !reflect.DeepEqual(allowedClientCIDRs, test.expectedAllowedClientCIDRs) {
+			t.Errorf("Test %d: Expected allowedClientCIDRs '%v'. Instead found allowedClientCIDRs '%v' for input '%s'", i, test.expectedAllowedClientCIDRs, allowedClientCIDRs, test.input)
+		}
+	}
+}
+
+func TestSetupMaxRecordAge(t *testing.T) {
+	tests := []struct {
+		input                string
+		shouldErr            bool
+		expectedMaxRecordAge time.Duration
+	}{
+		{`ocp_dnsnameresolver`, false, 0},
+		{`ocp_dnsnameresolver {
+			maxRecordAge 24h
+		}`, false, 24 * time.Hour},
+		// fails
+		{`ocp_dnsnameresolver {
+			maxRecordAge
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			maxRecordAge 24
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			maxRecordAge 0s
+		}`, true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.maxRecordAge != test.expectedMaxRecordAge {
+			t.Errorf("Test %d: Expected maxRecordAge '%s'. Instead found maxRecordAge '%s' for input '%s'", i, test.expectedMaxRecordAge, resolver.maxRecordAge, test.input)
+		}
+	}
+}
+
+func TestSetupMergeWindow(t *testing.T) {
+	tests := []struct {
+		input               string
+		shouldErr           bool
+		expectedMergeWindow time.Duration
+	}{
+		{`ocp_dnsnameresolver`, false, 0},
+		{`ocp_dnsnameresolver {
+			mergeWindow 5m
+		}`, false, 5 * time.Minute},
+		// fails
+		{`ocp_dnsnameresolver {
+			mergeWindow
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			mergeWindow 5
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			mergeWindow 0s
+		}`, true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.mergeWindow != test.expectedMergeWindow {
+			t.Errorf("Test %d: Expected mergeWindow '%s'. Instead found mergeWindow '%s' for input '%s'", i, test.expectedMergeWindow, resolver.mergeWindow, test.input)
+		}
+	}
+}
+
+func TestSetupSettleWindow(t *testing.T) {
+	tests := []struct {
+		input                string
+		shouldErr            bool
+		expectedSettleWindow time.Duration
+	}{
+		{`ocp_dnsnameresolver`, false, 0},
+		{`ocp_dnsnameresolver {
+			settleWindow 5m
+		}`, false, 5 * time.Minute},
+		// fails
+		{`ocp_dnsnameresolver {
+			settleWindow
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			settleWindow 5
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			settleWindow 0s
+		}`, true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.settleWindow != test.expectedSettleWindow {
+			t.Errorf("Test %d: Expected settleWindow '%s'. Instead found settleWindow '%s' for input '%s'", i, test.expectedSettleWindow, resolver.settleWindow, test.input)
+		}
+	}
+}
+
+func TestSetupWildcardFlushInterval(t *testing.T) {
+	tests := []struct {
+		input                         string
+		shouldErr                     bool
+		expectedWildcardFlushInterval time.Duration
+	}{
+		{`ocp_dnsnameresolver`, false, 0},
+		{`ocp_dnsnameresolver {
+			wildcardFlushInterval 5m
+		}`, false, 5 * time.Minute},
+		// fails
+		{`ocp_dnsnameresolver {
+			wildcardFlushInterval
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			wildcardFlushInterval 5
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			wildcardFlushInterval 0s
+		}`, true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.wildcardFlushInterval != test.expectedWildcardFlushInterval {
+			t.Errorf("Test %d: Expected wildcardFlushInterval '%s'. Instead found wildcardFlushInterval '%s' for input '%s'", i, test.expectedWildcardFlushInterval, resolver.wildcardFlushInterval, test.input)
+		}
+	}
+}
+
+func TestSetupReconcileInterval(t *testing.T) {
+	tests := []struct {
+		input                     string
+		shouldErr                 bool
+		expectedReconcileInterval time.Duration
+	}{
+		{`ocp_dnsnameresolver`, false, 0},
+		{`ocp_dnsnameresolver {
+			reconcileInterval 5m
+		}`, false, 5 * time.Minute},
+		// fails
+		{`ocp_dnsnameresolver {
+			reconcileInterval
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			reconcileInterval 5
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			reconcileInterval 0s
+		}`, true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.reconcileInterval != test.expectedReconcileInterval {
+			t.Errorf("Test %d: Expected reconcileInterval '%s'. Instead found reconcileInterval '%s' for input '%s'", i, test.expectedReconcileInterval, resolver.reconcileInterval, test.input)
+		}
+	}
+}
+
+func TestSetupSummaryEventInterval(t *testing.T) {
+	tests := []struct {
+		input                        string
+		shouldErr                    bool
+		expectedSummaryEventInterval time.Duration
+	}{
+		{`ocp_dnsnameresolver`, false, 0},
+		{`ocp_dnsnameresolver {
+			summaryEventInterval 1h
+		}`, false, time.Hour},
+		{`ocp_dnsnameresolver {
+			summaryEventInterval 5m
+		}`, false, 5 * time.Minute},
+		// fails
+		{`ocp_dnsnameresolver {
+			summaryEventInterval
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			summaryEventInterval 1
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			summaryEventInterval 1m
+		}`, true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.summaryEventInterval != test.expectedSummaryEventInterval {
+			t.Errorf("Test %d: Expected summaryEventInterval '%s'. Instead found summaryEventInterval '%s' for input '%s'", i, test.expectedSummaryEventInterval, resolver.summaryEventInterval, test.input)
+		}
+	}
+}
+
+func TestSetupFlushInterval(t *testing.T) {
+	tests := []struct {
+		input                 string
+		shouldErr             bool
+		expectedFlushInterval time.Duration
+	}{
+		{`ocp_dnsnameresolver`, false, 0},
+		{`ocp_dnsnameresolver {
+			flushInterval 5s
+		}`, false, 5 * time.Second},
+		// fails
+		{`ocp_dnsnameresolver {
+			flushInterval
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			flushInterval 5
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			flushInterval 0s
+		}`, true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.flushInterval != test.expectedFlushInterval {
+			t.Errorf("Test %d: Expected flushInterval '%s'. Instead found flushInterval '%s' for input '%s'", i, test.expectedFlushInterval, resolver.flushInterval, test.input)
+		}
+	}
+}
+
+func TestSetupMaxUpdatesPerObject(t *testing.T) {
+	tests := []struct {
+		input                       string
+		shouldErr                   bool
+		expectedMaxUpdatesPerObject int
+		expectedUpdateRateInterval  time.Duration
+	}{
+		{`ocp_dnsnameresolver`, false, 0, 0},
+		{`ocp_dnsnameresolver {
+			maxUpdatesPerObject 10 1m
+		}`, false, 10, time.Minute},
+		// fails
+		{`ocp_dnsnameresolver {
+			maxUpdatesPerObject
+		}`, true, 0, 0},
+		{`ocp_dnsnameresolver {
+			maxUpdatesPerObject 10
+		}`, true, 0, 0},
+		{`ocp_dnsnameresolver {
+			maxUpdatesPerObject 10 1m 5
+		}`, true, 0, 0},
+		{`ocp_dnsnameresolver {
+			maxUpdatesPerObject ten 1m
+		}`, true, 0, 0},
+		{`ocp_dnsnameresolver {
+			maxUpdatesPerObject 0 1m
+		}`, true, 0, 0},
+		{`ocp_dnsnameresolver {
+			maxUpdatesPerObject 10 60
+		}`, true, 0, 0},
+		{`ocp_dnsnameresolver {
+			maxUpdatesPerObject 10 0s
+		}`, true, 0, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.maxUpdatesPerObject != test.expectedMaxUpdatesPerObject {
+			t.Errorf("Test %d: Expected maxUpdatesPerObject '%d'. Instead found maxUpdatesPerObject '%d' for input '%s'", i, test.expectedMaxUpdatesPerObject, resolver.maxUpdatesPerObject, test.input)
+		}
+		if resolver.updateRateInterval != test.expectedUpdateRateInterval {
+			t.Errorf("Test %d: Expected updateRateInterval '%s'. Instead found updateRateInterval '%s' for input '%s'", i, test.expectedUpdateRateInterval, resolver.updateRateInterval, test.input)
+		}
+	}
+}
+
+func TestSetupExcludeServiceCIDR(t *testing.T) {
+	tests := []struct {
+		input           string
+		shouldErr       bool
+		expectedEnabled bool
+		expectedCIDRs   []netip.Prefix
+	}{
+		{`ocp_dnsnameresolver`, false, false, nil},
+		{`ocp_dnsnameresolver {
+			excludeServiceCIDR
+		}`, false, true, nil},
+		{`ocp_dnsnameresolver {
+			excludeServiceCIDR 172.30.0.0/16 fd02::/112
+		}`, false, true, []netip.Prefix{netip.MustParsePrefix("172.30.0.0/16"), netip.MustParsePrefix("fd02::/112")}},
+		// fails
+		{`ocp_dnsnameresolver {
+			excludeServiceCIDR 172.30.0.0
+		}`, true, false, nil},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.excludeServiceCIDR != test.expectedEnabled || !reflect.DeepEqual(resolver.serviceCIDRs, test.expectedCIDRs) {
+			t.Errorf("Test %d: Expected excludeServiceCIDR %t with CIDRs %v. Instead found %t with CIDRs %v for input '%s'",
+				i, test.expectedEnabled, test.expectedCIDRs, resolver.excludeServiceCIDR, resolver.serviceCIDRs, test.input)
+		}
+	}
+}
+
+func TestSetupStaleGrace(t *testing.T) {
+	tests := []struct {
+		input              string
+		shouldErr          bool
+		expectedStaleGrace time.Duration
+	}{
+		{`ocp_dnsnameresolver`, false, 0},
+		{`ocp_dnsnameresolver {
+			staleGrace 5m
+		}`, false, 5 * time.Minute},
+		// fails
+		{`ocp_dnsnameresolver {
+			staleGrace
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			staleGrace 5
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			staleGrace 0s
+		}`, true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.staleGrace != test.expectedStaleGrace {
+			t.Errorf("Test %d: Expected staleGrace '%s'. Instead found staleGrace '%s' for input '%s'", i, test.expectedStaleGrace, resolver.staleGrace, test.input)
+		}
+	}
+}
+
+func TestSetupNegativeMaxAge(t *testing.T) {
+	tests := []struct {
+		input                  string
+		shouldErr              bool
+		expectedNegativeMaxAge time.Duration
+	}{
+		{`ocp_dnsnameresolver`, false, 0},
+		{`ocp_dnsnameresolver {
+			negativeMaxAge 5m
+		}`, false, 5 * time.Minute},
+		// fails
+		{`ocp_dnsnameresolver {
+			negativeMaxAge
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			negativeMaxAge 5
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			negativeMaxAge 0s
+		}`, true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.negativeMaxAge != test.expectedNegativeMaxAge {
+			t.Errorf("Test %d: Expected negativeMaxAge '%s'. Instead found negativeMaxAge '%s' for input '%s'", i, test.expectedNegativeMaxAge, resolver.negativeMaxAge, test.input)
+		}
+	}
+}
+
+func TestSetupInstanceID(t *testing.T) {
+	tests := []struct {
+		input              string
+		shouldErr          bool
+		expectedInstanceID string
+	}{
+		{`ocp_dnsnameresolver`, false, ""},
+		{`ocp_dnsnameresolver {
+			instanceID cluster-a
+		}`, false, "cluster-a"},
+		// fails
+		{`ocp_dnsnameresolver {
+			instanceID
+		}`, true, ""},
+		{`ocp_dnsnameresolver {
+			instanceID cluster-a cluster-b
+		}`, true, ""},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.instanceID != test.expectedInstanceID {
+			t.Errorf("Test %d: Expected instanceID '%s'. Instead found instanceID '%s' for input '%s'", i, test.expectedInstanceID, resolver.instanceID, test.input)
+		}
+	}
+}
+
+func TestSetupFailureTTL(t *testing.T) {
+	tests := []struct {
+		input              string
+		shouldErr          bool
+		expectedFailureTTL int32
+	}{
+		{`ocp_dnsnameresolver`, false, 0},
+		{`ocp_dnsnameresolver {
+			failureTTL 2
+		}`, false, 2},
+		{`ocp_dnsnameresolver {
+			failureTTL 1m
+		}`, false, 60},
+		// fails
+		{`ocp_dnsnameresolver {
+			failureTTL
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			failureTTL 0
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			failureTTL 1500ms
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			failureTTL abc
+		}`, true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.failureTTL != test.expectedFailureTTL {
+			t.Errorf("Test %d: Expected failureTTL '%d'. Instead found failureTTL '%d' for input '%s'", i, test.expectedFailureTTL, resolver.failureTTL, test.input)
+		}
+	}
+}
+
+func TestSetupWebhookURL(t *testing.T) {
+	tests := []struct {
+		input              string
+		shouldErr          bool
+		expectedWebhookURL string
+	}{
+		{`ocp_dnsnameresolver`, false, ""},
+		{`ocp_dnsnameresolver {
+			webhookURL https://inventory.example.com/dnsnames
+		}`, false, "https://inventory.example.com/dnsnames"},
+		{`ocp_dnsnameresolver {
+			webhookURL http://localhost:8080
+		}`, false, "http://localhost:8080"},
+		// fails
+		{`ocp_dnsnameresolver {
+			webhookURL
+		}`, true, ""},
+		{`ocp_dnsnameresolver {
+			webhookURL inventory.example.com
+		}`, true, ""},
+		{`ocp_dnsnameresolver {
+			webhookURL ftp://inventory.example.com
+		}`, true, ""},
+		{`ocp_dnsnameresolver {
+			webhookURL https://
+		}`, true, ""},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.webhookURL != test.expectedWebhookURL {
+			t.Errorf("Test %d: Expected webhookURL '%s'. Instead found webhookURL '%s' for input '%s'", i, test.expectedWebhookURL, resolver.webhookURL, test.input)
+		}
+	}
+}
+
+func TestSetupDebugAddress(t *testing.T) {
+	tests := []struct {
+		input                string
+		shouldErr            bool
+		expectedDebugAddress string
+	}{
+		{`ocp_dnsnameresolver`, false, ""},
+		{`ocp_dnsnameresolver {
+			debugAddress localhost:9154
+		}`, false, "localhost:9154"},
+		// fails
+		{`ocp_dnsnameresolver {
+			debugAddress
+		}`, true, ""},
+		{`ocp_dnsnameresolver {
+			debugAddress localhost
+		}`, true, ""},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.debugAddress != test.expectedDebugAddress {
+			t.Errorf("Test %d: Expected debugAddress '%s'. Instead found debugAddress '%s' for input '%s'", i, test.expectedDebugAddress, resolver.debugAddress, test.input)
+		}
+	}
+}
+
+func TestSetupMirrorConfigMap(t *testing.T) {
+	tests := []struct {
+		input                   string
+		shouldErr               bool
+		expectedMirrorConfigMap types.NamespacedName
+	}{
+		{`ocp_dnsnameresolver`, false, types.NamespacedName{}},
+		{`ocp_dnsnameresolver {
+			mirrorConfigMap dns/dnsnames
+		}`, false, types.NamespacedName{Namespace: "dns", Name: "dnsnames"}},
+		// fails
+		{`ocp_dnsnameresolver {
+			mirrorConfigMap
+		}`, true, types.NamespacedName{}},
+		{`ocp_dnsnameresolver {
+			mirrorConfigMap dnsnames
+		}`, true, types.NamespacedName{}},
+		{`ocp_dnsnameresolver {
+			mirrorConfigMap dns/
+		}`, true, types.NamespacedName{}},
+		{`ocp_dnsnameresolver {
+			mirrorConfigMap dns/DNSNames
+		}`, true, types.NamespacedName{}},
+		{`ocp_dnsnameresolver {
+			mirrorConfigMap dns/dnsnames other
+		}`, true, types.NamespacedName{}},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.mirrorConfigMap != test.expectedMirrorConfigMap {
+			t.Errorf("Test %d: Expected mirrorConfigMap '%s'. Instead found mirrorConfigMap '%s' for input '%s'", i, test.expectedMirrorConfigMap, resolver.mirrorConfigMap, test.input)
+		}
+	}
+}
+
+func TestSetupMaintenanceSentinel(t *testing.T) {
+	tests := []struct {
+		input                       string
+		shouldErr                   bool
+		expectedMaintenanceSentinel types.NamespacedName
+	}{
+		{`ocp_dnsnameresolver`, false, types.NamespacedName{}},
+		{`ocp_dnsnameresolver {
+			maintenanceSentinel dns/sentinel
+		}`, false, types.NamespacedName{Namespace: "dns", Name: "sentinel"}},
+		// fails
+		{`ocp_dnsnameresolver {
+			maintenanceSentinel
+		}`, true, types.NamespacedName{}},
+		{`ocp_dnsnameresolver {
+			maintenanceSentinel sentinel
+		}`, true, types.NamespacedName{}},
+		{`ocp_dnsnameresolver {
+			maintenanceSentinel dns/
+		}`, true, types.NamespacedName{}},
+		{`ocp_dnsnameresolver {
+			maintenanceSentinel dns/Sentinel
+		}`, true, types.NamespacedName{}},
+		{`ocp_dnsnameresolver {
+			maintenanceSentinel dns/sentinel other
+		}`, true, types.NamespacedName{}},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.maintenanceSentinel != test.expectedMaintenanceSentinel {
+			t.Errorf("Test %d: Expected maintenanceSentinel '%s'. Instead found maintenanceSentinel '%s' for input '%s'", i, test.expectedMaintenanceSentinel, resolver.maintenanceSentinel, test.input)
+		}
+	}
+}
+
+func TestSetupFailureWeights(t *testing.T) {
+	tests := []struct {
+		input                  string
+		shouldErr              bool
+		expectedFailureWeights map[int]float64
+	}{
+		{`ocp_dnsnameresolver`, false, nil},
+		{`ocp_dnsnameresolver {
+			failureWeight SERVFAIL 1
+			failureWeight refused 0.5
+			failureWeight NXDOMAIN 0
+		}`, false, map[int]float64{dns.RcodeServerFailure: 1, dns.RcodeRefused: 0.5, dns.RcodeNameError: 0}},
+		// fails
+		{`ocp_dnsnameresolver {
+			failureWeight SERVFAIL
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			failureWeight NOERROR 1
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			failureWeight TIMEOUT 1
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			failureWeight SERVFAIL half
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			failureWeight SERVFAIL -1
+		}`, true, nil},
+		{`ocp_dnsnameresolver {
+			failureWeight SERVFAIL NaN
+		}`, true, nil},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(resolver.failureWeights, test.expectedFailureWeights) {
+			t.Errorf("Test %d: Expected failureWeights '%v'. Instead found failureWeights '%v' for input '%s'", i, test.expectedFailureWeights, resolver.failureWeights, test.input)
+		}
+	}
+}
+
+func TestSetupNamespacePacing(t *testing.T) {
+	tests := []struct {
+		input                   string
+		shouldErr               bool
+		expectedNamespacePacing time.Duration
+	}{
+		{`ocp_dnsnameresolver`, false, 0},
+		{`ocp_dnsnameresolver {
+			namespacePacing 200ms
+		}`, false, 200 * time.Millisecond},
+		// fails
+		{`ocp_dnsnameresolver {
+			namespacePacing
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			namespacePacing 200
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			namespacePacing 0s
+		}`, true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.namespacePacing != test.expectedNamespacePacing {
+			t.Errorf("Test %d: Expected namespacePacing '%s'. Instead found namespacePacing '%s' for input '%s'", i, test.expectedNamespacePacing, resolver.namespacePacing, test.input)
+		}
+	}
+}
+
+func TestSetupQueryCoalesceWindow(t *testing.T) {
+	tests := []struct {
+		input                       string
+		shouldErr                   bool
+		expectedQueryCoalesceWindow time.Duration
+	}{
+		{`ocp_dnsnameresolver`, false, 0},
+		{`ocp_dnsnameresolver {
+			queryCoalesceWindow 200ms
+		}`, false, 200 * time.Millisecond},
+		// fails
+		{`ocp_dnsnameresolver {
+			queryCoalesceWindow
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			queryCoalesceWindow 200
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			queryCoalesceWindow 0s
+		}`, true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.queryCoalesceWindow != test.expectedQueryCoalesceWindow {
+			t.Errorf("Test %d: Expected queryCoalesceWindow '%s'. Instead found queryCoalesceWindow '%s' for input '%s'", i, test.expectedQueryCoalesceWindow, resolver.queryCoalesceWindow, test.input)
+		}
+	}
+}
+
+func TestSetupFailureLogInterval(t *testing.T) {
+	tests := []struct {
+		input                      string
+		shouldErr                  bool
+		expectedFailureLogInterval time.Duration
+	}{
+		{`ocp_dnsnameresolver`, false, defaultFailureLogInterval},
+		{`ocp_dnsnameresolver {
+			failureLogInterval 200ms
+		}`, false, 200 * time.Millisecond},
+		// fails
+		{`ocp_dnsnameresolver {
+			failureLogInterval
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			failureLogInterval 200
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			failureLogInterval 0s
+		}`, true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.failureLogInterval != test.expectedFailureLogInterval {
+			t.Errorf("Test %d: Expected failureLogInterval '%s'. Instead found failureLogInterval '%s' for input '%s'", i, test.expectedFailureLogInterval, resolver.failureLogInterval, test.input)
+		}
+	}
+}
+
+func TestSetupBreakerThreshold(t *testing.T) {
+	tests := []struct {
+		input                    string
+		shouldErr                bool
+		expectedBreakerThreshold int
+	}{
+		{`ocp_dnsnameresolver`, false, 0},
+		{`ocp_dnsnameresolver {
+			breakerThreshold 50
+		}`, false, 50},
+		// fails
+		{`ocp_dnsnameresolver {
+			breakerThreshold
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			breakerThreshold 0
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			breakerThreshold foo
+		}`, true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.breakerThreshold != test.expectedBreakerThreshold {
+			t.Errorf("Test %d: Expected breakerThreshold '%d'. Instead found breakerThreshold '%d' for input '%s'", i, test.expectedBreakerThreshold, resolver.breakerThreshold, test.input)
+		}
+	}
+}
+
+func TestSetupBreakerCooldown(t *testing.T) {
+	tests := []struct {
+		input                   string
+		shouldErr               bool
+		expectedBreakerCooldown time.Duration
+	}{
+		{`ocp_dnsnameresolver`, false, defaultBreakerCooldown},
+		{`ocp_dnsnameresolver {
+			breakerCooldown 200ms
+		}`, false, 200 * time.Millisecond},
+		// fails
+		{`ocp_dnsnameresolver {
+			breakerCooldown
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			breakerCooldown 200
+		}`, true, 0},
+		{`ocp_dnsnameresolver {
+			breakerCooldown 0s
+		}`, true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.breakerCooldown != test.expectedBreakerCooldown {
+			t.Errorf("Test %d: Expected breakerCooldown '%s'. Instead found breakerCooldown '%s' for input '%s'", i, test.expectedBreakerCooldown, resolver.breakerCooldown, test.input)
+		}
+	}
+}
+
+func TestSetupPerNamespaceMetrics(t *testing.T) {
+	tests := []struct {
+		input                       string
+		shouldErr                   bool
+		expectedPerNamespaceMetrics bool
+		expectedMaxNamespaceLabels  int
+	}{
+		{`ocp_dnsnameresolver`, false, false, defaultMaxNamespaceLabels},
+		{`ocp_dnsnameresolver {
+			perNamespaceMetrics
+		}`, false, true, defaultMaxNamespaceLabels},
+		{`ocp_dnsnameresolver {
+			perNamespaceMetrics 20
+		}`, false, true, 20},
+		// fails
+		{`ocp_dnsnameresolver {
+			perNamespaceMetrics 0
+		}`, true, false, 0},
+		{`ocp_dnsnameresolver {
+			perNamespaceMetrics foo
+		}`, true, false, 0},
+		{`ocp_dnsnameresolver {
+			perNamespaceMetrics 10 20
+		}`, true, false, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		resolver, err := resolverParse(c)
+
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+
+		if resolver.perNamespaceMetrics != test.expectedPerNamespaceMetrics {
+			t.Errorf("Test %d: Expected perNamespaceMetrics '%t'. Instead found perNamespaceMetrics '%t' for input '%s'", i, test.expectedPerNamespaceMetrics, resolver.perNamespaceMetrics, test.input)
+		}
+		if resolver.maxNamespaceLabels != test.expectedMaxNamespaceLabels {
+			t.Errorf("Test %d: Expected maximum number of namespace labels '%d'. Instead found '%d' for input '%s'", i, test.expectedMaxNamespaceLabels, resolver.maxNamespaceLabels, test.input)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/request"
+	"github.com/miekg/dns"
+)
+
+// sharedResolution is the result of a DNS lookup through the plugin chain shared by the
+// concurrent identical DNS lookups.
+type sharedResolution struct {
+	msg    *dns.Msg
+	status int
+	err    error
+}
+
+// resolveOnce gets the response for the DNS lookup from the plugin chain, unless an
+// identical DNS lookup is already in flight, in which case its response is shared, for
+// singleflight. The DNS lookups are identical if they have the same lowercased DNS name,
+// query type, DO bit, transport and buffer size, so that a response truncated for a UDP
+// buffer is never shared with a DNS lookup over TCP or with a larger buffer. The first DNS lookup writes its response to rw like any other DNS
+// lookup. The response of the other DNS lookups, the followers, is already written when it
+// returns; they are not expected to update the status as the first DNS lookup does.
+func (resolver *OCPDNSNameResolver) resolveOnce(
+	ctx context.Context,
+	rw *dnstest.Recorder,
+	r *dns.Msg,
+	state request.Request,
+) (status int, err error, follower bool) {
+	key := state.Name() + "/" + strconv.Itoa(int(state.QType())) + "/" + strconv.FormatBool(state.Do()) +
+		"/" + state.Proto() + "/" + strconv.Itoa(state.Size())
+
+	leader := false
+	value, _, _ := resolver.inflight.Do(key, func() (any, error) {
+		leader = true
+		status, err := plugin.NextOrFailure(resolver.Name(), resolver.Next, ctx, rw, r)
+		return sharedResolution{msg: rw.Msg, status: status, err: err}, nil
+	})
+	resolution := value.(sharedResolution)
+	if leader {
+		return resolution.status, resolution.err, false
+	}
+
+	// Write the shared response as the response to the DNS lookup of the follower, with its
+	// ID and its question, whose case may differ, eg. with DNS 0x20 encoding.
+	if resolution.msg != nil {
+		msg := resolution.msg.Copy()
+		msg.Id = r.Id
+		msg.Question = append([]dns.Question(nil), r.Question...)
+		if writeErr := rw.WriteMsg(msg); writeErr != nil {
+			return dns.RcodeServerFailure, writeErr, true
+		}
+	}
+	return resolution.status, resolution.err, true
+}
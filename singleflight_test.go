@@ -0,0 +1,192 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestServeDNSSingleflight(t *testing.T) {
+	const concurrentQueries = 5
+
+	tests := []struct {
+		name                  string
+		singleflight          bool
+		expectedResolutions   int32
+		expectedStatusUpdates int
+	}{
+		{
+			name:                "Concurrent queries are resolved separately by default",
+			expectedResolutions: concurrentQueries,
+		},
+		{
+			name:                  "Concurrent queries collapse to one resolution and one update with singleflight",
+			singleflight:          true,
+			expectedResolutions:   1,
+			expectedStatusUpdates: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.singleflight = tc.singleflight
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			})
+
+			// The next plugin is slow, so that the queries are concurrent.
+			var resolutions atomic.Int32
+			resolver.Next = plugin.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+				resolutions.Add(1)
+				time.Sleep(200 * time.Millisecond)
+				m := new(dns.Msg)
+				m.SetReply(r)
+				m.Answer = append(m.Answer, test.A("www.example.com. 30 IN A 1.1.1.1"))
+				w.WriteMsg(m)
+				return dns.RcodeSuccess, nil
+			})
+
+			var wg sync.WaitGroup
+			recorders := make([]*dnstest.Recorder, concurrentQueries)
+			for i := range recorders {
+				recorders[i] = dnstest.NewRecorder(&test.ResponseWriter{})
+				// The queries differ in the ID and the case of the DNS name only.
+				msg := new(dns.Msg)
+				qname := "www.example.com."
+				if i%2 == 1 {
+					qname = strings.ToUpper(qname)
+				}
+				msg.SetQuestion(qname, dns.TypeA)
+				msg.Id = uint16(i + 1)
+
+				wg.Add(1)
+				go func(rec *dnstest.Recorder, msg *dns.Msg) {
+					defer wg.Done()
+					resolver.ServeDNS(ctx, rec, msg)
+				}(recorders[i], msg)
+			}
+			wg.Wait()
+
+			if count := resolutions.Load(); count != tc.expectedResolutions {
+				t.Fatalf("Expected %d resolutions through the plugin chain, found %d", tc.expectedResolutions, count)
+			}
+			for i, rec := range recorders {
+				if rec.Msg == nil || len(rec.Msg.Answer) != 1 {
+					t.Fatalf("Expected query %d to be answered, found: %v", i, rec.Msg)
+				}
+				if rec.Msg.Id != uint16(i+1) {
+					t.Fatalf("Expected the response to query %d to have ID %d, found %d", i, i+1, rec.Msg.Id)
+				}
+				if i%2 == 1 && rec.Msg.Question[0].Name != "WWW.EXAMPLE.COM." {
+					t.Fatalf("Expected the response to query %d to keep the question, found: %v", i, rec.Msg.Question)
+				}
+			}
+			if tc.singleflight {
+				if count := countStatusUpdates(fakeNetworkClient); count != tc.expectedStatusUpdates {
+					t.Fatalf("Expected %d status updates, found %d", tc.expectedStatusUpdates, count)
+				}
+			}
+		})
+	}
+}
+
+func TestServeDNSSingleflightTransport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.singleflight = true
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "regular",
+			Namespace: "dns",
+		},
+		Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+			Name: "www.example.com.",
+		},
+	})
+
+	// The next plugin is slow, so that the queries are concurrent, and truncates the
+	// responses over UDP without EDNS0.
+	var resolutions atomic.Int32
+	resolver.Next = plugin.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+		resolutions.Add(1)
+		time.Sleep(200 * time.Millisecond)
+		m := new(dns.Msg)
+		m.SetReply(r)
+		if _, isTCP := w.RemoteAddr().(*net.TCPAddr); !isTCP && r.IsEdns0() == nil {
+			m.Truncated = true
+		} else {
+			m.Answer = append(m.Answer, test.A("www.example.com. 30 IN A 1.1.1.1"))
+		}
+		w.WriteMsg(m)
+		return dns.RcodeSuccess, nil
+	})
+
+	// The queries differ in the transport or the buffer size only.
+	newQuery := func(bufsize uint16) *dns.Msg {
+		msg := new(dns.Msg)
+		msg.SetQuestion("www.example.com.", dns.TypeA)
+		if bufsize > 0 {
+			msg.SetEdns0(bufsize, false)
+		}
+		return msg
+	}
+	queries := []struct {
+		rw  *test.ResponseWriter
+		msg *dns.Msg
+	}{
+		{rw: &test.ResponseWriter{}, msg: newQuery(0)},
+		{rw: &test.ResponseWriter{TCP: true}, msg: newQuery(0)},
+		{rw: &test.ResponseWriter{}, msg: newQuery(4096)},
+	}
+
+	var wg sync.WaitGroup
+	recorders := make([]*dnstest.Recorder, len(queries))
+	for i, query := range queries {
+		recorders[i] = dnstest.NewRecorder(query.rw)
+		wg.Add(1)
+		go func(rec *dnstest.Recorder, msg *dns.Msg) {
+			defer wg.Done()
+			resolver.ServeDNS(ctx, rec, msg)
+		}(recorders[i], query.msg)
+	}
+	wg.Wait()
+
+	if count := resolutions.Load(); count != int32(len(queries)) {
+		t.Fatalf("Expected %d resolutions through the plugin chain, found %d", len(queries), count)
+	}
+	if rec := recorders[0]; rec.Msg == nil || !rec.Msg.Truncated {
+		t.Fatalf("Expected the response over UDP without EDNS0 to be truncated, found: %v", rec.Msg)
+	}
+	for i, rec := range recorders[1:] {
+		if rec.Msg == nil || rec.Msg.Truncated || len(rec.Msg.Answer) != 1 {
+			t.Fatalf("Expected query %d not to share the truncated response, found: %v", i+1, rec.Msg)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	// srvTargetsAnnotation is the annotation used for storing the host:port targets
+	// of the SRV records of the DNS names matching a DNSNameResolver object. The
+	// DNSNameResolver status does not have a field for ports, thus the targets are
+	// stored in the annotation as a JSON encoded map.
+	// key: DNS name, value: sorted list of host:port targets.
+	srvTargetsAnnotation = "dnsnameresolver.openshift.io/srv-targets"
+)
+
+// getSRVTargets returns the host:port targets of the SRV records in the answer
+// section of the DNS response, sorted to keep the annotation value stable.
+func getSRVTargets(msg *dns.Msg) []string {
+	if msg == nil {
+		return nil
+	}
+	targetSet := make(map[string]struct{})
+	for _, answer := range msg.Answer {
+		if rec, ok := answer.(*dns.SRV); ok {
+			targetSet[net.JoinHostPort(rec.Target, strconv.Itoa(int(rec.Port)))] = struct{}{}
+		}
+	}
+	targets := make([]string, 0, len(targetSet))
+	for target := range targetSet {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// updateSRVTargets updates the SRV targets annotation of the DNSNameResolver objects
+// corresponding to the regular and the wildcard DNS names.
+func (resolver *OCPDNSNameResolver) updateSRVTargets(
+	ctx context.Context,
+	regularDNSInfo namespaceDNSInfo,
+	wildcardDNSInfo namespaceDNSInfo,
+	dnsName string,
+	targets []string,
+) {
+	updateAnnotationEntries(ctx, resolver, regularDNSInfo, wildcardDNSInfo, srvTargetsAnnotation, "SRV targets",
+		func(srvTargets map[string][]string) bool {
+			// If there are no changes to the SRV targets of the DNS name then skip the update call.
+			if existingTargets, exists := srvTargets[dnsName]; exists && slices.Equal(existingTargets, targets) {
+				return false
+			}
+			srvTargets[dnsName] = targets
+			return true
+		})
+}
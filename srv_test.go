@@ -0,0 +1,105 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetSRVTargets(t *testing.T) {
+	msg := new(dns.Msg)
+	msg.Answer = []dns.RR{
+		test.SRV("_http._tcp.example.com. 30 IN SRV 10 10 8080 www2.example.com."),
+		test.SRV("_http._tcp.example.com. 30 IN SRV 10 10 80 www1.example.com."),
+		test.SRV("_http._tcp.example.com. 30 IN SRV 20 10 80 www1.example.com."),
+		test.A("www1.example.com. 30 IN A 1.1.1.1"),
+	}
+
+	expectedTargets := []string{"www1.example.com.:80", "www2.example.com.:8080"}
+	if diff := cmp.Diff(expectedTargets, getSRVTargets(msg)); diff != "" {
+		t.Fatalf("SRV targets did not match the expected targets:\nDiff: %s", diff)
+	}
+	if targets := getSRVTargets(nil); len(targets) != 0 {
+		t.Fatalf("Expected no SRV targets for nil message, found: %v", targets)
+	}
+}
+
+func TestServeDNSRecordSRV(t *testing.T) {
+	srvQuery := test.Case{
+		Qname: "_http._tcp.example.com.",
+		Qtype: dns.TypeSRV,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.SRV("_http._tcp.example.com. 30 IN SRV 10 10 80 www1.example.com."),
+			test.SRV("_http._tcp.example.com. 30 IN SRV 10 10 8080 www2.example.com."),
+		},
+	}
+
+	tests := []struct {
+		name               string
+		recordSRV          bool
+		expectedAnnotation map[string][]string
+	}{
+		{
+			name:               "SRV targets are not recorded when recordSRV is disabled",
+			recordSRV:          false,
+			expectedAnnotation: nil,
+		},
+		{
+			name:      "SRV targets are recorded when recordSRV is enabled",
+			recordSRV: true,
+			expectedAnnotation: map[string][]string{
+				"_http._tcp.example.com.": {"www1.example.com.:80", "www2.example.com.:8080"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.recordSRV = tc.recordSRV
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "srv",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "_http._tcp.example.com.",
+				},
+			})
+
+			resolver.Next = fakeNextPluginHandler(srvQuery)
+			resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), srvQuery.Msg())
+
+			resolverObj := getResolverObject(t, resolver, "dns", "srv", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+				_, exists := obj.Annotations[srvTargetsAnnotation]
+				return exists == tc.recordSRV
+			})
+
+			var actualAnnotation map[string][]string
+			if value, exists := resolverObj.Annotations[srvTargetsAnnotation]; exists {
+				if err := json.Unmarshal([]byte(value), &actualAnnotation); err != nil {
+					t.Fatalf("error parsing annotation %s: %v", srvTargetsAnnotation, err)
+				}
+			}
+			if diff := cmp.Diff(tc.expectedAnnotation, actualAnnotation); diff != "" {
+				t.Fatalf("SRV targets annotation did not match the expected value:\nDiff: %s", diff)
+			}
+			if len(resolverObj.Status.ResolvedNames) != 0 {
+				t.Fatalf("Expected no resolved names for SRV lookup, found: %v", resolverObj.Status.ResolvedNames)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// isTimeoutError checks if the error returned by the plugin chain is a timeout, eg. of the
+// upstream resolvers of the forward plugin, as opposed to an answer like NXDOMAIN.
+func isTimeoutError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// staleAnswer returns the answer to the DNS lookup made of the IP addresses of the DNS name
+// recorded in the status of the matching DNSNameResolver objects, for serveStaleOnTimeout.
+// Only the IP addresses whose TTL did not expire more than staleGrace ago are used. The TTL
+// of the records is the remaining TTL of the IP addresses, or 0 for the ones within the
+// grace period, so that they are not cached. It returns nil if no IP address is usable.
+func (resolver *OCPDNSNameResolver) staleAnswer(
+	ctx context.Context,
+	r *dns.Msg,
+	qtype uint16,
+	regularDNSInfo namespaceDNSInfo,
+	wildcardDNSInfo namespaceDNSInfo,
+	now time.Time,
+) *dns.Msg {
+	if qtype != dns.TypeA && qtype != dns.TypeAAAA {
+		return nil
+	}
+	qname := r.Question[0].Name
+
+	// Get the remaining TTL of the usable IP addresses, across the DNSNameResolver objects,
+	// which are read in the order of fanOutOrder.
+	// key: IP address, value: remaining TTL.
+	remaining := make(map[string]time.Duration)
+	var lock sync.Mutex
+	for _, namespaceDNS := range []namespaceDNSInfo{regularDNSInfo, wildcardDNSInfo} {
+		resolver.fanOut(namespaceDNS, func(namespace string, objName string) {
+			resolverObj, err := resolver.store.get(ctx, namespace, objName)
+			if err != nil {
+				return
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			for _, resolvedName := range resolverObj.Status.ResolvedNames {
+				if !strings.EqualFold(string(resolvedName.DNSName), qname) {
+					continue
+				}
+				for _, address := range resolvedName.ResolvedAddresses {
+					ip := net.ParseIP(address.IP)
+					if ip == nil || address.LastLookupTime == nil || (ip.To4() != nil) != (qtype == dns.TypeA) {
+						continue
+					}
+					left := address.LastLookupTime.Add(time.Duration(address.TTLSeconds) * time.Second).Sub(now)
+					if left+resolver.staleGrace <= 0 {
+						continue
+					}
+					if previous, exists := remaining[address.IP]; !exists || previous < left {
+						remaining[address.IP] = left
+					}
+				}
+			}
+		})
+	}
+	if len(remaining) == 0 {
+		return nil
+	}
+	// Sort the IP addresses, as the objects may be read concurrently.
+	ips := sortedKeys(remaining)
+
+	msg := new(dns.Msg)
+	msg.SetReply(r)
+	for _, ip := range ips {
+		ttl := uint32(max(remaining[ip], 0) / time.Second)
+		hdr := dns.RR_Header{Name: qname, Class: dns.ClassINET, Ttl: ttl}
+		if qtype == dns.TypeA {
+			hdr.Rrtype = dns.TypeA
+			msg.Answer = append(msg.Answer, &dns.A{Hdr: hdr, A: net.ParseIP(ip)})
+		} else {
+			hdr.Rrtype = dns.TypeAAAA
+			msg.Answer = append(msg.Answer, &dns.AAAA{Hdr: hdr, AAAA: net.ParseIP(ip)})
+		}
+	}
+	log.Debugf("Serving %d recorded IP addresses of DNS name %s as the DNS lookup timed out", len(ips), qname)
+	return msg
+}
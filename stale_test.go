@@ -0,0 +1,175 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsTimeoutError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "No error", err: nil, expected: false},
+		{name: "Context deadline", err: fmt.Errorf("lookup: %w", context.DeadlineExceeded), expected: true},
+		{name: "Network timeout", err: &net.OpError{Op: "read", Net: "udp", Err: os.ErrDeadlineExceeded}, expected: true},
+		{name: "Other error", err: errors.New("no healthy upstream"), expected: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if isTimeoutError(tc.err) != tc.expected {
+				t.Fatalf("Expected timeout error to be %t for %v", tc.expected, tc.err)
+			}
+		})
+	}
+}
+
+func TestServeDNSServeStaleOnTimeout(t *testing.T) {
+	// timeoutHandler is a next plugin handler timing out like the forward plugin, without
+	// writing a response.
+	timeoutHandler := plugin.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+		return dns.RcodeServerFailure, &net.OpError{Op: "read", Net: "udp", Err: os.ErrDeadlineExceeded}
+	})
+	nxdomainHandler := fakeNextPluginHandler(test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+	})
+
+	tests := []struct {
+		name                string
+		serveStaleOnTimeout bool
+		staleGrace          time.Duration
+		timeout             bool
+		// sinceLastLookup gives the time since the last lookup of the recorded IP address,
+		// whose TTL is 30 seconds.
+		sinceLastLookup time.Duration
+		expectedAnswer  bool
+		expectedTTL     uint32
+		expectedFailure bool
+	}{
+		{
+			name:            "Timeout is recorded as a failure by default",
+			timeout:         true,
+			sinceLastLookup: 10 * time.Second,
+			expectedFailure: true,
+		},
+		{
+			name:                "Recorded IP address within its TTL is served on timeout",
+			serveStaleOnTimeout: true,
+			timeout:             true,
+			sinceLastLookup:     10 * time.Second,
+			expectedAnswer:      true,
+			expectedTTL:         20,
+		},
+		{
+			name:                "Expired IP address is not served on timeout, and the status is kept",
+			serveStaleOnTimeout: true,
+			timeout:             true,
+			sinceLastLookup:     40 * time.Second,
+		},
+		{
+			name:                "Expired IP address within staleGrace is served on timeout with TTL 0",
+			serveStaleOnTimeout: true,
+			staleGrace:          time.Minute,
+			timeout:             true,
+			sinceLastLookup:     40 * time.Second,
+			expectedAnswer:      true,
+			expectedTTL:         0,
+		},
+		{
+			name:                "NXDOMAIN is recorded as a failure",
+			serveStaleOnTimeout: true,
+			sinceLastLookup:     10 * time.Second,
+			expectedFailure:     true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.serveStaleOnTimeout = tc.serveStaleOnTimeout
+			resolver.staleGrace = tc.staleGrace
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			})
+			resolverObj, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("dns").Get(ctx, "regular", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Unexpected error getting DNSNameResolver object: %v", err)
+			}
+			lastLookupTime := metav1.NewTime(time.Now().Add(-tc.sinceLastLookup))
+			resolverObj.Status.ResolvedNames = []ocpnetworkapiv1alpha1.DNSNameResolverResolvedName{{
+				DNSName: "www.example.com.",
+				ResolvedAddresses: []ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{
+					{IP: "1.1.1.1", TTLSeconds: 30, LastLookupTime: &lastLookupTime},
+				},
+				ResolutionFailures: 0,
+			}}
+			if _, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("dns").UpdateStatus(ctx, resolverObj, metav1.UpdateOptions{}); err != nil {
+				t.Fatalf("Unexpected error updating DNSNameResolver object: %v", err)
+			}
+			getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+				return len(obj.Status.ResolvedNames) == 1
+			})
+			statusUpdates := countStatusUpdates(fakeNetworkClient)
+
+			resolver.Next = nxdomainHandler
+			if tc.timeout {
+				resolver.Next = timeoutHandler
+			}
+			query := test.Case{Qname: "www.example.com.", Qtype: dns.TypeA}
+			w := dnstest.NewRecorder(&test.ResponseWriter{})
+			status, _ := resolver.ServeDNS(ctx, w, query.Msg())
+
+			if tc.expectedAnswer {
+				if status != dns.RcodeSuccess || w.Msg == nil || len(w.Msg.Answer) != 1 {
+					t.Fatalf("Expected the recorded IP address to be served, found status %d and response: %v", status, w.Msg)
+				}
+				rec, ok := w.Msg.Answer[0].(*dns.A)
+				if !ok || rec.A.String() != "1.1.1.1" {
+					t.Fatalf("Expected an A record of 1.1.1.1, found: %v", w.Msg.Answer[0])
+				}
+				// The TTL may be a second lower than expected depending on the timing.
+				if rec.Hdr.Ttl > tc.expectedTTL || rec.Hdr.Ttl+1 < tc.expectedTTL {
+					t.Fatalf("Expected TTL %d, found %d", tc.expectedTTL, rec.Hdr.Ttl)
+				}
+			} else if tc.timeout && w.Msg != nil {
+				t.Fatalf("Expected no response to be written on timeout, found: %v", w.Msg)
+			}
+
+			if tc.expectedFailure {
+				getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+					return countStatusUpdates(fakeNetworkClient) > statusUpdates
+				})
+			}
+			if updated := countStatusUpdates(fakeNetworkClient) > statusUpdates; updated != tc.expectedFailure {
+				t.Fatalf("Expected status updated for the failure to be %t, found %t", tc.expectedFailure, updated)
+			}
+		})
+	}
+}
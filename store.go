@@ -0,0 +1,78 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	ocpnetworkclientv1alpha1 "github.com/openshift/client-go/network/clientset/versioned/typed/network/v1alpha1"
+	ocpnetworkv1alpha1lister "github.com/openshift/client-go/network/listers/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// resolverStore abstracts reading and writing the DNSNameResolver objects of a
+// specific API version. The plugin internally works with the v1alpha1 representation
+// of the DNSNameResolver objects. An implementation for another API version is
+// responsible for converting its objects, including the field path of the resolved
+// addresses in the status, from and to the v1alpha1 representation.
+type resolverStore interface {
+	// get returns a copy of the DNSNameResolver object from the informer cache, or from
+	// the API server when liveGet is enabled, within the given context. The returned
+	// object can be modified by the caller.
+	get(ctx context.Context, namespace, name string) (*ocpnetworkapiv1alpha1.DNSNameResolver, error)
+	// update updates the DNSNameResolver object, excluding its status.
+	update(ctx context.Context, resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) error
+	// updateStatus updates the status of the DNSNameResolver object.
+	updateStatus(ctx context.Context, resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) error
+}
+
+// v1alpha1Store implements resolverStore for the v1alpha1 DNSNameResolver objects.
+type v1alpha1Store struct {
+	lister ocpnetworkv1alpha1lister.DNSNameResolverLister
+	client ocpnetworkclientv1alpha1.NetworkV1alpha1Interface
+	// live indicates whether the objects are read from the API server instead of the
+	// informer cache, when liveGet is enabled.
+	live bool
+}
+
+var _ resolverStore = &v1alpha1Store{}
+
+// newV1alpha1Store returns a resolverStore for the v1alpha1 DNSNameResolver objects.
+func newV1alpha1Store(
+	lister ocpnetworkv1alpha1lister.DNSNameResolverLister,
+	client ocpnetworkclientv1alpha1.NetworkV1alpha1Interface,
+	live bool,
+) *v1alpha1Store {
+	return &v1alpha1Store{
+		lister: lister,
+		client: client,
+		live:   live,
+	}
+}
+
+// get implements resolverStore.
+func (store *v1alpha1Store) get(ctx context.Context, namespace, name string) (*ocpnetworkapiv1alpha1.DNSNameResolver, error) {
+	// The informer cache may lag the object during a heavy churn, in which case the updates
+	// of the cached object conflict. Read the latest object from the API server instead, at
+	// the cost of a read, if liveGet is enabled.
+	if store.live {
+		return store.client.DNSNameResolvers(namespace).Get(ctx, name, metav1.GetOptions{})
+	}
+	resolverObj, err := store.lister.DNSNameResolvers(namespace).Get(name)
+	if err != nil {
+		return nil, err
+	}
+	// Make a copy of the object, as the object from the informer cache must not be modified.
+	return resolverObj.DeepCopy(), nil
+}
+
+// update implements resolverStore.
+func (store *v1alpha1Store) update(ctx context.Context, resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) error {
+	_, err := store.client.DNSNameResolvers(resolverObj.Namespace).Update(ctx, resolverObj, metav1.UpdateOptions{})
+	return err
+}
+
+// updateStatus implements resolverStore.
+func (store *v1alpha1Store) updateStatus(ctx context.Context, resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) error {
+	_, err := store.client.DNSNameResolvers(resolverObj.Namespace).UpdateStatus(ctx, resolverObj, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,152 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	ocpnetworkfakeclient "github.com/openshift/client-go/network/clientset/versioned/fake"
+	ocpnetworkv1alpha1lister "github.com/openshift/client-go/network/listers/network/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestV1alpha1Store(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "regular",
+			Namespace: "dns",
+		},
+		Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+			Name: "www.example.com.",
+		},
+	})
+
+	// The returned object should be a copy of the object in the informer cache.
+	resolverObj, err := resolver.store.get(ctx, "dns", "regular")
+	if err != nil {
+		t.Fatalf("error getting dns name resolver: %v", err)
+	}
+	resolverObj.Spec.Name = "modified.example.com."
+	cachedObj, err := resolver.store.get(ctx, "dns", "regular")
+	if err != nil {
+		t.Fatalf("error getting dns name resolver: %v", err)
+	}
+	if cachedObj.Spec.Name != "www.example.com." {
+		t.Fatalf("Expected the object in the informer cache to be unmodified, found spec.name %s", cachedObj.Spec.Name)
+	}
+
+	// The status should be written to the status.resolvedNames field.
+	expectedResolvedNames := []ocpnetworkapiv1alpha1.DNSNameResolverResolvedName{
+		{
+			DNSName: "www.example.com.",
+			ResolvedAddresses: []ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{
+				{
+					IP:             "1.1.1.1",
+					TTLSeconds:     30,
+					LastLookupTime: &metav1.Time{},
+				},
+			},
+		},
+	}
+	cachedObj.Status.ResolvedNames = expectedResolvedNames
+	if err := resolver.store.updateStatus(context.TODO(), cachedObj); err != nil {
+		t.Fatalf("error updating status of dns name resolver: %v", err)
+	}
+	updatedObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		return len(obj.Status.ResolvedNames) > 0
+	})
+	if diff := cmp.Diff(expectedResolvedNames, updatedObj.Status.ResolvedNames); diff != "" {
+		t.Fatalf("resolved names did not match the expected value:\nDiff: %s", diff)
+	}
+
+	// The object's metadata should be written.
+	updatedObj = updatedObj.DeepCopy()
+	updatedObj.Annotations = map[string]string{"foo": "bar"}
+	if err := resolver.store.update(context.TODO(), updatedObj); err != nil {
+		t.Fatalf("error updating dns name resolver: %v", err)
+	}
+	updatedObj = getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+		return obj.Annotations["foo"] == "bar"
+	})
+	if updatedObj.Annotations["foo"] != "bar" {
+		t.Fatalf("Expected annotation foo=bar, found annotations: %v", updatedObj.Annotations)
+	}
+}
+
+func TestV1alpha1StoreLiveGet(t *testing.T) {
+	tests := []struct {
+		name             string
+		live             bool
+		expectedConflict bool
+	}{
+		{
+			name:             "Update of the stale cached object conflicts",
+			live:             false,
+			expectedConflict: true,
+		},
+		{
+			name:             "Update of the live object does not conflict",
+			live:             true,
+			expectedConflict: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resolverObj := &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns", ResourceVersion: "2"},
+				Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+			}
+			fakeNetworkClient := ocpnetworkfakeclient.NewSimpleClientset(resolverObj)
+			// The updates of an object whose resource version is not the latest one conflict.
+			fakeNetworkClient.PrependReactor("update", "dnsnameresolvers", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				updatedObj := action.(clienttesting.UpdateAction).GetObject().(*ocpnetworkapiv1alpha1.DNSNameResolver)
+				if updatedObj.ResourceVersion != "2" {
+					return true, nil, apierrors.NewConflict(schema.GroupResource{Group: "network.openshift.io", Resource: "dnsnameresolvers"},
+						updatedObj.Name, nil)
+				}
+				return false, nil, nil
+			})
+
+			// The informer cache lags the object.
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			staleObj := resolverObj.DeepCopy()
+			staleObj.ResourceVersion = "1"
+			if err := indexer.Add(staleObj); err != nil {
+				t.Fatalf("Unexpected error adding the object to the informer cache: %v", err)
+			}
+			store := newV1alpha1Store(ocpnetworkv1alpha1lister.NewDNSNameResolverLister(indexer), fakeNetworkClient.NetworkV1alpha1(), tc.live)
+
+			retrier := newUpdateRetrier()
+			defer retrier.stop()
+			attempts := 0
+			err := retrier.retryUpdate("dns", "regular", "status", func() error {
+				attempts++
+				obj, err := store.get(context.TODO(), "dns", "regular")
+				if err != nil {
+					return err
+				}
+				obj.Status.ResolvedNames = []ocpnetworkapiv1alpha1.DNSNameResolverResolvedName{{DNSName: "www.example.com."}}
+				return store.updateStatus(context.TODO(), obj)
+			})
+			if conflict := apierrors.IsConflict(err); conflict != tc.expectedConflict {
+				t.Fatalf("Expected conflict %t, found error: %v", tc.expectedConflict, err)
+			}
+			if !tc.expectedConflict && attempts != 1 {
+				t.Fatalf("Expected the update to succeed at the first attempt, found %d attempts", attempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	ocpnetworkscheme "github.com/openshift/client-go/network/clientset/versioned/scheme"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+const (
+	// minSummaryEventInterval gives the minimum summaryEventInterval. The events recorder
+	// drops the events of an object emitted more often than once per 5 minutes, once a burst
+	// of them is used up.
+	minSummaryEventInterval = 5 * time.Minute
+	// summaryEventQPS and summaryEventBurst limit the rate of the summary events emitted for
+	// all the objects, so that the events API is not overloaded by a large number of objects.
+	// The objects beyond the limit are skipped until the next interval.
+	summaryEventQPS   = 1
+	summaryEventBurst = 10
+	// summaryEventReason is the reason of the summary events.
+	summaryEventReason = "Summary"
+	// eventSourceComponent is the component of the source of the events.
+	eventSourceComponent = "ocp-dnsnameresolver"
+)
+
+// newEventRecorder returns a recorder of the events of the DNSNameResolver objects written
+// with the client, along with its broadcaster, which must be shut down once the plugin is
+// shut down.
+func newEventRecorder(kubeClient kubernetes.Interface) (record.EventRecorder, record.EventBroadcaster) {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+	recorder := broadcaster.NewRecorder(ocpnetworkscheme.Scheme, corev1.EventSource{Component: eventSourceComponent})
+	return recorder, broadcaster
+}
+
+// newSummaryEventLimiter returns the rate limiter of the summary events.
+func newSummaryEventLimiter() flowcontrol.RateLimiter {
+	return flowcontrol.NewTokenBucketRateLimiter(summaryEventQPS, summaryEventBurst)
+}
+
+// summaryMessage returns the message of the summary event of the DNSNameResolver object:
+// the number of its recorded IP addresses and DNS names, and the time of its last refresh.
+func summaryMessage(resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) string {
+	addresses := 0
+	var lastRefresh time.Time
+	for _, resolvedName := range resolverObj.Status.ResolvedNames {
+		addresses += len(resolvedName.ResolvedAddresses)
+		if lastWrite := lastWriteTime(resolvedName); lastWrite.After(lastRefresh) {
+			lastRefresh = lastWrite
+		}
+	}
+	if lastRefresh.IsZero() {
+		return fmt.Sprintf("Recorded %d IP addresses of %d DNS names, never refreshed",
+			addresses, len(resolverObj.Status.ResolvedNames))
+	}
+	return fmt.Sprintf("Recorded %d IP addresses of %d DNS names, last refreshed at %s",
+		addresses, len(resolverObj.Status.ResolvedNames), lastRefresh.UTC().Format(time.RFC3339))
+}
+
+// emitSummaryEvents emits a summary event on each tracked DNSNameResolver object, as long
+// as the rate limit of the summary events allows it.
+func (resolver *OCPDNSNameResolver) emitSummaryEvents(ctx context.Context) {
+	skipped := 0
+	for object := range resolver.trackedObjects() {
+		if ctx.Err() != nil {
+			return
+		}
+		resolverObj, err := resolver.store.get(ctx, object.Namespace, object.Name)
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				log.Errorf("Encountered error while getting DNSNameResolver object %s for its summary event: %v", object, err)
+			}
+			continue
+		}
+		if !resolver.summaryEventLimiter.TryAccept() {
+			skipped++
+			continue
+		}
+		resolver.eventRecorder.Event(resolverObj, corev1.EventTypeNormal, summaryEventReason, summaryMessage(resolverObj))
+	}
+	if skipped > 0 {
+		log.Warningf("Skipped the summary events of %d DNSNameResolver objects due to their rate limit", skipped)
+	}
+}
+
+// runSummaryEvents periodically emits the summary events of the tracked DNSNameResolver
+// objects, once the DNSNameResolver informer is synced, until the stop channel is closed.
+func (resolver *OCPDNSNameResolver) runSummaryEvents(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(resolver.summaryEventInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
+		if !resolver.dnsNameResolverInformer.HasSynced() {
+			continue
+		}
+		resolver.emitSummaryEvents(context.Background())
+	}
+}
@@ -0,0 +1,136 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+func TestSummaryMessage(t *testing.T) {
+	lastLookup := metav1.NewTime(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	earlierLookup := metav1.NewTime(lastLookup.Add(-time.Minute))
+	tests := []struct {
+		name            string
+		resolvedNames   []ocpnetworkapiv1alpha1.DNSNameResolverResolvedName
+		expectedMessage string
+	}{
+		{
+			name:            "No resolved name",
+			expectedMessage: "Recorded 0 IP addresses of 0 DNS names, never refreshed",
+		},
+		{
+			name: "Resolved names",
+			resolvedNames: []ocpnetworkapiv1alpha1.DNSNameResolverResolvedName{
+				{
+					DNSName: "www.example.com.",
+					ResolvedAddresses: []ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{
+						{IP: "1.1.1.1", TTLSeconds: 30, LastLookupTime: &earlierLookup},
+						{IP: "1.1.1.2", TTLSeconds: 30, LastLookupTime: &lastLookup},
+					},
+				},
+				{
+					DNSName: "sub.example.com.",
+					ResolvedAddresses: []ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{
+						{IP: "1.1.1.3", TTLSeconds: 30, LastLookupTime: &earlierLookup},
+					},
+				},
+			},
+			expectedMessage: "Recorded 3 IP addresses of 2 DNS names, last refreshed at 2026-01-02T03:04:05Z",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resolverObj := &ocpnetworkapiv1alpha1.DNSNameResolver{
+				Status: ocpnetworkapiv1alpha1.DNSNameResolverStatus{ResolvedNames: tc.resolvedNames},
+			}
+			if message := summaryMessage(resolverObj); message != tc.expectedMessage {
+				t.Fatalf("Expected message %q, found %q", tc.expectedMessage, message)
+			}
+		})
+	}
+}
+
+func TestEmitSummaryEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	fakeRecorder := record.NewFakeRecorder(10)
+	resolver.eventRecorder = fakeRecorder
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	lastLookup := metav1.NewTime(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+		Status: ocpnetworkapiv1alpha1.DNSNameResolverStatus{
+			ResolvedNames: []ocpnetworkapiv1alpha1.DNSNameResolverResolvedName{
+				{
+					DNSName: "www.example.com.",
+					ResolvedAddresses: []ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{
+						{IP: "1.1.1.1", TTLSeconds: 30, LastLookupTime: &lastLookup},
+					},
+				},
+			},
+		},
+	})
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "wildcard", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "*.example.com."},
+	})
+
+	resolver.emitSummaryEvents(ctx)
+
+	events := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case event := <-fakeRecorder.Events:
+			events[event] = true
+		case <-time.After(time.Second):
+			t.Fatalf("Expected 2 summary events, found %d", i)
+		}
+	}
+	for _, expected := range []string{
+		"Normal Summary Recorded 1 IP addresses of 1 DNS names, last refreshed at 2026-01-02T03:04:05Z",
+		"Normal Summary Recorded 0 IP addresses of 0 DNS names, never refreshed",
+	} {
+		if !events[expected] {
+			t.Fatalf("Expected summary event %q, found %v", expected, events)
+		}
+	}
+}
+
+func TestEmitSummaryEventsRateLimit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	fakeRecorder := record.NewFakeRecorder(10)
+	resolver.eventRecorder = fakeRecorder
+	// Allow a single event, which is not refilled within the test.
+	resolver.summaryEventLimiter = flowcontrol.NewTokenBucketRateLimiter(0.001, 1)
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	for _, name := range []string{"first", "second", "third"} {
+		createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "dns"},
+			Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: ocpnetworkapiv1alpha1.DNSName(name + ".example.com.")},
+		})
+	}
+
+	resolver.emitSummaryEvents(ctx)
+	resolver.emitSummaryEvents(ctx)
+
+	if len(fakeRecorder.Events) != 1 {
+		t.Fatalf("Expected 1 summary event within the rate limit, found %d", len(fakeRecorder.Events))
+	}
+	if event := <-fakeRecorder.Events; !strings.HasPrefix(event, "Normal Summary ") {
+		t.Fatalf("Expected a summary event, found %q", event)
+	}
+}
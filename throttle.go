@@ -0,0 +1,195 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// throttleStore is a resolverStore limiting the rate of the status updates of each
+// DNSNameResolver object, when maxUpdatesPerObject is configured, so that a single hot
+// object does not stress the API server and etcd with its writes. Each object has its own
+// token bucket of maxUpdatesPerObject tokens, refilled over updateRateInterval. The status
+// updates exceeding the rate are buffered, keeping only the last status of the object,
+// which is written at the next allowed slot. The reads return the buffered status of the
+// objects, so that the following status updates build on it. The other updates are
+// issued as usual.
+type throttleStore struct {
+	resolverStore
+	retrier *updateRetrier
+	qps     float32
+	burst   int
+	// limiters stores the token bucket of each object.
+	// key: DNSNameResolver object, value: rate limiter.
+	limiters map[types.NamespacedName]flowcontrol.RateLimiter
+	// pending stores the buffered statuses waiting for the next allowed slot.
+	// key: DNSNameResolver object, value: status.
+	pending map[types.NamespacedName]*ocpnetworkapiv1alpha1.DNSNameResolverStatus
+	// writing stores the statuses being written at their allowed slot, which are still
+	// read until the write completes.
+	writing map[types.NamespacedName]*ocpnetworkapiv1alpha1.DNSNameResolverStatus
+	lock    sync.Mutex
+	// ctx is cancelled once the store is drained, which stops waiting for the allowed slots.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+var _ resolverStore = &throttleStore{}
+
+// newThrottleStore returns a throttleStore limiting the status updates of each object
+// written through the wrapped resolverStore to maxUpdates per interval.
+func newThrottleStore(store resolverStore, retrier *updateRetrier, maxUpdates int, interval time.Duration) *throttleStore {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &throttleStore{
+		resolverStore: store,
+		retrier:       retrier,
+		qps:           float32(float64(maxUpdates) / interval.Seconds()),
+		burst:         maxUpdates,
+		limiters:      make(map[types.NamespacedName]flowcontrol.RateLimiter),
+		pending:       make(map[types.NamespacedName]*ocpnetworkapiv1alpha1.DNSNameResolverStatus),
+		writing:       make(map[types.NamespacedName]*ocpnetworkapiv1alpha1.DNSNameResolverStatus),
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+}
+
+// get implements resolverStore.
+func (store *throttleStore) get(ctx context.Context, namespace, name string) (*ocpnetworkapiv1alpha1.DNSNameResolver, error) {
+	resolverObj, err := store.resolverStore.get(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	object := types.NamespacedName{Namespace: namespace, Name: name}
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	if status, exists := store.pending[object]; exists {
+		status.DeepCopyInto(&resolverObj.Status)
+	} else if status, exists := store.writing[object]; exists {
+		status.DeepCopyInto(&resolverObj.Status)
+	}
+	return resolverObj, nil
+}
+
+// updateStatus implements resolverStore. The status is written right away if the rate
+// limit of the object allows it. Otherwise it is buffered until the next allowed slot.
+func (store *throttleStore) updateStatus(ctx context.Context, resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) error {
+	object := types.NamespacedName{Namespace: resolverObj.Namespace, Name: resolverObj.Name}
+
+	store.lock.Lock()
+	// If a status of the object is already waiting for its slot or being written then
+	// coalesce the status with it.
+	_, waiting := store.pending[object]
+	_, writing := store.writing[object]
+	if waiting || writing {
+		store.pending[object] = resolverObj.Status.DeepCopy()
+		store.lock.Unlock()
+		throttledUpdates.Inc()
+		return nil
+	}
+	limiter, exists := store.limiters[object]
+	if !exists {
+		limiter = flowcontrol.NewTokenBucketRateLimiter(store.qps, store.burst)
+		store.limiters[object] = limiter
+	}
+	if limiter.TryAccept() {
+		store.lock.Unlock()
+		return store.resolverStore.updateStatus(ctx, resolverObj)
+	}
+	store.pending[object] = resolverObj.Status.DeepCopy()
+	store.lock.Unlock()
+	throttledUpdates.Inc()
+
+	log.Debugf("Throttling the status updates of DNSNameResolver object %s/%s until the next allowed slot", object.Namespace, object.Name)
+	go store.writeThrottled(object, limiter)
+	return nil
+}
+
+// writeThrottled writes the buffered status of the object at each allowed slot of its rate
+// limiter, as long as a status is buffered, until the store is drained.
+func (store *throttleStore) writeThrottled(object types.NamespacedName, limiter flowcontrol.RateLimiter) {
+	for {
+		if err := limiter.Wait(store.ctx); err != nil {
+			// The store is drained, which writes the buffered status.
+			return
+		}
+
+		store.lock.Lock()
+		status, exists := store.pending[object]
+		if !exists {
+			store.lock.Unlock()
+			return
+		}
+		delete(store.pending, object)
+		store.writing[object] = status
+		store.lock.Unlock()
+
+		store.write(context.Background(), object, status)
+
+		store.lock.Lock()
+		delete(store.writing, object)
+		_, exists = store.pending[object]
+		store.lock.Unlock()
+		if !exists {
+			return
+		}
+	}
+}
+
+// write writes the status of the object through the wrapped resolverStore. The status of
+// a deleted object is dropped.
+func (store *throttleStore) write(ctx context.Context, object types.NamespacedName, status *ocpnetworkapiv1alpha1.DNSNameResolverStatus) {
+	// Retry the update of the DNSNameResolver object if there's a conflict or a transient error during the update.
+	store.retrier.retryUpdate(object.Namespace, object.Name, "throttled status", func() error {
+		// Fetch a copy of the DNSNameResolver object. All the updates will be applied to the copied object.
+		resolverObj, err := store.resolverStore.get(ctx, object.Namespace, object.Name)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		status.DeepCopyInto(&resolverObj.Status)
+
+		// Update the status of the DNSNameResolver object.
+		return store.resolverStore.updateStatus(ctx, resolverObj)
+	})
+}
+
+// forget drops the rate limiter of the DNSNameResolver object, eg. once it is deleted.
+func (store *throttleStore) forget(object types.NamespacedName) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	delete(store.limiters, object)
+}
+
+// drain stops waiting for the allowed slots and writes the buffered statuses right away,
+// ordered by namespace and name, eg. on shutdown.
+func (store *throttleStore) drain(ctx context.Context) {
+	store.cancel()
+
+	store.lock.Lock()
+	pending := store.pending
+	store.pending = make(map[types.NamespacedName]*ocpnetworkapiv1alpha1.DNSNameResolverStatus)
+	store.lock.Unlock()
+
+	objects := make([]types.NamespacedName, 0, len(pending))
+	for object := range pending {
+		objects = append(objects, object)
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].Namespace != objects[j].Namespace {
+			return objects[i].Namespace < objects[j].Namespace
+		}
+		return objects[i].Name < objects[j].Name
+	})
+	for _, object := range objects {
+		store.write(ctx, object, pending[object])
+	}
+}
@@ -0,0 +1,150 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// serveThrottledQuery serves the A query of www.example.com. answered with the IP address.
+func serveThrottledQuery(ctx context.Context, resolver *OCPDNSNameResolver, ip string) {
+	query := test.Case{
+		Qname:  "www.example.com.",
+		Qtype:  dns.TypeA,
+		Rcode:  dns.RcodeSuccess,
+		Answer: []dns.RR{test.A("www.example.com. 30 IN A " + ip)},
+	}
+	resolver.Next = fakeNextPluginHandler(query)
+	resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+}
+
+func TestMaxUpdatesPerObjectBurst(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.maxUpdatesPerObject = 2
+	resolver.updateRateInterval = 400 * time.Millisecond
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+	defer resolver.throttler.cancel()
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+	})
+
+	throttledBefore := testutil.ToFloat64(throttledUpdates)
+	ips := []string{"1.1.1.1", "1.1.1.2", "1.1.1.3", "1.1.1.4", "1.1.1.5"}
+	for _, ip := range ips {
+		serveThrottledQuery(ctx, resolver, ip)
+	}
+
+	// The burst of the token bucket is written right away and the other status updates
+	// are coalesced until the next allowed slot.
+	if count := countStatusUpdates(fakeNetworkClient); count != 2 {
+		t.Fatalf("Expected 2 status updates within the burst, found %d", count)
+	}
+	if throttled := testutil.ToFloat64(throttledUpdates) - throttledBefore; throttled != 3 {
+		t.Fatalf("Expected 3 throttled status updates, found %v", throttled)
+	}
+	resolverObj, err := resolver.store.get(ctx, "dns", "regular")
+	if err != nil {
+		t.Fatalf("Unexpected error getting DNSNameResolver object: %v", err)
+	}
+	if !slices.Contains(resolvedIPs(resolverObj, "www.example.com."), "1.1.1.5") {
+		t.Fatalf("Expected the coalesced status to contain the last IP address, found %v", resolvedIPs(resolverObj, "www.example.com."))
+	}
+
+	// The coalesced status updates are written once, at the next allowed slot.
+	err = wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 2*time.Second, true, func(context.Context) (bool, error) {
+		return countStatusUpdates(fakeNetworkClient) == 3, nil
+	})
+	if err != nil {
+		t.Fatalf("Expected the coalesced status updates to be written, found %d status updates", countStatusUpdates(fakeNetworkClient))
+	}
+	resolverObj, err = fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("dns").Get(ctx, "regular", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error getting DNSNameResolver object: %v", err)
+	}
+	if !slices.Contains(resolvedIPs(resolverObj, "www.example.com."), "1.1.1.5") {
+		t.Fatalf("Expected the written status to contain the last IP address, found %v", resolvedIPs(resolverObj, "www.example.com."))
+	}
+	time.Sleep(300 * time.Millisecond)
+	if count := countStatusUpdates(fakeNetworkClient); count != 3 {
+		t.Fatalf("Expected no more status update once the coalesced status is written, found %d", count)
+	}
+}
+
+func TestMaxUpdatesPerObjectSteadyState(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.maxUpdatesPerObject = 2
+	resolver.updateRateInterval = 200 * time.Millisecond
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+	defer resolver.throttler.cancel()
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+	})
+
+	// The status updates within the rate pass through right away.
+	throttledBefore := testutil.ToFloat64(throttledUpdates)
+	for i, ip := range []string{"1.1.1.1", "1.1.1.2", "1.1.1.3", "1.1.1.4"} {
+		if i > 0 {
+			time.Sleep(150 * time.Millisecond)
+		}
+		serveThrottledQuery(ctx, resolver, ip)
+		if count := countStatusUpdates(fakeNetworkClient); count != i+1 {
+			t.Fatalf("Expected %d status updates, found %d", i+1, count)
+		}
+	}
+	if throttled := testutil.ToFloat64(throttledUpdates) - throttledBefore; throttled != 0 {
+		t.Fatalf("Expected no throttled status update, found %v", throttled)
+	}
+}
+
+func TestMaxUpdatesPerObjectDrain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.maxUpdatesPerObject = 1
+	resolver.updateRateInterval = time.Hour
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+	})
+
+	serveThrottledQuery(ctx, resolver, "1.1.1.1")
+	serveThrottledQuery(ctx, resolver, "1.1.1.2")
+	if count := countStatusUpdates(fakeNetworkClient); count != 1 {
+		t.Fatalf("Expected 1 status update before the drain, found %d", count)
+	}
+
+	// The drain writes the coalesced status right away.
+	resolver.throttler.drain(ctx)
+	if count := countStatusUpdates(fakeNetworkClient); count != 2 {
+		t.Fatalf("Expected 2 status updates after the drain, found %d", count)
+	}
+	resolverObj, err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers("dns").Get(ctx, "regular", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error getting DNSNameResolver object: %v", err)
+	}
+	if !slices.Contains(resolvedIPs(resolverObj, "www.example.com."), "1.1.1.2") {
+		t.Fatalf("Expected the drained status to contain the last IP address, found %v", resolvedIPs(resolverObj, "www.example.com."))
+	}
+}
@@ -0,0 +1,25 @@
+package ocp_dnsnameresolver
+
+// truncatedPolicy determines how the DNS lookup responses with the TC (truncated)
+// bit set are handled. A truncated response may contain only a subset of the
+// DNS records of the DNS name.
+type truncatedPolicy string
+
+const (
+	// truncatedPolicySkip skips the recording of truncated responses. The skipped
+	// responses are not counted as failures either.
+	truncatedPolicySkip truncatedPolicy = "skip"
+	// truncatedPolicyRecord records the DNS records present in the truncated
+	// responses.
+	truncatedPolicyRecord truncatedPolicy = "record"
+)
+
+// parseTruncatedPolicy returns the truncatedPolicy corresponding to the given
+// value and whether the value is a valid truncatedPolicy.
+func parseTruncatedPolicy(value string) (truncatedPolicy, bool) {
+	switch policy := truncatedPolicy(value); policy {
+	case truncatedPolicySkip, truncatedPolicyRecord:
+		return policy, true
+	}
+	return "", false
+}
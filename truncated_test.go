@@ -0,0 +1,128 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	ocpnetworkfakeclient "github.com/openshift/client-go/network/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeTruncatedNextPluginHandler is a fake implementation which returns a truncated response
+// with the DNS records of the test case.
+func fakeTruncatedNextPluginHandler(tc test.Case) plugin.Handler {
+	return plugin.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+		m := new(dns.Msg)
+		m.SetQuestion(tc.Qname, tc.Qtype)
+		m.Response = true
+		m.Truncated = true
+		m.Rcode = tc.Rcode
+		m.Answer = append(m.Answer, tc.Answer...)
+		w.WriteMsg(m)
+		return tc.Rcode, nil
+	})
+}
+
+// countStatusUpdates returns the number of status updates performed using the fake client.
+func countStatusUpdates(fakeNetworkClient *ocpnetworkfakeclient.Clientset) int {
+	count := 0
+	for _, action := range fakeNetworkClient.Actions() {
+		if action.GetVerb() == "update" && action.GetSubresource() == "status" {
+			count++
+		}
+	}
+	return count
+}
+
+func TestServeDNSTruncatedPolicy(t *testing.T) {
+	tests := []struct {
+		name                  string
+		truncatedPolicy       truncatedPolicy
+		query                 test.Case
+		expectedStatusUpdates int
+	}{
+		{
+			name:            "Truncated successful response is not recorded by default",
+			truncatedPolicy: defaultTruncatedPolicy,
+			query: test.Case{
+				Qname: "www.example.com.",
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A("www.example.com. 30 IN A 1.1.1.1"),
+				},
+			},
+			expectedStatusUpdates: 0,
+		},
+		{
+			name:            "Truncated failed response is not counted as a failure by default",
+			truncatedPolicy: defaultTruncatedPolicy,
+			query: test.Case{
+				Qname: "www.example.com.",
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeServerFailure,
+			},
+			expectedStatusUpdates: 0,
+		},
+		{
+			name:            "Truncated successful response is recorded with record policy",
+			truncatedPolicy: truncatedPolicyRecord,
+			query: test.Case{
+				Qname: "www.example.com.",
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A("www.example.com. 30 IN A 1.1.1.1"),
+				},
+			},
+			expectedStatusUpdates: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.truncatedPolicy = tc.truncatedPolicy
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+				Status: ocpnetworkapiv1alpha1.DNSNameResolverStatus{
+					ResolvedNames: []ocpnetworkapiv1alpha1.DNSNameResolverResolvedName{
+						{
+							DNSName: "www.example.com.",
+							ResolvedAddresses: []ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{
+								{
+									IP:             "1.1.1.2",
+									TTLSeconds:     30,
+									LastLookupTime: &metav1.Time{},
+								},
+							},
+						},
+					},
+				},
+			})
+
+			resolver.Next = fakeTruncatedNextPluginHandler(tc.query)
+			resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), tc.query.Msg())
+
+			if count := countStatusUpdates(fakeNetworkClient); count != tc.expectedStatusUpdates {
+				t.Fatalf("Expected %d status updates, found %d", tc.expectedStatusUpdates, count)
+			}
+		})
+	}
+}
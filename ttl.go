@@ -0,0 +1,95 @@
+package ocp_dnsnameresolver
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+)
+
+const (
+	// maxRecordedTTL is the maximum TTL which can be recorded in the ttlSeconds field of
+	// the resolved addresses of the DNSNameResolver API, which is an int32. It matches the
+	// maximum TTL of RFC 2181.
+	maxRecordedTTL = math.MaxInt32
+)
+
+// randInt31n returns a random number in the range [0, n). It is a variable
+// so that tests can replace it.
+var randInt31n = rand.Int31n
+
+// recordedTTL returns the TTL to be recorded in the status of the DNSNameResolver
+// objects for the TTL received in the DNS lookup response of the DNS name. The
+// TTLTransformer, if set, is applied to the received TTL first. If the DNS name belongs
+// to a zone configured with zoneTTL, the TTL is clamped to the bounds of the policy of
+// the zone, whose minimum TTL then replaces the global one. Otherwise, a zero TTL is
+// replaced by the minimum TTL. If TTL jitter is configured, a random amount of at most
+// ttlJitter seconds is subtracted from the TTL, so that the consumers refreshing
+// the IP addresses based on the recorded TTL do not synchronize. The jittered
+// TTL never drops below the minimum TTL. The policies are applied to the TTL as an int64,
+// so that they never overflow. If clampTTL is enabled, the TTL is finally clamped to the
+// range of the ttlSeconds field of the DNSNameResolver API, as the TTLs above it would
+// otherwise overflow into negative TTLs rejected by the API server.
+func (resolver *OCPDNSNameResolver) recordedTTL(dnsName string, responseTTL uint32) int32 {
+	if resolver.TTLTransformer != nil {
+		responseTTL = resolver.TTLTransformer(dnsName, responseTTL)
+	}
+	ttl := int64(responseTTL)
+	minimumTTL := int64(resolver.minimumTTL)
+	if policy, ok := resolver.zoneTTLPolicy(dnsName); ok {
+		ttl = policy.clamp(ttl)
+		minimumTTL = int64(policy.minTTL)
+	}
+	if ttl == 0 {
+		ttl = minimumTTL
+	}
+
+	if resolver.ttlJitter > 0 && ttl > minimumTTL {
+		ttl -= int64(randInt31n(resolver.ttlJitter + 1))
+		if ttl < minimumTTL {
+			ttl = minimumTTL
+		}
+	}
+
+	if resolver.clampTTL && ttl > maxRecordedTTL {
+		log.Warningf("Clamping TTL %d of DNS name %s to the maximum TTL %d of the DNSNameResolver API", ttl, dnsName, maxRecordedTTL)
+		ttl = maxRecordedTTL
+	}
+	return int32(ttl)
+}
+
+// lookupTimeMargin returns the margin within which the next lookup times of an IP address
+// are considered the same. The margin is widened by ttlJitter, as the TTLs recorded for the
+// same IP address differ by up to ttlJitter seconds from one DNS lookup to the next, so that
+// the jitter is only recorded when an IP address is added or its TTL really changes.
+func (resolver *OCPDNSNameResolver) lookupTimeMargin() time.Duration {
+	return nextLookupTimeMargin + time.Duration(resolver.ttlJitter)*time.Second
+}
+
+// servedTTL returns the TTL with which a recorded IP address may be served at the given
+// time, eg. when answering from the status of the DNSNameResolver objects instead of
+// the plugin chain. The served TTL is the minimum of the remaining lifetime of the IP
+// address (last lookup time + recorded TTL - now) and of its recorded TTL, thus it is
+// never longer than the freshness of the recorded data. In particular, the minimum TTL
+// is not applied: it is only used for the recorded TTLs, so that the consumers of the
+// status do not refresh the IP addresses too often, while a served TTL raised to the
+// minimum TTL would let the clients cache an IP address beyond its lifetime. An expired
+// IP address gives a zero TTL.
+func servedTTL(resolvedAddress ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress, now time.Time) uint32 {
+	if resolvedAddress.TTLSeconds <= 0 || resolvedAddress.LastLookupTime == nil {
+		return 0
+	}
+
+	remaining := resolvedAddress.LastLookupTime.Add(time.Duration(resolvedAddress.TTLSeconds) * time.Second).Sub(now)
+	if remaining <= 0 {
+		return 0
+	}
+	// The remaining lifetime may only be longer than the recorded TTL if the last lookup
+	// time is in the future, eg. due to a clock skew with the writer of the status.
+	ttl := uint32(remaining / time.Second)
+	if ttl > uint32(resolvedAddress.TTLSeconds) {
+		ttl = uint32(resolvedAddress.TTLSeconds)
+	}
+	return ttl
+}
@@ -0,0 +1,517 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRecordedTTL(t *testing.T) {
+	tests := []struct {
+		name        string
+		minimumTTL  int32
+		ttlJitter   int32
+		clampTTL    bool
+		responseTTL uint32
+		expectedMin int32
+		expectedMax int32
+	}{
+		{
+			name:        "Response TTL is recorded without jitter",
+			minimumTTL:  5,
+			responseTTL: 30,
+			expectedMin: 30,
+			expectedMax: 30,
+		},
+		{
+			name:        "Zero response TTL is replaced by minimum TTL",
+			minimumTTL:  5,
+			responseTTL: 0,
+			expectedMin: 5,
+			expectedMax: 5,
+		},
+		{
+			name:        "Jitter is subtracted from response TTL",
+			minimumTTL:  5,
+			ttlJitter:   10,
+			responseTTL: 30,
+			expectedMin: 20,
+			expectedMax: 30,
+		},
+		{
+			name:        "Jittered TTL never drops below minimum TTL",
+			minimumTTL:  5,
+			ttlJitter:   10,
+			responseTTL: 8,
+			expectedMin: 5,
+			expectedMax: 8,
+		},
+		{
+			name:        "Jitter is not applied to minimum TTL",
+			minimumTTL:  5,
+			ttlJitter:   10,
+			responseTTL: 0,
+			expectedMin: 5,
+			expectedMax: 5,
+		},
+		{
+			name:        "Over-range response TTL is clamped to the maximum TTL of the API",
+			minimumTTL:  5,
+			clampTTL:    true,
+			responseTTL: math.MaxUint32,
+			expectedMin: math.MaxInt32,
+			expectedMax: math.MaxInt32,
+		},
+		{
+			name:        "Response TTL just above the range of the API is clamped instead of overflowing",
+			minimumTTL:  5,
+			clampTTL:    true,
+			responseTTL: math.MaxInt32 + 1,
+			expectedMin: math.MaxInt32,
+			expectedMax: math.MaxInt32,
+		},
+		{
+			name:        "Jittered over-range TTL is clamped to the maximum TTL of the API",
+			minimumTTL:  5,
+			ttlJitter:   10,
+			clampTTL:    true,
+			responseTTL: math.MaxUint32,
+			expectedMin: math.MaxInt32,
+			expectedMax: math.MaxInt32,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := New()
+			resolver.minimumTTL = tc.minimumTTL
+			resolver.ttlJitter = tc.ttlJitter
+			resolver.clampTTL = tc.clampTTL
+
+			for i := 0; i < 100; i++ {
+				ttl := resolver.recordedTTL("www.example.com.", tc.responseTTL)
+				if ttl < tc.expectedMin || ttl > tc.expectedMax {
+					t.Fatalf("Recorded TTL %d is not within the expected bounds [%d, %d]", ttl, tc.expectedMin, tc.expectedMax)
+				}
+			}
+		})
+	}
+}
+
+func TestRecordedTTLZonePolicy(t *testing.T) {
+	resolver := New()
+	resolver.minimumTTL = 5
+	resolver.zoneTTLs = map[string]zoneTTLPolicy{
+		"example.com.":     {minTTL: 10, maxTTL: 60},
+		"api.example.com.": {minTTL: 1, maxTTL: 5},
+	}
+
+	tests := []struct {
+		name        string
+		dnsName     string
+		responseTTL uint32
+		expectedTTL int32
+	}{
+		{
+			name:        "TTL within the bounds of the zone is kept",
+			dnsName:     "www.example.com.",
+			responseTTL: 30,
+			expectedTTL: 30,
+		},
+		{
+			name:        "TTL below the minimum of the zone is raised",
+			dnsName:     "www.example.com.",
+			responseTTL: 3,
+			expectedTTL: 10,
+		},
+		{
+			name:        "TTL above the maximum of the zone is lowered",
+			dnsName:     "www.example.com.",
+			responseTTL: 300,
+			expectedTTL: 60,
+		},
+		{
+			name:        "Zero TTL is replaced by the minimum of the zone",
+			dnsName:     "www.example.com.",
+			responseTTL: 0,
+			expectedTTL: 10,
+		},
+		{
+			name:        "Apex of the zone uses the policy of the zone",
+			dnsName:     "EXAMPLE.com.",
+			responseTTL: 300,
+			expectedTTL: 60,
+		},
+		{
+			name:        "Nested zone overrides the policy of the parent zone",
+			dnsName:     "v1.api.example.com.",
+			responseTTL: 30,
+			expectedTTL: 5,
+		},
+		{
+			name:        "Zero TTL in the nested zone is replaced by its minimum",
+			dnsName:     "api.example.com.",
+			responseTTL: 0,
+			expectedTTL: 1,
+		},
+		{
+			name:        "Wildcard DNS name uses the policy of its zone",
+			dnsName:     "*.api.example.com.",
+			responseTTL: 30,
+			expectedTTL: 5,
+		},
+		{
+			name:        "DNS name sharing a suffix with the zone uses the global policy",
+			dnsName:     "www.notexample.com.",
+			responseTTL: 300,
+			expectedTTL: 300,
+		},
+		{
+			name:        "Zero TTL outside of the zones is replaced by the global minimum",
+			dnsName:     "www.example.org.",
+			responseTTL: 0,
+			expectedTTL: 5,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if ttl := resolver.recordedTTL(tc.dnsName, tc.responseTTL); ttl != tc.expectedTTL {
+				t.Fatalf("Expected recorded TTL %d, found %d", tc.expectedTTL, ttl)
+			}
+		})
+	}
+}
+
+func TestRecordedTTLJitterBounds(t *testing.T) {
+	defer func() { randInt31n = rand.Int31n }()
+
+	resolver := New()
+	resolver.minimumTTL = 5
+	resolver.ttlJitter = 10
+
+	// The largest possible jitter is subtracted from the TTL.
+	randInt31n = func(n int32) int32 { return n - 1 }
+	if ttl := resolver.recordedTTL("www.example.com.", 30); ttl != 20 {
+		t.Fatalf("Expected recorded TTL to be 20 with the largest jitter, found %d", ttl)
+	}
+
+	// The smallest possible jitter is subtracted from the TTL.
+	randInt31n = func(n int32) int32 { return 0 }
+	if ttl := resolver.recordedTTL("www.example.com.", 30); ttl != 30 {
+		t.Fatalf("Expected recorded TTL to be 30 with the smallest jitter, found %d", ttl)
+	}
+}
+
+func TestRecordedTTLClampedLast(t *testing.T) {
+	defer func() { randInt31n = rand.Int31n }()
+
+	resolver := New()
+	resolver.minimumTTL = 5
+	resolver.ttlJitter = 10
+	// The largest possible jitter is subtracted from the TTL.
+	randInt31n = func(n int32) int32 { return n - 1 }
+
+	// The jittered TTL is still above the range of the API, thus it is clamped to the
+	// maximum TTL of the API, which would be jittered if it were clamped first.
+	resolver.clampTTL = true
+	if ttl := resolver.recordedTTL("www.example.com.", math.MaxInt32+20); ttl != math.MaxInt32 {
+		t.Fatalf("Expected recorded TTL to be clamped to %d after the jitter, found %d", math.MaxInt32, ttl)
+	}
+	// The jittered TTL within the range of the API is not clamped.
+	if ttl := resolver.recordedTTL("www.example.com.", math.MaxInt32+9); ttl != math.MaxInt32-1 {
+		t.Fatalf("Expected recorded TTL to be %d, found %d", math.MaxInt32-1, ttl)
+	}
+
+	// The TTL is not clamped by default.
+	resolver.clampTTL = false
+	if ttl := resolver.recordedTTL("www.example.com.", math.MaxInt32+20); ttl == math.MaxInt32 {
+		t.Fatalf("Expected recorded TTL not to be clamped without clampTTL")
+	}
+}
+
+func TestServeDNSTTLJitterUnchangedAddresses(t *testing.T) {
+	defer func() { randInt31n = rand.Int31n }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.ttlJitter = 20
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+		ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+		Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+	})
+
+	query := test.Case{
+		Qname: "www.example.com.",
+		Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("www.example.com. 300 IN A 1.1.1.1"),
+		},
+	}
+	resolver.Next = fakeNextPluginHandler(query)
+
+	// Each of the identical DNS lookups draws a different jitter, up to ttlJitter.
+	for i, jitter := range []int32{0, 20, 10, 20} {
+		randInt31n = func(n int32) int32 { return jitter }
+		resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+		getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+			return len(obj.Status.ResolvedNames) == 1
+		})
+		if count := countStatusUpdates(fakeNetworkClient); count != 1 {
+			t.Fatalf("Lookup %d: Expected only the first DNS lookup to update the status, found %d status updates", i, count)
+		}
+	}
+}
+
+func TestRecordedTTLTransformer(t *testing.T) {
+	resolver := New()
+	resolver.minimumTTL = 5
+
+	var transformedNames []string
+	resolver.TTLTransformer = func(name string, ttl uint32) uint32 {
+		transformedNames = append(transformedNames, name)
+		if name == "www.example.com." {
+			return ttl * 2
+		}
+		return 0
+	}
+
+	if ttl := resolver.recordedTTL("www.example.com.", 30); ttl != 60 {
+		t.Fatalf("Expected recorded TTL to be the transformed TTL 60, found %d", ttl)
+	}
+	// The transformed TTL is clamped to the minimum TTL.
+	if ttl := resolver.recordedTTL("www.example.org.", 30); ttl != 5 {
+		t.Fatalf("Expected recorded TTL to be the minimum TTL 5, found %d", ttl)
+	}
+	if len(transformedNames) != 2 || transformedNames[0] != "www.example.com." || transformedNames[1] != "www.example.org." {
+		t.Fatalf("Expected the transformer to be invoked for both DNS names, found %v", transformedNames)
+	}
+}
+
+func TestServeDNSOverRangeTTL(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.clampTTL = true
+	// The transformed TTL is clamped too.
+	resolver.TTLTransformer = func(name string, ttl uint32) uint32 {
+		if name == "www.example.org." {
+			return math.MaxUint32
+		}
+		return ttl
+	}
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+	for name, dnsName := range map[string]string{"regular": "www.example.com.", "transformed": "www.example.org."} {
+		createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "dns"},
+			Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: ocpnetworkapiv1alpha1.DNSName(dnsName)},
+		})
+
+		query := test.Case{
+			Qname: dnsName,
+			Qtype: dns.TypeA,
+			Rcode: dns.RcodeSuccess,
+			Answer: []dns.RR{
+				test.A(dnsName + " 4294967295 IN A 1.1.1.1"),
+			},
+		}
+		resolver.Next = fakeNextPluginHandler(query)
+		resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+		resolverObj := getResolverObject(t, resolver, "dns", name, func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+			return len(obj.Status.ResolvedNames) == 1
+		})
+		if len(resolverObj.Status.ResolvedNames) != 1 || len(resolverObj.Status.ResolvedNames[0].ResolvedAddresses) != 1 {
+			t.Fatalf("Expected a single resolved address for %s, found status %v", dnsName, resolverObj.Status)
+		}
+		if ttl := resolverObj.Status.ResolvedNames[0].ResolvedAddresses[0].TTLSeconds; ttl != math.MaxInt32 {
+			t.Fatalf("Expected the over-range TTL of %s to be clamped to %d, found %d", dnsName, math.MaxInt32, ttl)
+		}
+	}
+}
+
+func TestServedTTL(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name           string
+		ttlSeconds     int32
+		sinceLookup    time.Duration
+		noLookupTime   bool
+		expectedServed uint32
+	}{
+		{
+			name:           "Fresh IP address is served with its recorded TTL",
+			ttlSeconds:     30,
+			sinceLookup:    0,
+			expectedServed: 30,
+		},
+		{
+			name:           "IP address is served with its remaining lifetime",
+			ttlSeconds:     30,
+			sinceLookup:    20 * time.Second,
+			expectedServed: 10,
+		},
+		{
+			name:           "Remaining lifetime is rounded down",
+			ttlSeconds:     30,
+			sinceLookup:    20*time.Second + 500*time.Millisecond,
+			expectedServed: 9,
+		},
+		{
+			name:           "Expired IP address is served with zero TTL",
+			ttlSeconds:     30,
+			sinceLookup:    time.Minute,
+			expectedServed: 0,
+		},
+		{
+			name:           "Served TTL is capped at the recorded TTL with a future last lookup time",
+			ttlSeconds:     30,
+			sinceLookup:    -time.Minute,
+			expectedServed: 30,
+		},
+		{
+			name:           "IP address without last lookup time is served with zero TTL",
+			ttlSeconds:     30,
+			noLookupTime:   true,
+			expectedServed: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resolvedAddress := ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{
+				IP:         "1.1.1.1",
+				TTLSeconds: tc.ttlSeconds,
+			}
+			if !tc.noLookupTime {
+				lastLookupTime := metav1.NewTime(now.Add(-tc.sinceLookup))
+				resolvedAddress.LastLookupTime = &lastLookupTime
+			}
+			if served := servedTTL(resolvedAddress, now); served != tc.expectedServed {
+				t.Fatalf("Expected served TTL %d, found %d", tc.expectedServed, served)
+			}
+		})
+	}
+}
+
+func TestServedTTLIgnoresMinimumTTL(t *testing.T) {
+	now := time.Now()
+	resolver := New()
+	resolver.minimumTTL = 60
+
+	// The IP address is recorded with the minimum TTL for a zero response TTL, but it is
+	// never served beyond its remaining lifetime.
+	lastLookupTime := metav1.NewTime(now.Add(-50 * time.Second))
+	resolvedAddress := ocpnetworkapiv1alpha1.DNSNameResolverResolvedAddress{
+		IP:             "1.1.1.1",
+		TTLSeconds:     resolver.recordedTTL("www.example.com.", 0),
+		LastLookupTime: &lastLookupTime,
+	}
+	if served := servedTTL(resolvedAddress, now); served != 10 {
+		t.Fatalf("Expected served TTL 10, found %d", served)
+	}
+}
+
+func TestServeDNSMinRemainingTTL(t *testing.T) {
+	tests := []struct {
+		name                  string
+		minRemainingTTL       uint32
+		answer                []dns.RR
+		expectedStatusUpdates int
+	}{
+		{
+			name: "Answer with a low remaining TTL is recorded by default",
+			answer: []dns.RR{
+				test.A("www.example.com. 1 IN A 1.1.1.1"),
+			},
+			expectedStatusUpdates: 1,
+		},
+		{
+			name:            "Answer just above the minimum remaining TTL is recorded",
+			minRemainingTTL: 10,
+			answer: []dns.RR{
+				test.A("www.example.com. 11 IN A 1.1.1.1"),
+			},
+			expectedStatusUpdates: 1,
+		},
+		{
+			name:            "Answer at the minimum remaining TTL is recorded",
+			minRemainingTTL: 10,
+			answer: []dns.RR{
+				test.A("www.example.com. 10 IN A 1.1.1.1"),
+			},
+			expectedStatusUpdates: 1,
+		},
+		{
+			name:            "Answer just below the minimum remaining TTL is not recorded",
+			minRemainingTTL: 10,
+			answer: []dns.RR{
+				test.A("www.example.com. 9 IN A 1.1.1.1"),
+			},
+			expectedStatusUpdates: 0,
+		},
+		{
+			name:            "Answer with any record below the minimum remaining TTL is not recorded",
+			minRemainingTTL: 10,
+			answer: []dns.RR{
+				test.A("www.example.com. 30 IN A 1.1.1.1"),
+				test.A("www.example.com. 9 IN A 1.1.1.2"),
+			},
+			expectedStatusUpdates: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.minRemainingTTL = tc.minRemainingTTL
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			})
+
+			query := test.Case{
+				Qname:  "www.example.com.",
+				Qtype:  dns.TypeA,
+				Rcode:  dns.RcodeSuccess,
+				Answer: tc.answer,
+			}
+			resolver.Next = fakeNextPluginHandler(query)
+
+			w := dnstest.NewRecorder(&test.ResponseWriter{})
+			resolver.ServeDNS(ctx, w, query.Msg())
+			if w.Msg == nil || len(w.Msg.Answer) != len(tc.answer) {
+				t.Fatalf("Expected the response of the next plugin to be served, found: %v", w.Msg)
+			}
+			if count := countStatusUpdates(fakeNetworkClient); count != tc.expectedStatusUpdates {
+				t.Fatalf("Expected %d status updates, found %d", tc.expectedStatusUpdates, count)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package ocp_dnsnameresolver
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	// updateRetryWorkers gives the number of the workers of the updateRetrier, i.e. the
+	// maximum number of the updates failed with a transient error retried concurrently.
+	updateRetryWorkers = 2
+)
+
+// transientErrorBackoff is the backoff used for retrying the updates of the DNSNameResolver
+// objects which failed with a transient error: the delay starts at Duration and doubles
+// at each retry, up to Cap, for at most Steps retries. It is a variable so that tests can
+// replace it.
+var transientErrorBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 100 * time.Millisecond,
+	Cap:      2 * time.Second,
+}
+
+// isTransientError checks if the error returned by the API server is transient, i.e. if
+// retrying the request may succeed. The errors of etcd for the objects exceeding its size
+// limit are returned as internal errors, they are not transient though.
+func isTransientError(err error) bool {
+	if isRequestTooLargeError(err) {
+		return false
+	}
+	return apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsInternalError(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsUnexpectedServerError(err)
+}
+
+// isPermanentError checks if the error returned by the API server is permanent, i.e. if
+// retrying the request will not help.
+func isPermanentError(err error) bool {
+	return apierrors.IsForbidden(err) ||
+		apierrors.IsUnauthorized(err) ||
+		apierrors.IsInvalid(err) ||
+		apierrors.IsBadRequest(err) ||
+		apierrors.IsMethodNotSupported(err) ||
+		isRequestTooLargeError(err)
+}
+
+// updateRetryKey identifies the updates of a DNSNameResolver object retried by the
+// updateRetrier.
+type updateRetryKey struct {
+	object      types.NamespacedName
+	description string
+}
+
+// updateRetrier retries in the background the updates of the DNSNameResolver objects which
+// failed with a transient error, so that the DNS lookups are not held while the API server
+// is overloaded or unavailable. The updates are queued by object and description, so that
+// only the last update of each is retried, and they are retried with transientErrorBackoff
+// by a fixed number of workers, until the retrier is stopped.
+type updateRetrier struct {
+	queue workqueue.RateLimitingInterface
+	// updates stores the update functions of the queued updates.
+	// key: object and description of the update, value: update function.
+	updates map[updateRetryKey]func() error
+	lock    sync.Mutex
+}
+
+// newUpdateRetrier returns an initialized updateRetrier.
+func newUpdateRetrier() *updateRetrier {
+	return &updateRetrier{
+		queue:   workqueue.NewRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(transientErrorBackoff.Duration, transientErrorBackoff.Cap)),
+		updates: make(map[updateRetryKey]func() error),
+	}
+}
+
+// retryUpdate calls the update function of the DNSNameResolver object. The update is retried
+// if there's a conflict during the update. If the update fails with a transient error, it is
+// queued for a retry in the background and the transient error is returned right away. The
+// update is dropped if it fails with a permanent error, or with a transient error without a
+// retrier. The description is used for logging the errors. The error of the last attempt is
+// returned, it is already logged.
+func (retrier *updateRetrier) retryUpdate(namespace, name, description string, update func() error) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, update)
+	if err == nil {
+		return nil
+	}
+	if isTransientError(err) {
+		statusUpdateErrors.WithLabelValues(errorClassTransient).Inc()
+		if retrier != nil {
+			log.Debugf("Retrying update of %s of DNSNameResolver object %s/%s in the background: %v", description, namespace, name, err)
+			retrier.enqueue(updateRetryKey{object: types.NamespacedName{Namespace: namespace, Name: name}, description: description}, update)
+			return err
+		}
+	}
+	return handleUpdateError(namespace, name, description, err)
+}
+
+// enqueue queues the update for a retry after the backoff of its key. A queued update of
+// the same key is replaced by the given one.
+func (retrier *updateRetrier) enqueue(key updateRetryKey, update func() error) {
+	retrier.lock.Lock()
+	retrier.updates[key] = update
+	retrier.lock.Unlock()
+	retrier.queue.AddRateLimited(key)
+}
+
+// run runs the workers of the retrier until the stop channel is closed.
+func (retrier *updateRetrier) run(stopCh <-chan struct{}) {
+	for i := 0; i < updateRetryWorkers; i++ {
+		go wait.Until(func() {
+			for retrier.processNext() {
+			}
+		}, time.Second, stopCh)
+	}
+}
+
+// stop stops the retrier: the queued updates are dropped and the workers exit once their
+// current update completes.
+func (retrier *updateRetrier) stop() {
+	retrier.queue.ShutDown()
+}
+
+// processNext retries the next queued update. The update is queued again with backoff if
+// it fails with a transient error again, unless its retries are exhausted. It returns false
+// once the retrier is stopped.
+func (retrier *updateRetrier) processNext() bool {
+	item, shutdown := retrier.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer retrier.queue.Done(item)
+
+	key := item.(updateRetryKey)
+	retrier.lock.Lock()
+	update, exists := retrier.updates[key]
+	delete(retrier.updates, key)
+	retrier.lock.Unlock()
+	if !exists {
+		return true
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, update)
+	if err != nil && isTransientError(err) && retrier.queue.NumRequeues(key) < transientErrorBackoff.Steps-1 {
+		statusUpdateErrors.WithLabelValues(errorClassTransient).Inc()
+		retrier.lock.Lock()
+		// Keep the update queued meanwhile, if any, as it is more recent.
+		if _, exists := retrier.updates[key]; !exists {
+			retrier.updates[key] = update
+		}
+		retrier.lock.Unlock()
+		retrier.queue.AddRateLimited(key)
+		return true
+	}
+	retrier.queue.Forget(key)
+	handleUpdateError(key.object.Namespace, key.object.Name, key.description, err)
+	return true
+}
+
+// handleUpdateError logs the error of the last attempt of the update of the DNSNameResolver
+// object, if any, and returns it.
+func handleUpdateError(namespace, name, description string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	// The circuit breaker already logged that it is open, and the start of the maintenance
+	// window is logged too, thus the rejected updates are not logged again.
+	if errors.Is(err, errBreakerOpen) || errors.Is(err, errMaintenanceWindow) {
+		log.Debugf("Dropping update of %s of DNSNameResolver object %s/%s: %v", description, namespace, name, err)
+		return err
+	}
+
+	if isPermanentError(err) {
+		statusUpdateErrors.WithLabelValues(errorClassPermanent).Inc()
+		log.Errorf("Dropping update of %s of DNSNameResolver object %s/%s as it failed with a permanent error, retrying won't help: %v",
+			description, namespace, name, err)
+		return err
+	}
+	log.Errorf("Encountered error while updating %s of DNSNameResolver object %s/%s: %v", description, namespace, name, err)
+	return err
+}
@@ -0,0 +1,178 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestRetryUpdate(t *testing.T) {
+	defaultBackoff := transientErrorBackoff
+	transientErrorBackoff = wait.Backoff{Steps: 5, Duration: time.Millisecond, Cap: time.Millisecond}
+	defer func() { transientErrorBackoff = defaultBackoff }()
+
+	resource := schema.GroupResource{Group: "network.openshift.io", Resource: "dnsnameresolvers"}
+
+	tests := []struct {
+		name                    string
+		errs                    []error
+		expectedCalls           int
+		expectedTransientErrors float64
+		expectedPermanentErrors float64
+	}{
+		{
+			name:          "Successful update is not retried",
+			errs:          []error{nil},
+			expectedCalls: 1,
+		},
+		{
+			name:          "Update is retried on conflict",
+			errs:          []error{apierrors.NewConflict(resource, "regular", errors.New("conflict")), nil},
+			expectedCalls: 2,
+		},
+		{
+			name: "Update is retried on transient errors",
+			errs: []error{
+				apierrors.NewTooManyRequests("too many requests", 1),
+				apierrors.NewInternalError(errors.New("internal error")),
+				apierrors.NewTimeoutError("timeout", 1),
+				nil,
+			},
+			expectedCalls:           4,
+			expectedTransientErrors: 3,
+		},
+		{
+			name:                    "Update is dropped on forbidden error",
+			errs:                    []error{apierrors.NewForbidden(resource, "regular", errors.New("forbidden"))},
+			expectedCalls:           1,
+			expectedPermanentErrors: 1,
+		},
+		{
+			name:                    "Update is dropped on invalid error",
+			errs:                    []error{apierrors.NewInvalid(schema.GroupKind{Group: "network.openshift.io", Kind: "DNSNameResolver"}, "regular", field.ErrorList{})},
+			expectedCalls:           1,
+			expectedPermanentErrors: 1,
+		},
+		{
+			name: "Update is dropped on permanent error after transient error",
+			errs: []error{
+				apierrors.NewServiceUnavailable("unavailable"),
+				apierrors.NewForbidden(resource, "regular", errors.New("forbidden")),
+			},
+			expectedCalls:           2,
+			expectedTransientErrors: 1,
+			expectedPermanentErrors: 1,
+		},
+		{
+			name:          "Update is not retried on not found error",
+			errs:          []error{apierrors.NewNotFound(resource, "regular")},
+			expectedCalls: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			transientErrors := testutil.ToFloat64(statusUpdateErrors.WithLabelValues(errorClassTransient))
+			permanentErrors := testutil.ToFloat64(statusUpdateErrors.WithLabelValues(errorClassPermanent))
+
+			var lock sync.Mutex
+			calls := 0
+			countCalls := func() int {
+				lock.Lock()
+				defer lock.Unlock()
+				return calls
+			}
+			retrier := newUpdateRetrier()
+			stopCh := make(chan struct{})
+			retrier.run(stopCh)
+			defer func() {
+				close(stopCh)
+				retrier.stop()
+			}()
+
+			err := retrier.retryUpdate("dns", "regular", "status", func() error {
+				lock.Lock()
+				defer lock.Unlock()
+				err := tc.errs[calls]
+				calls++
+				return err
+			})
+
+			// The update failing with a transient error should be retried in the background,
+			// without holding the caller.
+			if tc.errs[0] != nil && isTransientError(tc.errs[0]) {
+				if !isTransientError(err) || countCalls() != 1 {
+					t.Fatalf("Expected the transient error to be returned after the first call, found error %v after %d calls", err, countCalls())
+				}
+			}
+
+			if err := wait.PollUntilContextTimeout(context.Background(), time.Millisecond, time.Second, true, func(context.Context) (bool, error) {
+				return countCalls() == tc.expectedCalls &&
+					testutil.ToFloat64(statusUpdateErrors.WithLabelValues(errorClassTransient))-transientErrors == tc.expectedTransientErrors &&
+					testutil.ToFloat64(statusUpdateErrors.WithLabelValues(errorClassPermanent))-permanentErrors == tc.expectedPermanentErrors, nil
+			}); err != nil {
+				t.Fatalf("Expected %d calls of the update with %v transient and %v permanent errors, found %d calls with %v transient and %v permanent errors",
+					tc.expectedCalls, tc.expectedTransientErrors, tc.expectedPermanentErrors, countCalls(),
+					testutil.ToFloat64(statusUpdateErrors.WithLabelValues(errorClassTransient))-transientErrors,
+					testutil.ToFloat64(statusUpdateErrors.WithLabelValues(errorClassPermanent))-permanentErrors)
+			}
+		})
+	}
+}
+
+func TestUpdateRetrier(t *testing.T) {
+	defaultBackoff := transientErrorBackoff
+	transientErrorBackoff = wait.Backoff{Steps: 5, Duration: 50 * time.Millisecond, Cap: 50 * time.Millisecond}
+	defer func() { transientErrorBackoff = defaultBackoff }()
+
+	retrier := newUpdateRetrier()
+	stopCh := make(chan struct{})
+	retrier.run(stopCh)
+	defer close(stopCh)
+
+	var lock sync.Mutex
+	calls := make(map[string]int)
+	update := func(name string, errs ...error) func() error {
+		return func() error {
+			lock.Lock()
+			defer lock.Unlock()
+			err := errs[calls[name]]
+			calls[name]++
+			return err
+		}
+	}
+	countCalls := func(name string) int {
+		lock.Lock()
+		defer lock.Unlock()
+		return calls[name]
+	}
+	unavailable := apierrors.NewServiceUnavailable("unavailable")
+
+	// Only the last update of an object failed with a transient error is retried.
+	retrier.retryUpdate("dns", "regular", "status", update("first", unavailable, nil))
+	retrier.retryUpdate("dns", "regular", "status", update("last", unavailable, nil))
+	if err := wait.PollUntilContextTimeout(context.Background(), time.Millisecond, time.Second, true, func(context.Context) (bool, error) {
+		return countCalls("last") == 2, nil
+	}); err != nil {
+		t.Fatalf("Expected the last update to be retried, found %d calls", countCalls("last"))
+	}
+	if calls := countCalls("first"); calls != 1 {
+		t.Fatalf("Expected the replaced update not to be retried, found %d calls", calls)
+	}
+
+	// The queued updates are dropped once the retrier is stopped.
+	retrier.retryUpdate("dns", "other", "status", update("stopped", unavailable, nil))
+	retrier.stop()
+	time.Sleep(4 * transientErrorBackoff.Duration)
+	if calls := countCalls("stopped"); calls != 1 {
+		t.Fatalf("Expected the update not to be retried once the retrier is stopped, found %d calls", calls)
+	}
+}
@@ -0,0 +1,64 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+
+	"github.com/coredns/coredns/plugin/metadata"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+)
+
+const (
+	// upstreamAnnotation is the annotation used for storing the address of the upstream
+	// resolver which produced the IP addresses last recorded in the status of a
+	// DNSNameResolver object. It is only set when recordUpstream is enabled.
+	upstreamAnnotation = "dnsnameresolver.openshift.io/upstream"
+
+	// upstreamMetadataLabel is the metadata label under which the forward plugin provides
+	// the address of the upstream resolver of the DNS lookup.
+	upstreamMetadataLabel = "forward/upstream"
+)
+
+// upstreamFromContext returns the address of the upstream resolver which answered the DNS
+// lookup, as provided by the forward plugin in the metadata of the request context. An
+// empty string is returned if the address is not available, eg. if the metadata plugin
+// is not enabled or if the DNS lookup was not answered by the forward plugin.
+func upstreamFromContext(ctx context.Context) string {
+	valueFunc := metadata.ValueFunc(ctx, upstreamMetadataLabel)
+	if valueFunc == nil {
+		return ""
+	}
+	return valueFunc()
+}
+
+// updateUpstreamAnnotation sets the upstream annotation of the DNSNameResolver object to
+// the address of the upstream resolver which answered the DNS lookup, once the status of
+// the object is written. The object is the one whose status was written. Nothing is done
+// if recordUpstream is not enabled or if the address is not available.
+func (resolver *OCPDNSNameResolver) updateUpstreamAnnotation(ctx context.Context, resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) {
+	if !resolver.recordUpstream {
+		return
+	}
+
+	upstream := upstreamFromContext(ctx)
+	if upstream == "" || hasAnnotation(resolverObj, upstreamAnnotation, upstream) {
+		return
+	}
+
+	// Retry the update of the DNSNameResolver object if there's a conflict or a transient error during the update.
+	resolver.retrier.retryUpdate(resolverObj.Namespace, resolverObj.Name, "upstream annotation", func() error {
+		// Fetch a copy of the DNSNameResolver object. All the updates will be applied to the copied object.
+		newResolverObj, err := resolver.store.get(ctx, resolverObj.Namespace, resolverObj.Name)
+		if err != nil {
+			return err
+		}
+
+		// If the annotation is already set then skip the update call.
+		if hasAnnotation(newResolverObj, upstreamAnnotation, upstream) {
+			return nil
+		}
+		setAnnotation(newResolverObj, upstreamAnnotation, upstream)
+
+		// Update the DNSNameResolver object.
+		return resolver.store.update(ctx, newResolverObj)
+	})
+}
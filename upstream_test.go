@@ -0,0 +1,87 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/metadata"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestServeDNSRecordUpstream(t *testing.T) {
+	tests := []struct {
+		name             string
+		recordUpstream   bool
+		upstream         string
+		expectedUpstream string
+	}{
+		{
+			name:             "Upstream annotation is not set by default",
+			upstream:         "10.0.0.1:53",
+			expectedUpstream: "",
+		},
+		{
+			name:             "Upstream annotation is set to the upstream of the DNS lookup",
+			recordUpstream:   true,
+			upstream:         "10.0.0.1:53",
+			expectedUpstream: "10.0.0.1:53",
+		},
+		{
+			name:             "Upstream annotation is omitted when the upstream is not available",
+			recordUpstream:   true,
+			expectedUpstream: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.recordUpstream = tc.recordUpstream
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			})
+
+			query := test.Case{
+				Qname: "www.example.com.",
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A("www.example.com. 30 IN A 1.1.1.1"),
+				},
+			}
+			// The next plugin provides the upstream in the metadata of the request context,
+			// like the forward plugin.
+			next := fakeNextPluginHandler(query)
+			resolver.Next = plugin.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+				if tc.upstream != "" {
+					metadata.SetValueFunc(ctx, upstreamMetadataLabel, func() string { return tc.upstream })
+				}
+				return next.ServeDNS(ctx, w, r)
+			})
+			resolver.ServeDNS(metadata.ContextWithMetadata(ctx), dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+			resolverObj := getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+				return obj.Annotations[upstreamAnnotation] == tc.expectedUpstream && len(obj.Status.ResolvedNames) > 0
+			})
+			if upstream, exists := resolverObj.Annotations[upstreamAnnotation]; upstream != tc.expectedUpstream || exists != (tc.expectedUpstream != "") {
+				t.Fatalf("Expected upstream annotation %q, found %q", tc.expectedUpstream, upstream)
+			}
+		})
+	}
+}
@@ -14,6 +14,13 @@ func isWildcard(dnsName string) bool {
 	return strings.HasPrefix(dnsName, "*.")
 }
 
+// isApexWildcard checks if the domain name is the apex wildcard, i.e. a
+// wildcard without any parent domain, which would match every single label
+// DNS name.
+func isApexWildcard(dnsName string) bool {
+	return dnsName == "*" || dnsName == "*."
+}
+
 // getWildcard converts a regular DNS name to a wildcard DNS name. The
 // input should be a valid fqdn.
 func getWildcard(dnsName string) string {
@@ -23,14 +30,17 @@ func getWildcard(dnsName string) string {
 	return "*" + dnsName[strings.Index(dnsName, "."):]
 }
 
+// nextLookupTimeMargin is the margin within which the next lookup times of an IP address are
+// considered the same, when no TTL jitter is configured.
+const nextLookupTimeMargin = 5 * time.Second
+
 // isSameNextLookupTime checks if the existing next lookup time (existing last lookup time + existing ttl)
-// and the current next lookup time (current time + current ttl) are within a margin of 5 seconds of each
-// other.
-func isSameNextLookupTime(existingLastLookupTime time.Time, existingTTL, currentTTL int32) bool {
+// and the current next lookup time (current time + current ttl) are within the margin of each other.
+func isSameNextLookupTime(existingLastLookupTime time.Time, existingTTL, currentTTL int32, margin time.Duration) bool {
 	existingNextLookupTime := existingLastLookupTime.Add(time.Duration(existingTTL) * time.Second)
 	currentNextLookupTime := time.Now().Add(time.Duration(currentTTL) * time.Second)
 	cmpOpts := []cmp.Option{
-		cmpopts.EquateApproxTime(5 * time.Second),
+		cmpopts.EquateApproxTime(margin),
 	}
 	return cmp.Equal(currentNextLookupTime, existingNextLookupTime, cmpOpts...)
 }
@@ -26,6 +26,27 @@ func TestIsWildcard(t *testing.T) {
 	}
 }
 
+func TestIsApexWildcard(t *testing.T) {
+	tests := []struct {
+		dnsName        string
+		expectedOutput bool
+	}{
+		// success
+		{"*", true},
+		{"*.", true},
+		// negative
+		{"*.example.com.", false},
+		{"www.example.com.", false},
+	}
+
+	for _, test := range tests {
+		actualOutput := isApexWildcard(test.dnsName)
+		if actualOutput != test.expectedOutput {
+			t.Fatalf("Actual output does not match with expected output. Actual output: %t, Expected output: %t", actualOutput, test.expectedOutput)
+		}
+	}
+}
+
 func TestGetWildcard(t *testing.T) {
 	tests := []struct {
 		dnsName        string
@@ -51,6 +72,7 @@ func TestIsSameNextLookupTime(t *testing.T) {
 		existingLastLookupTime time.Time
 		existingTTL            int32
 		currentTTL             int32
+		margin                 time.Duration
 		expectedOutput         bool
 	}{
 		{
@@ -58,6 +80,7 @@ func TestIsSameNextLookupTime(t *testing.T) {
 			existingLastLookupTime: time.Now(),
 			existingTTL:            2,
 			currentTTL:             2,
+			margin:                 nextLookupTimeMargin,
 			expectedOutput:         true,
 		},
 		{
@@ -65,6 +88,7 @@ func TestIsSameNextLookupTime(t *testing.T) {
 			existingLastLookupTime: time.Now(),
 			existingTTL:            2,
 			currentTTL:             1,
+			margin:                 nextLookupTimeMargin,
 			expectedOutput:         true,
 		},
 		{
@@ -72,6 +96,7 @@ func TestIsSameNextLookupTime(t *testing.T) {
 			existingLastLookupTime: time.Now().Add(-3 * time.Second),
 			existingTTL:            2,
 			currentTTL:             1,
+			margin:                 nextLookupTimeMargin,
 			expectedOutput:         true,
 		},
 		{
@@ -79,6 +104,7 @@ func TestIsSameNextLookupTime(t *testing.T) {
 			existingLastLookupTime: time.Now(),
 			existingTTL:            7,
 			currentTTL:             1,
+			margin:                 nextLookupTimeMargin,
 			expectedOutput:         false,
 		},
 		{
@@ -86,13 +112,22 @@ func TestIsSameNextLookupTime(t *testing.T) {
 			existingLastLookupTime: time.Now().Add(-6 * time.Second),
 			existingTTL:            1,
 			currentTTL:             1,
+			margin:                 nextLookupTimeMargin,
 			expectedOutput:         false,
 		},
+		{
+			name:                   "Existing next lookup time is after current next lookup time and within the margin widened by the jitter",
+			existingLastLookupTime: time.Now(),
+			existingTTL:            30,
+			currentTTL:             10,
+			margin:                 nextLookupTimeMargin + 20*time.Second,
+			expectedOutput:         true,
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			actualOutput := isSameNextLookupTime(tc.existingLastLookupTime, tc.existingTTL, tc.currentTTL)
+			actualOutput := isSameNextLookupTime(tc.existingLastLookupTime, tc.existingTTL, tc.currentTTL, tc.margin)
 			if actualOutput != tc.expectedOutput {
 				t.Fatalf("Actual output does not match with expected output. Actual output: %t, Expected output: %t", actualOutput, tc.expectedOutput)
 			}
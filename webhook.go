@@ -0,0 +1,216 @@
+package ocp_dnsnameresolver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// webhookQueueSize gives the number of the webhook events buffered until they are
+	// sent. The events beyond it are dropped, so that the informer event handlers are
+	// never blocked by a slow webhook.
+	webhookQueueSize = 1024
+	// webhookMaxBatchSize gives the maximum number of the webhook events sent in a request.
+	webhookMaxBatchSize = 100
+	// webhookRequestTimeout gives the timeout of each request to the webhook.
+	webhookRequestTimeout = 10 * time.Second
+
+	// webhookActionTracked is the action of the webhook events of the newly tracked DNS names.
+	webhookActionTracked = "tracked"
+	// webhookActionUntracked is the action of the webhook events of the DNS names which are
+	// not tracked anymore.
+	webhookActionUntracked = "untracked"
+)
+
+// webhookBatchInterval gives the interval at which the buffered webhook events are sent.
+// It is a variable so that tests can replace it.
+var webhookBatchInterval = time.Second
+
+// webhookBackoff is the backoff used for retrying the requests to the webhook which failed.
+// It is a variable so that tests can replace it.
+var webhookBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 500 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.1,
+}
+
+// webhookEvent is a DNS name newly tracked or not tracked anymore, sent to the webhook.
+type webhookEvent struct {
+	Action  string    `json:"action"`
+	DNSName string    `json:"dnsName"`
+	Time    time.Time `json:"time"`
+}
+
+// webhookPayload is the body of the requests to the webhook.
+type webhookPayload struct {
+	Events []webhookEvent `json:"events"`
+}
+
+// webhookSender sends the DNS names newly tracked or not tracked anymore to the webhook of
+// webhookURL, in batches, asynchronously from the informer event handlers.
+type webhookSender struct {
+	url    string
+	client *http.Client
+	// events buffers the events until they are sent.
+	events chan webhookEvent
+	// pending stores the batch which was not sent once the sender stopped, it is only
+	// accessed once done is closed.
+	pending []webhookEvent
+	// done is closed once the sender stopped.
+	done chan struct{}
+}
+
+// newWebhookSender returns a webhookSender sending the events to the URL.
+func newWebhookSender(url string) *webhookSender {
+	return &webhookSender{
+		url:    url,
+		client: &http.Client{Timeout: webhookRequestTimeout},
+		events: make(chan webhookEvent, webhookQueueSize),
+		done:   make(chan struct{}),
+	}
+}
+
+// enqueue buffers the event of the DNS name until it is sent. The event is dropped if the
+// buffer is full, so that the caller is never blocked.
+func (sender *webhookSender) enqueue(action, dnsName string) {
+	select {
+	case sender.events <- webhookEvent{Action: action, DNSName: dnsName, Time: time.Now().UTC()}:
+	default:
+		log.Warningf("Dropping webhook event of %s DNS name %s as the webhook queue is full", action, dnsName)
+	}
+}
+
+// run sends the buffered events in batches at each webhookBatchInterval, or as soon as a
+// batch is full, until the stop channel is closed.
+func (sender *webhookSender) run(stopCh <-chan struct{}) {
+	defer close(sender.done)
+	ctx := wait.ContextForChannel(stopCh)
+
+	ticker := time.NewTicker(webhookBatchInterval)
+	defer ticker.Stop()
+	var batch []webhookEvent
+	for {
+		select {
+		case <-stopCh:
+			sender.pending = batch
+			return
+		case event := <-sender.events:
+			batch = append(batch, event)
+			if len(batch) < webhookMaxBatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		// Keep the batch which was interrupted by the stop, so that it is sent by the drain.
+		if err := sender.send(ctx, batch); err != nil && ctx.Err() != nil {
+			sender.pending = batch
+			return
+		}
+		batch = nil
+	}
+}
+
+// drain sends the events which were not sent once the sender stopped, within the context.
+func (sender *webhookSender) drain(ctx context.Context) {
+	select {
+	case <-sender.done:
+	case <-ctx.Done():
+		log.Warningf("Webhook sender did not stop before draining the webhook events")
+		return
+	}
+
+	batch := sender.pending
+	sender.pending = nil
+	for {
+		select {
+		case event := <-sender.events:
+			batch = append(batch, event)
+			if len(batch) < webhookMaxBatchSize {
+				continue
+			}
+		default:
+		}
+		if len(batch) == 0 {
+			return
+		}
+		if err := sender.send(ctx, batch); err != nil && ctx.Err() != nil {
+			return
+		}
+		batch = nil
+	}
+}
+
+// send posts the batch of events to the webhook. The request is retried with backoff if
+// it fails, or if the webhook responds with a server error or too many requests. The batch
+// is dropped once the retries are exhausted, on the other errors, or once the context is
+// done. The returned error is already logged.
+func (sender *webhookSender) send(ctx context.Context, batch []webhookEvent) error {
+	body, err := json.Marshal(webhookPayload{Events: batch})
+	if err != nil {
+		log.Errorf("Dropping %d webhook events as they could not be encoded: %v", len(batch), err)
+		return err
+	}
+
+	var lastErr error
+	err = wait.ExponentialBackoffWithContext(ctx, webhookBackoff, func(ctx context.Context) (bool, error) {
+		var retriable bool
+		retriable, lastErr = sender.post(ctx, body)
+		if lastErr == nil {
+			return true, nil
+		}
+		if !retriable {
+			return false, lastErr
+		}
+		log.Debugf("Retrying webhook request of %d events: %v", len(batch), lastErr)
+		return false, nil
+	})
+	if err != nil {
+		if lastErr != nil {
+			err = lastErr
+		}
+		log.Errorf("Dropping %d webhook events as the webhook request failed: %v", len(batch), err)
+		return err
+	}
+	return nil
+}
+
+// post posts the body to the webhook and returns an error if the request fails or if the
+// webhook does not respond with a success status code, along with whether retrying the
+// request may succeed.
+func (sender *webhookSender) post(ctx context.Context, body []byte) (bool, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, sender.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := sender.client.Do(request)
+	if err != nil {
+		return true, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		retriable := response.StatusCode >= 500 || response.StatusCode == http.StatusTooManyRequests
+		return retriable, fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+	return false, nil
+}
+
+// notifyWebhook sends the event of the DNS name newly tracked or not tracked anymore to the
+// webhook, if webhookURL is configured.
+func (resolver *OCPDNSNameResolver) notifyWebhook(action, dnsName string) {
+	if resolver.webhook == nil {
+		return
+	}
+	resolver.webhook.enqueue(action, dnsName)
+}
@@ -0,0 +1,307 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// webhookServer is a test webhook recording the events it receives. The first failures
+// requests are answered with the failure status code.
+type webhookServer struct {
+	*httptest.Server
+	failures      int
+	failureStatus int
+
+	lock     sync.Mutex
+	requests int
+	events   []webhookEvent
+}
+
+func newWebhookServer(t *testing.T, failures, failureStatus int) *webhookServer {
+	server := &webhookServer{failures: failures, failureStatus: failureStatus}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.lock.Lock()
+		defer server.lock.Unlock()
+		server.requests++
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected webhook request %s with content type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		if server.requests <= server.failures {
+			w.WriteHeader(server.failureStatus)
+			return
+		}
+		var payload webhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("Failed to decode webhook request: %v", err)
+		}
+		server.events = append(server.events, payload.Events...)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// received returns the actions and the DNS names of the received events, and the number
+// of the received requests.
+func (server *webhookServer) received() ([]string, int) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	received := make([]string, 0, len(server.events))
+	for _, event := range server.events {
+		received = append(received, event.Action+" "+event.DNSName)
+	}
+	return received, server.requests
+}
+
+// waitReceived waits until the webhook received the expected number of events.
+func (server *webhookServer) waitReceived(t *testing.T, expected int) []string {
+	t.Helper()
+	var received []string
+	err := wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+		received, _ = server.received()
+		return len(received) >= expected, nil
+	})
+	if err != nil {
+		t.Fatalf("Expected %d webhook events, found %v", expected, received)
+	}
+	return received
+}
+
+func useFastWebhook(t *testing.T) {
+	defaultInterval, defaultBackoff := webhookBatchInterval, webhookBackoff
+	webhookBatchInterval = 10 * time.Millisecond
+	webhookBackoff = wait.Backoff{Steps: 3, Duration: time.Millisecond, Factor: 1.0}
+	t.Cleanup(func() { webhookBatchInterval, webhookBackoff = defaultInterval, defaultBackoff })
+}
+
+func TestWebhookSender(t *testing.T) {
+	tests := []struct {
+		name             string
+		failures         int
+		failureStatus    int
+		expectedEvents   []string
+		expectedRequests int
+	}{
+		{
+			name:             "Events are sent in a batch",
+			expectedEvents:   []string{"tracked www.example.com.", "untracked *.example.com."},
+			expectedRequests: 1,
+		},
+		{
+			name:             "Request is retried on server errors",
+			failures:         2,
+			failureStatus:    http.StatusServiceUnavailable,
+			expectedEvents:   []string{"tracked www.example.com.", "untracked *.example.com."},
+			expectedRequests: 3,
+		},
+		{
+			name:             "Events are dropped once the retries are exhausted",
+			failures:         3,
+			failureStatus:    http.StatusTooManyRequests,
+			expectedRequests: 3,
+		},
+		{
+			name:             "Request is not retried on client errors",
+			failures:         1,
+			failureStatus:    http.StatusBadRequest,
+			expectedRequests: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			useFastWebhook(t)
+			server := newWebhookServer(t, tc.failures, tc.failureStatus)
+
+			sender := newWebhookSender(server.URL)
+			stopCh := make(chan struct{})
+			go sender.run(stopCh)
+			defer func() {
+				close(stopCh)
+				<-sender.done
+			}()
+
+			sender.enqueue(webhookActionTracked, "www.example.com.")
+			sender.enqueue(webhookActionUntracked, "*.example.com.")
+
+			err := wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+				_, requests := server.received()
+				return requests >= tc.expectedRequests, nil
+			})
+			if err != nil {
+				t.Fatalf("Expected %d webhook requests", tc.expectedRequests)
+			}
+			// Give the sender the time for an unexpected retry.
+			time.Sleep(50 * time.Millisecond)
+
+			events, requests := server.received()
+			if requests != tc.expectedRequests {
+				t.Fatalf("Expected %d webhook requests, found %d", tc.expectedRequests, requests)
+			}
+			if len(events) != len(tc.expectedEvents) {
+				t.Fatalf("Expected webhook events %v, found %v", tc.expectedEvents, events)
+			}
+			for i := range events {
+				if events[i] != tc.expectedEvents[i] {
+					t.Fatalf("Expected webhook events %v, found %v", tc.expectedEvents, events)
+				}
+			}
+		})
+	}
+}
+
+func TestWebhookSenderNeverBlocks(t *testing.T) {
+	sender := newWebhookSender("http://127.0.0.1:0")
+
+	// The sender is not running, thus the events beyond the queue are dropped.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < webhookQueueSize+10; i++ {
+			sender.enqueue(webhookActionTracked, "www.example.com.")
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected enqueuing the webhook events not to block")
+	}
+	if len(sender.events) != webhookQueueSize {
+		t.Fatalf("Expected %d buffered webhook events, found %d", webhookQueueSize, len(sender.events))
+	}
+}
+
+func TestWebhookSenderDrain(t *testing.T) {
+	useFastWebhook(t)
+	// The events are not sent before the stop.
+	webhookBatchInterval = time.Hour
+	server := newWebhookServer(t, 0, 0)
+
+	sender := newWebhookSender(server.URL)
+	stopCh := make(chan struct{})
+	go sender.run(stopCh)
+
+	sender.enqueue(webhookActionTracked, "www.example.com.")
+	sender.enqueue(webhookActionTracked, "*.example.com.")
+	// Let the sender buffer the first events in its pending batch.
+	time.Sleep(50 * time.Millisecond)
+	close(stopCh)
+	sender.enqueue(webhookActionUntracked, "www.example.com.")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sender.drain(ctx)
+
+	events, requests := server.received()
+	expected := []string{"tracked www.example.com.", "tracked *.example.com.", "untracked www.example.com."}
+	if requests != 1 || len(events) != len(expected) {
+		t.Fatalf("Expected webhook events %v in 1 request, found %v in %d requests", expected, events, requests)
+	}
+	for i := range events {
+		if events[i] != expected[i] {
+			t.Fatalf("Expected webhook events %v, found %v", expected, events)
+		}
+	}
+}
+
+func TestWebhookTrackedNames(t *testing.T) {
+	useFastWebhook(t)
+	server := newWebhookServer(t, 0, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.webhookURL = server.URL
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+	go resolver.webhook.run(ctx.Done())
+
+	// The DNS name is only sent once it is newly tracked and once it is not tracked anymore.
+	for _, obj := range []*ocpnetworkapiv1alpha1.DNSNameResolver{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+			Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "other"},
+			Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "wildcard", Namespace: "dns"},
+			Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "*.example.com."},
+		},
+	} {
+		createTrackedResolverObject(t, resolver, fakeNetworkClient, obj)
+	}
+	server.waitReceived(t, 2)
+	for _, namespace := range []string{"dns", "other"} {
+		if err := fakeNetworkClient.NetworkV1alpha1().DNSNameResolvers(namespace).Delete(ctx, "regular", metav1.DeleteOptions{}); err != nil {
+			t.Fatalf("error deleting dns name resolver: %v", err)
+		}
+	}
+
+	events := server.waitReceived(t, 3)
+	expected := []string{"tracked www.example.com.", "tracked *.example.com.", "untracked www.example.com."}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected webhook events %v, found %v", expected, events)
+	}
+	for i := range events {
+		if events[i] != expected[i] {
+			t.Fatalf("Expected webhook events %v, found %v", expected, events)
+		}
+	}
+}
+
+func TestWebhookPrunedNamespace(t *testing.T) {
+	useFastWebhook(t)
+	server := newWebhookServer(t, 0, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New()
+	resolver.webhookURL = server.URL
+	resolver.pruneDeletedNamespaces = true
+	fakeNetworkClient := newTestResolver(ctx, t, resolver)
+	go resolver.webhook.run(ctx.Done())
+
+	for _, obj := range []*ocpnetworkapiv1alpha1.DNSNameResolver{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "dns"},
+			Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "wildcard", Namespace: "dns"},
+			Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "*.example.com."},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "regular", Namespace: "other"},
+			Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "www.example.com."},
+		},
+	} {
+		createTrackedResolverObject(t, resolver, fakeNetworkClient, obj)
+	}
+	server.waitReceived(t, 2)
+
+	// The DNS names only tracked in the pruned namespace are sent as not tracked anymore.
+	resolver.pruneNamespace("dns")
+
+	events := server.waitReceived(t, 3)
+	expected := []string{"tracked www.example.com.", "tracked *.example.com.", "untracked *.example.com."}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected webhook events %v, found %v", expected, events)
+	}
+	for i := range events {
+		if events[i] != expected[i] {
+			t.Fatalf("Expected webhook events %v, found %v", expected, events)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package ocp_dnsnameresolver
+
+import (
+	"maps"
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// wildcardNamespaceScope determines which DNSNameResolver objects are updated when
+// a wildcard DNS name matching the DNS name being looked up is tracked in multiple
+// namespaces.
+type wildcardNamespaceScope string
+
+const (
+	// wildcardNamespaceScopeAll updates the wildcard DNSNameResolver objects of all
+	// the namespaces.
+	wildcardNamespaceScopeAll wildcardNamespaceScope = "all"
+	// wildcardNamespaceScopeFirst updates only the wildcard DNSNameResolver object
+	// of the first namespace in lexicographical order.
+	wildcardNamespaceScopeFirst wildcardNamespaceScope = "first"
+)
+
+// parseWildcardNamespaceScope returns the wildcardNamespaceScope corresponding to
+// the given value and whether the value is a valid wildcardNamespaceScope.
+func parseWildcardNamespaceScope(value string) (wildcardNamespaceScope, bool) {
+	switch scope := wildcardNamespaceScope(value); scope {
+	case wildcardNamespaceScopeAll, wildcardNamespaceScopeFirst:
+		return scope, true
+	}
+	return "", false
+}
+
+// applyWildcardNamespaceScope filters the namespaces of the wildcard DNS name info
+// according to the wildcard namespace scope. The given map is never modified, as it
+// is shared with the informer event handlers; a new map is returned instead whenever
+// filtering is required.
+func applyWildcardNamespaceScope(scope wildcardNamespaceScope, wildcardDNSInfo namespaceDNSInfo) namespaceDNSInfo {
+	if scope != wildcardNamespaceScopeFirst || len(wildcardDNSInfo) <= 1 {
+		return wildcardDNSInfo
+	}
+	namespaces := make([]string, 0, len(wildcardDNSInfo))
+	for namespace := range wildcardDNSInfo {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaceDNSInfo{namespaces[0]: wildcardDNSInfo[namespaces[0]]}
+}
+
+// wildcardSpecificity determines which wildcard DNS names match the DNS name being
+// looked up when the wildcard DNS names of both its parent domain and its ancestor
+// domains are tracked, eg. *.a.example.com and *.example.com for b.a.example.com.
+type wildcardSpecificity string
+
+const (
+	// wildcardSpecificityMostSpecific matches only the wildcard DNS name of the parent
+	// domain, as the '*' of a wildcard DNS name matches a single label.
+	wildcardSpecificityMostSpecific wildcardSpecificity = "most-specific"
+	// wildcardSpecificityAll matches the wildcard DNS names of the parent domain and of
+	// all the ancestor domains.
+	wildcardSpecificityAll wildcardSpecificity = "all"
+)
+
+// parseWildcardSpecificity returns the wildcardSpecificity corresponding to the given
+// value and whether the value is a valid wildcardSpecificity.
+func parseWildcardSpecificity(value string) (wildcardSpecificity, bool) {
+	switch specificity := wildcardSpecificity(value); specificity {
+	case wildcardSpecificityMostSpecific, wildcardSpecificityAll:
+		return specificity, true
+	}
+	return "", false
+}
+
+// getAncestorWildcards returns the wildcard DNS names of the ancestor domains of the
+// regular DNS name, excluding its parent domain and the root, from the most to the
+// least specific. The input should be a valid fqdn.
+func getAncestorWildcards(dnsName string) []string {
+	var wildcards []string
+	labels := dns.SplitDomainName(dnsName)
+	for i := 2; i < len(labels); i++ {
+		wildcards = append(wildcards, "*."+dns.Fqdn(strings.Join(labels[i:], ".")))
+	}
+	return wildcards
+}
+
+// lookupAncestorWildcards returns the wildcard DNS name info of the parent domain along
+// with the DNSNameResolver objects of the wildcard DNS names of the ancestor domains of
+// the DNS name, when wildcardSpecificity is set to all. As a namespace holds a single
+// DNSNameResolver object matching the DNS name, the most specific wildcard DNS name
+// tracked in a namespace wins. The given map is never modified.
+func (resolver *OCPDNSNameResolver) lookupAncestorWildcards(dnsName string, wildcardDNSInfo namespaceDNSInfo) namespaceDNSInfo {
+	if resolver.wildcardSpecificity != wildcardSpecificityAll {
+		return wildcardDNSInfo
+	}
+	var merged namespaceDNSInfo
+	for _, wildcard := range getAncestorWildcards(dnsName) {
+		ancestorDNSInfo, exists := resolver.lookupWildcard(wildcard)
+		if !exists {
+			continue
+		}
+		if merged == nil {
+			merged = maps.Clone(wildcardDNSInfo)
+			if merged == nil {
+				merged = make(namespaceDNSInfo)
+			}
+		}
+		for namespace, objName := range ancestorDNSInfo {
+			if _, exists := merged[namespace]; !exists {
+				merged[namespace] = objName
+			}
+		}
+	}
+	if merged == nil {
+		return wildcardDNSInfo
+	}
+	return merged
+}
@@ -0,0 +1,226 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApplyWildcardNamespaceScope(t *testing.T) {
+	wildcard := namespaceDNSInfo{"ns3": "wildcard3", "ns1": "wildcard1", "ns2": "wildcard2"}
+
+	tests := []struct {
+		name     string
+		scope    wildcardNamespaceScope
+		input    namespaceDNSInfo
+		expected namespaceDNSInfo
+	}{
+		{
+			name:     "All namespaces are updated",
+			scope:    wildcardNamespaceScopeAll,
+			input:    wildcard,
+			expected: namespaceDNSInfo{"ns1": "wildcard1", "ns2": "wildcard2", "ns3": "wildcard3"},
+		},
+		{
+			name:     "Only the first namespace is updated",
+			scope:    wildcardNamespaceScopeFirst,
+			input:    wildcard,
+			expected: namespaceDNSInfo{"ns1": "wildcard1"},
+		},
+		{
+			name:     "Empty wildcard DNS info",
+			scope:    wildcardNamespaceScopeFirst,
+			input:    nil,
+			expected: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, applyWildcardNamespaceScope(tc.scope, tc.input)); diff != "" {
+				t.Fatalf("wildcard DNS info did not match the expected value:\nDiff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestServeDNSWildcardNamespaceScope(t *testing.T) {
+	namespaces := []string{"ns-c", "ns-a", "ns-b"}
+
+	tests := []struct {
+		name               string
+		scope              wildcardNamespaceScope
+		expectedNamespaces map[string]bool
+	}{
+		{
+			name:               "Wildcard objects of all namespaces are updated",
+			scope:              wildcardNamespaceScopeAll,
+			expectedNamespaces: map[string]bool{"ns-a": true, "ns-b": true, "ns-c": true},
+		},
+		{
+			name:               "Only the wildcard object of the first namespace is updated",
+			scope:              wildcardNamespaceScopeFirst,
+			expectedNamespaces: map[string]bool{"ns-a": true},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.wildcardNamespaceScope = tc.scope
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			for _, namespace := range namespaces {
+				createTrackedResolverObject(t, resolver, fakeNetworkClient, &ocpnetworkapiv1alpha1.DNSNameResolver{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "wildcard",
+						Namespace: namespace,
+					},
+					Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+						Name: "*.example.com.",
+					},
+				})
+			}
+
+			query := test.Case{
+				Qname: "www.example.com.",
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A("www.example.com. 30 IN A 1.1.1.1"),
+				},
+			}
+			resolver.Next = fakeNextPluginHandler(query)
+			resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+			if count := countStatusUpdates(fakeNetworkClient); count != len(tc.expectedNamespaces) {
+				t.Fatalf("Expected %d status updates, found %d", len(tc.expectedNamespaces), count)
+			}
+			for _, namespace := range namespaces {
+				resolverObj := getResolverObject(t, resolver, namespace, "wildcard", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+					return (len(obj.Status.ResolvedNames) > 0) == tc.expectedNamespaces[namespace]
+				})
+				if updated := len(resolverObj.Status.ResolvedNames) > 0; updated != tc.expectedNamespaces[namespace] {
+					t.Fatalf("Expected wildcard object in namespace %s to be updated: %t, found: %t", namespace, tc.expectedNamespaces[namespace], updated)
+				}
+			}
+		})
+	}
+}
+
+func TestGetAncestorWildcards(t *testing.T) {
+	tests := []struct {
+		dnsName  string
+		expected []string
+	}{
+		{dnsName: "example.com.", expected: nil},
+		{dnsName: "www.example.com.", expected: []string{"*.com."}},
+		{dnsName: "b.a.example.com.", expected: []string{"*.example.com.", "*.com."}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.dnsName, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, getAncestorWildcards(tc.dnsName)); diff != "" {
+				t.Fatalf("ancestor wildcard DNS names did not match the expected value:\nDiff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestServeDNSWildcardSpecificity(t *testing.T) {
+	// The wildcard DNS names of the parent and the grandparent domains are tracked in
+	// different namespaces, and nested in the same namespace.
+	objects := []*ocpnetworkapiv1alpha1.DNSNameResolver{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "parent", Namespace: "ns-a"},
+			Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "*.a.example.com."},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "grandparent", Namespace: "ns-b"},
+			Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "*.example.com."},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "parent", Namespace: "ns-c"},
+			Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "*.a.example.com."},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "grandparent", Namespace: "ns-c"},
+			Spec:       ocpnetworkapiv1alpha1.DNSNameResolverSpec{Name: "*.example.com."},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		specificity     wildcardSpecificity
+		expectedUpdated map[string]bool
+	}{
+		{
+			name:        "Only the wildcard objects of the parent domain are updated",
+			specificity: wildcardSpecificityMostSpecific,
+			expectedUpdated: map[string]bool{
+				"ns-a/parent": true, "ns-b/grandparent": false, "ns-c/parent": true, "ns-c/grandparent": false,
+			},
+		},
+		{
+			name:        "The wildcard objects of the ancestor domains are updated in the namespaces without a more specific one",
+			specificity: wildcardSpecificityAll,
+			expectedUpdated: map[string]bool{
+				"ns-a/parent": true, "ns-b/grandparent": true, "ns-c/parent": true, "ns-c/grandparent": false,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.wildcardSpecificity = tc.specificity
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			for _, resolverObj := range objects {
+				createTrackedResolverObject(t, resolver, fakeNetworkClient, resolverObj.DeepCopy())
+			}
+
+			query := test.Case{
+				Qname: "b.a.example.com.",
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A("b.a.example.com. 30 IN A 1.1.1.1"),
+				},
+			}
+			resolver.Next = fakeNextPluginHandler(query)
+			resolver.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+			expectedCount := 0
+			for _, updated := range tc.expectedUpdated {
+				if updated {
+					expectedCount++
+				}
+			}
+			if count := countStatusUpdates(fakeNetworkClient); count != expectedCount {
+				t.Fatalf("Expected %d status updates, found %d", expectedCount, count)
+			}
+			for _, resolverObj := range objects {
+				key := resolverObj.Namespace + "/" + resolverObj.Name
+				obj := getResolverObject(t, resolver, resolverObj.Namespace, resolverObj.Name, func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+					return (len(obj.Status.ResolvedNames) > 0) == tc.expectedUpdated[key]
+				})
+				if updated := len(obj.Status.ResolvedNames) > 0; updated != tc.expectedUpdated[key] {
+					t.Fatalf("Expected wildcard object %s to be updated: %t, found: %t", key, tc.expectedUpdated[key], updated)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+)
+
+const (
+	// writerAnnotation is the annotation used for identifying the CoreDNS instance which
+	// last wrote the status of a DNSNameResolver object. It is only set when instanceID
+	// is configured, eg. when multiple CoreDNS clusters write to the same objects.
+	writerAnnotation = "dnsnameresolver.openshift.io/writer"
+)
+
+// updateWriterAnnotation sets the writer annotation of the DNSNameResolver object to the
+// configured instanceID, once the status of the object is written. The object is the one
+// whose status was written. If the annotation identifies another instance, then the
+// status was last written by a foreign writer: a warning is logged and the
+// foreignWrites metric is incremented, as both instances keep overwriting each other's
+// status. Nothing is done if instanceID is not configured.
+func (resolver *OCPDNSNameResolver) updateWriterAnnotation(ctx context.Context, resolverObj *ocpnetworkapiv1alpha1.DNSNameResolver) {
+	if resolver.instanceID == "" {
+		return
+	}
+
+	if hasAnnotation(resolverObj, writerAnnotation, resolver.instanceID) {
+		return
+	}
+	if writer := resolverObj.Annotations[writerAnnotation]; writer != "" && writer != resolver.instanceID {
+		foreignWrites.Inc()
+		log.Warningf("Status of DNSNameResolver object %s/%s was last written by instance %s, overwriting it as instance %s",
+			resolverObj.Namespace, resolverObj.Name, writer, resolver.instanceID)
+	}
+
+	// Retry the update of the DNSNameResolver object if there's a conflict or a transient error during the update.
+	resolver.retrier.retryUpdate(resolverObj.Namespace, resolverObj.Name, "writer annotation", func() error {
+		// Fetch a copy of the DNSNameResolver object. All the updates will be applied to the copied object.
+		newResolverObj, err := resolver.store.get(ctx, resolverObj.Namespace, resolverObj.Name)
+		if err != nil {
+			return err
+		}
+
+		// If the annotation is already set then skip the update call.
+		if hasAnnotation(newResolverObj, writerAnnotation, resolver.instanceID) {
+			return nil
+		}
+		setAnnotation(newResolverObj, writerAnnotation, resolver.instanceID)
+
+		// Update the DNSNameResolver object.
+		return resolver.store.update(ctx, newResolverObj)
+	})
+}
@@ -0,0 +1,96 @@
+package ocp_dnsnameresolver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+	ocpnetworkapiv1alpha1 "github.com/openshift/api/network/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestServeDNSWriterAnnotation(t *testing.T) {
+	tests := []struct {
+		name                 string
+		instanceID           string
+		existingWriter       string
+		expectedWriter       string
+		expectedForeignWrite bool
+	}{
+		{
+			name:           "Writer annotation is not set by default",
+			expectedWriter: "",
+		},
+		{
+			name:           "Writer annotation is set to instanceID",
+			instanceID:     "cluster-a",
+			expectedWriter: "cluster-a",
+		},
+		{
+			name:           "Writer annotation of the same instance is kept",
+			instanceID:     "cluster-a",
+			existingWriter: "cluster-a",
+			expectedWriter: "cluster-a",
+		},
+		{
+			name:                 "Foreign writer is detected and overwritten",
+			instanceID:           "cluster-a",
+			existingWriter:       "cluster-b",
+			expectedWriter:       "cluster-a",
+			expectedForeignWrite: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			resolver := New()
+			resolver.instanceID = tc.instanceID
+			fakeNetworkClient := newTestResolver(ctx, t, resolver)
+
+			resolverObj := &ocpnetworkapiv1alpha1.DNSNameResolver{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "regular",
+					Namespace: "dns",
+				},
+				Spec: ocpnetworkapiv1alpha1.DNSNameResolverSpec{
+					Name: "www.example.com.",
+				},
+			}
+			if tc.existingWriter != "" {
+				resolverObj.Annotations = map[string]string{writerAnnotation: tc.existingWriter}
+			}
+			createTrackedResolverObject(t, resolver, fakeNetworkClient, resolverObj)
+
+			foreignWritesBefore := testutil.ToFloat64(foreignWrites)
+
+			query := test.Case{
+				Qname: "www.example.com.",
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A("www.example.com. 30 IN A 1.1.1.1"),
+				},
+			}
+			resolver.Next = fakeNextPluginHandler(query)
+			resolver.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), query.Msg())
+
+			resolverObj = getResolverObject(t, resolver, "dns", "regular", func(obj *ocpnetworkapiv1alpha1.DNSNameResolver) bool {
+				return obj.Annotations[writerAnnotation] == tc.expectedWriter && len(obj.Status.ResolvedNames) > 0
+			})
+			if writer := resolverObj.Annotations[writerAnnotation]; writer != tc.expectedWriter {
+				t.Fatalf("Expected writer annotation %q, found %q", tc.expectedWriter, writer)
+			}
+
+			foreignWritten := testutil.ToFloat64(foreignWrites) > foreignWritesBefore
+			if foreignWritten != tc.expectedForeignWrite {
+				t.Fatalf("Expected foreign write detected to be %t, found %t", tc.expectedForeignWrite, foreignWritten)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package ocp_dnsnameresolver
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// inInternalZone returns whether the lowercased DNS name belongs to one of the
+// configured internal zones. The zones are fully qualified and lowercased.
+func (resolver *OCPDNSNameResolver) inInternalZone(qname string) bool {
+	return inZones(qname, resolver.internalZones)
+}
+
+// inExternalZone returns whether the lowercased DNS name belongs to one of the
+// configured external zones. All the DNS names belong to the external zones if
+// no external zones are configured.
+func (resolver *OCPDNSNameResolver) inExternalZone(qname string) bool {
+	return len(resolver.externalZones) == 0 || inZones(qname, resolver.externalZones)
+}
+
+// inZones returns whether the lowercased DNS name belongs to one of the given zones.
+// The zones are fully qualified and lowercased.
+func inZones(qname string, zones []string) bool {
+	for _, zone := range zones {
+		if zone == "." || qname == zone {
+			return true
+		}
+		if strings.HasSuffix(qname, zone) && qname[len(qname)-len(zone)-1] == '.' {
+			return true
+		}
+	}
+	return false
+}
+
+// overlappingInternalZone returns the configured internal zone overlapping the scope of
+// the wildcard DNS name, i.e. the internal zone containing the parent domain of the
+// wildcard DNS name, eg. cluster.local for *.svc.cluster.local, or contained in it, eg.
+// cluster.local for *.local. An empty string is returned if the DNS name is not a
+// wildcard DNS name or if none of the internal zones overlaps its scope.
+func (resolver *OCPDNSNameResolver) overlappingInternalZone(dnsName string) string {
+	if !isWildcard(dnsName) {
+		return ""
+	}
+	parent := strings.ToLower(strings.TrimPrefix(dnsName, "*."))
+	for _, zone := range resolver.internalZones {
+		if dns.IsSubDomain(zone, parent) || dns.IsSubDomain(parent, zone) {
+			return zone
+		}
+	}
+	return ""
+}
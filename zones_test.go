@@ -0,0 +1,55 @@
+package ocp_dnsnameresolver
+
+import "testing"
+
+func TestInInternalZone(t *testing.T) {
+	tests := []struct {
+		name          string
+		internalZones []string
+		qname         string
+		expected      bool
+	}{
+		{"No internal zones", nil, "foo.cluster.local.", false},
+		{"DNS name in internal zone", []string{"cluster.local."}, "foo.cluster.local.", true},
+		{"Apex of internal zone", []string{"cluster.local."}, "cluster.local.", true},
+		{"DNS name sharing the suffix of internal zone", []string{"cluster.local."}, "foo-cluster.local.", false},
+		{"DNS name in another zone", []string{"cluster.local."}, "www.example.com.", false},
+		{"DNS name in one of the internal zones", []string{"cluster.local.", "svc.example.com."}, "foo.svc.example.com.", true},
+		{"Root internal zone", []string{"."}, "www.example.com.", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := New()
+			resolver.internalZones = tc.internalZones
+			if actual := resolver.inInternalZone(tc.qname); actual != tc.expected {
+				t.Fatalf("Expected %t, found %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestInExternalZone(t *testing.T) {
+	tests := []struct {
+		name          string
+		externalZones []string
+		qname         string
+		expected      bool
+	}{
+		{"No external zones", nil, "www.example.com.", true},
+		{"DNS name in external zone", []string{"example.com."}, "www.example.com.", true},
+		{"DNS name sharing the suffix of external zone", []string{"example.com."}, "www.myexample.com.", false},
+		{"DNS name in another zone", []string{"example.com."}, "foo.cluster.local.", false},
+		{"DNS name in one of the external zones", []string{"example.com.", "example.org."}, "www.example.org.", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := New()
+			resolver.externalZones = tc.externalZones
+			if actual := resolver.inExternalZone(tc.qname); actual != tc.expected {
+				t.Fatalf("Expected %t, found %t", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package ocp_dnsnameresolver
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// zoneTTLPolicy gives the bounds of the TTLs recorded for the DNS names of a zone. A zero
+// maximum TTL means that the TTLs are not bounded from above.
+type zoneTTLPolicy struct {
+	minTTL int32
+	maxTTL int32
+}
+
+// clamp returns the TTL bounded by the minimum and the maximum TTLs of the policy.
+func (policy zoneTTLPolicy) clamp(ttl int64) int64 {
+	if ttl < int64(policy.minTTL) {
+		return int64(policy.minTTL)
+	}
+	if policy.maxTTL > 0 && ttl > int64(policy.maxTTL) {
+		return int64(policy.maxTTL)
+	}
+	return ttl
+}
+
+// zoneTTLPolicy returns the TTL policy of the zone of the DNS name, configured with
+// zoneTTL, and whether such a zone was found. The policy of the longest matching zone
+// wins, thus the policy of a nested zone overrides the policy of its parent zone.
+func (resolver *OCPDNSNameResolver) zoneTTLPolicy(dnsName string) (zoneTTLPolicy, bool) {
+	dnsName = strings.ToLower(dnsName)
+	longestZone := ""
+	for zone := range resolver.zoneTTLs {
+		if dns.IsSubDomain(zone, dnsName) && len(zone) > len(longestZone) {
+			longestZone = zone
+		}
+	}
+	if longestZone == "" {
+		return zoneTTLPolicy{}, false
+	}
+	return resolver.zoneTTLs[longestZone], true
+}
+
+// minimumTTLOf returns the minimum TTL of the DNS name: the minimum TTL of the policy of
+// its zone, if any, and the global minimum TTL otherwise.
+func (resolver *OCPDNSNameResolver) minimumTTLOf(dnsName string) int32 {
+	if policy, ok := resolver.zoneTTLPolicy(dnsName); ok {
+		return policy.minTTL
+	}
+	return resolver.minimumTTL
+}